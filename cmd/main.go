@@ -6,16 +6,23 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/kevensen/gollama-chat/internal/configuration"
 	"github.com/kevensen/gollama-chat/internal/logging"
+	"github.com/kevensen/gollama-chat/internal/rag"
+	"github.com/kevensen/gollama-chat/internal/tooling"
 	"github.com/kevensen/gollama-chat/internal/tui/core"
 )
 
 func main() {
 	// Parse command line flags
+	ingestPath := flag.String("ingest", "", "Embed the text files at this path into a RAG collection and exit")
+	collection := flag.String("collection", "documents", "Collection to add documents to when using -ingest")
+	importMCP := flag.String("import-mcp", "", "Merge the MCP servers of an mcp.json file, or of a project directory's .mcp.json, into the settings and exit")
+	exportMCP := flag.String("export-mcp", "", "Write the MCP servers to an mcp.json file (- for stdout) and exit")
 	flag.Parse()
 	ctx := context.Background()
 
@@ -45,15 +52,134 @@ func main() {
 	logger := logging.WithComponent("main")
 	logger.Info("Starting gollama-chat application")
 
+	if *ingestPath != "" {
+		runIngestMode(ctx, config, *ingestPath, *collection)
+		return
+	}
+
+	if *importMCP != "" {
+		runImportMCPMode(config, *importMCP)
+		return
+	}
+
+	if *exportMCP != "" {
+		runExportMCPMode(config, *exportMCP)
+		return
+	}
+
 	// Run TUI mode
 	logger.Debug("Running in TUI mode")
 	runTUIMode(ctx, config)
 }
 
+func runIngestMode(ctx context.Context, config *configuration.Config, path string, collection string) {
+	logger := logging.WithComponent("ingest")
+	logger.Info("Ingesting documents", "path", path, "collection", collection, "vector_store", config.VectorStore)
+
+	documents, err := rag.LoadDocuments(path)
+	if err != nil {
+		log.Fatalf("Failed to load documents: %v", err)
+	}
+	if len(documents) == 0 {
+		log.Fatalf("No text documents found in %s", path)
+	}
+
+	service := rag.NewService(config)
+	if err := service.Initialize(ctx); err != nil {
+		log.Fatalf("Failed to initialize RAG service: %v", err)
+	}
+
+	if err := service.AddDocuments(ctx, collection, documents); err != nil {
+		log.Fatalf("Failed to ingest documents: %v", err)
+	}
+
+	fmt.Printf("Added %d chunks from %s to collection %q\n", len(documents), path, collection)
+}
+
+func runImportMCPMode(config *configuration.Config, path string) {
+	logger := logging.WithComponent("mcp-import")
+
+	// A project directory is imported from its .mcp.json
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, configuration.ProjectMCPFileName)
+	}
+	logger.Info("Importing MCP servers", "path", path)
+
+	servers, err := configuration.ReadMCPJSON(path)
+	if err != nil {
+		log.Fatalf("Failed to import MCP servers: %v", err)
+	}
+	added, updated, err := config.ImportMCPServers(servers)
+	if err != nil {
+		log.Fatalf("Failed to import MCP servers: %v", err)
+	}
+	if err := config.Save(); err != nil {
+		log.Fatalf("Failed to save configuration: %v", err)
+	}
+
+	scope := "all directories"
+	if len(servers) > 0 && servers[0].Project != "" {
+		scope = servers[0].Project
+	}
+	fmt.Printf("Imported MCP servers from %s for %s: %d added, %d updated\n", path, scope, len(added), len(updated))
+	for _, name := range added {
+		fmt.Printf("  + %s\n", name)
+	}
+	for _, name := range updated {
+		fmt.Printf("  ~ %s\n", name)
+	}
+}
+
+func runExportMCPMode(config *configuration.Config, path string) {
+	logger := logging.WithComponent("mcp-export")
+
+	// A project's .mcp.json receives the servers of that project, other files the
+	// servers used in all directories
+	project := ""
+	if filepath.Base(path) == configuration.ProjectMCPFileName {
+		dir, err := filepath.Abs(filepath.Dir(path))
+		if err != nil {
+			log.Fatalf("Failed to resolve project directory: %v", err)
+		}
+		project = dir
+	}
+
+	var servers []configuration.MCPServer
+	for _, server := range config.MCPServers {
+		if server.Project == project {
+			servers = append(servers, server)
+		}
+	}
+	logger.Info("Exporting MCP servers", "path", path, "project", project, "count", len(servers))
+
+	data, err := configuration.MarshalMCPJSON(servers)
+	if err != nil {
+		log.Fatalf("Failed to export MCP servers: %v", err)
+	}
+	if path == "-" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Fatalf("Failed to write MCP server file: %v", err)
+	}
+	fmt.Printf("Exported %d MCP servers to %s\n", len(servers), path)
+}
+
 func runTUIMode(ctx context.Context, config *configuration.Config) {
 	logger := logging.WithComponent("tui")
 	logger.Info("Initializing TUI mode")
 
+	// Record every tool request in the audit log next to the application logs
+	tooling.SetAuditLogPath(filepath.Join(logging.DefaultDir(), tooling.AuditFileName))
+
+	// Save the full output of tools next to the other application data
+	if dir, err := configuration.ToolOutputDir(); err != nil {
+		logger.Warn("Tool output will be saved to the temporary directory", "error", err)
+	} else {
+		tooling.SetToolOutputDir(dir)
+	}
+
 	// Create TUI model
 	model := core.NewModel(ctx, config)
 
@@ -52,16 +52,24 @@ func (d *Detector) DetectInWorkingDirectory() (*AgentsFile, error) {
 		return nil, nil
 	}
 
-	// Get current working directory
-	wd, err := os.Getwd()
+	wd, err := d.WorkingDirectory()
 	if err != nil {
-		d.logger.Error("Failed to get working directory", "error", err)
-		return nil, fmt.Errorf("failed to get working directory: %w", err)
+		return nil, err
 	}
 
 	return d.DetectInDirectory(wd)
 }
 
+// WorkingDirectory returns the directory DetectInWorkingDirectory looks in
+func (d *Detector) WorkingDirectory() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		d.logger.Error("Failed to get working directory", "error", err)
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	return wd, nil
+}
+
 // DetectInDirectory looks for an AGENTS.md file in the specified directory
 func (d *Detector) DetectInDirectory(dir string) (*AgentsFile, error) {
 	if !d.enabled {
@@ -3,59 +3,204 @@ package configuration
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
+	"time"
 )
 
 // MCPServer represents configuration for an MCP server
 type MCPServer struct {
-	Name      string   `json:"name"`      // Unique name for the server
-	Command   string   `json:"command"`   // Path to the MCP server binary
-	Arguments []string `json:"arguments"` // Arguments to pass to the server
-	Enabled   bool     `json:"enabled"`   // Whether the server should be started
+	Name       string            `json:"name"`                 // Unique name for the server
+	Transport  string            `json:"transport,omitempty"`  // stdio (default), http or sse
+	Command    string            `json:"command"`              // Path to the MCP server binary (stdio)
+	Arguments  []string          `json:"arguments"`            // Arguments to pass to the server (stdio)
+	Env        map[string]string `json:"env,omitempty"`        // Environment variables set for the server (stdio); values may be secret references
+	WorkingDir string            `json:"workingDir,omitempty"` // Directory the server runs in (stdio); relative to the working directory
+	InheritEnv *bool             `json:"inheritEnv,omitempty"` // Whether the server inherits the environment (stdio); defaults to true
+	URL        string            `json:"url,omitempty"`        // Endpoint of a remote server (http, sse)
+	Headers    map[string]string `json:"headers,omitempty"`    // HTTP headers sent with every request (http, sse); values may contain secret references
+	Roots      []string          `json:"roots,omitempty"`      // Directories exposed to the server; defaults to the working directory
+	Sampling   string            `json:"sampling,omitempty"`   // Whether the server may use the chat model: never, ask (default) or always
+	Project    string            `json:"project,omitempty"`    // Directory of the project the server belongs to; empty for servers used everywhere
+	Enabled    bool              `json:"enabled"`              // Whether the server should be started
 }
 
+// MCP transports
+const (
+	MCPTransportStdio = "stdio" // Local subprocess speaking JSON-RPC over stdin/stdout
+	MCPTransportHTTP  = "http"  // Streamable HTTP
+	MCPTransportSSE   = "sse"   // Legacy HTTP+SSE transport
+)
+
+// MCP sampling policies, which control whether a server may request completions from the chat model
+const (
+	MCPSamplingNever  = "never"  // Sampling requests are rejected
+	MCPSamplingAsk    = "ask"    // The user approves each sampling request
+	MCPSamplingAlways = "always" // Sampling requests are answered without asking
+)
+
+// TransportName returns the server's transport, treating an empty value as stdio
+func (s MCPServer) TransportName() string {
+	if s.Transport == "" {
+		return MCPTransportStdio
+	}
+	return s.Transport
+}
+
+// IsRemote reports whether the server is reached over HTTP rather than spawned locally
+func (s MCPServer) IsRemote() bool {
+	transport := s.TransportName()
+	return transport == MCPTransportHTTP || transport == MCPTransportSSE
+}
+
+// AppliesIn reports whether the server is used in a directory: servers that do not belong
+// to a project are used everywhere, project servers only in their project directory
+func (s MCPServer) AppliesIn(dir string) bool {
+	return s.Project == "" || filepath.Clean(s.Project) == filepath.Clean(dir)
+}
+
+// InheritsEnv reports whether the server inherits the environment, treating an unset value as true
+func (s MCPServer) InheritsEnv() bool {
+	return s.InheritEnv == nil || *s.InheritEnv
+}
+
+// SamplingPolicy returns the server's sampling policy, treating an empty value as ask
+func (s MCPServer) SamplingPolicy() string {
+	if s.Sampling == "" {
+		return MCPSamplingAsk
+	}
+	return s.Sampling
+}
+
+// CollectionSettings holds per-collection RAG settings
+type CollectionSettings struct {
+	Selected     bool    `json:"selected"`               // Whether the collection is queried by default
+	MaxDocuments int     `json:"maxDocuments,omitempty"` // Overrides Config.MaxDocuments when greater than 0
+	Distance     float64 `json:"distance,omitempty"`     // Overrides Config.ChromaDBDistance when greater than 0
+}
+
+// UnmarshalJSON accepts both the legacy boolean form and the struct form
+func (cs *CollectionSettings) UnmarshalJSON(data []byte) error {
+	// Older configurations stored selectedCollections as map[string]bool
+	var selected bool
+	if err := json.Unmarshal(data, &selected); err == nil {
+		*cs = CollectionSettings{Selected: selected}
+		return nil
+	}
+
+	type collectionSettings CollectionSettings
+	var settings collectionSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("failed to parse collection settings: %w", err)
+	}
+	*cs = CollectionSettings(settings)
+	return nil
+}
+
+// Vector store backends for RAG
+const (
+	VectorStoreChromaDB = "chromadb" // Remote ChromaDB server
+	VectorStoreLocal    = "local"    // Embedded file-backed store
+)
+
+// RAG modes control how retrieved documents reach the model
+const (
+	RAGModeAlways = "always" // Retrieve for every prompt and inject the results
+	RAGModeTool   = "tool"   // Only retrieve when the model calls the rag_search tool
+	RAGModeBoth   = "both"   // Inject results and offer the rag_search tool
+)
+
+// MaxRAGQueryExpansions is the largest number of paraphrased queries allowed per retrieval
+const MaxRAGQueryExpansions = 5
+
+// DefaultToolOutputMaxBytes is the tool output sent to the model when no limit is configured
+const DefaultToolOutputMaxBytes = 32 * 1024
+
+// Limits of tool calls when none are configured
+const (
+	DefaultToolConcurrency    = 4   // Approved calls of one response running at once
+	DefaultToolTimeoutSeconds = 300 // Time limit of each call
+)
+
 // Config represents the application configuration
 type Config struct {
-	ChatModel           string          `json:"chatModel"`
-	EmbeddingModel      string          `json:"embeddingModel"`
-	RAGEnabled          bool            `json:"ragEnabled"`
-	OllamaURL           string          `json:"ollamaURL"`
-	ChromaDBURL         string          `json:"chromaDBURL"`
-	ChromaDBDistance    float64         `json:"chromaDBDistance"`
-	MaxDocuments        int             `json:"maxDocuments"`
-	SelectedCollections map[string]bool `json:"selectedCollections"`
+	ChatModel           string                        `json:"chatModel"`
+	EmbeddingModel      string                        `json:"embeddingModel"`
+	RAGEnabled          bool                          `json:"ragEnabled"`
+	RAGMode             string                        `json:"ragMode"`                      // How retrieval is used: "always", "tool" or "both"
+	VectorStore         string                        `json:"vectorStore"`                  // Vector store backend: "chromadb" or "local"
+	LocalStorePath      string                        `json:"localStorePath,omitempty"`     // Directory for the local vector store; empty uses the data directory
+	EmbeddingCacheSize  int                           `json:"embeddingCacheSize,omitempty"` // Embeddings kept in the persistent cache; 0 uses the default, negative disables it
+	OllamaURL           string                        `json:"ollamaURL"`
+	ChromaDBURL         string                        `json:"chromaDBURL"`
+	ChromaDBDistance    float64                       `json:"chromaDBDistance"`
+	MaxDocuments        int                           `json:"maxDocuments"`
+	SelectedCollections map[string]CollectionSettings `json:"selectedCollections"`
+	RAGQueryRewrite     bool                          `json:"ragQueryRewrite"`    // Rewrite follow-up questions into standalone queries before retrieval
+	RAGQueryExpansions  int                           `json:"ragQueryExpansions"` // Number of extra paraphrased queries to retrieve with (0 disables expansion)
 	// DefaultSystemPrompt is deprecated - system prompt is now stored in SYSTEM_PROMPT.md
 	// TODO: Remove DefaultSystemPrompt field after migration period (target: 2024-12-31)
 	DefaultSystemPrompt string          `json:"defaultSystemPrompt,omitempty"` // Keep for migration
-	ToolTrustLevels     map[string]int  `json:"toolTrustLevels"`   // Maps tool name to trust level: 0=None(block), 1=Ask(prompt), 2=Session(allow)
-	MCPServers          []MCPServer     `json:"mcpServers"`        // MCP server configurations
-	LogLevel            string          `json:"logLevel"`          // Log level: debug, info, warn, error
-	EnableFileLogging   bool            `json:"enableFileLogging"` // Whether to log to file
-	AgentsFileEnabled   bool            `json:"agentsFileEnabled"` // Whether to automatically detect and use AGENTS.md files
-	
+	ToolTrustLevels     map[string]int  `json:"toolTrustLevels"`               // Maps tool name to trust level: 0=None(block), 1=Ask(prompt), 2=Session(allow)
+	ToolTrustRules      []ToolTrustRule `json:"toolTrustRules,omitempty"`      // Rules on tool arguments, evaluated before the trust levels
+	MCPServers          []MCPServer     `json:"mcpServers"`                    // MCP server configurations
+	BashSandbox         BashSandbox     `json:"bashSandbox"`                   // How the execute_bash tool runs commands
+	FileAccess          FileAccess      `json:"fileAccess"`                    // What the filesystem tools can read and write
+	HTTPFetch           HTTPFetch       `json:"httpFetch"`                     // Which hosts the http_fetch tool may contact
+	ScriptTools         []ScriptTool    `json:"scriptTools,omitempty"`         // Tools that run programs with the call's arguments
+	ToolOutputMaxBytes  int             `json:"toolOutputMaxBytes,omitempty"`  // Tool output sent to the model; 0 uses the default, negative disables the limit
+	ToolConcurrency     int             `json:"toolConcurrency,omitempty"`     // Approved tool calls of one response run at once; 0 uses the default, 1 runs them one by one
+	ToolTimeoutSeconds  int             `json:"toolTimeoutSeconds,omitempty"`  // Time limit of each tool call; 0 uses the default, negative disables it
+	LogLevel            string          `json:"logLevel"`                      // Log level: debug, info, warn, error
+	EnableFileLogging   bool            `json:"enableFileLogging"`             // Whether to log to file
+	AgentsFileEnabled   bool            `json:"agentsFileEnabled"`             // Whether to automatically detect and use AGENTS.md files
+
 	// systemPrompt is the cached system prompt content from SYSTEM_PROMPT.md
 	// This field is not serialized to JSON
 	systemPrompt string
 }
 
+// defaultToolTrustLevels are the trust levels of builtin tools the user has not set one
+// for. Tools missing here ask for permission.
+var defaultToolTrustLevels = map[string]int{
+	"execute_bash":     1, // Ask for permission
+	"filesystem_write": 1, // Changes are previewed and approved one by one
+	"http_fetch":       1, // Requests can reach services and send data
+	"filesystem_read":  1, // Reads file contents on request
+	"grep":             2, // Read-only search tools are trusted for the session
+	"glob":             2,
+	"file_tree":        2,
+	"rag_search":       2,
+}
+
+// DefaultToolTrustLevel returns the trust level of a tool the user has not set one for
+func DefaultToolTrustLevel(toolName string) int {
+	if trustLevel, exists := defaultToolTrustLevels[toolName]; exists {
+		return trustLevel
+	}
+	return 1 // Default to asking for permission
+}
+
 // DefaultConfig returns a configuration with sensible defaults
 func DefaultConfig() *Config {
-	// Create default tool trust levels with hardcoded values for built-in tools
-	defaultToolTrustLevels := make(map[string]int)
-	defaultToolTrustLevels["execute_bash"] = 1 // Hardcoded: ask for permission
 
 	return &Config{
 		ChatModel:           "llama3.3:latest",
 		EmbeddingModel:      "nomic-embed-text:latest",
 		RAGEnabled:          false,
+		RAGMode:             RAGModeAlways,
+		VectorStore:         VectorStoreChromaDB,
 		OllamaURL:           "http://localhost:11434",
 		ChromaDBURL:         "http://localhost:8000",
 		ChromaDBDistance:    1.0, // Updated for cosine similarity (0-2 range)
 		MaxDocuments:        5,
-		SelectedCollections: make(map[string]bool),
-		ToolTrustLevels:     defaultToolTrustLevels,
+		SelectedCollections: make(map[string]CollectionSettings),
+		ToolTrustLevels:     maps.Clone(defaultToolTrustLevels),
 		MCPServers:          []MCPServer{},
 		LogLevel:            "info",
 		EnableFileLogging:   true,
@@ -100,6 +245,34 @@ func path() (string, error) {
 	return filepath.Join(configDir, "settings.json"), nil
 }
 
+// DefaultLocalStoreDir returns the directory used by the local vector store when
+// no localStorePath is configured
+func DefaultLocalStoreDir() (string, error) {
+	configDir, err := dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configDir), "vectors"), nil
+}
+
+// ToolOutputDir returns the directory the full output of tools is saved to
+func ToolOutputDir() (string, error) {
+	configDir, err := dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configDir), "tool-output"), nil
+}
+
+// EmbeddingCachePath returns the file used to persist the RAG embedding cache
+func EmbeddingCachePath() (string, error) {
+	configDir, err := dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configDir), "cache", "embeddings.json"), nil
+}
+
 // systemPromptPath returns the full path to the system prompt markdown file
 func systemPromptPath() (string, error) {
 	configDir, err := dir()
@@ -241,7 +414,7 @@ func migrateSystemPrompt(c *Config) error {
 	}
 
 	_, fileExists := os.Stat(promptPath)
-	
+
 	// If we have a DefaultSystemPrompt in the config and no file exists, migrate it
 	if c.DefaultSystemPrompt != "" && os.IsNotExist(fileExists) {
 		if err := SaveSystemPrompt(c.DefaultSystemPrompt); err != nil {
@@ -273,11 +446,26 @@ func applyDefaultsIfMissing(c *Config) {
 		c.ToolTrustLevels = make(map[string]int)
 	}
 
+	// Initialize SelectedCollections if nil (for backward compatibility)
+	if c.SelectedCollections == nil {
+		c.SelectedCollections = make(map[string]CollectionSettings)
+	}
+
 	// Initialize MCPServers if nil (for backward compatibility)
 	if c.MCPServers == nil {
 		c.MCPServers = []MCPServer{}
 	}
 
+	// Older configurations always injected RAG context
+	if c.RAGMode == "" {
+		c.RAGMode = defaultConfig.RAGMode
+	}
+
+	// Older configurations always used ChromaDB
+	if c.VectorStore == "" {
+		c.VectorStore = defaultConfig.VectorStore
+	}
+
 	// Initialize logging fields if empty (for backward compatibility)
 	if c.LogLevel == "" {
 		c.LogLevel = defaultConfig.LogLevel
@@ -333,8 +521,20 @@ func (c *Config) Validate() error {
 	if c.EmbeddingModel == "" && c.RAGEnabled {
 		return fmt.Errorf("embeddingModel cannot be empty when RAG is enabled")
 	}
-	if c.ChromaDBURL == "" && c.RAGEnabled {
-		return fmt.Errorf("chromaDBURL cannot be empty when RAG is enabled")
+	switch c.RAGMode {
+	case RAGModeAlways, RAGModeTool, RAGModeBoth, "":
+	default:
+		return fmt.Errorf("ragMode must be %q, %q or %q", RAGModeAlways, RAGModeTool, RAGModeBoth)
+	}
+
+	switch c.VectorStore {
+	case VectorStoreChromaDB, "":
+		if c.ChromaDBURL == "" && c.RAGEnabled {
+			return fmt.Errorf("chromaDBURL cannot be empty when RAG is enabled")
+		}
+	case VectorStoreLocal:
+	default:
+		return fmt.Errorf("vectorStore must be %q or %q", VectorStoreChromaDB, VectorStoreLocal)
 	}
 	if c.ChromaDBDistance < 0 || c.ChromaDBDistance > 2 {
 		return fmt.Errorf("chromaDBDistance must be between 0 and 2 (cosine similarity range)")
@@ -344,9 +544,46 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("maxDocuments must be greater than 0 when RAG is enabled")
 	}
 
-	// Validate MCP servers
+	if c.RAGQueryExpansions < 0 || c.RAGQueryExpansions > MaxRAGQueryExpansions {
+		return fmt.Errorf("ragQueryExpansions must be between 0 and %d", MaxRAGQueryExpansions)
+	}
+
+	// Validate per-collection overrides
+	for name, settings := range c.SelectedCollections {
+		if settings.Distance < 0 || settings.Distance > 2 {
+			return fmt.Errorf("distance override for collection '%s' must be between 0 and 2", name)
+		}
+		if settings.MaxDocuments < 0 {
+			return fmt.Errorf("maxDocuments override for collection '%s' cannot be negative", name)
+		}
+	}
+
+	if err := c.BashSandbox.validate(); err != nil {
+		return err
+	}
+	if err := c.FileAccess.validate(); err != nil {
+		return err
+	}
+	if err := c.HTTPFetch.validate(); err != nil {
+		return err
+	}
+	if err := validateScriptTools(c.ScriptTools); err != nil {
+		return err
+	}
+	if c.ToolConcurrency < 0 {
+		return fmt.Errorf("toolConcurrency cannot be negative")
+	}
+	if err := validateTrustRules(c.ToolTrustRules); err != nil {
+		return err
+	}
+
+	return validateMCPServers(c.MCPServers)
+}
+
+// validateMCPServers checks that MCP servers have unique names and valid settings
+func validateMCPServers(servers []MCPServer) error {
 	serverNames := make(map[string]bool)
-	for i, server := range c.MCPServers {
+	for i, server := range servers {
 		if server.Name == "" {
 			return fmt.Errorf("MCP server at index %d has empty name", i)
 		}
@@ -355,22 +592,62 @@ func (c *Config) Validate() error {
 		}
 		serverNames[server.Name] = true
 
-		if server.Command == "" {
-			return fmt.Errorf("MCP server '%s' has empty command", server.Name)
+		switch server.TransportName() {
+		case MCPTransportStdio:
+			if server.Command == "" {
+				return fmt.Errorf("MCP server '%s' has empty command", server.Name)
+			}
+		case MCPTransportHTTP, MCPTransportSSE:
+			if server.URL == "" {
+				return fmt.Errorf("MCP server '%s' has empty url", server.Name)
+			}
+			if u, err := url.Parse(server.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("MCP server '%s' has invalid url %q", server.Name, server.URL)
+			}
+		default:
+			return fmt.Errorf("MCP server '%s' has unknown transport %q (must be %q, %q or %q)",
+				server.Name, server.Transport, MCPTransportStdio, MCPTransportHTTP, MCPTransportSSE)
+		}
+
+		for name, value := range server.Env {
+			if name == "" || strings.Contains(name, "=") {
+				return fmt.Errorf("MCP server '%s' has invalid environment variable name %q", server.Name, name)
+			}
+			if err := validateSecretReference(value); err != nil {
+				return fmt.Errorf("MCP server '%s' environment variable %s: %w", server.Name, name, err)
+			}
+		}
+
+		for name, value := range server.Headers {
+			if err := validateSecretReferences(value); err != nil {
+				return fmt.Errorf("MCP server '%s' header %s: %w", server.Name, name, err)
+			}
+		}
+
+		if slices.Contains(server.Roots, "") {
+			return fmt.Errorf("MCP server '%s' has an empty root directory", server.Name)
+		}
+
+		switch server.SamplingPolicy() {
+		case MCPSamplingNever, MCPSamplingAsk, MCPSamplingAlways:
+		default:
+			return fmt.Errorf("MCP server '%s' has unknown sampling policy %q (must be %q, %q or %q)",
+				server.Name, server.Sampling, MCPSamplingNever, MCPSamplingAsk, MCPSamplingAlways)
 		}
 	}
 
 	return nil
 }
 
-// GetToolTrustLevel returns the trust level for a tool, defaulting to 1 (ask for permission) if not found
+// GetToolTrustLevel returns the trust level for a tool, defaulting to DefaultToolTrustLevel
+// if not found
 func (c *Config) GetToolTrustLevel(toolName string) int {
 	if c.ToolTrustLevels == nil {
-		return 1 // Default to asking for permission
+		return DefaultToolTrustLevel(toolName)
 	}
 	trustLevel, exists := c.ToolTrustLevels[toolName]
 	if !exists {
-		return 1 // Default to asking for permission
+		return DefaultToolTrustLevel(toolName)
 	}
 	return trustLevel
 }
@@ -384,6 +661,87 @@ func (c *Config) SetToolTrustLevel(toolName string, trustLevel int) error {
 	return c.Save()
 }
 
+// SetToolTrustRule adds a trust rule, or replaces the rule at index when it is a valid
+// index, and saves the configuration
+func (c *Config) SetToolTrustRule(index int, rule ToolTrustRule) error {
+	if err := rule.validate(); err != nil {
+		return err
+	}
+	if index >= 0 && index < len(c.ToolTrustRules) {
+		c.ToolTrustRules[index] = rule
+	} else {
+		c.ToolTrustRules = append(c.ToolTrustRules, rule)
+	}
+	return c.Save()
+}
+
+// RemoveToolTrustRule removes the trust rule at index and saves the configuration
+func (c *Config) RemoveToolTrustRule(index int) error {
+	if index < 0 || index >= len(c.ToolTrustRules) {
+		return fmt.Errorf("no tool trust rule at index %d", index)
+	}
+	c.ToolTrustRules = slices.Delete(c.ToolTrustRules, index, index+1)
+	return c.Save()
+}
+
+// ToolOutputLimit returns the bytes of tool output sent to the model, with 0 meaning unlimited
+func (c *Config) ToolOutputLimit() int {
+	switch {
+	case c.ToolOutputMaxBytes < 0:
+		return 0
+	case c.ToolOutputMaxBytes == 0:
+		return DefaultToolOutputMaxBytes
+	default:
+		return c.ToolOutputMaxBytes
+	}
+}
+
+// ToolConcurrencyLimit returns how many approved tool calls of one response run at once
+func (c *Config) ToolConcurrencyLimit() int {
+	if c.ToolConcurrency <= 0 {
+		return DefaultToolConcurrency
+	}
+	return c.ToolConcurrency
+}
+
+// ToolTimeout returns the time limit of each tool call, with 0 meaning unlimited
+func (c *Config) ToolTimeout() time.Duration {
+	switch {
+	case c.ToolTimeoutSeconds < 0:
+		return 0
+	case c.ToolTimeoutSeconds == 0:
+		return DefaultToolTimeoutSeconds * time.Second
+	default:
+		return time.Duration(c.ToolTimeoutSeconds) * time.Second
+	}
+}
+
+// RAGInjectsContext reports whether retrieved documents are added to every prompt
+func (c *Config) RAGInjectsContext() bool {
+	return c.RAGEnabled && c.RAGMode != RAGModeTool
+}
+
+// RAGToolEnabled reports whether the model is offered the rag_search tool
+func (c *Config) RAGToolEnabled() bool {
+	return c.RAGEnabled && (c.RAGMode == RAGModeTool || c.RAGMode == RAGModeBoth)
+}
+
+// GetCollectionMaxDocuments returns the result count for a collection, honoring any per-collection override
+func (c *Config) GetCollectionMaxDocuments(collectionName string) int {
+	if settings, exists := c.SelectedCollections[collectionName]; exists && settings.MaxDocuments > 0 {
+		return settings.MaxDocuments
+	}
+	return c.MaxDocuments
+}
+
+// GetCollectionDistance returns the distance threshold for a collection, honoring any per-collection override
+func (c *Config) GetCollectionDistance(collectionName string) float64 {
+	if settings, exists := c.SelectedCollections[collectionName]; exists && settings.Distance > 0 {
+		return settings.Distance
+	}
+	return c.ChromaDBDistance
+}
+
 // GetMCPServer returns an MCP server by name, or nil if not found
 func (c *Config) GetMCPServer(name string) *MCPServer {
 	for i := range c.MCPServers {
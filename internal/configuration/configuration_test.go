@@ -1,8 +1,10 @@
 package configuration
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestConfig_Validate(t *testing.T) {
@@ -27,7 +29,7 @@ func TestConfig_Validate(t *testing.T) {
 				ChromaDBURL:         "",
 				ChromaDBDistance:    1.0,
 				MaxDocuments:        5,
-				SelectedCollections: make(map[string]bool),
+				SelectedCollections: make(map[string]CollectionSettings),
 				DefaultSystemPrompt: "You are a helpful assistant.",
 				MCPServers:          []MCPServer{},
 			},
@@ -43,7 +45,7 @@ func TestConfig_Validate(t *testing.T) {
 				ChromaDBURL:         "http://localhost:8000",
 				ChromaDBDistance:    1.0,
 				MaxDocuments:        5,
-				SelectedCollections: make(map[string]bool),
+				SelectedCollections: make(map[string]CollectionSettings),
 				DefaultSystemPrompt: "You are a helpful assistant.",
 				MCPServers: []MCPServer{
 					{
@@ -112,6 +114,182 @@ func TestConfig_Validate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "MCP server 'test-server' has empty command",
 		},
+		{
+			name: "remote MCP server without url",
+			config: &Config{
+				ChatModel:        "llama3.3:latest",
+				EmbeddingModel:   "embeddinggemma:latest",
+				RAGEnabled:       true,
+				OllamaURL:        "http://localhost:11434",
+				ChromaDBURL:      "http://localhost:8000",
+				ChromaDBDistance: 1.0,
+				MaxDocuments:     5,
+				MCPServers: []MCPServer{
+					{
+						Name:      "remote-server",
+						Transport: MCPTransportHTTP,
+						Enabled:   true,
+					},
+				},
+			},
+			expectError: true,
+			errorMsg:    "MCP server 'remote-server' has empty url",
+		},
+		{
+			name: "remote MCP server with url",
+			config: &Config{
+				ChatModel:        "llama3.3:latest",
+				EmbeddingModel:   "embeddinggemma:latest",
+				RAGEnabled:       true,
+				OllamaURL:        "http://localhost:11434",
+				ChromaDBURL:      "http://localhost:8000",
+				ChromaDBDistance: 1.0,
+				MaxDocuments:     5,
+				MCPServers: []MCPServer{
+					{
+						Name:      "remote-server",
+						Transport: MCPTransportSSE,
+						URL:       "https://mcp.example.com/sse",
+						Headers:   map[string]string{"Authorization": "Bearer token"},
+						Enabled:   true,
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "MCP server with unknown transport",
+			config: &Config{
+				ChatModel:        "llama3.3:latest",
+				EmbeddingModel:   "embeddinggemma:latest",
+				RAGEnabled:       true,
+				OllamaURL:        "http://localhost:11434",
+				ChromaDBURL:      "http://localhost:8000",
+				ChromaDBDistance: 1.0,
+				MaxDocuments:     5,
+				MCPServers: []MCPServer{
+					{
+						Name:      "test-server",
+						Transport: "websocket",
+						URL:       "ws://localhost:9000",
+						Enabled:   true,
+					},
+				},
+			},
+			expectError: true,
+			errorMsg:    `MCP server 'test-server' has unknown transport "websocket" (must be "stdio", "http" or "sse")`,
+		},
+		{
+			name: "MCP server with invalid environment variable name",
+			config: &Config{
+				ChatModel:        "llama3.3:latest",
+				EmbeddingModel:   "embeddinggemma:latest",
+				RAGEnabled:       true,
+				OllamaURL:        "http://localhost:11434",
+				ChromaDBURL:      "http://localhost:8000",
+				ChromaDBDistance: 1.0,
+				MaxDocuments:     5,
+				MCPServers: []MCPServer{
+					{
+						Name:    "test-server",
+						Command: "/path/to/server",
+						Env:     map[string]string{"API=KEY": "value"},
+						Enabled: true,
+					},
+				},
+			},
+			expectError: true,
+			errorMsg:    `MCP server 'test-server' has invalid environment variable name "API=KEY"`,
+		},
+		{
+			name: "MCP server with unknown secret source",
+			config: &Config{
+				ChatModel:        "llama3.3:latest",
+				EmbeddingModel:   "embeddinggemma:latest",
+				RAGEnabled:       true,
+				OllamaURL:        "http://localhost:11434",
+				ChromaDBURL:      "http://localhost:8000",
+				ChromaDBDistance: 1.0,
+				MaxDocuments:     5,
+				MCPServers: []MCPServer{
+					{
+						Name:    "test-server",
+						Command: "/path/to/server",
+						Env:     map[string]string{"API_KEY": "${vault:api-key}"},
+						Enabled: true,
+					},
+				},
+			},
+			expectError: true,
+			errorMsg:    `MCP server 'test-server' environment variable API_KEY: unknown secret source "vault" in "${vault:api-key}" (must be "env" or "file")`,
+		},
+		{
+			name: "MCP server header with unknown secret source",
+			config: &Config{
+				ChatModel:        "llama3.3:latest",
+				EmbeddingModel:   "embeddinggemma:latest",
+				RAGEnabled:       true,
+				OllamaURL:        "http://localhost:11434",
+				ChromaDBURL:      "http://localhost:8000",
+				ChromaDBDistance: 1.0,
+				MaxDocuments:     5,
+				MCPServers: []MCPServer{
+					{
+						Name:      "test-server",
+						Transport: MCPTransportHTTP,
+						URL:       "https://mcp.example.com/mcp",
+						Headers:   map[string]string{"Authorization": "Bearer ${vault:token}"},
+						Enabled:   true,
+					},
+				},
+			},
+			expectError: true,
+			errorMsg:    `MCP server 'test-server' header Authorization: unknown secret source "vault" in "${vault:token}" (must be "env" or "file")`,
+		},
+		{
+			name: "MCP server with empty root",
+			config: &Config{
+				ChatModel:        "llama3.3:latest",
+				EmbeddingModel:   "embeddinggemma:latest",
+				RAGEnabled:       true,
+				OllamaURL:        "http://localhost:11434",
+				ChromaDBURL:      "http://localhost:8000",
+				ChromaDBDistance: 1.0,
+				MaxDocuments:     5,
+				MCPServers: []MCPServer{
+					{
+						Name:    "test-server",
+						Command: "/path/to/server",
+						Roots:   []string{"/srv/docs", ""},
+						Enabled: true,
+					},
+				},
+			},
+			expectError: true,
+			errorMsg:    "MCP server 'test-server' has an empty root directory",
+		},
+		{
+			name: "MCP server with unknown sampling policy",
+			config: &Config{
+				ChatModel:        "llama3.3:latest",
+				EmbeddingModel:   "embeddinggemma:latest",
+				RAGEnabled:       true,
+				OllamaURL:        "http://localhost:11434",
+				ChromaDBURL:      "http://localhost:8000",
+				ChromaDBDistance: 1.0,
+				MaxDocuments:     5,
+				MCPServers: []MCPServer{
+					{
+						Name:     "test-server",
+						Command:  "/path/to/server",
+						Sampling: "sometimes",
+						Enabled:  true,
+					},
+				},
+			},
+			expectError: true,
+			errorMsg:    `MCP server 'test-server' has unknown sampling policy "sometimes" (must be "never", "ask" or "always")`,
+		},
 		{
 			name: "duplicate MCP server names",
 			config: &Config{
@@ -542,8 +720,8 @@ func TestDefaultConfig_Validation(t *testing.T) {
 
 		// Should contain hardcoded defaults for built-in tools
 		expectedExecuteBashTrustLevel := 1
-		if len(config.ToolTrustLevels) != 1 {
-			t.Errorf("ToolTrustLevels should contain 1 hardcoded default, got %d", len(config.ToolTrustLevels))
+		if len(config.ToolTrustLevels) != len(defaultToolTrustLevels) {
+			t.Errorf("ToolTrustLevels should contain %d hardcoded defaults, got %d", len(defaultToolTrustLevels), len(config.ToolTrustLevels))
 		}
 
 		actualExecuteBashTrustLevel, exists := config.ToolTrustLevels["execute_bash"]
@@ -593,3 +771,165 @@ func TestGetToolTrustLevel_ExecuteBashDefault(t *testing.T) {
 		}
 	})
 }
+
+func TestGetToolTrustLevel_BuiltinDefaults(t *testing.T) {
+	// Read-only search tools are trusted for the session; the other tools ask
+	tests := map[string]int{
+		"filesystem_read":  1,
+		"grep":             2,
+		"glob":             2,
+		"file_tree":        2,
+		"rag_search":       2,
+		"filesystem_write": 1,
+		"http_fetch":       1,
+		"execute_bash":     1,
+		"server.tool":      1,
+	}
+	for _, config := range []*Config{DefaultConfig(), {}} {
+		for name, want := range tests {
+			if got := config.GetToolTrustLevel(name); got != want {
+				t.Errorf("GetToolTrustLevel(%s) = %d, want %d", name, got, want)
+			}
+		}
+	}
+
+	// Changing a configuration's trust levels does not change the defaults
+	config := DefaultConfig()
+	config.ToolTrustLevels["grep"] = 0
+	if got := DefaultConfig().GetToolTrustLevel("grep"); got != 2 {
+		t.Errorf("Expected the default to stay 2, got %d", got)
+	}
+}
+
+func TestCollectionSettings_UnmarshalJSON(t *testing.T) {
+	// Legacy boolean form and struct form should both be accepted
+	data := `{"legacy": true, "unselected": false, "tuned": {"selected": true, "maxDocuments": 8, "distance": 0.5}}`
+
+	var collections map[string]CollectionSettings
+	if err := json.Unmarshal([]byte(data), &collections); err != nil {
+		t.Fatalf("Failed to unmarshal collections: %v", err)
+	}
+
+	if !collections["legacy"].Selected {
+		t.Error("Expected legacy collection to be selected")
+	}
+	if collections["unselected"].Selected {
+		t.Error("Expected unselected collection to not be selected")
+	}
+	tuned := collections["tuned"]
+	if !tuned.Selected || tuned.MaxDocuments != 8 || tuned.Distance != 0.5 {
+		t.Errorf("Unexpected tuned collection settings: %+v", tuned)
+	}
+}
+
+func TestConfig_CollectionOverrides(t *testing.T) {
+	config := DefaultConfig()
+	config.SelectedCollections["tuned"] = CollectionSettings{Selected: true, MaxDocuments: 8, Distance: 0.5}
+	config.SelectedCollections["plain"] = CollectionSettings{Selected: true}
+
+	if got := config.GetCollectionMaxDocuments("tuned"); got != 8 {
+		t.Errorf("Expected override maxDocuments 8, got %d", got)
+	}
+	if got := config.GetCollectionDistance("tuned"); got != 0.5 {
+		t.Errorf("Expected override distance 0.5, got %f", got)
+	}
+	if got := config.GetCollectionMaxDocuments("plain"); got != config.MaxDocuments {
+		t.Errorf("Expected global maxDocuments %d, got %d", config.MaxDocuments, got)
+	}
+	if got := config.GetCollectionDistance("unknown"); got != config.ChromaDBDistance {
+		t.Errorf("Expected global distance %f, got %f", config.ChromaDBDistance, got)
+	}
+
+	config.SelectedCollections["invalid"] = CollectionSettings{Selected: true, Distance: 3}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected validation error for out-of-range distance override")
+	}
+}
+
+func TestConfig_VectorStoreValidation(t *testing.T) {
+	config := DefaultConfig()
+	config.RAGEnabled = true
+	config.ChromaDBURL = ""
+
+	if err := config.Validate(); err == nil {
+		t.Error("Expected error for ChromaDB store without a URL")
+	}
+
+	config.VectorStore = VectorStoreLocal
+	if err := config.Validate(); err != nil {
+		t.Errorf("Local store should not require a ChromaDB URL, got %v", err)
+	}
+
+	config.VectorStore = "pinecone"
+	if err := config.Validate(); err == nil {
+		t.Error("Expected error for unknown vector store")
+	}
+}
+
+func TestConfig_RAGMode(t *testing.T) {
+	tests := []struct {
+		mode        string
+		ragEnabled  bool
+		wantInject  bool
+		wantTool    bool
+		expectValid bool
+	}{
+		{RAGModeAlways, true, true, false, true},
+		{RAGModeTool, true, false, true, true},
+		{RAGModeBoth, true, true, true, true},
+		{RAGModeBoth, false, false, false, true},
+		{"sometimes", true, true, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			config := DefaultConfig()
+			config.RAGEnabled = tt.ragEnabled
+			config.RAGMode = tt.mode
+
+			if got := config.RAGInjectsContext(); got != tt.wantInject {
+				t.Errorf("RAGInjectsContext() = %v, expected %v", got, tt.wantInject)
+			}
+			if got := config.RAGToolEnabled(); got != tt.wantTool {
+				t.Errorf("RAGToolEnabled() = %v, expected %v", got, tt.wantTool)
+			}
+			if err := config.Validate(); (err == nil) != tt.expectValid {
+				t.Errorf("Validate() error = %v, expected valid %v", err, tt.expectValid)
+			}
+		})
+	}
+}
+
+func TestConfig_ToolCallLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		timeout     int
+		wantLimit   int
+		wantTimeout time.Duration
+		expectValid bool
+	}{
+		{"defaults", 0, 0, DefaultToolConcurrency, DefaultToolTimeoutSeconds * time.Second, true},
+		{"one by one", 1, 10, 1, 10 * time.Second, true},
+		{"no timeout", 8, -1, 8, 0, true},
+		{"negative concurrency", -1, 0, DefaultToolConcurrency, DefaultToolTimeoutSeconds * time.Second, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.ToolConcurrency = tt.concurrency
+			config.ToolTimeoutSeconds = tt.timeout
+
+			if got := config.ToolConcurrencyLimit(); got != tt.wantLimit {
+				t.Errorf("ToolConcurrencyLimit() = %d, expected %d", got, tt.wantLimit)
+			}
+			if got := config.ToolTimeout(); got != tt.wantTimeout {
+				t.Errorf("ToolTimeout() = %v, expected %v", got, tt.wantTimeout)
+			}
+			if err := config.Validate(); (err == nil) != tt.expectValid {
+				t.Errorf("Validate() error = %v, expected valid %v", err, tt.expectValid)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package configuration
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultMaxReadBytes is the largest part of a file filesystem_read returns when no limit
+// is configured
+const DefaultMaxReadBytes = 1 << 20
+
+// DefaultDenyPatterns are paths filesystem_read never reads because they usually hold secrets
+var DefaultDenyPatterns = []string{
+	".env", ".env.*", ".netrc", ".npmrc", ".pypirc", ".git-credentials",
+	"*.pem", "*.key", "*.p12", "*.pfx", "*.kdbx",
+	"id_rsa*", "id_dsa*", "id_ecdsa*", "id_ed25519*",
+	".ssh/**", ".gnupg/**", ".aws/**",
+}
+
+// FileAccess limits what the filesystem tools can read and write. Paths are resolved
+// through symlinks before they are checked, so links cannot reach outside the allowed
+// roots.
+type FileAccess struct {
+	AllowedRoots   []string `json:"allowedRoots,omitempty"`   // Directories that may be read; empty allows the project directory
+	DenyPatterns   []string `json:"denyPatterns,omitempty"`   // Globs of paths that are never read, in addition to the defaults
+	ReadGitignored bool     `json:"readGitignored,omitempty"` // Whether files ignored by .gitignore may be read
+	MaxReadBytes   int      `json:"maxReadBytes,omitempty"`   // Largest part of a file returned; 0 uses the default, negative disables the limit
+}
+
+// Roots returns the allowed roots as absolute paths, resolving relative roots and the
+// default project root against projectDir
+func (a FileAccess) Roots(projectDir string) ([]string, error) {
+	if len(a.AllowedRoots) == 0 {
+		return []string{projectDir}, nil
+	}
+	roots := make([]string, 0, len(a.AllowedRoots))
+	for _, root := range a.AllowedRoots {
+		expanded, err := expandHome(root)
+		if err != nil {
+			return nil, err
+		}
+		if !filepath.IsAbs(expanded) {
+			expanded = filepath.Join(projectDir, expanded)
+		}
+		roots = append(roots, filepath.Clean(expanded))
+	}
+	return roots, nil
+}
+
+// Patterns returns the deny patterns in effect
+func (a FileAccess) Patterns() []string {
+	return append(append([]string(nil), DefaultDenyPatterns...), a.DenyPatterns...)
+}
+
+// ReadLimit returns the largest part of a file returned, with 0 meaning unlimited
+func (a FileAccess) ReadLimit() int {
+	switch {
+	case a.MaxReadBytes < 0:
+		return 0
+	case a.MaxReadBytes == 0:
+		return DefaultMaxReadBytes
+	default:
+		return a.MaxReadBytes
+	}
+}
+
+// DeniedBy returns the deny pattern matching a path relative to an allowed root, or an
+// empty string. Patterns without a slash match any name in the path, so ".env" denies
+// "config/.env" and "*.key" denies every key file; patterns with a slash match the
+// relative path, with ** crossing directories. A pattern such as .ssh/** denies the
+// directory itself too, so listing its parent does not reveal it.
+func (a FileAccess) DeniedBy(relativePath string) string {
+	relativePath = filepath.ToSlash(relativePath)
+	names := strings.Split(relativePath, "/")
+	for _, pattern := range a.Patterns() {
+		if !strings.Contains(pattern, "/") {
+			for _, name := range names {
+				if matched, _ := filepath.Match(pattern, name); matched {
+					return pattern
+				}
+			}
+			continue
+		}
+
+		// A directory pattern such as .ssh/** also matches below any directory
+		expression := GlobExpression(strings.TrimPrefix(pattern, "/"), true)
+		if !strings.HasPrefix(pattern, "/") {
+			expression = "(.*/)?" + expression
+		}
+		if matched, _ := regexp.MatchString("^"+expression+"$", relativePath); matched {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// validate checks the roots and patterns
+func (a FileAccess) validate() error {
+	for _, root := range a.AllowedRoots {
+		if strings.TrimSpace(root) == "" {
+			return fmt.Errorf("fileAccess allowed roots cannot be empty")
+		}
+	}
+	for _, pattern := range a.DenyPatterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid fileAccess deny pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package configuration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileAccess_DeniedBy(t *testing.T) {
+	access := FileAccess{DenyPatterns: []string{"secrets/*.json", "/local.conf"}}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"main.go", ""},
+		{".env", ".env"},
+		{"config/.env.production", ".env.*"},
+		{"deploy/tls/server.pem", "*.pem"},
+		{".ssh/id_ed25519.pub", "id_ed25519*"},
+		{"home/.aws/credentials", ".aws/**"},
+		{".ssh", ".ssh/**"},
+		{"home/.gnupg", ".gnupg/**"},
+		{"secrets/db.json", "secrets/*.json"},
+		{"app/secrets/db.json", "secrets/*.json"},
+		{"secrets/nested/db.json", ""},
+		{"local.conf", "/local.conf"},
+		{"app/local.conf", ""},
+	}
+	for _, tt := range tests {
+		if got := access.DeniedBy(filepath.FromSlash(tt.path)); got != tt.want {
+			t.Errorf("DeniedBy(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFileAccess_Roots(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	roots, err := FileAccess{}.Roots("/srv/project")
+	if err != nil || len(roots) != 1 || roots[0] != "/srv/project" {
+		t.Errorf("Expected the project directory by default, got %v (%v)", roots, err)
+	}
+
+	roots, err = FileAccess{AllowedRoots: []string{"docs", "~/notes", "/etc/app/"}}.Roots("/srv/project")
+	want := []string{"/srv/project/docs", filepath.Join(home, "notes"), "/etc/app"}
+	if err != nil || len(roots) != len(want) {
+		t.Fatalf("Expected roots %v, got %v (%v)", want, roots, err)
+	}
+	for i := range want {
+		if roots[i] != want[i] {
+			t.Errorf("Expected root %s, got %s", want[i], roots[i])
+		}
+	}
+}
+
+func TestFileAccess_ReadLimit(t *testing.T) {
+	tests := []struct {
+		maxReadBytes int
+		want         int
+	}{
+		{0, DefaultMaxReadBytes},
+		{4096, 4096},
+		{-1, 0},
+	}
+	for _, tt := range tests {
+		if got := (FileAccess{MaxReadBytes: tt.maxReadBytes}).ReadLimit(); got != tt.want {
+			t.Errorf("ReadLimit with %d = %d, want %d", tt.maxReadBytes, got, tt.want)
+		}
+	}
+}
+
+func TestConfig_FileAccessValidation(t *testing.T) {
+	config := DefaultConfig()
+	config.FileAccess = FileAccess{AllowedRoots: []string{"~/src"}, DenyPatterns: []string{"*.sqlite"}}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected valid file access settings, got %v", err)
+	}
+
+	config.FileAccess = FileAccess{AllowedRoots: []string{" "}}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected an empty root to be rejected")
+	}
+
+	config.FileAccess = FileAccess{DenyPatterns: []string{"[abc"}}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected a malformed deny pattern to be rejected")
+	}
+}
@@ -0,0 +1,145 @@
+package configuration
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Host policies of the http_fetch tool
+const (
+	HTTPFetchPolicyLocal = "local" // Only loopback addresses and the allowed hosts
+	HTTPFetchPolicyAny   = "any"   // Any host that is not denied
+)
+
+// Limits of the http_fetch tool when none are configured
+const (
+	DefaultHTTPFetchTimeoutSeconds   = 30
+	DefaultHTTPFetchMaxResponseBytes = 1 << 20
+)
+
+// HTTPFetch configures which hosts the http_fetch tool may contact and how much it may
+// download. Hosts are checked again after DNS resolution and on every redirect, so a
+// name resolving to a denied address is denied too.
+type HTTPFetch struct {
+	Policy           string   `json:"policy,omitempty"`           // local (default) or any
+	AllowedHosts     []string `json:"allowedHosts,omitempty"`     // Host names, with * wildcards, or CIDR ranges fetched in the local policy
+	DeniedHosts      []string `json:"deniedHosts,omitempty"`      // Host names or CIDR ranges that are never fetched
+	TimeoutSeconds   int      `json:"timeoutSeconds,omitempty"`   // Time limit of a request; 0 uses the default, negative disables it
+	MaxResponseBytes int      `json:"maxResponseBytes,omitempty"` // Response body kept; 0 uses the default, negative disables the limit
+}
+
+// PolicyName returns the host policy, treating an empty value as local
+func (h HTTPFetch) PolicyName() string {
+	if h.Policy == "" {
+		return HTTPFetchPolicyLocal
+	}
+	return h.Policy
+}
+
+// Limits returns the request timeout and the response size limit, with 0 meaning
+// unlimited
+func (h HTTPFetch) Limits() (time.Duration, int) {
+	limit := func(configured, def int) int {
+		switch {
+		case configured < 0:
+			return 0
+		case configured == 0:
+			return def
+		default:
+			return configured
+		}
+	}
+	return time.Duration(limit(h.TimeoutSeconds, DefaultHTTPFetchTimeoutSeconds)) * time.Second,
+		limit(h.MaxResponseBytes, DefaultHTTPFetchMaxResponseBytes)
+}
+
+// HostDenied reports whether a host name, or an address given as the host, is denied
+func (h HTTPFetch) HostDenied(host string) bool {
+	return matchHost(h.DeniedHosts, host, nil)
+}
+
+// HostAllowedByName reports whether a host name is allowed before it is resolved: denied
+// names never are, and in the local policy only localhost and the allowed names are
+func (h HTTPFetch) HostAllowedByName(host string) bool {
+	if h.HostDenied(host) {
+		return false
+	}
+	return h.PolicyName() == HTTPFetchPolicyAny || isLocalhost(host) || matchHost(h.AllowedHosts, host, nil)
+}
+
+// AddressAllowed reports whether a host may be contacted at one of its addresses
+func (h HTTPFetch) AddressAllowed(host string, ip net.IP) bool {
+	if matchHost(h.DeniedHosts, host, ip) {
+		return false
+	}
+	if h.PolicyName() == HTTPFetchPolicyAny || ip.IsLoopback() {
+		return true
+	}
+	return matchHost(h.AllowedHosts, host, ip)
+}
+
+// AllowsAddressRanges reports whether the local policy allows CIDR ranges, so names
+// outside the allowed names have to be resolved before they can be judged
+func (h HTTPFetch) AllowsAddressRanges() bool {
+	for _, pattern := range h.AllowedHosts {
+		if strings.Contains(pattern, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchHost reports whether a host name, or its address when ip is not nil, matches one
+// of the patterns. Name patterns are case-insensitive globs, range patterns are CIDRs.
+func matchHost(patterns []string, host string, ip net.IP) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if ip == nil {
+		ip = net.ParseIP(host)
+	}
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, "/") {
+			if _, network, err := net.ParseCIDR(pattern); err == nil && ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if matched, _ := filepath.Match(strings.ToLower(pattern), host); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isLocalhost reports whether a host name always refers to the local machine
+func isLocalhost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// validate checks the policy and host patterns
+func (h HTTPFetch) validate() error {
+	switch h.PolicyName() {
+	case HTTPFetchPolicyLocal, HTTPFetchPolicyAny:
+	default:
+		return fmt.Errorf("httpFetch policy must be %q or %q", HTTPFetchPolicyLocal, HTTPFetchPolicyAny)
+	}
+	for _, pattern := range append(append([]string(nil), h.AllowedHosts...), h.DeniedHosts...) {
+		if strings.Contains(pattern, "/") {
+			if _, _, err := net.ParseCIDR(pattern); err != nil {
+				return fmt.Errorf("invalid httpFetch host range %q: %w", pattern, err)
+			}
+			continue
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil || strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("invalid httpFetch host pattern %q", pattern)
+		}
+	}
+	return nil
+}
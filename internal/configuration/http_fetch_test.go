@@ -0,0 +1,71 @@
+package configuration
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestHTTPFetch_Hosts(t *testing.T) {
+	local := HTTPFetch{AllowedHosts: []string{"docs.internal", "*.corp.example", "10.0.0.0/8"}, DeniedHosts: []string{"secret.corp.example", "10.9.0.0/16"}}
+	anyPolicy := HTTPFetch{Policy: HTTPFetchPolicyAny, DeniedHosts: []string{"169.254.0.0/16"}}
+
+	tests := []struct {
+		name   string
+		policy HTTPFetch
+		host   string
+		ip     string
+		byName bool
+		byIP   bool
+	}{
+		{"localhost", local, "localhost", "127.0.0.1", true, true},
+		{"allowed name", local, "docs.internal", "192.168.1.5", true, true},
+		{"allowed wildcard, case-insensitive", local, "Wiki.Corp.Example", "192.168.1.6", true, true},
+		{"denied name", local, "secret.corp.example", "192.168.1.7", false, false},
+		{"allowed range", local, "build", "10.1.2.3", false, true},
+		{"denied range inside allowed range", local, "metrics", "10.9.1.1", false, false},
+		{"remote host", local, "example.com", "93.184.216.34", false, false},
+		{"name resolving to loopback", local, "dev.test", "127.0.0.1", false, true},
+		{"any policy", anyPolicy, "example.com", "93.184.216.34", true, true},
+		{"any policy denied range", anyPolicy, "metadata", "169.254.169.254", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.HostAllowedByName(tt.host); got != tt.byName {
+				t.Errorf("HostAllowedByName(%s) = %v, want %v", tt.host, got, tt.byName)
+			}
+			if got := tt.policy.AddressAllowed(tt.host, net.ParseIP(tt.ip)); got != tt.byIP {
+				t.Errorf("AddressAllowed(%s, %s) = %v, want %v", tt.host, tt.ip, got, tt.byIP)
+			}
+		})
+	}
+}
+
+func TestHTTPFetch_Limits(t *testing.T) {
+	timeout, maxBytes := HTTPFetch{}.Limits()
+	if timeout != DefaultHTTPFetchTimeoutSeconds*time.Second || maxBytes != DefaultHTTPFetchMaxResponseBytes {
+		t.Errorf("Expected the default limits, got %v and %d", timeout, maxBytes)
+	}
+	timeout, maxBytes = HTTPFetch{TimeoutSeconds: -1, MaxResponseBytes: 4096}.Limits()
+	if timeout != 0 || maxBytes != 4096 {
+		t.Errorf("Expected no timeout and 4096 bytes, got %v and %d", timeout, maxBytes)
+	}
+}
+
+func TestConfig_HTTPFetchValidation(t *testing.T) {
+	config := DefaultConfig()
+	config.HTTPFetch = HTTPFetch{AllowedHosts: []string{"*.internal", "192.168.0.0/16"}}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected valid httpFetch settings, got %v", err)
+	}
+
+	config.HTTPFetch = HTTPFetch{Policy: "intranet"}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected an unknown policy to be rejected")
+	}
+
+	config.HTTPFetch = HTTPFetch{DeniedHosts: []string{"10.0.0.0/33"}}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected a malformed range to be rejected")
+	}
+}
@@ -0,0 +1,213 @@
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+)
+
+// ProjectMCPFileName is the name of the file defining a project's MCP servers, kept in
+// the project directory next to AGENTS.md. Servers imported from it are project-scoped.
+const ProjectMCPFileName = ".mcp.json"
+
+// mcpJSONFile is the mcpServers format shared by MCP clients
+type mcpJSONFile struct {
+	MCPServers map[string]mcpJSONServer `json:"mcpServers"`
+	Servers    map[string]mcpJSONServer `json:"servers,omitempty"` // VS Code's name for mcpServers
+}
+
+// mcpJSONServer is a server definition in an mcp.json file
+type mcpJSONServer struct {
+	Type     string            `json:"type,omitempty"` // stdio, http, streamable-http or sse
+	Command  string            `json:"command,omitempty"`
+	Args     []string          `json:"args,omitempty"`
+	Env      map[string]string `json:"env,omitempty"`
+	Cwd      string            `json:"cwd,omitempty"`
+	URL      string            `json:"url,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Disabled bool              `json:"disabled,omitempty"`
+}
+
+// envVariableReference matches values that are just a ${NAME} variable reference
+var envVariableReference = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+// embeddedEnvVariableReference matches ${NAME} variable references within a longer value
+var embeddedEnvVariableReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// embeddedEnvSecretReference matches ${env:NAME} secret references within a longer value
+var embeddedEnvSecretReference = regexp.MustCompile(`\$\{env:([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ReadMCPJSON reads the servers defined in an mcp.json file. Servers defined in a
+// project's .mcp.json are scoped to the directory containing it.
+func ReadMCPJSON(path string) ([]MCPServer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MCP server file: %w", err)
+	}
+
+	project := ""
+	if filepath.Base(path) == ProjectMCPFileName {
+		project, err = filepath.Abs(filepath.Dir(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve project directory: %w", err)
+		}
+	}
+
+	servers, err := ParseMCPJSON(data, project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return servers, nil
+}
+
+// ParseMCPJSON converts the servers of an mcp.json document, in name order, scoping them
+// to project unless it is empty. Environment values that are just ${NAME}, and ${NAME}
+// within header values, become ${env:NAME} secret references.
+func ParseMCPJSON(data []byte, project string) ([]MCPServer, error) {
+	var file mcpJSONFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	definitions := file.MCPServers
+	if len(definitions) == 0 {
+		definitions = file.Servers
+	}
+
+	servers := make([]MCPServer, 0, len(definitions))
+	for _, name := range slices.Sorted(maps.Keys(definitions)) {
+		definition := definitions[name]
+
+		transport, err := mcpJSONTransport(definition)
+		if err != nil {
+			return nil, fmt.Errorf("server '%s': %w", name, err)
+		}
+
+		server := MCPServer{
+			Name:       name,
+			Transport:  transport,
+			Command:    definition.Command,
+			Arguments:  definition.Args,
+			WorkingDir: definition.Cwd,
+			URL:        definition.URL,
+			Headers:    convertHeaderReferences(definition.Headers, embeddedEnvVariableReference, "$${env:$1}"),
+			Project:    project,
+			Enabled:    !definition.Disabled,
+		}
+		if server.Arguments == nil {
+			server.Arguments = []string{}
+		}
+		for variable, value := range definition.Env {
+			if server.Env == nil {
+				server.Env = make(map[string]string)
+			}
+			if match := envVariableReference.FindStringSubmatch(value); match != nil {
+				value = "${" + SecretSourceEnv + ":" + match[1] + "}"
+			}
+			server.Env[variable] = value
+		}
+		servers = append(servers, server)
+	}
+	return servers, nil
+}
+
+// mcpJSONTransport maps the type of an mcp.json server to a transport. Servers without
+// a type are remote when they have a URL and no command.
+func mcpJSONTransport(definition mcpJSONServer) (string, error) {
+	switch definition.Type {
+	case "":
+		if definition.Command == "" && definition.URL != "" {
+			return MCPTransportHTTP, nil
+		}
+		return "", nil
+	case MCPTransportStdio:
+		return "", nil
+	case MCPTransportHTTP, "streamable-http", "streamableHttp":
+		return MCPTransportHTTP, nil
+	case MCPTransportSSE:
+		return MCPTransportSSE, nil
+	default:
+		return "", fmt.Errorf("unknown server type %q", definition.Type)
+	}
+}
+
+// MarshalMCPJSON converts servers to an mcp.json document. ${env:NAME} references in
+// environment and header values are written as ${NAME}, the form other clients expand.
+func MarshalMCPJSON(servers []MCPServer) ([]byte, error) {
+	file := mcpJSONFile{MCPServers: make(map[string]mcpJSONServer, len(servers))}
+	for _, server := range servers {
+		definition := mcpJSONServer{
+			Type:     server.TransportName(),
+			Command:  server.Command,
+			Args:     server.Arguments,
+			Cwd:      server.WorkingDir,
+			URL:      server.URL,
+			Headers:  convertHeaderReferences(server.Headers, embeddedEnvSecretReference, "$${$1}"),
+			Disabled: !server.Enabled,
+		}
+		for variable, value := range server.Env {
+			if definition.Env == nil {
+				definition.Env = make(map[string]string)
+			}
+			if source, target, ok := parseSecretReference(value); ok && source == SecretSourceEnv {
+				value = "${" + target + "}"
+			}
+			definition.Env[variable] = value
+		}
+		file.MCPServers[server.Name] = definition
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal MCP servers: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// convertHeaderReferences returns a copy of headers with the variable references matched
+// by reference rewritten to template, as in regexp.Regexp.ReplaceAllString
+func convertHeaderReferences(headers map[string]string, reference *regexp.Regexp, template string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	converted := make(map[string]string, len(headers))
+	for name, value := range headers {
+		converted[name] = reference.ReplaceAllString(value, template)
+	}
+	return converted
+}
+
+// ImportMCPServers merges servers into the configuration without saving it. New servers
+// are added; a server with the name of a configured one replaces its definition but keeps
+// whether it is enabled and the settings mcp.json files do not have. It returns the
+// names of the added and updated servers.
+func (c *Config) ImportMCPServers(servers []MCPServer) (added, updated []string, err error) {
+	merged := slices.Clone(c.MCPServers)
+	for _, server := range servers {
+		index := slices.IndexFunc(merged, func(configured MCPServer) bool {
+			return configured.Name == server.Name
+		})
+		if index < 0 {
+			merged = append(merged, server)
+			added = append(added, server.Name)
+			continue
+		}
+
+		configured := merged[index]
+		server.InheritEnv = configured.InheritEnv
+		server.Roots = configured.Roots
+		server.Sampling = configured.Sampling
+		server.Enabled = configured.Enabled
+		merged[index] = server
+		updated = append(updated, server.Name)
+	}
+
+	if err := validateMCPServers(merged); err != nil {
+		return nil, nil, err
+	}
+	c.MCPServers = merged
+	return added, updated, nil
+}
@@ -0,0 +1,160 @@
+package configuration
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseMCPJSON(t *testing.T) {
+	data := []byte(`{
+  "mcpServers": {
+    "github": {
+      "command": "github-mcp-server",
+      "args": ["stdio"],
+      "env": { "GITHUB_TOKEN": "${GH_TOKEN}", "LOG": "debug ${LEVEL}" },
+      "cwd": "tools"
+    },
+    "tickets": { "type": "streamable-http", "url": "https://mcp.example.com/mcp",
+      "headers": { "X-Team": "platform", "Authorization": "Bearer ${TICKETS_TOKEN}" } },
+    "events": { "type": "sse", "url": "https://mcp.example.com/sse", "disabled": true },
+    "docs": { "url": "https://docs.example.com/mcp" }
+  }
+}`)
+
+	servers, err := ParseMCPJSON(data, "/home/user/project")
+	if err != nil {
+		t.Fatalf("ParseMCPJSON failed: %v", err)
+	}
+
+	expected := []MCPServer{
+		{Name: "docs", Transport: MCPTransportHTTP, Arguments: []string{}, URL: "https://docs.example.com/mcp",
+			Project: "/home/user/project", Enabled: true},
+		{Name: "events", Transport: MCPTransportSSE, Arguments: []string{}, URL: "https://mcp.example.com/sse",
+			Project: "/home/user/project", Enabled: false},
+		{Name: "github", Command: "github-mcp-server", Arguments: []string{"stdio"},
+			Env:        map[string]string{"GITHUB_TOKEN": "${env:GH_TOKEN}", "LOG": "debug ${LEVEL}"},
+			WorkingDir: "tools", Project: "/home/user/project", Enabled: true},
+		{Name: "tickets", Transport: MCPTransportHTTP, Arguments: []string{}, URL: "https://mcp.example.com/mcp",
+			Headers: map[string]string{"X-Team": "platform", "Authorization": "Bearer ${env:TICKETS_TOKEN}"},
+			Project: "/home/user/project", Enabled: true},
+	}
+	if !reflect.DeepEqual(servers, expected) {
+		t.Errorf("Expected servers\n%+v\ngot\n%+v", expected, servers)
+	}
+}
+
+func TestParseMCPJSON_VSCodeServers(t *testing.T) {
+	servers, err := ParseMCPJSON([]byte(`{"servers": {"files": {"type": "stdio", "command": "files-mcp"}}}`), "")
+	if err != nil {
+		t.Fatalf("ParseMCPJSON failed: %v", err)
+	}
+	if len(servers) != 1 || servers[0].Name != "files" || servers[0].Command != "files-mcp" || servers[0].Project != "" {
+		t.Errorf("Unexpected servers %+v", servers)
+	}
+}
+
+func TestParseMCPJSON_UnknownType(t *testing.T) {
+	if _, err := ParseMCPJSON([]byte(`{"mcpServers": {"ws": {"type": "websocket", "url": "ws://localhost"}}}`), ""); err == nil {
+		t.Error("Expected an error for an unknown server type")
+	}
+}
+
+func TestMarshalMCPJSON_RoundTrip(t *testing.T) {
+	servers := []MCPServer{
+		{Name: "github", Command: "github-mcp-server", Arguments: []string{"stdio"},
+			Env: map[string]string{"GITHUB_TOKEN": "${env:GH_TOKEN}", "KEY": "${file:~/.key}"}, Enabled: true},
+		{Name: "tickets", Transport: MCPTransportHTTP, Arguments: []string{}, URL: "https://mcp.example.com/mcp",
+			Headers: map[string]string{"Authorization": "Bearer ${env:TICKETS_TOKEN}", "X-Key": "${file:~/.key}"}, Enabled: false},
+	}
+
+	data, err := MarshalMCPJSON(servers)
+	if err != nil {
+		t.Fatalf("MarshalMCPJSON failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"Bearer ${TICKETS_TOKEN}"`) {
+		t.Errorf("Expected header references in the form other clients expand, got\n%s", data)
+	}
+	parsed, err := ParseMCPJSON(data, "")
+	if err != nil {
+		t.Fatalf("ParseMCPJSON failed: %v\n%s", err, data)
+	}
+	if !reflect.DeepEqual(parsed, servers) {
+		t.Errorf("Expected servers\n%+v\ngot\n%+v\nfrom\n%s", servers, parsed, data)
+	}
+}
+
+func TestReadMCPJSON_ProjectScope(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte(`{"mcpServers": {"files": {"command": "files-mcp"}}}`)
+	for _, name := range []string{ProjectMCPFileName, "mcp.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	servers, err := ReadMCPJSON(filepath.Join(dir, ProjectMCPFileName))
+	if err != nil {
+		t.Fatalf("ReadMCPJSON failed: %v", err)
+	}
+	if servers[0].Project != dir {
+		t.Errorf("Expected servers of %s to belong to %s, got %q", ProjectMCPFileName, dir, servers[0].Project)
+	}
+
+	servers, err = ReadMCPJSON(filepath.Join(dir, "mcp.json"))
+	if err != nil {
+		t.Fatalf("ReadMCPJSON failed: %v", err)
+	}
+	if servers[0].Project != "" {
+		t.Errorf("Expected servers of mcp.json to be global, got %q", servers[0].Project)
+	}
+}
+
+func TestImportMCPServers(t *testing.T) {
+	config := DefaultConfig()
+	config.MCPServers = []MCPServer{
+		{Name: "github", Command: "old-github-mcp", Sampling: MCPSamplingNever, Roots: []string{"src"}, Enabled: false},
+	}
+
+	added, updated, err := config.ImportMCPServers([]MCPServer{
+		{Name: "github", Command: "github-mcp-server", Arguments: []string{"stdio"}, Enabled: true},
+		{Name: "files", Command: "files-mcp", Arguments: []string{}, Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("ImportMCPServers failed: %v", err)
+	}
+	if !slices.Equal(added, []string{"files"}) || !slices.Equal(updated, []string{"github"}) {
+		t.Errorf("Expected files added and github updated, got %v and %v", added, updated)
+	}
+
+	github := config.GetMCPServer("github")
+	if github.Command != "github-mcp-server" || github.Sampling != MCPSamplingNever || !slices.Equal(github.Roots, []string{"src"}) || github.Enabled {
+		t.Errorf("Expected the definition to be replaced and local settings kept, got %+v", github)
+	}
+
+	// Invalid servers leave the configuration unchanged
+	if _, _, err := config.ImportMCPServers([]MCPServer{{Name: "broken", Enabled: true}}); err == nil {
+		t.Error("Expected a server without a command to be rejected")
+	}
+	if len(config.MCPServers) != 2 {
+		t.Errorf("Expected the failed import to leave 2 servers, got %d", len(config.MCPServers))
+	}
+}
+
+func TestMCPServer_AppliesIn(t *testing.T) {
+	global := MCPServer{Name: "global"}
+	scoped := MCPServer{Name: "scoped", Project: "/home/user/project/"}
+
+	if !global.AppliesIn("/anywhere") {
+		t.Error("Expected servers without a project to apply everywhere")
+	}
+	if !scoped.AppliesIn("/home/user/project") {
+		t.Error("Expected project servers to apply in their project")
+	}
+	if scoped.AppliesIn("/home/user/other") {
+		t.Error("Expected project servers not to apply in other directories")
+	}
+}
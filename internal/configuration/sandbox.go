@@ -0,0 +1,108 @@
+package configuration
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Sandbox profiles for commands run by the execute_bash tool
+const (
+	SandboxProfileNone     = "none"     // Commands run with the user's privileges, environment and access
+	SandboxProfileIsolated = "isolated" // Commands run in a Linux namespace sandbox using bubblewrap
+)
+
+// Resource limits of the isolated profile when none are configured
+const (
+	DefaultSandboxCPUSeconds     = 60
+	DefaultSandboxMemoryMB       = 2048
+	DefaultSandboxMaxOutputBytes = 1 << 20
+)
+
+// BashSandbox configures how the execute_bash tool runs commands. In the isolated profile
+// commands see the project read-only, can only write to a scratch directory and the
+// writable paths, get a scrubbed environment and have no network unless it is allowed.
+type BashSandbox struct {
+	Profile        string   `json:"profile,omitempty"`        // none (default) or isolated
+	Network        bool     `json:"network,omitempty"`        // Whether isolated commands may use the network
+	WritablePaths  []string `json:"writablePaths,omitempty"`  // Absolute directories isolated commands may write to besides the scratch directory
+	CPUSeconds     int      `json:"cpuSeconds,omitempty"`     // CPU time limit; 0 uses the profile's default, negative disables it
+	MemoryMB       int      `json:"memoryMB,omitempty"`       // Virtual memory limit; 0 uses the profile's default, negative disables it
+	MaxOutputBytes int      `json:"maxOutputBytes,omitempty"` // Output kept from stdout and from stderr; 0 uses the profile's default, negative disables it
+}
+
+// ProfileName returns the sandbox profile, treating an empty value as none
+func (s BashSandbox) ProfileName() string {
+	if s.Profile == "" {
+		return SandboxProfileNone
+	}
+	return s.Profile
+}
+
+// IsIsolated reports whether commands run in the isolated profile
+func (s BashSandbox) IsIsolated() bool {
+	return s.ProfileName() == SandboxProfileIsolated
+}
+
+// Limits returns the CPU time, memory and output limits that apply, with 0 meaning
+// unlimited. The none profile has no limits unless they are configured.
+func (s BashSandbox) Limits() (cpuSeconds, memoryMB, maxOutputBytes int) {
+	limit := func(configured, isolatedDefault int) int {
+		switch {
+		case configured < 0:
+			return 0
+		case configured > 0:
+			return configured
+		case s.IsIsolated():
+			return isolatedDefault
+		default:
+			return 0
+		}
+	}
+	return limit(s.CPUSeconds, DefaultSandboxCPUSeconds),
+		limit(s.MemoryMB, DefaultSandboxMemoryMB),
+		limit(s.MaxOutputBytes, DefaultSandboxMaxOutputBytes)
+}
+
+// Summary describes the profile and its limits on one line
+func (s BashSandbox) Summary() string {
+	var details []string
+	if s.IsIsolated() {
+		if s.Network {
+			details = append(details, "network")
+		} else {
+			details = append(details, "no network")
+		}
+	}
+
+	cpuSeconds, memoryMB, maxOutputBytes := s.Limits()
+	if cpuSeconds > 0 {
+		details = append(details, fmt.Sprintf("CPU %ds", cpuSeconds))
+	}
+	if memoryMB > 0 {
+		details = append(details, fmt.Sprintf("memory %d MB", memoryMB))
+	}
+	if maxOutputBytes > 0 {
+		details = append(details, fmt.Sprintf("output %d KB", maxOutputBytes/1024))
+	}
+
+	if len(details) == 0 {
+		return s.ProfileName()
+	}
+	return fmt.Sprintf("%s (%s)", s.ProfileName(), strings.Join(details, ", "))
+}
+
+// validate checks the profile and writable paths
+func (s BashSandbox) validate() error {
+	switch s.ProfileName() {
+	case SandboxProfileNone, SandboxProfileIsolated:
+	default:
+		return fmt.Errorf("bashSandbox profile must be %q or %q", SandboxProfileNone, SandboxProfileIsolated)
+	}
+	for _, path := range s.WritablePaths {
+		if !filepath.IsAbs(path) {
+			return fmt.Errorf("bashSandbox writable path %q must be absolute", path)
+		}
+	}
+	return nil
+}
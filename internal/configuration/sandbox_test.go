@@ -0,0 +1,71 @@
+package configuration
+
+import "testing"
+
+func TestBashSandbox_Limits(t *testing.T) {
+	tests := []struct {
+		name    string
+		sandbox BashSandbox
+		cpu     int
+		memory  int
+		output  int
+		summary string
+	}{
+		{
+			name:    "default profile is unlimited",
+			sandbox: BashSandbox{},
+			summary: "none",
+		},
+		{
+			name:    "configured limits apply without isolation",
+			sandbox: BashSandbox{CPUSeconds: 5, MaxOutputBytes: 4096},
+			cpu:     5,
+			output:  4096,
+			summary: "none (CPU 5s, output 4 KB)",
+		},
+		{
+			name:    "isolated profile defaults",
+			sandbox: BashSandbox{Profile: SandboxProfileIsolated},
+			cpu:     DefaultSandboxCPUSeconds,
+			memory:  DefaultSandboxMemoryMB,
+			output:  DefaultSandboxMaxOutputBytes,
+			summary: "isolated (no network, CPU 60s, memory 2048 MB, output 1024 KB)",
+		},
+		{
+			name:    "negative limits disable the defaults",
+			sandbox: BashSandbox{Profile: SandboxProfileIsolated, Network: true, CPUSeconds: -1, MemoryMB: -1, MaxOutputBytes: 512 * 1024},
+			output:  512 * 1024,
+			summary: "isolated (network, output 512 KB)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpu, memory, output := tt.sandbox.Limits()
+			if cpu != tt.cpu || memory != tt.memory || output != tt.output {
+				t.Errorf("Limits() = %d, %d, %d, want %d, %d, %d", cpu, memory, output, tt.cpu, tt.memory, tt.output)
+			}
+			if summary := tt.sandbox.Summary(); summary != tt.summary {
+				t.Errorf("Summary() = %q, want %q", summary, tt.summary)
+			}
+		})
+	}
+}
+
+func TestConfig_BashSandboxValidation(t *testing.T) {
+	config := DefaultConfig()
+	config.BashSandbox = BashSandbox{Profile: SandboxProfileIsolated, WritablePaths: []string{"/srv/build"}}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected the isolated profile to be valid, got %v", err)
+	}
+
+	config.BashSandbox = BashSandbox{Profile: "chroot"}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected an unknown profile to be rejected")
+	}
+
+	config.BashSandbox = BashSandbox{Profile: SandboxProfileIsolated, WritablePaths: []string{"build"}}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected a relative writable path to be rejected")
+	}
+}
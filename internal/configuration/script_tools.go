@@ -0,0 +1,118 @@
+package configuration
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultScriptToolTimeoutSeconds is the time limit of a script tool when none is configured
+const DefaultScriptToolTimeoutSeconds = 30
+
+// ScriptToolPlaceholder matches a {{name}} placeholder in a script tool's command
+var ScriptToolPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// scriptToolName matches the names script tools may have; dots are left to MCP tools
+var scriptToolName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// scriptToolTypes are the JSON schema types script tool parameters may have
+var scriptToolTypes = []string{"string", "integer", "number", "boolean", "array"}
+
+// ScriptTool declares a tool that runs a program. Its command is a fixed argv in which
+// {{name}} placeholders are replaced by the call's arguments, each value staying one
+// argument, so no shell ever interprets them.
+type ScriptTool struct {
+	Name           string           `json:"name"`
+	Description    string           `json:"description"`
+	Parameters     ScriptToolSchema `json:"parameters"`               // JSON schema of the arguments
+	Command        []string         `json:"command"`                  // Program and arguments, with {{name}} placeholders
+	WorkingDir     string           `json:"workingDir,omitempty"`     // Directory the command runs in; relative to the project directory, empty uses it
+	TimeoutSeconds int              `json:"timeoutSeconds,omitempty"` // Time limit of a call; 0 uses the default
+}
+
+// ScriptToolSchema is the JSON schema of a script tool's arguments
+type ScriptToolSchema struct {
+	Type       string                        `json:"type,omitempty"` // Always object
+	Properties map[string]ScriptToolProperty `json:"properties,omitempty"`
+	Required   []string                      `json:"required,omitempty"`
+}
+
+// ScriptToolProperty is the JSON schema of one argument
+type ScriptToolProperty struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Enum         []any  `json:"enum,omitempty"`
+	AllowOptions bool   `json:"allowOptions,omitempty"` // Whether values may start a command argument with -, so the program reads them as options
+}
+
+// Timeout returns the time limit of a call in seconds
+func (s ScriptTool) Timeout() int {
+	if s.TimeoutSeconds <= 0 {
+		return DefaultScriptToolTimeoutSeconds
+	}
+	return s.TimeoutSeconds
+}
+
+// validate checks the name, schema and command, and that every placeholder names a
+// declared parameter
+func (s ScriptTool) validate() error {
+	if !scriptToolName.MatchString(s.Name) {
+		return fmt.Errorf("script tool name %q must start with a letter and contain only letters, digits, _ and -", s.Name)
+	}
+	if strings.TrimSpace(s.Description) == "" {
+		return fmt.Errorf("script tool '%s' has no description", s.Name)
+	}
+	if s.Parameters.Type != "" && s.Parameters.Type != "object" {
+		return fmt.Errorf("script tool '%s' parameters must be of type object", s.Name)
+	}
+	for name, property := range s.Parameters.Properties {
+		if !slices.Contains(scriptToolTypes, property.Type) {
+			return fmt.Errorf("script tool '%s' parameter %s has type %q (must be one of %s)",
+				s.Name, name, property.Type, strings.Join(scriptToolTypes, ", "))
+		}
+	}
+	for _, name := range s.Parameters.Required {
+		if _, ok := s.Parameters.Properties[name]; !ok {
+			return fmt.Errorf("script tool '%s' requires undeclared parameter %s", s.Name, name)
+		}
+	}
+
+	if len(s.Command) == 0 || strings.TrimSpace(s.Command[0]) == "" {
+		return fmt.Errorf("script tool '%s' has empty command", s.Name)
+	}
+	if ScriptToolPlaceholder.MatchString(s.Command[0]) {
+		return fmt.Errorf("script tool '%s' program cannot contain placeholders", s.Name)
+	}
+	for _, argument := range s.Command[1:] {
+		for _, match := range ScriptToolPlaceholder.FindAllStringSubmatch(argument, -1) {
+			property, ok := s.Parameters.Properties[match[1]]
+			if !ok {
+				return fmt.Errorf("script tool '%s' command uses undeclared parameter %s", s.Name, match[1])
+			}
+			if property.Type == "array" && strings.TrimSpace(argument) != match[0] {
+				return fmt.Errorf("script tool '%s' array parameter %s must be a whole command argument", s.Name, match[1])
+			}
+		}
+	}
+
+	if s.TimeoutSeconds < 0 {
+		return fmt.Errorf("script tool '%s' timeoutSeconds cannot be negative", s.Name)
+	}
+	return nil
+}
+
+// validateScriptTools checks that script tools are valid and have unique names
+func validateScriptTools(tools []ScriptTool) error {
+	names := make(map[string]bool)
+	for _, tool := range tools {
+		if err := tool.validate(); err != nil {
+			return err
+		}
+		if names[tool.Name] {
+			return fmt.Errorf("duplicate script tool name: %s", tool.Name)
+		}
+		names[tool.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package configuration
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScriptTool_Validate(t *testing.T) {
+	valid := func() ScriptTool {
+		return ScriptTool{
+			Name:        "go_test",
+			Description: "Run Go tests",
+			Parameters: ScriptToolSchema{
+				Type: "object",
+				Properties: map[string]ScriptToolProperty{
+					"package": {Type: "string"},
+					"run":     {Type: "string"},
+					"flags":   {Type: "array"},
+				},
+				Required: []string{"package"},
+			},
+			Command: []string{"go", "test", "{{package}}", "-run={{ run }}", "{{flags}}"},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*ScriptTool)
+		errMsg string
+	}{
+		{"valid", func(*ScriptTool) {}, ""},
+		{"name with a dot", func(s *ScriptTool) { s.Name = "server.tool" }, "must start with a letter"},
+		{"no description", func(s *ScriptTool) { s.Description = " " }, "has no description"},
+		{"parameters not an object", func(s *ScriptTool) { s.Parameters.Type = "string" }, "must be of type object"},
+		{"unknown parameter type", func(s *ScriptTool) { s.Parameters.Properties["run"] = ScriptToolProperty{Type: "object"} }, `has type "object"`},
+		{"undeclared required parameter", func(s *ScriptTool) { s.Parameters.Required = []string{"missing"} }, "requires undeclared parameter missing"},
+		{"empty command", func(s *ScriptTool) { s.Command = nil }, "has empty command"},
+		{"placeholder in program", func(s *ScriptTool) { s.Command[0] = "{{package}}" }, "program cannot contain placeholders"},
+		{"undeclared placeholder", func(s *ScriptTool) { s.Command = append(s.Command, "{{count}}") }, "uses undeclared parameter count"},
+		{"array inside an argument", func(s *ScriptTool) { s.Command = append(s.Command, "--flags={{flags}}") }, "must be a whole command argument"},
+		{"negative timeout", func(s *ScriptTool) { s.TimeoutSeconds = -1 }, "cannot be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := valid()
+			tt.modify(&tool)
+			err := validateScriptTools([]ScriptTool{tool})
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+
+	if err := validateScriptTools([]ScriptTool{valid(), valid()}); err == nil || !strings.Contains(err.Error(), "duplicate script tool name") {
+		t.Errorf("Expected duplicate names to fail, got %v", err)
+	}
+}
+
+func TestScriptTool_Timeout(t *testing.T) {
+	if got := (ScriptTool{}).Timeout(); got != DefaultScriptToolTimeoutSeconds {
+		t.Errorf("Timeout() = %d, want the default %d", got, DefaultScriptToolTimeoutSeconds)
+	}
+	if got := (ScriptTool{TimeoutSeconds: 5}).Timeout(); got != 5 {
+		t.Errorf("Timeout() = %d, want 5", got)
+	}
+}
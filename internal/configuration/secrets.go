@@ -0,0 +1,126 @@
+package configuration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Secret sources, which keep secret values out of the settings file. A value of the
+// form ${env:NAME} is read from an environment variable and ${file:PATH} from a file.
+const (
+	SecretSourceEnv  = "env"
+	SecretSourceFile = "file"
+)
+
+// secretReference matches values of the form ${source:target}
+var secretReference = regexp.MustCompile(`^\$\{([a-z]+):(.*)\}$`)
+
+// embeddedSecretReference matches references within a longer value, such as the token
+// in "Bearer ${env:TOKEN}"
+var embeddedSecretReference = regexp.MustCompile(`\$\{[a-z]+:[^}]*\}`)
+
+// parseSecretReference splits a secret reference into its source and target, reporting
+// whether value is a reference at all
+func parseSecretReference(value string) (source, target string, ok bool) {
+	match := secretReference.FindStringSubmatch(value)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
+
+// IsSecretReference reports whether value refers to a secret rather than holding it
+func IsSecretReference(value string) bool {
+	_, _, ok := parseSecretReference(value)
+	return ok
+}
+
+// validateSecretReference checks that a value is either a plain value or a well-formed reference
+func validateSecretReference(value string) error {
+	source, target, ok := parseSecretReference(value)
+	if !ok {
+		return nil
+	}
+	switch source {
+	case SecretSourceEnv, SecretSourceFile:
+	default:
+		return fmt.Errorf("unknown secret source %q in %q (must be %q or %q)", source, value, SecretSourceEnv, SecretSourceFile)
+	}
+	if strings.TrimSpace(target) == "" {
+		return fmt.Errorf("secret reference %q has no variable name or path", value)
+	}
+	return nil
+}
+
+// validateSecretReferences checks every reference within a value
+func validateSecretReferences(value string) error {
+	for _, reference := range embeddedSecretReference.FindAllString(value, -1) {
+		if err := validateSecretReference(reference); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResolveSecret returns the value a secret reference refers to. Values that are not
+// references are returned unchanged. Trailing newlines are removed from secret files.
+func ResolveSecret(value string) (string, error) {
+	source, target, ok := parseSecretReference(value)
+	if !ok {
+		return value, nil
+	}
+	if err := validateSecretReference(value); err != nil {
+		return "", err
+	}
+
+	switch source {
+	case SecretSourceEnv:
+		secret, found := os.LookupEnv(target)
+		if !found {
+			return "", fmt.Errorf("environment variable %s is not set", target)
+		}
+		return secret, nil
+	default:
+		path, err := expandHome(target)
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+}
+
+// ResolveSecrets returns value with every reference within it replaced by the secret it
+// refers to, so a reference can be part of a longer value such as an HTTP header
+func ResolveSecrets(value string) (string, error) {
+	var resolveErr error
+	resolved := embeddedSecretReference.ReplaceAllStringFunc(value, func(reference string) string {
+		secret, err := ResolveSecret(reference)
+		if err != nil && resolveErr == nil {
+			resolveErr = err
+		}
+		return secret
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return resolved, nil
+}
+
+// expandHome replaces a leading ~ in a path with the user's home directory
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
@@ -0,0 +1,112 @@
+package configuration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveSecret(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOLLAMA_TEST_SECRET", "from-env")
+
+	tests := []struct {
+		name        string
+		value       string
+		want        string
+		expectError bool
+	}{
+		{name: "plain value", value: "plain", want: "plain"},
+		{name: "dollar sign in plain value", value: "pa$$word", want: "pa$$word"},
+		{name: "environment variable", value: "${env:GOLLAMA_TEST_SECRET}", want: "from-env"},
+		{name: "unset environment variable", value: "${env:GOLLAMA_TEST_UNSET}", expectError: true},
+		{name: "file", value: "${file:" + keyFile + "}", want: "s3cret"},
+		{name: "missing file", value: "${file:" + filepath.Join(dir, "missing") + "}", expectError: true},
+		{name: "unknown source", value: "${vault:key}", expectError: true},
+		{name: "empty target", value: "${env:}", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveSecret(tt.value)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveSecret failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	t.Setenv("GOLLAMA_TEST_SECRET", "from-env")
+
+	tests := []struct {
+		name        string
+		value       string
+		want        string
+		expectError bool
+	}{
+		{name: "plain value", value: "Bearer token", want: "Bearer token"},
+		{name: "whole value", value: "${env:GOLLAMA_TEST_SECRET}", want: "from-env"},
+		{name: "within a value", value: "Bearer ${env:GOLLAMA_TEST_SECRET}", want: "Bearer from-env"},
+		{name: "several references", value: "${env:GOLLAMA_TEST_SECRET}:${env:GOLLAMA_TEST_SECRET}", want: "from-env:from-env"},
+		{name: "unset environment variable", value: "Bearer ${env:GOLLAMA_TEST_UNSET}", expectError: true},
+		{name: "unknown source", value: "Bearer ${vault:key}", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveSecrets(tt.value)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveSecrets failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResolveSecret_HomeDirectory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.WriteFile(filepath.Join(home, "token"), []byte("from-home"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ResolveSecret("${file:~/token}")
+	if err != nil {
+		t.Fatalf("ResolveSecret failed: %v", err)
+	}
+	if got != "from-home" {
+		t.Errorf("Expected %q, got %q", "from-home", got)
+	}
+}
+
+func TestMCPServer_InheritsEnv(t *testing.T) {
+	inherit := false
+	if !(MCPServer{}).InheritsEnv() {
+		t.Error("Expected servers to inherit the environment by default")
+	}
+	if (MCPServer{InheritEnv: &inherit}).InheritsEnv() {
+		t.Error("Expected InheritEnv false to be respected")
+	}
+}
@@ -0,0 +1,330 @@
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Trust rule decisions, from least to most restrictive
+const (
+	TrustRuleAllow = "allow" // The call runs without asking
+	TrustRuleAsk   = "ask"   // The user approves the call
+	TrustRuleDeny  = "deny"  // The call is blocked
+)
+
+// commandArguments names the argument holding the shell command of command tools
+var commandArguments = map[string]string{
+	"execute_bash": "command",
+}
+
+// pathArguments names the argument holding the path of filesystem tools
+var pathArguments = map[string]string{
+	"filesystem_read":  "path",
+	"filesystem_write": "path",
+	"grep":             "path",
+	"glob":             "path",
+	"file_tree":        "path",
+}
+
+// commandSeparators split a shell command into the commands it runs
+var commandSeparators = regexp.MustCompile(`&&|\|\||[;&|\n]`)
+
+// commandSubstitutions mark commands whose effect cannot be judged from their text
+var commandSubstitutions = regexp.MustCompile("\\$\\(|`|[<>]")
+
+// ToolTrustRule decides whether calls to a tool run, need approval or are blocked, based
+// on an argument. Patterns are globs, or regular expressions between slashes. Commands
+// match globs by prefix, so "git status" also covers "git status --short"; paths match
+// globs where * stays within a directory and ** crosses directories.
+type ToolTrustRule struct {
+	Tool     string `json:"tool"`               // Tool name, or a glob such as "github.*"
+	Argument string `json:"argument,omitempty"` // Argument to match; defaults to the command of execute_bash, the path of filesystem tools and any argument of other tools
+	Pattern  string `json:"pattern"`            // Glob, or a regular expression such as /^git (status|diff)\b/
+	Decision string `json:"decision"`           // allow, ask or deny
+}
+
+// String describes the rule on one line
+func (r ToolTrustRule) String() string {
+	argument := r.Argument
+	if argument == "" {
+		argument = "*"
+	}
+	return fmt.Sprintf("%s %s %s → %s", r.Tool, argument, r.Pattern, r.Decision)
+}
+
+// validate checks the decision and patterns of the rule
+func (r ToolTrustRule) validate() error {
+	if r.Tool == "" {
+		return fmt.Errorf("tool cannot be empty")
+	}
+	if _, err := filepath.Match(r.Tool, ""); err != nil {
+		return fmt.Errorf("invalid tool pattern %q: %w", r.Tool, err)
+	}
+	if r.Pattern == "" {
+		return fmt.Errorf("pattern cannot be empty")
+	}
+	if expression, ok := regexPattern(r.Pattern); ok {
+		if _, err := regexp.Compile(expression); err != nil {
+			return fmt.Errorf("invalid regular expression %q: %w", r.Pattern, err)
+		}
+	}
+	switch r.Decision {
+	case TrustRuleAllow, TrustRuleAsk, TrustRuleDeny:
+	default:
+		return fmt.Errorf("decision must be %q, %q or %q", TrustRuleAllow, TrustRuleAsk, TrustRuleDeny)
+	}
+	return nil
+}
+
+// validateTrustRules checks every trust rule
+func validateTrustRules(rules []ToolTrustRule) error {
+	for i, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("tool trust rule %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// appliesTo reports whether the rule is for a tool
+func (r ToolTrustRule) appliesTo(tool string) bool {
+	matched, _ := filepath.Match(r.Tool, tool)
+	return matched
+}
+
+// argumentFor returns the argument the rule matches for a tool, or an empty string when
+// it matches any argument
+func (r ToolTrustRule) argumentFor(tool string) string {
+	if r.Argument != "" {
+		return r.Argument
+	}
+	if argument, ok := commandArguments[tool]; ok {
+		return argument
+	}
+	return pathArguments[tool]
+}
+
+// ToolTrustRulesFor returns the trust rules applying to a tool
+func (c *Config) ToolTrustRulesFor(tool string) []ToolTrustRule {
+	var rules []ToolTrustRule
+	for _, rule := range c.ToolTrustRules {
+		if rule.appliesTo(tool) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// ToolTrustDecision evaluates the trust rules for a tool call, resolving relative paths
+// against workingDir. The most restrictive matching rule decides. A shell command is
+// judged command by command: it is allowed only when every command it runs is allowed
+// and it has no substitutions or redirections. It returns an empty decision when no
+// rule decides, in which case the tool's trust level applies.
+func (c *Config) ToolTrustDecision(tool string, args map[string]any, workingDir string) (string, *ToolTrustRule) {
+	var decision string
+	var decidingRule *ToolTrustRule
+	decide := func(ruleDecision string, rule *ToolTrustRule) {
+		if restrictiveness(ruleDecision) > restrictiveness(decision) {
+			decision, decidingRule = ruleDecision, rule
+		}
+	}
+
+	commandArgument := commandArguments[tool]
+	var commandRules []*ToolTrustRule
+	for i := range c.ToolTrustRules {
+		rule := &c.ToolTrustRules[i]
+		if !rule.appliesTo(tool) {
+			continue
+		}
+		argument := rule.argumentFor(tool)
+		if commandArgument != "" && argument == commandArgument {
+			commandRules = append(commandRules, rule)
+			continue
+		}
+		if rule.matchesArguments(tool, argument, args, workingDir) {
+			decide(rule.Decision, rule)
+		}
+	}
+
+	command, ok := args[commandArgument].(string)
+	if len(commandRules) == 0 || !ok {
+		return decision, decidingRule
+	}
+
+	// Every command of a compound command must be allowed for the call to be allowed
+	allowed := !commandSubstitutions.MatchString(command)
+	var allowingRule *ToolTrustRule
+	for _, segment := range commandSeparators.Split(command, -1) {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			continue
+		}
+
+		var segmentDecision string
+		var segmentRule *ToolTrustRule
+		for _, rule := range commandRules {
+			if rule.matchesCommand(segment) && restrictiveness(rule.Decision) > restrictiveness(segmentDecision) {
+				segmentDecision, segmentRule = rule.Decision, rule
+			}
+		}
+
+		switch segmentDecision {
+		case TrustRuleDeny, TrustRuleAsk:
+			decide(segmentDecision, segmentRule)
+		case TrustRuleAllow:
+			allowingRule = segmentRule
+		default:
+			allowed = false
+		}
+	}
+	if allowed && allowingRule != nil {
+		decide(TrustRuleAllow, allowingRule)
+	}
+	return decision, decidingRule
+}
+
+// restrictiveness orders decisions, with no decision least restrictive
+func restrictiveness(decision string) int {
+	switch decision {
+	case TrustRuleAllow:
+		return 1
+	case TrustRuleAsk:
+		return 2
+	case TrustRuleDeny:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// matchesCommand reports whether a single shell command matches the rule
+func (r ToolTrustRule) matchesCommand(command string) bool {
+	if expression, ok := regexPattern(r.Pattern); ok {
+		matched, _ := regexp.MatchString(expression, command)
+		return matched
+	}
+	// A command glob matches whole words at the start of the command
+	matched, _ := regexp.MatchString("^"+GlobExpression(r.Pattern, false)+`(\s|$)`, command)
+	return matched
+}
+
+// matchesArguments reports whether an argument of a call matches the rule, or any
+// argument when argument is empty
+func (r ToolTrustRule) matchesArguments(tool, argument string, args map[string]any, workingDir string) bool {
+	if argument == "" {
+		for _, value := range args {
+			if r.matchesValue(argumentText(value)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	value, ok := args[argument]
+	if !ok {
+		return false
+	}
+	if pathArguments[tool] == argument {
+		return r.matchesPath(argumentText(value), workingDir)
+	}
+	return r.matchesValue(argumentText(value))
+}
+
+// matchesValue reports whether an argument value matches the rule
+func (r ToolTrustRule) matchesValue(value string) bool {
+	if expression, ok := regexPattern(r.Pattern); ok {
+		matched, _ := regexp.MatchString(expression, value)
+		return matched
+	}
+	matched, _ := regexp.MatchString("^"+GlobExpression(r.Pattern, false)+"$", value)
+	return matched
+}
+
+// matchesPath reports whether a path matches the rule, with both resolved against workingDir
+func (r ToolTrustRule) matchesPath(value, workingDir string) bool {
+	if !filepath.IsAbs(value) {
+		value = filepath.Join(workingDir, value)
+	}
+	value = filepath.Clean(value)
+
+	if expression, ok := regexPattern(r.Pattern); ok {
+		matched, _ := regexp.MatchString(expression, value)
+		return matched
+	}
+	pattern, err := expandHome(r.Pattern)
+	if err != nil {
+		return false
+	}
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(workingDir, pattern)
+	}
+	matched, _ := regexp.MatchString("^"+GlobExpression(filepath.ToSlash(pattern), true)+"$", filepath.ToSlash(value))
+	return matched
+}
+
+// regexPattern returns the expression of a pattern written between slashes
+func regexPattern(pattern string) (string, bool) {
+	if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		return pattern[1 : len(pattern)-1], true
+	}
+	return "", false
+}
+
+// GlobExpression converts a glob to a regular expression. In paths * and ? stay within a
+// directory, ** crosses directories, a trailing /** matches the directory itself too and
+// [...] matches a character class, with [!...] negating it; elsewhere * matches anything. It is shared by trust rules, deny patterns,
+// .gitignore files and the code search tools so they read patterns the same way.
+func GlobExpression(glob string, paths bool) string {
+	var expression strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case glob[i:] == "/**" && paths:
+			// A trailing /** matches the directory itself too, so it is hidden as well
+			expression.WriteString("(/.*)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**/") && paths:
+			expression.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			expression.WriteString(".*")
+			i++
+		case glob[i] == '*' && paths:
+			expression.WriteString("[^/]*")
+		case glob[i] == '*':
+			expression.WriteString(".*")
+		case glob[i] == '?' && paths:
+			expression.WriteString("[^/]")
+		case glob[i] == '?':
+			expression.WriteString(".")
+		case glob[i] == '[' && paths:
+			if end := strings.IndexByte(glob[i:], ']'); end > 1 {
+				class := glob[i+1 : i+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				expression.WriteString("[" + class + "]")
+				i += end
+				continue
+			}
+			expression.WriteString(`\[`)
+		default:
+			expression.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return expression.String()
+}
+
+// argumentText returns an argument value as text; values that are not strings are JSON
+func argumentText(value any) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
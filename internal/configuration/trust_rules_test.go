@@ -0,0 +1,111 @@
+package configuration
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestConfig_ToolTrustDecision(t *testing.T) {
+	config := DefaultConfig()
+	config.ToolTrustRules = []ToolTrustRule{
+		{Tool: "execute_bash", Pattern: "git status", Decision: TrustRuleAllow},
+		{Tool: "execute_bash", Pattern: "git diff*", Decision: TrustRuleAllow},
+		{Tool: "execute_bash", Pattern: "ls", Decision: TrustRuleAllow},
+		{Tool: "execute_bash", Pattern: "git push", Decision: TrustRuleAsk},
+		{Tool: "execute_bash", Pattern: `/\brm\s+-\w*r/`, Decision: TrustRuleDeny},
+		{Tool: "filesystem_write", Pattern: "src/**", Decision: TrustRuleAllow},
+		{Tool: "filesystem_write", Pattern: "**/.env", Decision: TrustRuleDeny},
+		{Tool: "filesystem_read", Pattern: "~/.ssh/**", Decision: TrustRuleDeny},
+		{Tool: "github.*", Argument: "repo", Pattern: "myorg/*", Decision: TrustRuleAllow},
+		{Tool: "tickets.*", Pattern: "/DELETE/", Decision: TrustRuleDeny},
+	}
+
+	tests := []struct {
+		name     string
+		tool     string
+		args     map[string]any
+		expected string
+	}{
+		{"allowed command", "execute_bash", map[string]any{"command": "git status"}, TrustRuleAllow},
+		{"allowed command with arguments", "execute_bash", map[string]any{"command": "git status --short"}, TrustRuleAllow},
+		{"prefix must end at a word", "execute_bash", map[string]any{"command": "git statusx"}, ""},
+		{"glob command", "execute_bash", map[string]any{"command": "git diff HEAD~1"}, TrustRuleAllow},
+		{"every command allowed", "execute_bash", map[string]any{"command": "git status && ls -la"}, TrustRuleAllow},
+		{"unmatched command in chain", "execute_bash", map[string]any{"command": "git status; curl example.com"}, ""},
+		{"denied command in chain", "execute_bash", map[string]any{"command": "ls && rm -rf /"}, TrustRuleDeny},
+		{"ask wins over allow", "execute_bash", map[string]any{"command": "git status | git push"}, TrustRuleAsk},
+		{"substitution is not allowed", "execute_bash", map[string]any{"command": "ls $(cat dirs)"}, ""},
+		{"redirection is not allowed", "execute_bash", map[string]any{"command": "git status > status.txt"}, ""},
+		{"relative path", "filesystem_write", map[string]any{"path": "src/app/main.go"}, TrustRuleAllow},
+		{"absolute path", "filesystem_write", map[string]any{"path": "/home/user/project/src/main.go"}, TrustRuleAllow},
+		{"path outside the glob", "filesystem_write", map[string]any{"path": "../other/src/main.go"}, ""},
+		{"deny wins over allow", "filesystem_write", map[string]any{"path": "src/.env"}, TrustRuleDeny},
+		{"cleaned path", "filesystem_write", map[string]any{"path": "src/../.env"}, TrustRuleDeny},
+		{"MCP argument", "github.create_issue", map[string]any{"repo": "myorg/app"}, TrustRuleAllow},
+		{"MCP argument mismatch", "github.create_issue", map[string]any{"repo": "other/app"}, ""},
+		{"MCP any argument", "tickets.query", map[string]any{"sql": "DELETE FROM tickets"}, TrustRuleDeny},
+		{"missing argument", "execute_bash", map[string]any{}, ""},
+		{"tool without rules", "rag_search", map[string]any{"query": "git status"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, rule := config.ToolTrustDecision(tt.tool, tt.args, "/home/user/project")
+			if decision != tt.expected {
+				t.Errorf("Expected %q, got %q (rule %v)", tt.expected, decision, rule)
+			}
+			if (rule != nil) != (decision != "") {
+				t.Errorf("Expected a deciding rule exactly when there is a decision, got %v", rule)
+			}
+		})
+	}
+}
+
+func TestConfig_ToolTrustRuleValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		rule ToolTrustRule
+	}{
+		{"empty tool", ToolTrustRule{Pattern: "ls", Decision: TrustRuleAllow}},
+		{"empty pattern", ToolTrustRule{Tool: "execute_bash", Decision: TrustRuleAllow}},
+		{"invalid regular expression", ToolTrustRule{Tool: "execute_bash", Pattern: "/(/", Decision: TrustRuleDeny}},
+		{"unknown decision", ToolTrustRule{Tool: "execute_bash", Pattern: "ls", Decision: "maybe"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.ToolTrustRules = []ToolTrustRule{tt.rule}
+			if err := config.Validate(); err == nil {
+				t.Errorf("Expected rule %+v to be rejected", tt.rule)
+			}
+		})
+	}
+}
+
+func TestGlobExpression(t *testing.T) {
+	tests := []struct {
+		glob    string
+		paths   bool
+		value   string
+		matches bool
+	}{
+		{glob: "src/*.go", paths: true, value: "src/main.go", matches: true},
+		{glob: "src/*.go", paths: true, value: "src/a/main.go", matches: false},
+		{glob: "src/**/*.go", paths: true, value: "src/main.go", matches: true},
+		{glob: "src/**/*.go", paths: true, value: "src/a/b/main.go", matches: true},
+		{glob: ".ssh/**", paths: true, value: ".ssh", matches: true},
+		{glob: ".ssh/**", paths: true, value: ".ssh/id_rsa", matches: true},
+		{glob: ".ssh/**", paths: true, value: ".sshd", matches: false},
+		{glob: "file?.txt", paths: true, value: "file1.txt", matches: true},
+		{glob: "file[0-9].txt", paths: true, value: "file7.txt", matches: true},
+		{glob: "file[!0-9].txt", paths: true, value: "file7.txt", matches: false},
+		{glob: "git *", paths: false, value: "git log -p", matches: true},
+		{glob: "a.b", paths: false, value: "axb", matches: false},
+	}
+	for _, tt := range tests {
+		matched := regexp.MustCompile("^" + GlobExpression(tt.glob, tt.paths) + "$").MatchString(tt.value)
+		if matched != tt.matches {
+			t.Errorf("GlobExpression(%q, %v) matching %q = %v, want %v", tt.glob, tt.paths, tt.value, matched, tt.matches)
+		}
+	}
+}
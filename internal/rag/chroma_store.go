@@ -0,0 +1,177 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+
+	v2 "github.com/amikos-tech/chroma-go/pkg/api/v2"
+	"github.com/amikos-tech/chroma-go/pkg/embeddings"
+)
+
+// ChromaStore is a VectorStore backed by a ChromaDB server
+type ChromaStore struct {
+	client        v2.Client
+	embeddingFunc embeddings.EmbeddingFunction
+}
+
+// NewChromaStore creates a store using the ChromaDB server at baseURL.
+// The embedding function is attached to collections so ChromaDB does not fall
+// back to its own default model; queries always supply embeddings directly.
+func NewChromaStore(baseURL string, embeddingFunc embeddings.EmbeddingFunction) (*ChromaStore, error) {
+	client, err := v2.NewHTTPClient(v2.WithBaseURL(baseURL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ChromaDB client: %w", err)
+	}
+
+	return &ChromaStore{
+		client:        client,
+		embeddingFunc: embeddingFunc,
+	}, nil
+}
+
+// ListCollections returns every collection on the ChromaDB server
+func (cs *ChromaStore) ListCollections(ctx context.Context) ([]CollectionInfo, error) {
+	chromaCollections, err := cs.client.ListCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	collections := make([]CollectionInfo, 0, len(chromaCollections))
+	for _, collection := range chromaCollections {
+		// Convert metadata from chroma-go format to our expected format
+		metadataMap := make(map[string]string)
+		if metadata := collection.Metadata(); metadata != nil {
+			for _, key := range metadata.Keys() {
+				if strValue, ok := metadata.GetString(key); ok {
+					metadataMap[key] = strValue
+				} else if rawValue, ok := metadata.GetRaw(key); ok {
+					metadataMap[key] = fmt.Sprintf("%v", rawValue)
+				}
+			}
+		}
+
+		collections = append(collections, CollectionInfo{
+			Name:     collection.Name(),
+			ID:       collection.ID(),
+			Metadata: metadataMap,
+		})
+	}
+
+	return collections, nil
+}
+
+// Query searches a ChromaDB collection with a precomputed embedding
+func (cs *ChromaStore) Query(ctx context.Context, collectionName string, query StoreQuery) ([]RetrievedDocument, error) {
+	collection, err := cs.client.GetCollection(ctx, collectionName, v2.WithEmbeddingFunctionGet(cs.embeddingFunc))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection %s: %w", collectionName, err)
+	}
+
+	queryOptions := []v2.CollectionQueryOption{
+		v2.WithQueryEmbeddings(embeddings.NewEmbeddingFromFloat32(query.Embedding)),
+		v2.WithNResults(query.Limit),
+		v2.WithIncludeQuery("documents", "metadatas", "distances"),
+	}
+	if where := whereFilter(query.Filters); where != nil {
+		queryOptions = append(queryOptions, v2.WithWhereQuery(where))
+	}
+
+	queryResult, err := collection.Query(ctx, queryOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection %s: %w", collectionName, err)
+	}
+
+	documents := make([]RetrievedDocument, 0)
+	for groupIdx, group := range queryResult.GetDocumentsGroups() {
+		for i, doc := range group {
+			// Get distance for this document
+			var distance float32 = 1.0 // Default high distance
+			if distanceGroups := queryResult.GetDistancesGroups(); len(distanceGroups) > groupIdx && len(distanceGroups[groupIdx]) > i {
+				distance = float32(distanceGroups[groupIdx][i])
+			}
+
+			// Get metadata for this document
+			metadata := make(map[string]string)
+			if metadataGroups := queryResult.GetMetadatasGroups(); len(metadataGroups) > groupIdx && len(metadataGroups[groupIdx]) > i {
+				docMetadata := metadataGroups[groupIdx][i]
+				if docMetadata != nil {
+					// Type assert to DocumentMetadataImpl to access Keys() method
+					if impl, ok := docMetadata.(*v2.DocumentMetadataImpl); ok {
+						for _, key := range impl.Keys() {
+							if value, ok := impl.GetRaw(key); ok && value != nil {
+								metadata[key] = fmt.Sprintf("%v", value)
+							}
+						}
+					}
+				}
+			}
+
+			// Get document ID
+			var docID string
+			if idGroups := queryResult.GetIDGroups(); len(idGroups) > groupIdx && len(idGroups[groupIdx]) > i {
+				docID = string(idGroups[groupIdx][i])
+			}
+
+			documents = append(documents, RetrievedDocument{
+				Content:    doc.ContentString(),
+				Metadata:   metadata,
+				Collection: collectionName,
+				Distance:   distance,
+				ID:         docID,
+			})
+		}
+	}
+
+	return documents, nil
+}
+
+// Upsert writes documents with their embeddings to a ChromaDB collection, creating it if needed
+func (cs *ChromaStore) Upsert(ctx context.Context, collectionName string, documents []StoredDocument) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	collection, err := cs.client.GetOrCreateCollection(ctx, collectionName,
+		v2.WithEmbeddingFunctionCreate(cs.embeddingFunc),
+		v2.WithHNSWSpaceCreate(embeddings.COSINE),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get or create collection %s: %w", collectionName, err)
+	}
+
+	ids := make([]v2.DocumentID, 0, len(documents))
+	texts := make([]string, 0, len(documents))
+	metadatas := make([]v2.DocumentMetadata, 0, len(documents))
+	vectors := make([]embeddings.Embedding, 0, len(documents))
+	for _, doc := range documents {
+		attributes := make(map[string]interface{}, len(doc.Metadata))
+		for key, value := range doc.Metadata {
+			attributes[key] = value
+		}
+		metadata, err := v2.NewDocumentMetadataFromMap(attributes)
+		if err != nil {
+			return fmt.Errorf("invalid metadata for document %s: %w", doc.ID, err)
+		}
+
+		ids = append(ids, v2.DocumentID(doc.ID))
+		texts = append(texts, doc.Content)
+		metadatas = append(metadatas, metadata)
+		vectors = append(vectors, embeddings.NewEmbeddingFromFloat32(doc.Embedding))
+	}
+
+	err = collection.Upsert(ctx,
+		v2.WithIDs(ids...),
+		v2.WithTexts(texts...),
+		v2.WithMetadatas(metadatas...),
+		v2.WithEmbeddings(vectors...),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert documents into %s: %w", collectionName, err)
+	}
+	return nil
+}
+
+// Close closes the ChromaDB client connection
+func (cs *ChromaStore) Close() error {
+	return cs.client.Close()
+}
@@ -0,0 +1,182 @@
+package rag
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kevensen/gollama-chat/internal/logging"
+)
+
+// DefaultEmbeddingCacheSize is the number of embeddings kept when no size is configured
+const DefaultEmbeddingCacheSize = 2000
+
+// cacheEntry is a single cached embedding, also used for the on-disk format
+type cacheEntry struct {
+	Key    string    `json:"key"`
+	Vector []float32 `json:"vector"`
+}
+
+// EmbeddingCache is a least-recently-used cache of embeddings keyed by model and text hash.
+// It is safe for concurrent use and can be persisted to a JSON file between sessions.
+type EmbeddingCache struct {
+	mu        sync.Mutex
+	capacity  int
+	path      string // Empty for a memory-only cache
+	entries   map[string]*list.Element
+	order     *list.List // Front is most recently used
+	dirty     bool
+	saveTimer *time.Timer // Pending deferred save, if any
+}
+
+// NewEmbeddingCache creates a cache holding up to capacity embeddings. When path is not
+// empty, previously saved entries are loaded from it; a missing file is not an error.
+func NewEmbeddingCache(capacity int, path string) (*EmbeddingCache, error) {
+	cache := &EmbeddingCache{
+		capacity: capacity,
+		path:     path,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+
+	if path == "" {
+		return cache, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding cache: %w", err)
+	}
+
+	var saved []cacheEntry
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse embedding cache: %w", err)
+	}
+
+	// Entries are saved most recently used first
+	for i := len(saved) - 1; i >= 0; i-- {
+		cache.put(saved[i].Key, saved[i].Vector)
+	}
+	cache.dirty = false
+	return cache, nil
+}
+
+// embeddingCacheKey identifies the embedding of text by a given model
+func embeddingCacheKey(model, text string) string {
+	sum := sha256.Sum256([]byte(model + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the cached embedding of text by model, marking it as recently used
+func (c *EmbeddingCache) Get(model, text string) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.entries[embeddingCacheKey(model, text)]
+	if !exists {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*cacheEntry).Vector, true
+}
+
+// Put stores the embedding of text by model, evicting the least recently used entry when full
+func (c *EmbeddingCache) Put(model, text string, vector []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.put(embeddingCacheKey(model, text), vector)
+}
+
+// put stores an entry by key. The caller must hold c.mu.
+func (c *EmbeddingCache) put(key string, vector []float32) {
+	if c.capacity <= 0 {
+		return
+	}
+
+	if element, exists := c.entries[key]; exists {
+		element.Value.(*cacheEntry).Vector = vector
+		c.order.MoveToFront(element)
+		c.dirty = true
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{Key: key, Vector: vector})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).Key)
+	}
+	c.dirty = true
+}
+
+// Len returns the number of cached embeddings
+func (c *EmbeddingCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// SaveAfter schedules a save after delay unless one is already pending, so bursts of
+// new embeddings are written to disk once
+func (c *EmbeddingCache) SaveAfter(delay time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.path == "" || !c.dirty || c.saveTimer != nil {
+		return
+	}
+
+	c.saveTimer = time.AfterFunc(delay, func() {
+		c.mu.Lock()
+		c.saveTimer = nil
+		c.mu.Unlock()
+
+		if err := c.Save(); err != nil {
+			logger := logging.WithComponent("rag")
+			logger.Warn("Failed to save embedding cache", "error", err.Error())
+		}
+	})
+}
+
+// Save writes the cache to its file if it changed since the last save
+func (c *EmbeddingCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.path == "" || !c.dirty {
+		return nil
+	}
+
+	saved := make([]cacheEntry, 0, c.order.Len())
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		saved = append(saved, *element.Value.(*cacheEntry))
+	}
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return fmt.Errorf("failed to marshal embedding cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create embedding cache directory: %w", err)
+	}
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write embedding cache: %w", err)
+	}
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		return fmt.Errorf("failed to save embedding cache: %w", err)
+	}
+
+	c.dirty = false
+	return nil
+}
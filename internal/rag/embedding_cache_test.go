@@ -0,0 +1,167 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/amikos-tech/chroma-go/pkg/embeddings"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+func TestEmbeddingCache_LRUEviction(t *testing.T) {
+	cache, err := NewEmbeddingCache(2, "")
+	if err != nil {
+		t.Fatalf("NewEmbeddingCache failed: %v", err)
+	}
+
+	cache.Put("model", "a", []float32{1})
+	cache.Put("model", "b", []float32{2})
+
+	// Touch "a" so "b" becomes the least recently used entry
+	if _, ok := cache.Get("model", "a"); !ok {
+		t.Fatal("Expected 'a' to be cached")
+	}
+	cache.Put("model", "c", []float32{3})
+
+	if _, ok := cache.Get("model", "b"); ok {
+		t.Error("Expected 'b' to be evicted")
+	}
+	if vector, ok := cache.Get("model", "a"); !ok || vector[0] != 1 {
+		t.Errorf("Expected 'a' to survive eviction, got %v %v", vector, ok)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", cache.Len())
+	}
+}
+
+func TestEmbeddingCache_KeyIncludesModel(t *testing.T) {
+	cache, _ := NewEmbeddingCache(10, "")
+	cache.Put("model-a", "text", []float32{1})
+
+	if _, ok := cache.Get("model-b", "text"); ok {
+		t.Error("Expected embeddings from another model not to be returned")
+	}
+}
+
+func TestEmbeddingCache_Disabled(t *testing.T) {
+	cache, _ := NewEmbeddingCache(0, "")
+	cache.Put("model", "text", []float32{1})
+
+	if cache.Len() != 0 {
+		t.Errorf("Expected a zero capacity cache to store nothing, got %d entries", cache.Len())
+	}
+}
+
+func TestEmbeddingCache_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "embeddings.json")
+
+	cache, err := NewEmbeddingCache(2, path)
+	if err != nil {
+		t.Fatalf("NewEmbeddingCache failed: %v", err)
+	}
+	cache.Put("model", "a", []float32{1, 2})
+	cache.Put("model", "b", []float32{3, 4})
+	cache.Get("model", "a")
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := NewEmbeddingCache(2, path)
+	if err != nil {
+		t.Fatalf("Loading cache failed: %v", err)
+	}
+	if vector, ok := loaded.Get("model", "b"); !ok || !reflect.DeepEqual(vector, []float32{3, 4}) {
+		t.Errorf("Expected 'b' to be loaded, got %v %v", vector, ok)
+	}
+
+	// Recency survives the round trip: "b" was just used, so "a" is evicted next
+	loaded.Put("model", "c", []float32{5})
+	if _, ok := loaded.Get("model", "a"); ok {
+		t.Error("Expected 'a' to be evicted after reload")
+	}
+}
+
+// countingEmbedding wraps letterEmbedding and records each embedding request
+type countingEmbedding struct {
+	letterEmbedding
+	mu      sync.Mutex
+	batches []int
+}
+
+func (e *countingEmbedding) EmbedDocuments(ctx context.Context, texts []string) ([]embeddings.Embedding, error) {
+	e.mu.Lock()
+	e.batches = append(e.batches, len(texts))
+	e.mu.Unlock()
+	return e.letterEmbedding.EmbedDocuments(ctx, texts)
+}
+
+func TestService_EmbedTextsUsesCacheAndBatches(t *testing.T) {
+	counter := &countingEmbedding{}
+	service := NewService(&configuration.Config{EmbeddingModel: "test-model"})
+	service.embeddingFunc = counter
+	service.cache, _ = NewEmbeddingCache(100, "")
+
+	texts := make([]string, embeddingBatchSize+5)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("text %d", i)
+	}
+	texts = append(texts, texts[0]) // Duplicates are embedded once
+
+	vectors, err := service.embedTexts(t.Context(), service.snapshot(), texts)
+	if err != nil {
+		t.Fatalf("embedTexts failed: %v", err)
+	}
+	if len(vectors) != len(texts) {
+		t.Fatalf("Expected %d vectors, got %d", len(texts), len(vectors))
+	}
+	if !reflect.DeepEqual(counter.batches, []int{embeddingBatchSize, 5}) {
+		t.Errorf("Expected batches of %d and 5, got %v", embeddingBatchSize, counter.batches)
+	}
+	if !reflect.DeepEqual(vectors[0], vectors[len(vectors)-1]) {
+		t.Error("Expected duplicate texts to share an embedding")
+	}
+
+	// A second query for cached text does not call the model
+	if _, err := service.embedQuery(t.Context(), service.snapshot(), texts[3]); err != nil {
+		t.Fatalf("embedQuery failed: %v", err)
+	}
+	if len(counter.batches) != 2 {
+		t.Errorf("Expected cached query not to be embedded again, got batches %v", counter.batches)
+	}
+}
+
+func TestService_AddDocumentsBypassesCache(t *testing.T) {
+	config := &configuration.Config{
+		EmbeddingModel: "test-model",
+		VectorStore:    configuration.VectorStoreLocal,
+		LocalStorePath: t.TempDir(),
+	}
+	store, err := NewStore(config, nil)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+
+	service := NewService(config)
+	service.store = store
+	service.embeddingFunc = letterEmbedding{}
+	service.connected = true
+	service.cache, _ = NewEmbeddingCache(2, "")
+	if _, err := service.embedQuery(t.Context(), service.snapshot(), "query"); err != nil {
+		t.Fatalf("embedQuery failed: %v", err)
+	}
+
+	documents := []Document{{ID: "1", Content: "cat"}, {ID: "2", Content: "dog"}, {ID: "3", Content: "bird"}}
+	if err := service.AddDocuments(t.Context(), "animals", documents); err != nil {
+		t.Fatalf("AddDocuments failed: %v", err)
+	}
+
+	// Ingested documents do not evict the cached query embedding
+	if _, ok := service.cache.Get("test-model", "query"); !ok || service.cache.Len() != 1 {
+		t.Errorf("Expected only the query embedding to be cached, got %d entries", service.cache.Len())
+	}
+}
@@ -0,0 +1,206 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kevensen/gollama-chat/internal/logging"
+)
+
+const (
+	// DefaultChunkSize is the target chunk length in characters for ingested files
+	DefaultChunkSize = 1000
+	// DefaultChunkOverlap is the number of characters shared by consecutive chunks
+	DefaultChunkOverlap = 100
+	// maxIngestFileSize skips files that are unlikely to be useful text documents
+	maxIngestFileSize = 5 * 1024 * 1024
+)
+
+// Document is a piece of text to be embedded and stored in a collection
+type Document struct {
+	ID       string            `json:"id"`
+	Content  string            `json:"content"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// AddDocuments embeds documents with the configured embedding model and writes them to a collection.
+// Embeddings are requested in batches. They bypass the embedding cache so a large ingest
+// does not evict the cached query embeddings.
+func (s *Service) AddDocuments(ctx context.Context, collection string, documents []Document) error {
+	logger := logging.WithComponent("rag")
+	state := s.snapshot()
+
+	if !state.connected {
+		return fmt.Errorf("RAG service not connected to a vector store")
+	}
+	if len(documents) == 0 {
+		return nil
+	}
+
+	texts := make([]string, len(documents))
+	for i, doc := range documents {
+		texts[i] = doc.Content
+	}
+
+	uncached := state
+	uncached.cache = nil
+	vectors, err := s.embedTexts(ctx, uncached, texts)
+	if err != nil {
+		return fmt.Errorf("failed to embed documents: %w", err)
+	}
+
+	stored := make([]StoredDocument, len(documents))
+	for i, doc := range documents {
+		stored[i] = StoredDocument{
+			ID:        doc.ID,
+			Content:   doc.Content,
+			Metadata:  doc.Metadata,
+			Embedding: vectors[i],
+		}
+	}
+
+	if err := state.store.Upsert(ctx, collection, stored); err != nil {
+		return err
+	}
+
+	// The collection may be new; @mentions can scope queries to it from now on
+	s.mu.Lock()
+	if !slices.Contains(s.collections, collection) {
+		s.collections = append(s.collections, collection)
+	}
+	s.mu.Unlock()
+
+	logger.Info("Added documents to collection",
+		"collection_name", collection,
+		"documents", len(documents),
+		"vector_store", state.config.VectorStore,
+	)
+	return nil
+}
+
+// LoadDocuments reads the text files at root (a file or directory) and splits them into chunks.
+// Hidden files and directories, binary files and very large files are skipped.
+func LoadDocuments(root string) ([]Document, error) {
+	documents := make([]Document, 0)
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path != root && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil || info.Size() > maxIngestFileSize {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if !isText(data) {
+			return nil
+		}
+
+		for i, chunk := range ChunkText(string(data), DefaultChunkSize, DefaultChunkOverlap) {
+			documents = append(documents, Document{
+				ID:      path + "#" + strconv.Itoa(i),
+				Content: chunk,
+				Metadata: map[string]string{
+					"source": path,
+					"chunk":  strconv.Itoa(i),
+				},
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load documents from %s: %w", root, err)
+	}
+
+	return documents, nil
+}
+
+// ChunkText splits text into chunks of roughly size characters that overlap by overlap
+// characters. Chunks end on a paragraph, line or word boundary where one is available.
+func ChunkText(text string, size, overlap int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if size <= 0 {
+		return []string{text}
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	runes := []rune(text)
+	chunks := make([]string, 0, len(runes)/size+1)
+
+	for start := 0; start < len(runes); {
+		end := min(start+size, len(runes))
+		if end < len(runes) {
+			end = chunkBoundary(runes, start, end)
+		}
+
+		if chunk := strings.TrimSpace(string(runes[start:end])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(runes) {
+			break
+		}
+
+		// Step back for the overlap but always make progress
+		start = max(end-overlap, start+1)
+	}
+
+	return chunks
+}
+
+// chunkBoundary moves end back to the nearest paragraph, line or word break in the
+// second half of the chunk, or returns end unchanged when there is none
+func chunkBoundary(runes []rune, start, end int) int {
+	window := string(runes[start:end])
+	half := len(window) / 2
+
+	for _, separator := range []string{"\n\n", "\n", " "} {
+		if i := strings.LastIndex(window, separator); i > half {
+			return start + utf8.RuneCountInString(window[:i+len(separator)])
+		}
+	}
+	return end
+}
+
+// isText reports whether data looks like UTF-8 text rather than a binary file
+func isText(data []byte) bool {
+	sample := data[:min(len(data), 512)]
+	return !bytes.Contains(sample, []byte{0}) && utf8.Valid(sample[:validUTF8Prefix(sample)])
+}
+
+// validUTF8Prefix trims a trailing partial rune so a truncated sample still validates
+func validUTF8Prefix(sample []byte) int {
+	for i := len(sample); i > 0 && i > len(sample)-utf8.UTFMax; i-- {
+		if utf8.Valid(sample[:i]) {
+			return i
+		}
+	}
+	return len(sample)
+}
@@ -0,0 +1,88 @@
+package rag
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChunkText(t *testing.T) {
+	if chunks := ChunkText("   ", 10, 2); len(chunks) != 0 {
+		t.Errorf("Expected no chunks for blank text, got %q", chunks)
+	}
+
+	if chunks := ChunkText("short text", 100, 10); len(chunks) != 1 || chunks[0] != "short text" {
+		t.Errorf("Expected a single chunk, got %q", chunks)
+	}
+
+	text := strings.Repeat("word ", 100)
+	chunks := ChunkText(text, 50, 10)
+	if len(chunks) < 10 {
+		t.Fatalf("Expected text to be split into many chunks, got %d", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if len([]rune(chunk)) > 50 {
+			t.Errorf("Chunk %d exceeds chunk size: %d", i, len(chunk))
+		}
+		if strings.HasPrefix(chunk, "ord") || strings.HasSuffix(chunk, "wor") {
+			t.Errorf("Chunk %d splits a word: %q", i, chunk)
+		}
+	}
+
+	// Paragraph breaks are preferred over word breaks
+	paragraphs := strings.Repeat("a", 30) + "\n\n" + strings.Repeat("b", 30)
+	chunks = ChunkText(paragraphs, 40, 0)
+	if len(chunks) != 2 || chunks[0] != strings.Repeat("a", 30) {
+		t.Errorf("Expected split at paragraph boundary, got %q", chunks)
+	}
+
+	// Text without any break still makes progress
+	chunks = ChunkText(strings.Repeat("x", 25), 10, 5)
+	if len(chunks) == 0 || chunks[len(chunks)-1] != strings.Repeat("x", 10) {
+		t.Errorf("Unexpected chunks for unbroken text: %q", chunks)
+	}
+}
+
+func TestLoadDocuments(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"readme.md":         []byte("# Title\n\nSome documentation."),
+		"sub/notes.txt":     []byte("Nested notes."),
+		".hidden/secret.md": []byte("should be skipped"),
+		".env":              []byte("TOKEN=skipped"),
+		"image.bin":         {0x89, 0x50, 0x00, 0x01},
+		"empty.txt":         []byte("   "),
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	documents, err := LoadDocuments(dir)
+	if err != nil {
+		t.Fatalf("LoadDocuments failed: %v", err)
+	}
+	if len(documents) != 2 {
+		t.Fatalf("Expected 2 documents, got %+v", documents)
+	}
+
+	for _, doc := range documents {
+		source := doc.Metadata["source"]
+		if !strings.HasSuffix(source, "readme.md") && !strings.HasSuffix(source, "notes.txt") {
+			t.Errorf("Unexpected document source %q", source)
+		}
+		if doc.ID != source+"#0" || doc.Metadata["chunk"] != "0" {
+			t.Errorf("Unexpected document ID %q for source %q", doc.ID, source)
+		}
+	}
+
+	if _, err := LoadDocuments(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Expected error for a missing path")
+	}
+}
@@ -0,0 +1,260 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// localCollectionExt is the file extension used for persisted collections
+const localCollectionExt = ".json"
+
+// validCollectionName limits collection names to characters that are safe in file names
+var validCollectionName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// localCollection is the on-disk representation of a collection
+type localCollection struct {
+	Name      string            `json:"name"`
+	ID        string            `json:"id"`
+	Metadata  map[string]string `json:"metadata"`
+	Documents []StoredDocument  `json:"documents"`
+}
+
+// LocalStore is an embedded VectorStore that keeps each collection in a JSON file.
+//
+// Queries are an exact (flat) cosine search over every document in the collection,
+// which is fast enough for the personal knowledge bases this store is meant for and
+// needs nothing but Ollama to run.
+type LocalStore struct {
+	dir         string
+	mu          sync.RWMutex
+	collections map[string]*localCollection // Loaded collections keyed by name
+}
+
+// NewLocalStore creates a local store persisted under dir, creating the directory if needed
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create local vector store directory: %w", err)
+	}
+
+	return &LocalStore{
+		dir:         dir,
+		collections: make(map[string]*localCollection),
+	}, nil
+}
+
+// ListCollections returns every collection persisted in the store directory
+func (ls *LocalStore) ListCollections(ctx context.Context) ([]CollectionInfo, error) {
+	entries, err := os.ReadDir(ls.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local vector store directory: %w", err)
+	}
+
+	collections := make([]CollectionInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), localCollectionExt) {
+			continue
+		}
+
+		name := strings.TrimSuffix(entry.Name(), localCollectionExt)
+		collection, err := ls.collection(name, false)
+		if err != nil {
+			return nil, err
+		}
+
+		collections = append(collections, CollectionInfo{
+			Name:     collection.Name,
+			ID:       collection.ID,
+			Metadata: collection.Metadata,
+		})
+	}
+
+	return collections, nil
+}
+
+// Query performs an exact cosine similarity search over a collection
+func (ls *LocalStore) Query(ctx context.Context, collectionName string, query StoreQuery) ([]RetrievedDocument, error) {
+	collection, err := ls.collection(collectionName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	documents := make([]RetrievedDocument, 0)
+	for _, doc := range collection.Documents {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !matchesFilters(doc.Metadata, query.Filters) {
+			continue
+		}
+
+		documents = append(documents, RetrievedDocument{
+			Content:    doc.Content,
+			Metadata:   doc.Metadata,
+			Collection: collection.Name,
+			Distance:   cosineDistance(query.Embedding, doc.Embedding),
+			ID:         doc.ID,
+		})
+	}
+
+	sort.SliceStable(documents, func(i, j int) bool {
+		return documents[i].Distance < documents[j].Distance
+	})
+
+	if query.Limit > 0 && len(documents) > query.Limit {
+		documents = documents[:query.Limit]
+	}
+	return documents, nil
+}
+
+// Upsert adds or replaces documents in a collection and persists it to disk. The batch
+// is validated as a whole and the cached collection only changes once it has been saved,
+// so a failed write leaves the store as it was.
+func (ls *LocalStore) Upsert(ctx context.Context, collectionName string, documents []StoredDocument) error {
+	for _, doc := range documents {
+		if doc.ID == "" {
+			return fmt.Errorf("document in collection %s has an empty ID", collectionName)
+		}
+		if len(doc.Embedding) == 0 {
+			return fmt.Errorf("document %s in collection %s has no embedding", doc.ID, collectionName)
+		}
+	}
+
+	collection, err := ls.collection(collectionName, true)
+	if err != nil {
+		return err
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	updated := *collection
+	updated.Documents = slices.Clone(collection.Documents)
+	index := make(map[string]int, len(updated.Documents))
+	for i, doc := range updated.Documents {
+		index[doc.ID] = i
+	}
+	for _, doc := range documents {
+		if i, exists := index[doc.ID]; exists {
+			updated.Documents[i] = doc
+			continue
+		}
+		index[doc.ID] = len(updated.Documents)
+		updated.Documents = append(updated.Documents, doc)
+	}
+
+	if err := ls.save(&updated); err != nil {
+		return err
+	}
+	collection.Documents = updated.Documents
+	return nil
+}
+
+// Close releases cached collections; data is already persisted on every write
+func (ls *LocalStore) Close() error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.collections = make(map[string]*localCollection)
+	return nil
+}
+
+// collection returns a cached collection, loading it from disk on first use.
+// When create is true a missing collection is created in memory.
+func (ls *LocalStore) collection(name string, create bool) (*localCollection, error) {
+	if !validCollectionName.MatchString(name) {
+		return nil, fmt.Errorf("invalid collection name: %q", name)
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if collection, exists := ls.collections[name]; exists {
+		return collection, nil
+	}
+
+	data, err := os.ReadFile(ls.collectionPath(name))
+	if os.IsNotExist(err) {
+		if !create {
+			return nil, fmt.Errorf("collection %s does not exist", name)
+		}
+		collection := &localCollection{
+			Name:      name,
+			ID:        ulid.Make().String(),
+			Metadata:  make(map[string]string),
+			Documents: make([]StoredDocument, 0),
+		}
+		ls.collections[name] = collection
+		return collection, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collection %s: %w", name, err)
+	}
+
+	var collection localCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("failed to parse collection %s: %w", name, err)
+	}
+	collection.Name = name
+	if collection.Metadata == nil {
+		collection.Metadata = make(map[string]string)
+	}
+
+	ls.collections[name] = &collection
+	return &collection, nil
+}
+
+// save writes a collection to disk atomically. The caller must hold ls.mu.
+func (ls *LocalStore) save(collection *localCollection) error {
+	data, err := json.Marshal(collection)
+	if err != nil {
+		return fmt.Errorf("failed to marshal collection %s: %w", collection.Name, err)
+	}
+
+	path := ls.collectionPath(collection.Name)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write collection %s: %w", collection.Name, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to save collection %s: %w", collection.Name, err)
+	}
+	return nil
+}
+
+// collectionPath returns the file holding the named collection
+func (ls *LocalStore) collectionPath(name string) string {
+	return filepath.Join(ls.dir, name+localCollectionExt)
+}
+
+// cosineDistance returns 1 minus the cosine similarity of two vectors, in the range 0-2.
+// Vectors of different lengths or zero vectors are treated as maximally distant.
+func cosineDistance(a, b []float32) float32 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 2
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 2
+	}
+
+	return float32(1 - dot/(math.Sqrt(normA)*math.Sqrt(normB)))
+}
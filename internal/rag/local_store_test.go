@@ -0,0 +1,270 @@
+package rag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/amikos-tech/chroma-go/pkg/embeddings"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+// letterEmbedding is a deterministic embedding function for tests that counts letters
+type letterEmbedding struct{}
+
+func (letterEmbedding) embed(text string) embeddings.Embedding {
+	vector := make([]float32, 26)
+	for _, r := range strings.ToLower(text) {
+		if r >= 'a' && r <= 'z' {
+			vector[r-'a']++
+		}
+	}
+	return embeddings.NewEmbeddingFromFloat32(vector)
+}
+
+func (e letterEmbedding) EmbedDocuments(ctx context.Context, texts []string) ([]embeddings.Embedding, error) {
+	result := make([]embeddings.Embedding, len(texts))
+	for i, text := range texts {
+		result[i] = e.embed(text)
+	}
+	return result, nil
+}
+
+func (e letterEmbedding) EmbedQuery(ctx context.Context, text string) (embeddings.Embedding, error) {
+	return e.embed(text), nil
+}
+
+func TestLocalStore_UpsertAndQuery(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+
+	store, err := NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("NewLocalStore failed: %v", err)
+	}
+
+	docs := []StoredDocument{
+		{ID: "x", Content: "x axis", Metadata: map[string]string{"lang": "en"}, Embedding: []float32{1, 0}},
+		{ID: "y", Content: "y axis", Metadata: map[string]string{"lang": "de"}, Embedding: []float32{0, 1}},
+		{ID: "xy", Content: "diagonal", Metadata: map[string]string{"lang": "en"}, Embedding: []float32{1, 1}},
+	}
+	if err := store.Upsert(ctx, "axes", docs); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	results, err := store.Query(ctx, "axes", StoreQuery{Embedding: []float32{1, 0}, Limit: 2})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "x" || results[1].ID != "xy" {
+		t.Fatalf("Unexpected results: %+v", results)
+	}
+	if results[0].Distance != 0 || results[0].Collection != "axes" {
+		t.Errorf("Expected exact match in collection axes, got %+v", results[0])
+	}
+
+	// Metadata filters
+	results, err = store.Query(ctx, "axes", StoreQuery{Embedding: []float32{1, 0}, Limit: 5, Filters: map[string]string{"lang": "de"}})
+	if err != nil {
+		t.Fatalf("Query with filter failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "y" {
+		t.Errorf("Expected only the filtered document, got %+v", results)
+	}
+
+	// Upsert replaces documents with the same ID
+	if err := store.Upsert(ctx, "axes", []StoredDocument{{ID: "y", Content: "replaced", Embedding: []float32{1, 0}}}); err != nil {
+		t.Fatalf("Upsert replace failed: %v", err)
+	}
+
+	// A fresh store reads the persisted collection
+	reopened, err := NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("NewLocalStore reopen failed: %v", err)
+	}
+	collections, err := reopened.ListCollections(ctx)
+	if err != nil {
+		t.Fatalf("ListCollections failed: %v", err)
+	}
+	if len(collections) != 1 || collections[0].Name != "axes" || collections[0].ID == "" {
+		t.Fatalf("Unexpected collections: %+v", collections)
+	}
+
+	results, err = reopened.Query(ctx, "axes", StoreQuery{Embedding: []float32{1, 0}, Limit: 5})
+	if err != nil {
+		t.Fatalf("Query after reopen failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 persisted documents, got %d", len(results))
+	}
+	for _, doc := range results {
+		if doc.ID == "y" && doc.Content != "replaced" {
+			t.Errorf("Expected document y to be replaced, got %q", doc.Content)
+		}
+	}
+}
+
+func TestLocalStore_Errors(t *testing.T) {
+	ctx := t.Context()
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore failed: %v", err)
+	}
+
+	if _, err := store.Query(ctx, "missing", StoreQuery{Embedding: []float32{1}}); err == nil {
+		t.Error("Expected error querying a missing collection")
+	}
+	if err := store.Upsert(ctx, "../escape", []StoredDocument{{ID: "a", Embedding: []float32{1}}}); err == nil {
+		t.Error("Expected error for a collection name containing a path")
+	}
+	if err := store.Upsert(ctx, "docs", []StoredDocument{{ID: "", Embedding: []float32{1}}}); err == nil {
+		t.Error("Expected error for a document without an ID")
+	}
+	if err := store.Upsert(ctx, "docs", []StoredDocument{{ID: "a"}}); err == nil {
+		t.Error("Expected error for a document without an embedding")
+	}
+}
+
+func TestLocalStore_UpsertIsAtomic(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("NewLocalStore failed: %v", err)
+	}
+	if err := store.Upsert(ctx, "docs", []StoredDocument{{ID: "a", Content: "first", Embedding: []float32{1, 0}}}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	contents := func() []string {
+		t.Helper()
+		documents, err := store.Query(ctx, "docs", StoreQuery{Embedding: []float32{1, 0}})
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		var contents []string
+		for _, doc := range documents {
+			contents = append(contents, doc.Content)
+		}
+		return contents
+	}
+
+	// An invalid document late in the batch leaves the earlier ones out too
+	err = store.Upsert(ctx, "docs", []StoredDocument{
+		{ID: "a", Content: "replaced", Embedding: []float32{1, 0}},
+		{ID: "b", Content: "second"},
+	})
+	if err == nil {
+		t.Fatal("Expected error for a document without an embedding")
+	}
+	if got := contents(); !slices.Equal(got, []string{"first"}) {
+		t.Errorf("Expected the collection to be unchanged after a failed validation, got %v", got)
+	}
+
+	// A failed save leaves the cached collection as it is on disk
+	if err := os.Mkdir(filepath.Join(dir, "docs"+localCollectionExt+".tmp"), 0755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	if err := store.Upsert(ctx, "docs", []StoredDocument{{ID: "a", Content: "replaced", Embedding: []float32{1, 0}}}); err == nil {
+		t.Fatal("Expected the save to fail")
+	}
+	if got := contents(); !slices.Equal(got, []string{"first"}) {
+		t.Errorf("Expected the collection to be unchanged after a failed save, got %v", got)
+	}
+}
+
+func TestLocalStore_IgnoresOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a collection"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	store, err := NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("NewLocalStore failed: %v", err)
+	}
+	collections, err := store.ListCollections(t.Context())
+	if err != nil {
+		t.Fatalf("ListCollections failed: %v", err)
+	}
+	if len(collections) != 0 {
+		t.Errorf("Expected no collections, got %+v", collections)
+	}
+}
+
+func TestCosineDistance(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     []float32
+		expected float32
+	}{
+		{"identical", []float32{1, 2}, []float32{1, 2}, 0},
+		{"orthogonal", []float32{1, 0}, []float32{0, 1}, 1},
+		{"opposite", []float32{1, 0}, []float32{-1, 0}, 2},
+		{"length mismatch", []float32{1}, []float32{1, 0}, 2},
+		{"zero vector", []float32{0, 0}, []float32{1, 0}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cosineDistance(tt.a, tt.b)
+			if diff := got - tt.expected; diff > 1e-6 || diff < -1e-6 {
+				t.Errorf("cosineDistance() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestService_LocalStoreEndToEnd(t *testing.T) {
+	ctx := t.Context()
+	config := &configuration.Config{
+		RAGEnabled:       true,
+		VectorStore:      configuration.VectorStoreLocal,
+		LocalStorePath:   t.TempDir(),
+		MaxDocuments:     2,
+		ChromaDBDistance: 0.5,
+	}
+
+	store, err := NewStore(config, nil)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+
+	service := NewService(config)
+	service.store = store
+	service.embeddingFunc = letterEmbedding{}
+	service.connected = true
+	service.selectedCollections = []string{"animals"}
+
+	err = service.AddDocuments(ctx, "animals", []Document{
+		{ID: "1", Content: "cat cat cat", Metadata: map[string]string{"kind": "pet"}},
+		{ID: "2", Content: "zebra", Metadata: map[string]string{"kind": "wild"}},
+		{ID: "3", Content: "cats", Metadata: map[string]string{"kind": "pet"}},
+	})
+	if err != nil {
+		t.Fatalf("AddDocuments failed: %v", err)
+	}
+
+	result, err := service.QueryDocuments(ctx, "cat")
+	if err != nil {
+		t.Fatalf("QueryDocuments failed: %v", err)
+	}
+	if len(result.Documents) != 2 {
+		t.Fatalf("Expected 2 documents within the distance threshold, got %+v", result.Documents)
+	}
+	if result.Documents[0].ID != "1" {
+		t.Errorf("Expected the closest document first, got %+v", result.Documents[0])
+	}
+
+	result, err = service.QueryDocuments(ctx, "cat #kind=wild")
+	if err != nil {
+		t.Fatalf("Filtered QueryDocuments failed: %v", err)
+	}
+	if len(result.Documents) != 0 {
+		t.Errorf("Expected no wild animals near 'cat', got %+v", result.Documents)
+	}
+}
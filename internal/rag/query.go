@@ -0,0 +1,163 @@
+package rag
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	v2 "github.com/amikos-tech/chroma-go/pkg/api/v2"
+)
+
+// Query represents a parsed RAG query with optional collection scoping and metadata filters
+type Query struct {
+	Text        string            `json:"text"`        // Query text with scoping tokens removed
+	Collections []string          `json:"collections"` // Collections named with @mentions; empty means the selected collections
+	Filters     map[string]string `json:"filters"`     // Metadata filters from #key=value tokens
+	Limit       int               `json:"limit"`       // Overrides the configured document limits when greater than 0
+}
+
+// ParseQuery extracts @collection mentions and #key=value metadata filters from chat input.
+//
+// Tokens are only recognized at the start of a whitespace-separated word, so email
+// addresses and hashtags without a value are left in the query text. Only mentions of
+// the given collections scope the query; other @words, such as a person's name, stay in
+// the text. Values may be quoted to force a string comparison (e.g. #year="2024").
+func ParseQuery(input string, collections []string) Query {
+	query := Query{
+		Collections: make([]string, 0),
+		Filters:     make(map[string]string),
+	}
+
+	var textWords []string
+	seen := make(map[string]bool)
+
+	for _, word := range strings.Fields(input) {
+		collection, key, value := scopeToken(word, collections)
+		switch {
+		case collection != "":
+			if !seen[collection] {
+				seen[collection] = true
+				query.Collections = append(query.Collections, collection)
+			}
+		case key != "":
+			query.Filters[key] = value
+		default:
+			textWords = append(textWords, word)
+		}
+	}
+
+	query.Text = strings.Join(textWords, " ")
+	return query
+}
+
+// PromptText returns chat input without the tokens ParseQuery recognizes for the given
+// collections, keeping the layout of the remaining text. The tokens only steer retrieval,
+// so this is the text the chat model is sent.
+func PromptText(input string, collections []string) string {
+	var text strings.Builder
+	for input != "" {
+		start := strings.IndexFunc(input, func(r rune) bool { return !unicode.IsSpace(r) })
+		if start < 0 {
+			text.WriteString(input)
+			break
+		}
+		end := strings.IndexFunc(input[start:], unicode.IsSpace)
+		if end < 0 {
+			end = len(input)
+		} else {
+			end += start
+		}
+
+		if collection, key, _ := scopeToken(input[start:end], collections); collection != "" || key != "" {
+			// Drop the token and the spaces after it, keeping line breaks
+			text.WriteString(input[:start])
+			input = strings.TrimLeft(input[end:], " \t")
+			continue
+		}
+		text.WriteString(input[:end])
+		input = input[end:]
+	}
+	return strings.TrimSpace(text.String())
+}
+
+// scopeToken returns the collection named by an @mention of one of collections, or the
+// key and value of a #key=value filter; all are empty for other words
+func scopeToken(word string, collections []string) (collection, key, value string) {
+	switch {
+	case strings.HasPrefix(word, "@"):
+		if name := strings.TrimRight(word[1:], ",;:!?"); name != "" && slices.Contains(collections, name) {
+			return name, "", ""
+		}
+	case strings.HasPrefix(word, "#") && strings.Contains(word, "="):
+		key, value, _ := strings.Cut(word[1:], "=")
+		if key != "" && value != "" {
+			return "", key, value
+		}
+	}
+	return "", "", ""
+}
+
+// IsScoped reports whether the query restricts collections or filters metadata
+func (q Query) IsScoped() bool {
+	return len(q.Collections) > 0 || len(q.Filters) > 0
+}
+
+// whereFilter converts metadata filters into a ChromaDB where clause.
+// It returns nil when there are no filters.
+func whereFilter(filters map[string]string) v2.WhereFilter {
+	if len(filters) == 0 {
+		return nil
+	}
+
+	// Sort keys so the generated clause is deterministic
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	clauses := make([]v2.WhereClause, 0, len(keys))
+	for _, key := range keys {
+		clauses = append(clauses, whereClause(key, filters[key]))
+	}
+
+	if len(clauses) == 1 {
+		return clauses[0]
+	}
+	return v2.And(clauses...)
+}
+
+// whereClause builds an equality clause, inferring the metadata type from the value
+func whereClause(key, value string) v2.WhereClause {
+	// Quoted values are always compared as strings
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return v2.EqString(key, value[1:len(value)-1])
+	}
+
+	if value == "true" || value == "false" {
+		return v2.EqBool(key, value == "true")
+	}
+	if i, err := strconv.Atoi(value); err == nil {
+		return v2.EqInt(key, i)
+	}
+	if f, err := strconv.ParseFloat(value, 32); err == nil {
+		return v2.EqFloat(key, float32(f))
+	}
+	return v2.EqString(key, value)
+}
+
+// matchesFilters reports whether document metadata satisfies every filter.
+// Values are compared as strings; quotes used to force string comparison are ignored.
+func matchesFilters(metadata map[string]string, filters map[string]string) bool {
+	for key, value := range filters {
+		if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+			value = value[1 : len(value)-1]
+		}
+		if actual, exists := metadata[key]; !exists || actual != value {
+			return false
+		}
+	}
+	return true
+}
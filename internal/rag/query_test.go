@@ -0,0 +1,155 @@
+package rag
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testCollections are the collections the query tests can scope to
+var testCollections = []string{"api", "docs"}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		text        string
+		collections []string
+		filters     map[string]string
+	}{
+		{
+			name:        "plain query",
+			input:       "how do I configure logging?",
+			text:        "how do I configure logging?",
+			collections: []string{},
+			filters:     map[string]string{},
+		},
+		{
+			name:        "collection mentions",
+			input:       "@docs @api, how do I authenticate?",
+			text:        "how do I authenticate?",
+			collections: []string{"docs", "api"},
+			filters:     map[string]string{},
+		},
+		{
+			name:        "duplicate mentions are collapsed",
+			input:       "@docs what is @docs",
+			text:        "what is",
+			collections: []string{"docs"},
+			filters:     map[string]string{},
+		},
+		{
+			name:        "mentions of unknown collections stay in the text",
+			input:       "ask @alice about @docs",
+			text:        "ask @alice about",
+			collections: []string{"docs"},
+			filters:     map[string]string{},
+		},
+		{
+			name:        "metadata filters",
+			input:       "release notes #version=2 #product=\"chat\"",
+			text:        "release notes",
+			collections: []string{},
+			filters:     map[string]string{"version": "2", "product": "\"chat\""},
+		},
+		{
+			name:        "non-token words are preserved",
+			input:       "email me@example.com about #golang and a lone @",
+			text:        "email me@example.com about #golang and a lone @",
+			collections: []string{},
+			filters:     map[string]string{},
+		},
+		{
+			name:        "incomplete filters stay in the text",
+			input:       "#=value #key= question",
+			text:        "#=value #key= question",
+			collections: []string{},
+			filters:     map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := ParseQuery(tt.input, testCollections)
+			if query.Text != tt.text {
+				t.Errorf("Text = %q, expected %q", query.Text, tt.text)
+			}
+			if !reflect.DeepEqual(query.Collections, tt.collections) {
+				t.Errorf("Collections = %v, expected %v", query.Collections, tt.collections)
+			}
+			if !reflect.DeepEqual(query.Filters, tt.filters) {
+				t.Errorf("Filters = %v, expected %v", query.Filters, tt.filters)
+			}
+		})
+	}
+}
+
+func TestPromptText(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "how do I configure logging?", expected: "how do I configure logging?"},
+		{input: "@docs @api, how do I authenticate?", expected: "how do I authenticate?"},
+		{input: "release notes #version=2 for @docs please", expected: "release notes for please"},
+		{input: "@docs explain:\n\n    func main() {}\n", expected: "explain:\n\n    func main() {}"},
+		{input: "email me@example.com about #golang", expected: "email me@example.com about #golang"},
+		{input: "@docs #lang=go", expected: ""},
+		{input: "ask @alice, about @docs", expected: "ask @alice, about"},
+	}
+	for _, tt := range tests {
+		if got := PromptText(tt.input, testCollections); got != tt.expected {
+			t.Errorf("PromptText(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestQuery_WhereFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filters  map[string]string
+		expected string
+	}{
+		{
+			name:     "no filters",
+			filters:  map[string]string{},
+			expected: "",
+		},
+		{
+			name:     "string value",
+			filters:  map[string]string{"lang": "go"},
+			expected: `{"lang":{"$eq":"go"}}`,
+		},
+		{
+			name:     "quoted value forces string",
+			filters:  map[string]string{"year": `"2024"`},
+			expected: `{"year":{"$eq":"2024"}}`,
+		},
+		{
+			name:     "typed values",
+			filters:  map[string]string{"draft": "false", "version": "2"},
+			expected: `{"$and":[{"draft":{"$eq":false}},{"version":{"$eq":2}}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where := whereFilter(tt.filters)
+			if tt.expected == "" {
+				if where != nil {
+					t.Errorf("Expected nil where filter, got %v", where)
+				}
+				return
+			}
+			if where == nil {
+				t.Fatal("Expected where filter, got nil")
+			}
+			data, err := where.MarshalJSON()
+			if err != nil {
+				t.Fatalf("Failed to marshal where filter: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("where = %s, expected %s", data, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/kevensen/gollama-chat/internal/logging"
+)
+
+const (
+	// rewriteHistoryTurns is the number of recent conversation turns given to the rewriter
+	rewriteHistoryTurns = 6
+	// rewriteTurnLength caps each history turn so long answers do not dominate the prompt
+	rewriteTurnLength = 500
+	// rewriteTimeout bounds how long retrieval waits on the chat model
+	rewriteTimeout = 30 * time.Second
+)
+
+// HistoryTurn is a single prior conversation message used to resolve follow-up questions
+type HistoryTurn struct {
+	Role    string `json:"role"` // "user" or "assistant"
+	Content string `json:"content"`
+}
+
+// rewriteFunc sends a rewrite prompt to a chat model and returns its raw reply
+type rewriteFunc func(ctx context.Context, messages []api.Message) (string, error)
+
+// RewriteQuery turns the latest user turn into one or more standalone search queries.
+//
+// The first returned query is the rewritten question; any further entries are
+// paraphrases requested through Config.RAGQueryExpansions. Collection scoping and
+// metadata filters from the original query are carried over to every rewrite. On
+// any failure the original query is returned so retrieval can still proceed.
+func (s *Service) RewriteQuery(ctx context.Context, query Query, history []HistoryTurn) []Query {
+	logger := logging.WithComponent("rag")
+
+	config := s.snapshot().config
+	expansions := config.RAGQueryExpansions
+	history = recentHistory(history)
+
+	// Without history or expansions there is nothing for the model to do
+	if !config.RAGQueryRewrite || query.Text == "" || (len(history) == 0 && expansions == 0) {
+		return []Query{query}
+	}
+
+	rewrite := s.rewrite
+	if rewrite == nil {
+		rewrite = s.ollamaRewrite
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, rewriteTimeout)
+	defer cancel()
+
+	reply, err := rewrite(ctx, buildRewriteMessages(query.Text, history, expansions))
+	if err != nil {
+		logger.Warn("Query rewrite failed, using original query",
+			"query_preview", contentPreview(query.Text, 100),
+			"error", err.Error(),
+		)
+		return []Query{query}
+	}
+
+	lines := parseRewriteReply(reply, expansions+1)
+	if len(lines) == 0 {
+		logger.Warn("Query rewrite returned no usable queries, using original query",
+			"query_preview", contentPreview(query.Text, 100),
+		)
+		return []Query{query}
+	}
+
+	queries := make([]Query, 0, len(lines))
+	for _, line := range lines {
+		queries = append(queries, Query{
+			Text:        line,
+			Collections: query.Collections,
+			Filters:     query.Filters,
+			Limit:       query.Limit,
+		})
+	}
+
+	logger.Info("Rewrote RAG query",
+		"original_query", contentPreview(query.Text, 100),
+		"rewritten_queries", lines,
+		"history_turns", len(history),
+	)
+
+	return queries
+}
+
+// ollamaRewrite asks the configured chat model to rewrite the query
+func (s *Service) ollamaRewrite(ctx context.Context, messages []api.Message) (string, error) {
+	config := s.snapshot().config
+	baseURL, err := url.Parse(config.OllamaURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid Ollama URL %s: %w", config.OllamaURL, err)
+	}
+	client := api.NewClient(baseURL, &http.Client{Timeout: rewriteTimeout})
+
+	stream := false
+	req := &api.ChatRequest{
+		Model:    config.ChatModel,
+		Messages: messages,
+		Stream:   &stream,
+		Options: map[string]any{
+			"temperature": 0.2,
+		},
+	}
+
+	var reply strings.Builder
+	err = client.Chat(ctx, req, func(resp api.ChatResponse) error {
+		reply.WriteString(resp.Message.Content)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to rewrite query: %w", err)
+	}
+
+	return reply.String(), nil
+}
+
+// recentHistory keeps the last user and assistant turns, truncating long content
+func recentHistory(history []HistoryTurn) []HistoryTurn {
+	turns := make([]HistoryTurn, 0, rewriteHistoryTurns)
+	for i := len(history) - 1; i >= 0 && len(turns) < rewriteHistoryTurns; i-- {
+		turn := history[i]
+		if (turn.Role != "user" && turn.Role != "assistant") || strings.TrimSpace(turn.Content) == "" {
+			continue
+		}
+		turns = append(turns, HistoryTurn{
+			Role:    turn.Role,
+			Content: contentPreview(strings.TrimSpace(turn.Content), rewriteTurnLength),
+		})
+	}
+
+	// Restore chronological order
+	for i, j := 0, len(turns)-1; i < j; i, j = i+1, j-1 {
+		turns[i], turns[j] = turns[j], turns[i]
+	}
+	return turns
+}
+
+// buildRewriteMessages creates the prompt asking the model for standalone search queries
+func buildRewriteMessages(question string, history []HistoryTurn, expansions int) []api.Message {
+	var instructions strings.Builder
+	instructions.WriteString("You rewrite questions into search queries for a document retrieval system.\n")
+	instructions.WriteString("Rewrite the latest question so it can be understood without the conversation, ")
+	instructions.WriteString("replacing pronouns and references with the things they refer to.\n")
+	if expansions > 0 {
+		instructions.WriteString(fmt.Sprintf("Then write %d alternative phrasings of the rewritten query using different wording.\n", expansions))
+	}
+	instructions.WriteString("Reply with one query per line and nothing else. Do not answer the question.")
+
+	var user strings.Builder
+	if len(history) > 0 {
+		user.WriteString("Conversation:\n")
+		for _, turn := range history {
+			user.WriteString(fmt.Sprintf("%s: %s\n", turn.Role, turn.Content))
+		}
+		user.WriteString("\n")
+	}
+	user.WriteString("Latest question: ")
+	user.WriteString(question)
+
+	return []api.Message{
+		{Role: "system", Content: instructions.String()},
+		{Role: "user", Content: user.String()},
+	}
+}
+
+// parseRewriteReply extracts up to limit distinct queries from the model reply,
+// stripping list markers and quotes that models commonly add
+func parseRewriteReply(reply string, limit int) []string {
+	queries := make([]string, 0, limit)
+	seen := make(map[string]bool)
+
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "-*•")
+		line = trimNumbering(line)
+		line = strings.Trim(strings.TrimSpace(line), `"'`)
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		key := strings.ToLower(line)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		queries = append(queries, line)
+		if len(queries) == limit {
+			break
+		}
+	}
+
+	return queries
+}
+
+// trimNumbering removes a leading "1." or "2)" style list marker
+func trimNumbering(line string) string {
+	i := 0
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	if i > 0 && i < len(line) && (line[i] == '.' || line[i] == ')') {
+		return line[i+1:]
+	}
+	return line
+}
@@ -0,0 +1,215 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+func TestParseRewriteReply(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    string
+		limit    int
+		expected []string
+	}{
+		{
+			name:     "plain lines",
+			reply:    "kubernetes pod restart policy\nhow pods restart in kubernetes",
+			limit:    3,
+			expected: []string{"kubernetes pod restart policy", "how pods restart in kubernetes"},
+		},
+		{
+			name:     "numbered and bulleted",
+			reply:    "1. first query\n2) second query\n- third query\n* fourth query",
+			limit:    4,
+			expected: []string{"first query", "second query", "third query", "fourth query"},
+		},
+		{
+			name:     "quotes and blank lines",
+			reply:    "\n\"quoted query\"\n\n  'single quoted'  \n",
+			limit:    5,
+			expected: []string{"quoted query", "single quoted"},
+		},
+		{
+			name:     "duplicates removed case-insensitively",
+			reply:    "Go modules\ngo modules\nGo workspaces",
+			limit:    5,
+			expected: []string{"Go modules", "Go workspaces"},
+		},
+		{
+			name:     "limit applied",
+			reply:    "a\nb\nc",
+			limit:    2,
+			expected: []string{"a", "b"},
+		},
+		{
+			name:     "numbers that are not list markers",
+			reply:    "2024 release notes",
+			limit:    1,
+			expected: []string{"2024 release notes"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseRewriteReply(tt.reply, tt.limit)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("parseRewriteReply() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRecentHistory(t *testing.T) {
+	history := []HistoryTurn{
+		{Role: "user", Content: "one"},
+		{Role: "assistant", Content: "two"},
+		{Role: "tool", Content: "ignored"},
+		{Role: "user", Content: "   "},
+		{Role: "user", Content: "three"},
+		{Role: "assistant", Content: strings.Repeat("x", rewriteTurnLength+10)},
+	}
+
+	result := recentHistory(history)
+	if len(result) != 4 {
+		t.Fatalf("Expected 4 turns, got %d", len(result))
+	}
+	if result[0].Content != "one" || result[2].Content != "three" {
+		t.Errorf("Expected chronological order, got %+v", result[:3])
+	}
+	if len(result[3].Content) != rewriteTurnLength+len("...") {
+		t.Errorf("Expected long turn to be truncated, got length %d", len(result[3].Content))
+	}
+
+	// Multi-byte characters are never split
+	accented := recentHistory([]HistoryTurn{{Role: "user", Content: "a" + strings.Repeat("é", rewriteTurnLength)}})
+	if content := accented[0].Content; !utf8.ValidString(content) || len(content) > rewriteTurnLength+len("...") {
+		t.Errorf("Expected valid UTF-8 truncated to %d bytes, got %q", rewriteTurnLength, content)
+	}
+
+	many := make([]HistoryTurn, 0, rewriteHistoryTurns*2)
+	for range rewriteHistoryTurns * 2 {
+		many = append(many, HistoryTurn{Role: "user", Content: "turn"})
+	}
+	if got := len(recentHistory(many)); got != rewriteHistoryTurns {
+		t.Errorf("Expected history capped at %d turns, got %d", rewriteHistoryTurns, got)
+	}
+}
+
+func TestService_RewriteQuery(t *testing.T) {
+	ctx := t.Context()
+	history := []HistoryTurn{
+		{Role: "user", Content: "What are the supported databases?"},
+		{Role: "assistant", Content: "PostgreSQL and SQLite."},
+	}
+	original := Query{
+		Text:        "what about the second one?",
+		Collections: []string{"docs"},
+		Filters:     map[string]string{"lang": "en"},
+	}
+
+	t.Run("disabled returns original", func(t *testing.T) {
+		service := NewService(&configuration.Config{RAGQueryRewrite: false})
+		service.rewrite = func(ctx context.Context, messages []api.Message) (string, error) {
+			t.Fatal("rewriter should not be called when disabled")
+			return "", nil
+		}
+
+		result := service.RewriteQuery(ctx, original, history)
+		if len(result) != 1 || result[0].Text != original.Text {
+			t.Errorf("Expected original query, got %+v", result)
+		}
+	})
+
+	t.Run("no history and no expansions skips model", func(t *testing.T) {
+		service := NewService(&configuration.Config{RAGQueryRewrite: true})
+		service.rewrite = func(ctx context.Context, messages []api.Message) (string, error) {
+			t.Fatal("rewriter should not be called without history or expansions")
+			return "", nil
+		}
+
+		result := service.RewriteQuery(ctx, original, nil)
+		if len(result) != 1 || result[0].Text != original.Text {
+			t.Errorf("Expected original query, got %+v", result)
+		}
+	})
+
+	t.Run("rewrites and expands preserving scope", func(t *testing.T) {
+		service := NewService(&configuration.Config{RAGQueryRewrite: true, RAGQueryExpansions: 1})
+		service.rewrite = func(ctx context.Context, messages []api.Message) (string, error) {
+			if len(messages) != 2 || !strings.Contains(messages[1].Content, "PostgreSQL and SQLite.") {
+				t.Errorf("Expected history in rewrite prompt, got %+v", messages)
+			}
+			return "1. SQLite support\n2. using SQLite as a database\n3. extra line", nil
+		}
+
+		result := service.RewriteQuery(ctx, original, history)
+		if len(result) != 2 {
+			t.Fatalf("Expected 2 queries, got %d", len(result))
+		}
+		if result[0].Text != "SQLite support" || result[1].Text != "using SQLite as a database" {
+			t.Errorf("Unexpected rewritten queries: %+v", result)
+		}
+		for _, q := range result {
+			if !reflect.DeepEqual(q.Collections, original.Collections) || !reflect.DeepEqual(q.Filters, original.Filters) {
+				t.Errorf("Expected scope to be preserved, got %+v", q)
+			}
+		}
+	})
+
+	t.Run("error falls back to original", func(t *testing.T) {
+		service := NewService(&configuration.Config{RAGQueryRewrite: true})
+		service.rewrite = func(ctx context.Context, messages []api.Message) (string, error) {
+			return "", errors.New("model unavailable")
+		}
+
+		result := service.RewriteQuery(ctx, original, history)
+		if len(result) != 1 || result[0].Text != original.Text {
+			t.Errorf("Expected original query on error, got %+v", result)
+		}
+	})
+}
+
+func TestMergeDocuments(t *testing.T) {
+	results := []*RAGResult{
+		{Documents: []RetrievedDocument{
+			{ID: "a", Collection: "docs", Distance: 0.5},
+			{ID: "b", Collection: "docs", Distance: 0.3},
+		}},
+		{Documents: []RetrievedDocument{
+			{ID: "a", Collection: "docs", Distance: 0.2},
+			{ID: "a", Collection: "notes", Distance: 0.4},
+			{ID: "c", Collection: "docs", Distance: 0.9},
+		}},
+	}
+
+	merged := mergeDocuments(results, 3)
+	if len(merged) != 3 {
+		t.Fatalf("Expected 3 documents, got %d", len(merged))
+	}
+
+	expected := []struct {
+		id         string
+		collection string
+		distance   float32
+	}{
+		{"a", "docs", 0.2},
+		{"b", "docs", 0.3},
+		{"a", "notes", 0.4},
+	}
+	for i, want := range expected {
+		got := merged[i]
+		if got.ID != want.id || got.Collection != want.collection || got.Distance != want.distance {
+			t.Errorf("Document %d = %s/%s (%.1f), expected %s/%s (%.1f)",
+				i, got.Collection, got.ID, got.Distance, want.collection, want.id, want.distance)
+		}
+	}
+}
@@ -3,16 +3,29 @@ package rag
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
+	"sync"
 	"time"
+	"unicode/utf8"
 
-	v2 "github.com/amikos-tech/chroma-go/pkg/api/v2"
 	"github.com/amikos-tech/chroma-go/pkg/embeddings"
 	"github.com/amikos-tech/chroma-go/pkg/embeddings/ollama"
 	"github.com/kevensen/gollama-chat/internal/configuration"
 	"github.com/kevensen/gollama-chat/internal/logging"
 )
 
+const (
+	// maxConcurrentCollectionQueries bounds how many collections are queried at once
+	maxConcurrentCollectionQueries = 4
+	// collectionQueryTimeout bounds the query of a single collection
+	collectionQueryTimeout = 15 * time.Second
+	// embeddingBatchSize is the number of texts sent per embedding request
+	embeddingBatchSize = 32
+	// cacheSaveDelay groups new cache entries into a single write
+	cacheSaveDelay = 5 * time.Second
+)
+
 // RetrievedDocument represents a document retrieved from ChromaDB with relevance score
 type RetrievedDocument struct {
 	Content    string            `json:"content"`
@@ -29,15 +42,48 @@ type RAGResult struct {
 	Error     error               `json:"error,omitempty"`
 }
 
-// Service handles RAG operations using ChromaDB
+// Service handles RAG operations against the configured vector store. It is safe for
+// concurrent use: queries run on tool goroutines while the UI updates the configuration
+// and the selected collections.
 type Service struct {
+	mu                  sync.RWMutex // Guards the fields below except rewrite
+	config              *configuration.Config
+	store               VectorStore
+	embeddingFunc       embeddings.EmbeddingFunction
+	cache               *EmbeddingCache // Embeddings by model and text; nil when disabled
+	connected           bool
+	collections         []string // Collections in the vector store, as of the last listing
+	selectedCollections []string
+	rewrite             rewriteFunc // Overrides the Ollama query rewriter; nil uses the configured chat model
+}
+
+// serviceState is the state a query works with, copied under the lock when it starts so
+// concurrent updates do not change it halfway through
+type serviceState struct {
 	config              *configuration.Config
-	client              v2.Client
+	store               VectorStore
 	embeddingFunc       embeddings.EmbeddingFunction
+	cache               *EmbeddingCache
 	connected           bool
+	collections         []string
 	selectedCollections []string
 }
 
+// snapshot returns the current state of the service
+func (s *Service) snapshot() serviceState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return serviceState{
+		config:              s.config,
+		store:               s.store,
+		embeddingFunc:       s.embeddingFunc,
+		cache:               s.cache,
+		connected:           s.connected,
+		collections:         slices.Clone(s.collections),
+		selectedCollections: slices.Clone(s.selectedCollections),
+	}
+}
+
 // NewService creates a new RAG service
 func NewService(config *configuration.Config) *Service {
 	return &Service{
@@ -47,61 +93,61 @@ func NewService(config *configuration.Config) *Service {
 	}
 }
 
-// Initialize sets up the ChromaDB client and embedding function
+// Initialize sets up the vector store and embedding function
 func (s *Service) Initialize(ctx context.Context) error {
 	logger := logging.WithComponent("rag")
-
-	if s.config.ChromaDBURL == "" {
-		return fmt.Errorf("ChromaDB URL not configured")
-	}
+	config := s.snapshot().config
 
 	logger.Info("Initializing RAG service",
-		"chromadb_url", s.config.ChromaDBURL,
-		"ollama_url", s.config.OllamaURL,
-		"embedding_model", s.config.EmbeddingModel,
+		"vector_store", config.VectorStore,
+		"chromadb_url", config.ChromaDBURL,
+		"ollama_url", config.OllamaURL,
+		"embedding_model", config.EmbeddingModel,
+	)
+
+	// Create Ollama embedding function
+	logger.Info("Creating Ollama embedding function",
+		"ollama_url", config.OllamaURL,
+		"embedding_model", config.EmbeddingModel,
+	)
+	embeddingFunc, err := ollama.NewOllamaEmbeddingFunction(
+		ollama.WithBaseURL(config.OllamaURL),
+		ollama.WithModel(embeddings.EmbeddingModel(config.EmbeddingModel)),
 	)
+	if err != nil {
+		logger.Error("Failed to create Ollama embedding function", "error", err.Error())
+		return fmt.Errorf("failed to create Ollama embedding function: %w", err)
+	}
 
-	// Create ChromaDB client
-	logger.Info("Creating ChromaDB client", "chromadb_url", s.config.ChromaDBURL)
-	client, err := v2.NewHTTPClient(v2.WithBaseURL(s.config.ChromaDBURL))
+	// Create the vector store
+	logger.Info("Creating vector store", "vector_store", config.VectorStore)
+	store, err := NewStore(config, embeddingFunc)
 	if err != nil {
-		logger.Error("Failed to create ChromaDB client", "error", err.Error())
-		return fmt.Errorf("failed to create ChromaDB client: %w", err)
+		logger.Error("Failed to create vector store", "error", err.Error())
+		return err
 	}
 
 	// Test connection
-	logger.Info("Testing connection to ChromaDB data store")
+	logger.Info("Testing connection to vector store")
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	collections, err := client.ListCollections(ctx)
+	collections, err := store.ListCollections(ctx)
 	if err != nil {
-		logger.Error("Failed to connect to ChromaDB data store",
-			"chromadb_url", s.config.ChromaDBURL,
+		logger.Error("Failed to connect to vector store",
+			"vector_store", config.VectorStore,
+			"chromadb_url", config.ChromaDBURL,
 			"error", err.Error(),
 		)
-		return fmt.Errorf("failed to connect to ChromaDB: %w", err)
+		store.Close()
+		return fmt.Errorf("failed to connect to vector store: %w", err)
 	}
 
-	logger.Info("Successfully connected to ChromaDB data store",
+	logger.Info("Successfully connected to vector store",
 		"available_collections", len(collections),
-		"chromadb_url", s.config.ChromaDBURL,
+		"vector_store", config.VectorStore,
 	)
 
-	// Create Ollama embedding function
-	logger.Info("Creating Ollama embedding function",
-		"ollama_url", s.config.OllamaURL,
-		"embedding_model", s.config.EmbeddingModel,
-	)
-	embeddingFunc, err := ollama.NewOllamaEmbeddingFunction(
-		ollama.WithBaseURL(s.config.OllamaURL),
-		ollama.WithModel(embeddings.EmbeddingModel(s.config.EmbeddingModel)),
-	)
-	if err != nil {
-		logger.Error("Failed to create Ollama embedding function", "error", err.Error())
-		return fmt.Errorf("failed to create Ollama embedding function: %w", err)
-	}
-
 	// Test the embedding function to ensure it works
 	logger.Info("Testing embedding function with sample text")
 	testCtx, testCancel := context.WithTimeout(ctx, 10*time.Second)
@@ -110,33 +156,37 @@ func (s *Service) Initialize(ctx context.Context) error {
 	_, err = embeddingFunc.EmbedDocuments(testCtx, []string{"test"})
 	if err != nil {
 		logger.Error("Embedding model test failed - this model does not support embeddings",
-			"embedding_model", s.config.EmbeddingModel,
+			"embedding_model", config.EmbeddingModel,
 			"error", err.Error(),
 		)
-		return fmt.Errorf("embedding model '%s' does not support embeddings. Please use a model like 'nomic-embed-text:latest' or 'all-minilm:latest': %w", s.config.EmbeddingModel, err)
+		store.Close()
+		return fmt.Errorf("embedding model '%s' does not support embeddings. Please use a model like 'nomic-embed-text:latest' or 'all-minilm:latest': %w", config.EmbeddingModel, err)
 	}
 	logger.Info("Embedding function test successful")
 
-	s.client = client
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Release the previous store when reinitializing after a configuration change
+	if s.store != nil {
+		s.store.Close()
+	}
+	s.store = store
 	s.embeddingFunc = embeddingFunc
 	s.connected = true
+	s.collections = collectionNames(collections)
+	if s.cache == nil {
+		s.cache = newEmbeddingCache(config)
+	}
 
-	// Automatically load and select all collections if none are selected
+	// Automatically select all collections if none are selected
 	if len(s.selectedCollections) == 0 {
-		logger.Info("No collections selected, auto-loading all available collections")
+		logger.Info("No collections selected, auto-selecting all available collections")
 
-		collections, err := client.ListCollections(ctx)
-		if err != nil {
-			logger.Warn("Failed to auto-load collections for RAG service", "error", err.Error())
-		} else {
-			s.selectedCollections = make([]string, 0, len(collections))
-			for _, collection := range collections {
-				s.selectedCollections = append(s.selectedCollections, collection.Name())
-			}
-			logger.Info("Auto-selected all available collections for RAG service",
-				"selected_collections", s.selectedCollections,
-				"count", len(s.selectedCollections))
-		}
+		s.selectedCollections = slices.Clone(s.collections)
+		logger.Info("Auto-selected all available collections for RAG service",
+			"selected_collections", s.selectedCollections,
+			"count", len(s.selectedCollections))
 	}
 
 	logger.Info("RAG service initialization completed successfully")
@@ -144,55 +194,88 @@ func (s *Service) Initialize(ctx context.Context) error {
 }
 
 // UpdateSelectedCollections updates the list of selected collections
-func (s *Service) UpdateSelectedCollections(ctx context.Context, selectedCollections map[string]bool) {
+func (s *Service) UpdateSelectedCollections(ctx context.Context, selectedCollections map[string]configuration.CollectionSettings) {
 	logger := logging.WithComponent("rag")
+	state := s.snapshot()
 
+	selected := make([]string, 0)
 	// If no collections are specifically selected (empty map), auto-select all available collections
 	if len(selectedCollections) == 0 {
 		logger.Info("No collections specified in configuration, attempting to auto-select all available collections")
 
 		// Only auto-select if we have a connected client
-		if s.connected && s.client != nil {
-			collections, err := s.client.ListCollections(ctx)
+		if state.connected && state.store != nil {
+			collections, err := state.store.ListCollections(ctx)
 			if err != nil {
 				logger.Warn("Failed to auto-load collections, keeping existing selections", "error", err.Error())
 				// Keep existing selections if we can't fetch collections
 				return
 			}
 
-			s.selectedCollections = make([]string, 0, len(collections))
-			for _, collection := range collections {
-				s.selectedCollections = append(s.selectedCollections, collection.Name())
-			}
+			selected = collectionNames(collections)
+			s.mu.Lock()
+			s.collections = slices.Clone(selected)
+			s.mu.Unlock()
 			logger.Info("Auto-selected all available collections",
-				"selected_collections", s.selectedCollections,
-				"count", len(s.selectedCollections))
+				"selected_collections", selected,
+				"count", len(selected))
 		} else {
 			logger.Info("RAG service not connected, cannot auto-select collections")
-			s.selectedCollections = make([]string, 0)
 		}
 	} else {
 		// Use explicitly selected collections
-		s.selectedCollections = make([]string, 0)
-		for collection, selected := range selectedCollections {
-			if selected {
-				s.selectedCollections = append(s.selectedCollections, collection)
+		for collection, settings := range selectedCollections {
+			if settings.Selected {
+				selected = append(selected, collection)
 			}
 		}
 		logger.Info("Updated RAG service with explicitly selected collections",
-			"selected_collections", s.selectedCollections,
-			"count", len(s.selectedCollections))
+			"selected_collections", selected,
+			"count", len(selected))
+	}
+
+	s.mu.Lock()
+	s.selectedCollections = selected
+	s.mu.Unlock()
+}
+
+// Collections returns the names @mentions can scope a query to: the collections in the
+// vector store and those in the configuration
+func (s *Service) Collections() []string {
+	return s.snapshot().knownCollections()
+}
+
+// knownCollections returns the collections in the vector store and in the configuration
+func (state serviceState) knownCollections() []string {
+	names := slices.Clone(state.collections)
+	names = append(names, state.selectedCollections...)
+	for name := range state.config.SelectedCollections {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// collectionNames returns the names of collections
+func collectionNames(collections []CollectionInfo) []string {
+	names := make([]string, 0, len(collections))
+	for _, collection := range collections {
+		names = append(names, collection.Name)
 	}
+	return names
 }
 
 // GetSelectedCollections returns the list of currently selected collections
 func (s *Service) GetSelectedCollections() []string {
-	return s.selectedCollections
+	return s.snapshot().selectedCollections
 }
 
 // UpdateConfig updates the service's configuration reference
 func (s *Service) UpdateConfig(newConfig *configuration.Config) {
 	logger := logging.WithComponent("rag")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	logger.Info("Updating RAG service configuration reference",
 		"old_chromadb_url", s.config.ChromaDBURL,
 		"new_chromadb_url", newConfig.ChromaDBURL,
@@ -207,202 +290,408 @@ func (s *Service) UpdateConfig(newConfig *configuration.Config) {
 // IsReady checks if the service is ready to perform RAG operations
 func (s *Service) IsReady() bool {
 	logger := logging.WithComponent("rag")
-	ready := s.config.RAGEnabled && s.connected && len(s.selectedCollections) > 0
+	state := s.snapshot()
+	ready := state.config.RAGEnabled && state.connected && len(state.selectedCollections) > 0
 
 	logger.Info("RAG service readiness check",
-		"rag_enabled", s.config.RAGEnabled,
-		"connected", s.connected,
-		"selected_collections_count", len(s.selectedCollections),
-		"selected_collections", s.selectedCollections,
+		"rag_enabled", state.config.RAGEnabled,
+		"connected", state.connected,
+		"selected_collections_count", len(state.selectedCollections),
+		"selected_collections", state.selectedCollections,
 		"is_ready", ready,
 	)
 
 	return ready
-} // QueryDocuments retrieves relevant documents for the given query
+}
+
+// QueryDocuments retrieves relevant documents for the given query.
+// The query may contain @collection mentions and #key=value metadata filters (see ParseQuery).
 func (s *Service) QueryDocuments(ctx context.Context, query string) (*RAGResult, error) {
+	return s.QueryDocumentsScoped(ctx, ParseQuery(query, s.Collections()))
+}
+
+// QueryDocumentsScoped retrieves relevant documents for an already parsed query
+func (s *Service) QueryDocumentsScoped(ctx context.Context, query Query) (*RAGResult, error) {
 	logger := logging.WithComponent("rag")
+	state := s.snapshot()
 
-	if !s.connected {
-		return nil, fmt.Errorf("RAG service not connected to ChromaDB")
+	if !state.connected {
+		return nil, fmt.Errorf("RAG service not connected to a vector store")
 	}
 
-	if !s.config.RAGEnabled {
+	if !state.config.RAGEnabled {
 		return nil, fmt.Errorf("RAG is disabled in configuration")
 	}
 
-	if len(s.selectedCollections) == 0 {
+	collections := state.queryCollections(query)
+	if len(collections) == 0 {
 		return nil, fmt.Errorf("no collections selected for RAG")
 	}
 
+	if query.Text == "" {
+		return nil, fmt.Errorf("query text is empty")
+	}
+
 	// Log the start of RAG query with selected collections
 	logger.Info("Starting RAG query",
-		"query_preview", contentPreview(query, 100),
-		"selected_collections", s.selectedCollections,
-		"max_documents", s.config.MaxDocuments,
-		"distance_threshold", s.config.ChromaDBDistance,
+		"query_preview", contentPreview(query.Text, 100),
+		"collections", collections,
+		"scoped_collections", len(query.Collections) > 0,
+		"metadata_filters", query.Filters,
+		"max_documents", state.config.MaxDocuments,
+		"distance_threshold", state.config.ChromaDBDistance,
 	)
 
 	result := &RAGResult{
-		Query:     query,
+		Query:     query.Text,
 		Documents: make([]RetrievedDocument, 0),
 	}
 
-	// Query each selected collection
-	for _, collectionName := range s.selectedCollections {
-		// Log accessing each collection
-		logger.Info("Accessing collection",
-			"collection_name", collectionName,
-			"chromadb_url", s.config.ChromaDBURL,
-		)
+	maxDocuments := state.maxDocuments(collections)
+	if query.Limit > 0 {
+		maxDocuments = query.Limit
+	}
 
-		docs, err := s.queryCollection(ctx, collectionName, query)
-		if err != nil {
-			// Log error but continue with other collections
-			logger.Warn("Failed to query collection",
+	// Embed the query once and reuse the vector for every collection
+	embedding, err := s.embedQuery(ctx, state, query.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	// Query collections concurrently with a bounded number of workers. Results are
+	// stored by index so the merged order does not depend on completion order.
+	collectionDocs := make([][]RetrievedDocument, len(collections))
+	semaphore := make(chan struct{}, maxConcurrentCollectionQueries)
+	var wg sync.WaitGroup
+
+	for i, collectionName := range collections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			// Log accessing each collection
+			logger.Info("Accessing collection",
 				"collection_name", collectionName,
-				"error", err.Error(),
+				"vector_store", state.config.VectorStore,
 			)
-			continue
-		}
 
-		// Log successful collection query
-		logger.Info("Collection query completed",
-			"collection_name", collectionName,
-			"documents_found", len(docs),
-		)
+			collectionCtx, cancel := context.WithTimeout(ctx, collectionQueryTimeout)
+			defer cancel()
 
+			docs, err := s.queryCollection(collectionCtx, state, collectionName, query, embedding)
+			if err != nil {
+				// Log error but continue with other collections
+				logger.Warn("Failed to query collection",
+					"collection_name", collectionName,
+					"error", err.Error(),
+				)
+				return
+			}
+
+			// Log successful collection query
+			logger.Info("Collection query completed",
+				"collection_name", collectionName,
+				"documents_found", len(docs),
+			)
+
+			collectionDocs[i] = docs
+		}()
+	}
+	wg.Wait()
+
+	for _, docs := range collectionDocs {
 		result.Documents = append(result.Documents, docs...)
 	}
 
 	// Sort documents by distance (most relevant first)
-	sort.Slice(result.Documents, func(i, j int) bool {
+	sort.SliceStable(result.Documents, func(i, j int) bool {
 		return result.Documents[i].Distance < result.Documents[j].Distance
 	})
 
-	// Limit total documents to maxDocuments from config
-	if len(result.Documents) > s.config.MaxDocuments {
+	// Limit total documents
+	if len(result.Documents) > maxDocuments {
 		logger.Info("Limiting documents to max",
 			"total_found", len(result.Documents),
-			"max_documents", s.config.MaxDocuments,
+			"max_documents", maxDocuments,
 		)
-		result.Documents = result.Documents[:s.config.MaxDocuments]
+		result.Documents = result.Documents[:maxDocuments]
 	}
 
 	// Log final results
 	logger.Info("RAG query completed",
 		"total_documents_returned", len(result.Documents),
-		"collections_queried", len(s.selectedCollections),
+		"collections_queried", len(collections),
 	)
 
 	return result, nil
 }
 
+// QueryDocumentsWithHistory retrieves documents for a chat turn. When query rewriting is
+// enabled the turn is first rewritten into standalone queries using the conversation
+// history, and the results of every query are merged.
+func (s *Service) QueryDocumentsWithHistory(ctx context.Context, prompt string, history []HistoryTurn) (*RAGResult, error) {
+	state := s.snapshot()
+	query := ParseQuery(prompt, state.knownCollections())
+	if !state.config.RAGQueryRewrite || !state.connected {
+		return s.QueryDocumentsScoped(ctx, query)
+	}
+	return s.QueryDocumentsMulti(ctx, query, s.RewriteQuery(ctx, query, history))
+}
+
+// QueryDocumentsMulti runs several queries and returns the union of their results,
+// de-duplicated by collection and document ID and ordered by distance.
+// The result's Query field reports the original query text.
+func (s *Service) QueryDocumentsMulti(ctx context.Context, original Query, queries []Query) (*RAGResult, error) {
+	logger := logging.WithComponent("rag")
+
+	if len(queries) == 0 {
+		queries = []Query{original}
+	}
+
+	results := make([]*RAGResult, 0, len(queries))
+	var firstErr error
+	for _, query := range queries {
+		result, err := s.QueryDocumentsScoped(ctx, query)
+		if err != nil {
+			logger.Warn("Expanded query failed",
+				"query_preview", contentPreview(query.Text, 100),
+				"error", err.Error(),
+			)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		results = append(results, result)
+	}
+
+	if len(results) == 0 {
+		return nil, firstErr
+	}
+
+	state := s.snapshot()
+	limit := state.maxDocuments(state.queryCollections(original))
+	if original.Limit > 0 {
+		limit = original.Limit
+	}
+
+	merged := &RAGResult{
+		Query:     original.Text,
+		Documents: mergeDocuments(results, limit),
+	}
+
+	logger.Info("Multi-query RAG completed",
+		"queries", len(queries),
+		"successful_queries", len(results),
+		"total_documents_returned", len(merged.Documents),
+	)
+
+	return merged, nil
+}
+
+// queryCollections returns the collections a query targets.
+// @mentions take precedence over the selected collections.
+func (state serviceState) queryCollections(query Query) []string {
+	if len(query.Collections) > 0 {
+		return query.Collections
+	}
+	return state.selectedCollections
+}
+
+// maxDocuments returns the overall document limit for the given collections. It is the
+// largest limit of any queried collection so that a per-collection override is not cut
+// short by the global setting.
+func (state serviceState) maxDocuments(collections []string) int {
+	maxDocuments := 0
+	for _, collectionName := range collections {
+		maxDocuments = max(maxDocuments, state.config.GetCollectionMaxDocuments(collectionName))
+	}
+	return maxDocuments
+}
+
+// mergeDocuments unions the documents of several results, keeping the closest match for
+// documents returned more than once, then sorts by distance and applies the limit
+func mergeDocuments(results []*RAGResult, limit int) []RetrievedDocument {
+	best := make(map[string]int)
+	documents := make([]RetrievedDocument, 0)
+
+	for _, result := range results {
+		for _, doc := range result.Documents {
+			key := doc.Collection + "\x00" + doc.ID
+			if doc.ID == "" {
+				// Without an ID fall back to the content to detect duplicates
+				key = doc.Collection + "\x00" + doc.Content
+			}
+
+			if i, exists := best[key]; exists {
+				if doc.Distance < documents[i].Distance {
+					documents[i] = doc
+				}
+				continue
+			}
+			best[key] = len(documents)
+			documents = append(documents, doc)
+		}
+	}
+
+	sort.SliceStable(documents, func(i, j int) bool {
+		return documents[i].Distance < documents[j].Distance
+	})
+
+	if limit > 0 && len(documents) > limit {
+		documents = documents[:limit]
+	}
+	return documents
+}
+
 // queryCollection queries a specific collection for relevant documents
-func (s *Service) queryCollection(ctx context.Context, collectionName, query string) ([]RetrievedDocument, error) {
+func (s *Service) queryCollection(ctx context.Context, state serviceState, collectionName string, query Query, embedding []float32) ([]RetrievedDocument, error) {
 	logger := logging.WithComponent("rag")
 
-	// Log attempting to get collection
-	logger.Info("Getting collection from data store",
+	maxResults := state.config.GetCollectionMaxDocuments(collectionName)
+	if query.Limit > 0 {
+		maxResults = query.Limit
+	}
+	distanceThreshold := state.config.GetCollectionDistance(collectionName)
+
+	logger.Info("Querying collection",
 		"collection_name", collectionName,
-		"chromadb_url", s.config.ChromaDBURL,
+		"vector_store", state.config.VectorStore,
+		"max_results", maxResults,
+		"distance_threshold", distanceThreshold,
+		"metadata_filters", query.Filters,
 	)
 
-	// Get the collection with embedding function
-	collection, err := s.client.GetCollection(ctx, collectionName, v2.WithEmbeddingFunctionGet(s.embeddingFunc))
+	results, err := state.store.Query(ctx, collectionName, StoreQuery{
+		Embedding: embedding,
+		Limit:     maxResults,
+		Filters:   query.Filters,
+	})
 	if err != nil {
-		logger.Warn("Failed to get collection",
+		logger.Warn("Failed to query collection",
 			"collection_name", collectionName,
 			"error", err.Error(),
 		)
-		return nil, fmt.Errorf("failed to get collection %s: %w", collectionName, err)
+		return nil, err
 	}
 
-	// Log successful collection access and querying
-	logger.Info("Querying collection",
+	// Filter by distance threshold
+	documents := make([]RetrievedDocument, 0, len(results))
+	for _, doc := range results {
+		if doc.Distance > float32(distanceThreshold) {
+			continue
+		}
+		documents = append(documents, doc)
+	}
+
+	// Log detailed results for this collection
+	logger.Info("Collection query results",
 		"collection_name", collectionName,
-		"max_results", s.config.MaxDocuments,
-		"distance_threshold", s.config.ChromaDBDistance,
+		"total_results_returned", len(results),
+		"results_after_distance_filter", len(documents),
+		"distance_threshold", distanceThreshold,
+		"relevant_documents", len(documents),
 	)
 
-	// Query the collection
-	queryResult, err := collection.Query(
-		ctx,
-		v2.WithQueryTexts(query),
-		v2.WithNResults(s.config.MaxDocuments),
-		v2.WithIncludeQuery("documents", "metadatas", "distances"),
-	)
+	return documents, nil
+}
+
+// embedQuery embeds query text with the configured embedding model
+func (s *Service) embedQuery(ctx context.Context, state serviceState, text string) ([]float32, error) {
+	vectors, err := s.embedTexts(ctx, state, []string{text})
 	if err != nil {
-		logger.Warn("Failed to query collection",
-			"collection_name", collectionName,
-			"error", err.Error(),
-		)
-		return nil, fmt.Errorf("failed to query collection %s: %w", collectionName, err)
+		return nil, fmt.Errorf("failed to embed query: %w", err)
 	}
+	return vectors[0], nil
+}
 
-	documents := make([]RetrievedDocument, 0)
-	totalResults := 0
-	filteredResults := 0
-
-	// Process query results
-	for groupIdx, group := range queryResult.GetDocumentsGroups() {
-		for i, doc := range group {
-			totalResults++
-
-			// Get distance for this document
-			var distance float32 = 1.0 // Default high distance
-			if distanceGroups := queryResult.GetDistancesGroups(); len(distanceGroups) > groupIdx && len(distanceGroups[groupIdx]) > i {
-				distance = float32(distanceGroups[groupIdx][i])
-			}
+// embedTexts embeds texts with the configured embedding model. Cached embeddings are
+// reused and the remaining texts are sent to the model in batches.
+func (s *Service) embedTexts(ctx context.Context, state serviceState, texts []string) ([][]float32, error) {
+	logger := logging.WithComponent("rag")
+	model := state.config.EmbeddingModel
+
+	vectors := make([][]float32, len(texts))
+	missing := make(map[string][]int) // Uncached text -> positions in texts
+	var pending []string
 
-			// Filter by distance threshold
-			if distance > float32(s.config.ChromaDBDistance) {
+	for i, text := range texts {
+		if state.cache != nil {
+			if vector, ok := state.cache.Get(model, text); ok {
+				vectors[i] = vector
 				continue
 			}
+		}
+		if _, seen := missing[text]; !seen {
+			pending = append(pending, text)
+		}
+		missing[text] = append(missing[text], i)
+	}
 
-			filteredResults++
-
-			// Get metadata for this document
-			metadata := make(map[string]string)
-			if metadataGroups := queryResult.GetMetadatasGroups(); len(metadataGroups) > groupIdx && len(metadataGroups[groupIdx]) > i {
-				docMetadata := metadataGroups[groupIdx][i]
-				if docMetadata != nil {
-					// Type assert to DocumentMetadataImpl to access Keys() method
-					if impl, ok := docMetadata.(*v2.DocumentMetadataImpl); ok {
-						for _, key := range impl.Keys() {
-							if value, ok := impl.GetRaw(key); ok && value != nil {
-								metadata[key] = fmt.Sprintf("%v", value)
-							}
-						}
-					}
-				}
-			}
+	for start := 0; start < len(pending); start += embeddingBatchSize {
+		batch := pending[start:min(start+embeddingBatchSize, len(pending))]
 
-			// Get document ID
-			var docID string
-			if idGroups := queryResult.GetIDGroups(); len(idGroups) > groupIdx && len(idGroups[groupIdx]) > i {
-				docID = string(idGroups[groupIdx][i])
-			}
+		embedded, err := state.embeddingFunc.EmbedDocuments(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(embedded) != len(batch) {
+			return nil, fmt.Errorf("embedding model returned %d embeddings for %d texts", len(embedded), len(batch))
+		}
 
-			documents = append(documents, RetrievedDocument{
-				Content:    doc.ContentString(),
-				Metadata:   metadata,
-				Collection: collectionName,
-				Distance:   distance,
-				ID:         docID,
-			})
+		for j, text := range batch {
+			vector := embedded[j].ContentAsFloat32()
+			for _, i := range missing[text] {
+				vectors[i] = vector
+			}
+			if state.cache != nil {
+				state.cache.Put(model, text, vector)
+			}
 		}
 	}
 
-	// Log detailed results for this collection
-	logger.Info("Collection query results",
-		"collection_name", collectionName,
-		"total_results_returned", totalResults,
-		"results_after_distance_filter", filteredResults,
-		"distance_threshold", s.config.ChromaDBDistance,
-		"relevant_documents", len(documents),
+	if state.cache != nil && len(pending) > 0 {
+		state.cache.SaveAfter(cacheSaveDelay)
+	}
+
+	logger.Debug("Embedded texts",
+		"texts", len(texts),
+		"cache_hits", len(texts)-len(pending),
+		"embedded", len(pending),
+		"batches", (len(pending)+embeddingBatchSize-1)/embeddingBatchSize,
 	)
 
-	return documents, nil
+	return vectors, nil
+}
+
+// newEmbeddingCache creates the persistent embedding cache configured by EmbeddingCacheSize.
+// It falls back to a memory-only cache when the cache file cannot be used.
+func newEmbeddingCache(config *configuration.Config) *EmbeddingCache {
+	logger := logging.WithComponent("rag")
+
+	size := config.EmbeddingCacheSize
+	if size == 0 {
+		size = DefaultEmbeddingCacheSize
+	}
+	if size < 0 {
+		return nil
+	}
+
+	path, err := configuration.EmbeddingCachePath()
+	if err == nil {
+		var cache *EmbeddingCache
+		if cache, err = NewEmbeddingCache(size, path); err == nil {
+			logger.Info("Loaded embedding cache", "path", path, "entries", cache.Len(), "capacity", size)
+			return cache
+		}
+	}
+
+	logger.Warn("Embedding cache unavailable, using memory-only cache", "error", err.Error())
+	cache, _ := NewEmbeddingCache(size, "")
+	return cache
 }
 
 // FormatDocumentsForPrompt formats retrieved documents for inclusion in chat prompt
@@ -426,10 +715,15 @@ func (r *RAGResult) FormatDocumentsForPrompt() string {
 	return prompt
 }
 
-// contentPreview returns a truncated preview of content for logging
+// contentPreview returns content truncated to at most maxLength bytes, cut at the start
+// of a UTF-8 character so the preview stays valid text
 func contentPreview(content string, maxLength int) string {
 	if len(content) <= maxLength {
 		return content
 	}
-	return content[:maxLength] + "..."
+	end := maxLength
+	for end > 0 && !utf8.RuneStart(content[end]) {
+		end--
+	}
+	return content[:end] + "..."
 }
@@ -1,6 +1,8 @@
 package rag
 
 import (
+	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/kevensen/gollama-chat/internal/configuration"
@@ -152,7 +154,7 @@ func TestService_UpdateSelectedCollections_AutoSelect(t *testing.T) {
 	service.selectedCollections = []string{"existing-collection"}
 	service.connected = false
 
-	emptyMap := make(map[string]bool)
+	emptyMap := make(map[string]configuration.CollectionSettings)
 	service.UpdateSelectedCollections(ctx, emptyMap)
 
 	if len(service.selectedCollections) != 0 {
@@ -160,10 +162,10 @@ func TestService_UpdateSelectedCollections_AutoSelect(t *testing.T) {
 	}
 
 	// Test 2: Non-empty map should use explicit selections
-	explicitMap := map[string]bool{
-		"collection1": true,
-		"collection2": false,
-		"collection3": true,
+	explicitMap := map[string]configuration.CollectionSettings{
+		"collection1": {Selected: true},
+		"collection2": {Selected: false},
+		"collection3": {Selected: true, MaxDocuments: 10},
 	}
 
 	service.UpdateSelectedCollections(ctx, explicitMap)
@@ -192,3 +194,113 @@ func TestService_UpdateSelectedCollections_AutoSelect(t *testing.T) {
 		}
 	}
 }
+
+func TestService_Collections(t *testing.T) {
+	config := &configuration.Config{
+		SelectedCollections: map[string]configuration.CollectionSettings{"configured": {Selected: true}},
+	}
+	service := NewService(config)
+	service.collections = []string{"stored", "notes"}
+	service.selectedCollections = []string{"stored"}
+
+	expected := []string{"configured", "notes", "stored"}
+	if got := service.Collections(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Collections() = %v, expected %v", got, expected)
+	}
+
+	// Unknown @mentions stay in the query text
+	query := ParseQuery("ask @alice about @notes", service.Collections())
+	if query.Text != "ask @alice about" || !reflect.DeepEqual(query.Collections, []string{"notes"}) {
+		t.Errorf("Unexpected query %+v", query)
+	}
+}
+
+func TestService_QueryDocumentsScoped_Errors(t *testing.T) {
+	ctx := t.Context()
+	config := &configuration.Config{
+		RAGEnabled:   true,
+		MaxDocuments: 5,
+	}
+	service := NewService(config)
+
+	// Not connected
+	if _, err := service.QueryDocumentsScoped(ctx, ParseQuery("hello", nil)); err == nil {
+		t.Error("Expected error when service is not connected")
+	}
+
+	service.connected = true
+
+	// No selected collections and no @mentions
+	if _, err := service.QueryDocumentsScoped(ctx, ParseQuery("hello", nil)); err == nil {
+		t.Error("Expected error when no collections are selected")
+	}
+
+	// Only scoping tokens, no query text
+	if _, err := service.QueryDocumentsScoped(ctx, ParseQuery("@docs #lang=go", []string{"docs"})); err == nil {
+		t.Error("Expected error when query text is empty")
+	}
+}
+
+func TestService_QueryDuringUpdates(t *testing.T) {
+	ctx := t.Context()
+	config := &configuration.Config{
+		RAGEnabled:       true,
+		VectorStore:      configuration.VectorStoreLocal,
+		LocalStorePath:   t.TempDir(),
+		MaxDocuments:     2,
+		ChromaDBDistance: 1,
+	}
+	store, err := NewStore(config, nil)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+
+	service := NewService(config)
+	service.store = store
+	service.embeddingFunc = letterEmbedding{}
+	service.connected = true
+	service.selectedCollections = []string{"animals"}
+	if err := service.AddDocuments(ctx, "animals", []Document{{ID: "1", Content: "cat"}}); err != nil {
+		t.Fatalf("AddDocuments failed: %v", err)
+	}
+
+	// Queries run on tool goroutines while the UI changes the configuration; run with
+	// -race to check they do not share state unguarded
+	done := make(chan struct{})
+	updated := make(chan struct{})
+	go func() {
+		defer close(updated)
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			changed := *config
+			changed.MaxDocuments = i%5 + 1
+			service.UpdateConfig(&changed)
+			service.UpdateSelectedCollections(ctx, map[string]configuration.CollectionSettings{"animals": {Selected: true}})
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				if _, err := service.QueryDocuments(ctx, "cat"); err != nil {
+					t.Errorf("QueryDocuments failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	<-updated
+
+	if selected := service.GetSelectedCollections(); len(selected) != 1 || selected[0] != "animals" {
+		t.Errorf("Expected animals to stay selected, got %v", selected)
+	}
+}
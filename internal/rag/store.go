@@ -0,0 +1,72 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/amikos-tech/chroma-go/pkg/embeddings"
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+// CollectionInfo describes a collection held by a vector store
+type CollectionInfo struct {
+	Name     string            `json:"name"`
+	ID       string            `json:"id"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// StoredDocument is a document with its embedding, as written to a vector store
+type StoredDocument struct {
+	ID        string            `json:"id"`
+	Content   string            `json:"content"`
+	Metadata  map[string]string `json:"metadata"`
+	Embedding []float32         `json:"embedding"`
+}
+
+// StoreQuery describes a nearest-neighbour search against a single collection
+type StoreQuery struct {
+	Embedding []float32         // Query vector
+	Limit     int               // Maximum number of documents to return
+	Filters   map[string]string // Metadata equality filters (see ParseQuery)
+}
+
+// VectorStore is a backend that stores embedded documents in named collections.
+//
+// Stores only deal in vectors; embedding text is the caller's responsibility so
+// every backend uses the configured Ollama embedding model.
+type VectorStore interface {
+	// ListCollections returns every collection in the store
+	ListCollections(ctx context.Context) ([]CollectionInfo, error)
+	// Query returns the documents closest to the query embedding, ordered by ascending distance.
+	// Distances are cosine distances in the range 0-2.
+	Query(ctx context.Context, collection string, query StoreQuery) ([]RetrievedDocument, error)
+	// Upsert adds documents to a collection, replacing documents with the same ID.
+	// The collection is created if it does not exist.
+	Upsert(ctx context.Context, collection string, documents []StoredDocument) error
+	// Close releases any resources held by the store
+	Close() error
+}
+
+// NewStore creates the vector store selected by config.VectorStore.
+// The embedding function is only used by backends that need one when creating collections.
+func NewStore(config *configuration.Config, embeddingFunc embeddings.EmbeddingFunction) (VectorStore, error) {
+	switch config.VectorStore {
+	case configuration.VectorStoreLocal:
+		dir := config.LocalStorePath
+		if dir == "" {
+			var err error
+			dir, err = configuration.DefaultLocalStoreDir()
+			if err != nil {
+				return nil, fmt.Errorf("failed to determine local vector store directory: %w", err)
+			}
+		}
+		return NewLocalStore(dir)
+	case configuration.VectorStoreChromaDB, "":
+		if config.ChromaDBURL == "" {
+			return nil, fmt.Errorf("ChromaDB URL not configured")
+		}
+		return NewChromaStore(config.ChromaDBURL, embeddingFunc)
+	default:
+		return nil, fmt.Errorf("unknown vector store: %s", config.VectorStore)
+	}
+}
@@ -0,0 +1,220 @@
+package tooling
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevensen/gollama-chat/internal/logging"
+)
+
+// AuditFileName is the name of the tool audit log in the log directory
+const AuditFileName = "tool-audit.jsonl"
+
+// Trust decisions recorded in the audit log
+const (
+	AuditAllow = "allow" // The call was allowed to run
+	AuditAsk   = "ask"   // The user was asked to approve the call
+	AuditDeny  = "deny"  // The call was blocked
+)
+
+// Who made the trust decision recorded in the audit log
+const (
+	DecidedByRule         = "rule"          // A trust rule on the arguments
+	DecidedBySessionTrust = "session_trust" // The tool is trusted for the session
+	DecidedByTrustLevel   = "trust_level"   // The tool's trust level is None or Ask
+	DecidedByUser         = "user"          // The user answered a permission prompt
+)
+
+// Outcomes of tool calls recorded in the audit log
+const (
+	AuditStatusPending = "pending" // Waiting for the user's approval
+	AuditStatusBlocked = "blocked" // Not run
+	AuditStatusSuccess = "success" // Ran and succeeded
+	AuditStatusFailed  = "failed"  // Ran and exited with a non-zero status
+	AuditStatusError   = "error"   // Could not be run or returned an error
+)
+
+// AuditEntry records one tool request and what came of it
+type AuditEntry struct {
+	Time           time.Time      `json:"time"`
+	ConversationID string         `json:"conversation_id,omitempty"`
+	Tool           string         `json:"tool"`
+	Source         string         `json:"source,omitempty"` // builtin or mcp
+	Server         string         `json:"server,omitempty"` // MCP server of the tool
+	Arguments      map[string]any `json:"arguments,omitempty"`
+	Decision       string         `json:"decision,omitempty"`   // allow, ask or deny
+	DecidedBy      string         `json:"decided_by,omitempty"` // rule, session_trust, trust_level or user
+	Rule           string         `json:"rule,omitempty"`       // Trust rule that decided, if any
+	Status         string         `json:"status"`
+	ExitCode       *int           `json:"exit_code,omitempty"` // Exit status of commands
+	Error          string         `json:"error,omitempty"`
+	DurationMS     int64          `json:"duration_ms,omitempty"`
+	ResultHash     string         `json:"result_hash,omitempty"` // SHA-256 of the result text
+}
+
+// AuditFilter selects audit entries; empty fields match everything
+type AuditFilter struct {
+	Tool     string // Substring of the tool name
+	Decision string // allow, ask or deny
+	Date     string // Prefix of the local date, e.g. 2025-06 or 2025-06-14
+}
+
+// Matches reports whether an entry is selected by the filter
+func (f AuditFilter) Matches(entry AuditEntry) bool {
+	if f.Tool != "" && !strings.Contains(strings.ToLower(entry.Tool), strings.ToLower(f.Tool)) {
+		return false
+	}
+	if f.Decision != "" && entry.Decision != f.Decision {
+		return false
+	}
+	if f.Date != "" && !strings.HasPrefix(entry.Time.Local().Format("2006-01-02"), f.Date) {
+		return false
+	}
+	return true
+}
+
+// auditLog is the file tool requests are recorded in
+var auditLog = struct {
+	mu   sync.Mutex
+	path string
+}{}
+
+// SetAuditLogPath sets the file tool requests are recorded in; an empty path disables
+// the audit log
+func SetAuditLogPath(path string) {
+	auditLog.mu.Lock()
+	defer auditLog.mu.Unlock()
+	auditLog.path = path
+}
+
+// AuditLogPath returns the file tool requests are recorded in, or an empty string when
+// the audit log is disabled
+func AuditLogPath() string {
+	auditLog.mu.Lock()
+	defer auditLog.mu.Unlock()
+	return auditLog.path
+}
+
+// NewAuditEntry starts an audit entry for a call to a tool, filling in where the tool
+// comes from
+func NewAuditEntry(conversationID, toolName string, args map[string]any) AuditEntry {
+	entry := AuditEntry{Time: time.Now(), ConversationID: conversationID, Tool: toolName, Arguments: args}
+	if DefaultRegistry != nil {
+		if tool, exists := DefaultRegistry.GetUnifiedTool(toolName); exists {
+			entry.Source = tool.Source
+			entry.Server = tool.ServerName
+		}
+	}
+	return entry
+}
+
+// SetResult records the outcome of running the tool, which started at started
+func (e *AuditEntry) SetResult(result any, err error, started time.Time) {
+	e.DurationMS = time.Since(started).Milliseconds()
+	if err != nil {
+		e.Status = AuditStatusError
+		e.Error = err.Error()
+		return
+	}
+
+	e.Status = AuditStatusSuccess
+	if fields, ok := result.(map[string]any); ok {
+		if exitCode, ok := fields["exit_code"].(int); ok {
+			e.ExitCode = &exitCode
+			if exitCode != 0 {
+				e.Status = AuditStatusFailed
+			}
+		}
+	}
+	var resultText string
+	switch v := result.(type) {
+	case string:
+		resultText = v
+	case *ToolResult:
+		resultText = v.Text
+	default:
+		resultText = fmt.Sprintf("%+v", result)
+	}
+	hash := sha256.Sum256([]byte(resultText))
+	e.ResultHash = hex.EncodeToString(hash[:])
+}
+
+// RecordToolCall appends an entry to the audit log. Failures are logged rather than
+// returned so they never stop a tool call.
+func RecordToolCall(entry AuditEntry) {
+	auditLog.mu.Lock()
+	defer auditLog.mu.Unlock()
+	if auditLog.path == "" {
+		return
+	}
+
+	if err := appendAuditEntry(auditLog.path, entry); err != nil {
+		logging.WithComponent("tooling").Error("Failed to record tool call in audit log", "tool", entry.Tool, "error", err)
+	}
+}
+
+// appendAuditEntry writes an entry as one JSON line
+func appendAuditEntry(path string, entry AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return file.Close()
+}
+
+// ReadAuditLog returns the audit entries selected by filter, newest first. Lines that
+// cannot be parsed are skipped.
+func ReadAuditLog(filter AuditFilter) ([]AuditEntry, error) {
+	path := AuditLogPath()
+	if path == "" {
+		return nil, fmt.Errorf("the tool audit log is disabled")
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // Arguments can be long
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	// The log is in time order, so reversing it puts the newest entries first
+	slices.Reverse(entries)
+	return entries, nil
+}
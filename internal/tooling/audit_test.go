@@ -0,0 +1,103 @@
+package tooling
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// useAuditLog records tool requests in a temporary audit log for the test
+func useAuditLog(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "logs", AuditFileName)
+	SetAuditLogPath(path)
+	t.Cleanup(func() { SetAuditLogPath("") })
+	return path
+}
+
+func TestAuditLog_RecordAndFilter(t *testing.T) {
+	path := useAuditLog(t)
+	yesterday := time.Now().AddDate(0, 0, -1)
+
+	RecordToolCall(AuditEntry{Time: yesterday, Tool: "execute_bash", Decision: AuditDeny, DecidedBy: DecidedByRule, Status: AuditStatusBlocked})
+	RecordToolCall(AuditEntry{Time: time.Now(), Tool: "files.search", Source: "mcp", Server: "files", Decision: AuditAllow, DecidedBy: DecidedBySessionTrust, Status: AuditStatusSuccess})
+	RecordToolCall(AuditEntry{Time: time.Now(), Tool: "execute_bash", Decision: AuditAllow, DecidedBy: DecidedByUser, Status: AuditStatusFailed})
+
+	// Lines that cannot be parsed are skipped
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("Expected the audit log to exist: %v", err)
+	}
+	file.WriteString("not json\n")
+	file.Close()
+
+	entries, err := ReadAuditLog(AuditFilter{})
+	if err != nil {
+		t.Fatalf("ReadAuditLog failed: %v", err)
+	}
+	if len(entries) != 3 || entries[0].DecidedBy != DecidedByUser || entries[2].Status != AuditStatusBlocked {
+		t.Fatalf("Expected all entries newest first, got %+v", entries)
+	}
+
+	tests := []struct {
+		name     string
+		filter   AuditFilter
+		expected int
+	}{
+		{"tool substring", AuditFilter{Tool: "BASH"}, 2},
+		{"decision", AuditFilter{Decision: AuditAllow}, 2},
+		{"tool and decision", AuditFilter{Tool: "bash", Decision: AuditDeny}, 1},
+		{"day", AuditFilter{Date: yesterday.Format("2006-01-02")}, 1},
+		{"date prefix", AuditFilter{Date: time.Now().Format("2006")[:3]}, 3},
+		{"no match", AuditFilter{Tool: "http"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := ReadAuditLog(tt.filter)
+			if err != nil || len(entries) != tt.expected {
+				t.Errorf("Expected %d entries, got %d (%v)", tt.expected, len(entries), err)
+			}
+		})
+	}
+}
+
+func TestAuditLog_Disabled(t *testing.T) {
+	SetAuditLogPath("")
+	RecordToolCall(AuditEntry{Tool: "execute_bash"}) // Must not fail or write anywhere
+	if _, err := ReadAuditLog(AuditFilter{}); err == nil {
+		t.Error("Expected an error reading a disabled audit log")
+	}
+
+	useAuditLog(t)
+	if entries, err := ReadAuditLog(AuditFilter{}); err != nil || len(entries) != 0 {
+		t.Errorf("Expected no entries before anything is recorded, got %v (%v)", entries, err)
+	}
+}
+
+func TestAuditEntry_SetResult(t *testing.T) {
+	started := time.Now()
+
+	var entry AuditEntry
+	entry.SetResult(map[string]any{"exit_code": 2, "stdout": ""}, nil, started)
+	if entry.Status != AuditStatusFailed || entry.ExitCode == nil || *entry.ExitCode != 2 {
+		t.Errorf("Expected a failed command with exit code 2, got %+v", entry)
+	}
+
+	entry = AuditEntry{}
+	entry.SetResult("result", nil, started)
+	if entry.Status != AuditStatusSuccess || entry.ResultHash != "f6a214f7a5fcda0c2cee9660b7fc29f5649e3c68aad48e20e950137c98913a68" {
+		t.Errorf("Expected a successful call with a result hash, got %+v", entry)
+	}
+	other := AuditEntry{}
+	other.SetResult(&ToolResult{Text: "result"}, nil, started)
+	if other.ResultHash != entry.ResultHash {
+		t.Error("Expected results with the same text to have the same hash")
+	}
+
+	entry = AuditEntry{}
+	entry.SetResult(nil, errors.New("boom"), started)
+	if entry.Status != AuditStatusError || entry.Error != "boom" || entry.ResultHash != "" {
+		t.Errorf("Expected an error without a result hash, got %+v", entry)
+	}
+}
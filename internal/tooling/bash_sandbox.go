@@ -0,0 +1,165 @@
+package tooling
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+// sandboxSystemDirs are mounted read-only in the isolated profile so commands can run
+// the system's programs and libraries
+var sandboxSystemDirs = []string{"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/etc", "/opt"}
+
+// sandboxEnvironment lists the variables isolated commands receive besides HOME and TMPDIR
+var sandboxEnvironment = []string{"PATH", "LANG", "LC_ALL", "TERM", "USER", "LOGNAME"}
+
+// SetBashSandbox sets the sandbox the registered execute_bash tool runs commands in
+func SetBashSandbox(sandbox configuration.BashSandbox) {
+	executeBashTool.SetSandbox(sandbox)
+}
+
+// SetSandbox sets the sandbox commands run in
+func (ebt *ExecuteBashTool) SetSandbox(sandbox configuration.BashSandbox) {
+	ebt.mu.Lock()
+	defer ebt.mu.Unlock()
+	ebt.sandbox = sandbox
+}
+
+// Sandbox returns the sandbox commands run in
+func (ebt *ExecuteBashTool) Sandbox() configuration.BashSandbox {
+	ebt.mu.RLock()
+	defer ebt.mu.RUnlock()
+	return ebt.sandbox
+}
+
+// sandboxedCommand builds the command running argv in the configured sandbox with its
+// resource limits
+func (ebt *ExecuteBashTool) sandboxedCommand(argv []string, workingDir string) (*exec.Cmd, error) {
+	sandbox := ebt.Sandbox()
+	cpuSeconds, memoryMB, _ := sandbox.Limits()
+
+	// ulimit sets both the soft and hard limits, so commands cannot raise them again.
+	// The command is passed as arguments to avoid quoting it.
+	if limits := ulimitArgs(cpuSeconds, memoryMB); limits != "" {
+		argv = append([]string{"bash", "-c", "ulimit " + limits + ` && exec "$@"`, "bash"}, argv...)
+	}
+
+	if !sandbox.IsIsolated() {
+		cmd := exec.Command(argv[0], argv[1:]...)
+		cmd.Dir = workingDir
+		return cmd, nil
+	}
+
+	project, err := projectDir()
+	if err != nil {
+		return nil, err
+	}
+	if workingDir == "" {
+		workingDir = project
+	}
+	scratchDir, err := ebt.scratch()
+	if err != nil {
+		return nil, err
+	}
+	args, err := bubblewrapArgs(sandbox, project, workingDir, scratchDir)
+	if err != nil {
+		return nil, err
+	}
+
+	bwrap, err := exec.LookPath("bwrap")
+	if err != nil {
+		return nil, fmt.Errorf("the %s sandbox profile requires bubblewrap (bwrap): %w", sandbox.ProfileName(), err)
+	}
+	args = append(args, "--")
+	return exec.Command(bwrap, append(args, argv...)...), nil
+}
+
+// bubblewrapArgs returns the bubblewrap options isolating a command: the system
+// directories and project read-only, the scratch directory and writable paths writable,
+// no namespaces shared with the host except the network when allowed, and only a few
+// environment variables
+func bubblewrapArgs(sandbox configuration.BashSandbox, projectDir, workingDir, scratchDir string) ([]string, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("the %s sandbox profile is only supported on Linux", sandbox.ProfileName())
+	}
+
+	args := []string{"--die-with-parent", "--new-session", "--unshare-all"}
+	if sandbox.Network {
+		args = append(args, "--share-net")
+	}
+	for _, dir := range sandboxSystemDirs {
+		if _, err := os.Stat(dir); err == nil {
+			args = append(args, "--ro-bind", dir, dir)
+		}
+	}
+	args = append(args, "--proc", "/proc", "--dev", "/dev", "--tmpfs", "/tmp")
+	args = append(args, "--ro-bind", projectDir, projectDir)
+	for _, path := range sandbox.WritablePaths {
+		args = append(args, "--bind", path, path)
+	}
+	args = append(args, "--bind", scratchDir, scratchDir, "--chdir", workingDir)
+
+	args = append(args, "--clearenv", "--setenv", "HOME", scratchDir, "--setenv", "TMPDIR", scratchDir)
+	for _, name := range sandboxEnvironment {
+		if value, ok := os.LookupEnv(name); ok {
+			args = append(args, "--setenv", name, value)
+		}
+	}
+	return args, nil
+}
+
+// ulimitArgs returns the ulimit options for the CPU time and memory limits, or an empty
+// string when neither is limited
+func ulimitArgs(cpuSeconds, memoryMB int) string {
+	var options []string
+	if cpuSeconds > 0 {
+		options = append(options, fmt.Sprintf("-t %d", cpuSeconds))
+	}
+	if memoryMB > 0 {
+		options = append(options, fmt.Sprintf("-v %d", memoryMB*1024))
+	}
+	return strings.Join(options, " ")
+}
+
+// scratch returns the writable directory of isolated commands, created on first use and
+// kept for the session so commands can leave files for later ones
+func (ebt *ExecuteBashTool) scratch() (string, error) {
+	ebt.mu.Lock()
+	defer ebt.mu.Unlock()
+	if ebt.scratchDir == "" {
+		dir, err := os.MkdirTemp("", "gollama-chat-sandbox-")
+		if err != nil {
+			return "", fmt.Errorf("cannot create sandbox scratch directory: %w", err)
+		}
+		ebt.scratchDir = dir
+	}
+	return ebt.scratchDir, nil
+}
+
+// limitedBuffer keeps up to limit bytes of a command's output and discards the rest;
+// a limit of 0 keeps everything
+type limitedBuffer struct {
+	limit     int
+	buffer    strings.Builder
+	truncated bool
+}
+
+// Write keeps what fits and reports everything written so the command is not interrupted
+func (lb *limitedBuffer) Write(p []byte) (int, error) {
+	if lb.limit > 0 && lb.buffer.Len()+len(p) > lb.limit {
+		lb.buffer.Write(p[:lb.limit-lb.buffer.Len()])
+		lb.truncated = true
+		return len(p), nil
+	}
+	lb.buffer.Write(p)
+	return len(p), nil
+}
+
+// String returns the kept output
+func (lb *limitedBuffer) String() string {
+	return lb.buffer.String()
+}
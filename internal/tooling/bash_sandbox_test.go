@@ -0,0 +1,86 @@
+package tooling
+
+import (
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+func TestBubblewrapArgs(t *testing.T) {
+	t.Setenv("GOLLAMA_SANDBOX_SECRET", "hunter2")
+	sandbox := configuration.BashSandbox{Profile: configuration.SandboxProfileIsolated, WritablePaths: []string{"/srv/build"}}
+
+	args, err := bubblewrapArgs(sandbox, "/home/user/project", "/home/user/project/src", "/tmp/scratch")
+	if err != nil {
+		t.Fatalf("bubblewrapArgs failed: %v", err)
+	}
+	joined := strings.Join(args, " ")
+	for _, expected := range []string{
+		"--unshare-all",
+		"--ro-bind /home/user/project /home/user/project",
+		"--bind /srv/build /srv/build",
+		"--bind /tmp/scratch /tmp/scratch",
+		"--chdir /home/user/project/src",
+		"--clearenv",
+		"--setenv HOME /tmp/scratch",
+	} {
+		if !strings.Contains(joined, expected) {
+			t.Errorf("Expected %q in the bubblewrap arguments, got %s", expected, joined)
+		}
+	}
+	if slices.Contains(args, "--share-net") || strings.Contains(joined, "hunter2") {
+		t.Errorf("Expected no network and a scrubbed environment, got %s", joined)
+	}
+
+	sandbox.Network = true
+	args, err = bubblewrapArgs(sandbox, "/home/user/project", "/home/user/project", "/tmp/scratch")
+	if err != nil {
+		t.Fatalf("bubblewrapArgs failed: %v", err)
+	}
+	if !slices.Contains(args, "--share-net") {
+		t.Errorf("Expected the network to be shared when allowed, got %v", args)
+	}
+}
+
+func TestExecuteBashTool_ResourceLimits(t *testing.T) {
+	ebt := &ExecuteBashTool{}
+	ebt.SetSandbox(configuration.BashSandbox{CPUSeconds: 7, MemoryMB: 512, MaxOutputBytes: 10})
+
+	result, err := ebt.Execute(t.Context(), map[string]any{"command": "ulimit -t; ulimit -v; echo 0123456789"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	output := result.(map[string]any)
+	if output["stdout"] != "7\n524288\n0" || output["output_truncated"] != true {
+		t.Errorf("Expected limited CPU, memory and output, got %q (truncated: %v)", output["stdout"], output["output_truncated"])
+	}
+
+	// Commands cannot raise the limits again, unless they run as root
+	if os.Geteuid() == 0 {
+		return
+	}
+	result, err = ebt.Execute(t.Context(), map[string]any{"command": "ulimit -t 100"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.(map[string]any)["success"] == true {
+		t.Error("Expected raising the CPU limit to fail")
+	}
+}
+
+func TestExecuteBashTool_IsolatedRequiresBubblewrap(t *testing.T) {
+	if _, err := exec.LookPath("bwrap"); err == nil {
+		t.Skip("bubblewrap is installed")
+	}
+	ebt := &ExecuteBashTool{}
+	ebt.SetSandbox(configuration.BashSandbox{Profile: configuration.SandboxProfileIsolated})
+
+	_, err := ebt.Execute(t.Context(), map[string]any{"command": "echo hello"})
+	if err == nil || !strings.Contains(err.Error(), "bubblewrap") {
+		t.Errorf("Expected isolated commands to fail without bubblewrap, got %v", err)
+	}
+}
@@ -0,0 +1,517 @@
+package tooling
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+const (
+	// grepDefaultResults and grepMaxResults bound the matching lines grep returns
+	grepDefaultResults = 100
+	grepMaxResults     = 1000
+	// grepMaxContext caps the context lines shown around each match
+	grepMaxContext = 10
+	// grepLineWidth caps the characters shown of each line
+	grepLineWidth = 300
+	// globDefaultResults and globMaxResults bound the paths glob returns
+	globDefaultResults = 200
+	globMaxResults     = 1000
+	// treeDefaultDepth and treeMaxDepth bound how deep file_tree descends
+	treeDefaultDepth = 3
+	treeMaxDepth     = 10
+	// treeMaxEntries caps the entries file_tree shows
+	treeMaxEntries = 500
+)
+
+// GrepTool searches file contents with a regular expression
+type GrepTool struct {
+	files *FileSystemTool // Provides the file access settings
+}
+
+// GlobTool finds files whose paths match a glob pattern
+type GlobTool struct {
+	files *FileSystemTool // Provides the file access settings
+}
+
+// FileTreeTool shows the files and directories below a directory as a tree
+type FileTreeTool struct {
+	files *FileSystemTool // Provides the file access settings
+}
+
+// The instances registered in DefaultRegistry share the access settings of filesystem_read
+var (
+	grepTool     = &GrepTool{files: fileSystemTool}
+	globTool     = &GlobTool{files: fileSystemTool}
+	fileTreeTool = &FileTreeTool{files: fileSystemTool}
+)
+
+// walkAllowed calls visit for the files and directories below a directory, in lexical
+// order, that filesystem_read could read. It skips .git directories, entries matching deny
+// patterns or ignored by .gitignore and symlinks leading outside the allowed roots, and it
+// does not follow symlinked directories. Directories deeper than maxDepth are not entered
+// unless maxDepth is 0, and the walk stops when ctx is done. visit receives the path
+// relative to the directory and its depth, starting at 1, and can return fs.SkipAll to stop.
+func (fst *FileSystemTool) walkAllowed(ctx context.Context, dir string, maxDepth int, visit func(path, relative string, entry fs.DirEntry, depth int) error) (string, error) {
+	start, err := fst.resolvePath(filepath.Clean(dir))
+	if err != nil {
+		return "", err
+	}
+	if !isDirectory(start) {
+		return "", fmt.Errorf("path %s is not a directory", dir)
+	}
+	access := fst.Access()
+	_, roots, err := allowedRoots(access)
+	if err != nil {
+		return "", err
+	}
+	root, relative, _ := rootOf(roots, start)
+
+	// .gitignore files above the directory apply too
+	var inherited []gitignoreRule
+	base := ""
+	if relative != "." {
+		base = filepath.ToSlash(relative)
+		parts := strings.Split(base, "/")
+		for i := range parts {
+			inherited = append(inherited, readGitignore(root, path.Join(parts[:i]...))...)
+		}
+	}
+
+	var walk func(dir, dirRelative string, rules []gitignoreRule, depth int) error
+	walk = func(dir, dirRelative string, rules []gitignoreRule, depth int) error {
+		rules = append(slices.Clip(rules), readGitignore(root, dirRelative)...)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil // Unreadable directories are skipped
+		}
+		for _, entry := range entries {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("search stopped: %w", err)
+			}
+			entryPath := filepath.Join(dir, entry.Name())
+			entryRelative := path.Join(dirRelative, entry.Name())
+			isDir := entry.IsDir()
+			if entry.Type()&fs.ModeSymlink != 0 {
+				target, err := filepath.EvalSymlinks(entryPath)
+				if err != nil {
+					continue
+				}
+				if _, _, ok := rootOf(roots, target); !ok {
+					continue
+				}
+				isDir = isDirectory(target)
+			}
+			if (isDir && entry.Name() == ".git") || access.DeniedBy(entryRelative) != "" || matchGitignore(rules, entryRelative, isDir) {
+				continue
+			}
+
+			visitRelative := strings.TrimPrefix(strings.TrimPrefix(entryRelative, base), "/")
+			if err := visit(entryPath, visitRelative, entry, depth); err != nil {
+				return err
+			}
+			if entry.IsDir() && (maxDepth == 0 || depth < maxDepth) {
+				if err := walk(entryPath, entryRelative, rules, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := walk(start, base, inherited, 1); err != nil && !errors.Is(err, fs.SkipAll) {
+		return "", err
+	}
+	return start, nil
+}
+
+// intArgument returns an integer argument, def when it is missing, clamped to [low, high]
+func intArgument(args map[string]any, name string, def, low, high int) int {
+	value, ok := args[name].(float64)
+	if !ok {
+		return def
+	}
+	return max(low, min(int(value), high))
+}
+
+// pathArgument returns the directory argument, defaulting to the working directory
+func pathArgument(args map[string]any) string {
+	if dir, ok := args["path"].(string); ok && strings.TrimSpace(dir) != "" {
+		return dir
+	}
+	return "."
+}
+
+// Name returns the tool name
+func (gt *GrepTool) Name() string {
+	return "grep"
+}
+
+// Description returns the tool description
+func (gt *GrepTool) Description() string {
+	return "Search file contents with a regular expression, skipping files ignored by .gitignore"
+}
+
+// GetAPITool returns the Ollama API tool definition
+func (gt *GrepTool) GetAPITool() *api.Tool {
+	return &api.Tool{
+		Type: "function",
+		Function: api.ToolFunction{
+			Name:        "grep",
+			Description: "Search file contents with a regular expression (Go RE2 syntax). Returns matching lines as path:line: text. Files ignored by .gitignore, binary files and denied files are skipped.",
+			Parameters: api.ToolFunctionParameters{
+				Type: "object",
+				Properties: map[string]api.ToolProperty{
+					"pattern": {
+						Type:        api.PropertyType{"string"},
+						Description: "Regular expression to search for",
+					},
+					"path": {
+						Type:        api.PropertyType{"string"},
+						Description: "Directory to search (optional, default: the working directory)",
+					},
+					"include": {
+						Type:        api.PropertyType{"string"},
+						Description: "Glob limiting the files searched, e.g. '*.go' or 'internal/**/*.go' (optional)",
+					},
+					"case_insensitive": {
+						Type:        api.PropertyType{"boolean"},
+						Description: "Ignore case when matching (optional, default: false)",
+					},
+					"context_lines": {
+						Type:        api.PropertyType{"integer"},
+						Description: fmt.Sprintf("Lines of context to show before and after each match (optional, 0-%d, default: 0)", grepMaxContext),
+					},
+					"max_results": {
+						Type:        api.PropertyType{"integer"},
+						Description: fmt.Sprintf("Maximum matching lines to return (optional, 1-%d, default: %d)", grepMaxResults, grepDefaultResults),
+					},
+				},
+				Required: []string{"pattern"},
+			},
+		},
+	}
+}
+
+// Execute searches the files below the directory and formats the matching lines
+func (gt *GrepTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	pattern, ok := args["pattern"].(string)
+	if !ok || pattern == "" {
+		return nil, fmt.Errorf("pattern parameter required and must be a non-empty string")
+	}
+	if caseInsensitive, _ := args["case_insensitive"].(bool); caseInsensitive {
+		pattern = "(?i)" + pattern
+	}
+	expression, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	include, err := includeMatcher(args)
+	if err != nil {
+		return nil, err
+	}
+	contextLines := intArgument(args, "context_lines", 0, 0, grepMaxContext)
+	maxResults := intArgument(args, "max_results", grepDefaultResults, 1, grepMaxResults)
+	readLimit := gt.files.Access().ReadLimit()
+
+	var output strings.Builder
+	matches, filesSearched, truncated := 0, 0, false
+	start, err := gt.files.walkAllowed(ctx, pathArgument(args), 0, func(file, relative string, entry fs.DirEntry, _ int) error {
+		if entry.IsDir() || !include(relative) {
+			return nil
+		}
+		lines, ok := readTextLines(file, readLimit)
+		if !ok {
+			return nil
+		}
+		filesSearched++
+
+		shownUntil := -1 // Last line already written, so context does not repeat
+		for i, line := range lines {
+			if !expression.MatchString(line) {
+				continue
+			}
+			if matches == maxResults {
+				truncated = true
+				return fs.SkipAll
+			}
+			matches++
+
+			from := max(i-contextLines, shownUntil+1)
+			if shownUntil >= 0 && from > shownUntil+1 && contextLines > 0 {
+				output.WriteString("--\n")
+			}
+			to := min(i+contextLines, len(lines)-1)
+			for j := from; j <= to; j++ {
+				separator := "-"
+				if expression.MatchString(lines[j]) {
+					separator = ":"
+				}
+				fmt.Fprintf(&output, "%s%s%d%s %s\n", relative, separator, j+1, separator, truncateLine(lines[j]))
+			}
+			shownUntil = max(shownUntil, to)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if matches == 0 {
+		return fmt.Sprintf("No matches for %q in %s (%d files searched).", pattern, start, filesSearched), nil
+	}
+	summary := fmt.Sprintf("%d matching lines in %s (%d files searched)", matches, start, filesSearched)
+	if truncated {
+		summary += fmt.Sprintf("; stopped at %d results, narrow the search to see more", maxResults)
+	}
+	return summary + ":\n" + output.String(), nil
+}
+
+// includeMatcher returns a function reporting whether a relative path matches the include
+// glob. Globs without a slash match file names at any depth.
+func includeMatcher(args map[string]any) (func(string) bool, error) {
+	include, _ := args["include"].(string)
+	if include == "" {
+		return func(string) bool { return true }, nil
+	}
+	if !strings.Contains(include, "/") {
+		if _, err := path.Match(include, ""); err != nil {
+			return nil, fmt.Errorf("invalid include pattern %q: %w", include, err)
+		}
+		return func(relative string) bool {
+			matched, _ := path.Match(include, path.Base(relative))
+			return matched
+		}, nil
+	}
+	expression, err := regexp.Compile("^" + configuration.GlobExpression(strings.TrimPrefix(include, "/"), true) + "$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid include pattern %q: %w", include, err)
+	}
+	return expression.MatchString, nil
+}
+
+// readTextLines reads up to limit bytes of a file as lines, reporting false for binary
+// and unreadable files. A limit of 0 reads the whole file.
+func readTextLines(file string, limit int) ([]string, bool) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	var reader io.Reader = f
+	if limit > 0 {
+		reader = io.LimitReader(f, int64(limit))
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil || bytes.IndexByte(content[:min(512, len(content))], 0) >= 0 {
+		return nil, false
+	}
+	text := strings.TrimSuffix(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	return strings.Split(text, "\n"), true
+}
+
+// truncateLine shortens a line to grepLineWidth characters
+func truncateLine(line string) string {
+	if runes := []rune(line); len(runes) > grepLineWidth {
+		return string(runes[:grepLineWidth]) + "…"
+	}
+	return line
+}
+
+// Name returns the tool name
+func (gt *GlobTool) Name() string {
+	return "glob"
+}
+
+// Description returns the tool description
+func (gt *GlobTool) Description() string {
+	return "Find files whose paths match a glob pattern, skipping files ignored by .gitignore"
+}
+
+// GetAPITool returns the Ollama API tool definition
+func (gt *GlobTool) GetAPITool() *api.Tool {
+	return &api.Tool{
+		Type: "function",
+		Function: api.ToolFunction{
+			Name:        "glob",
+			Description: "Find files and directories whose paths match a glob pattern, such as '**/*_test.go' or 'cmd/*'. * and ? stay within a directory, ** crosses directories. Files ignored by .gitignore and denied files are skipped.",
+			Parameters: api.ToolFunctionParameters{
+				Type: "object",
+				Properties: map[string]api.ToolProperty{
+					"pattern": {
+						Type:        api.PropertyType{"string"},
+						Description: "Glob matched against paths relative to the directory",
+					},
+					"path": {
+						Type:        api.PropertyType{"string"},
+						Description: "Directory to search (optional, default: the working directory)",
+					},
+					"max_results": {
+						Type:        api.PropertyType{"integer"},
+						Description: fmt.Sprintf("Maximum paths to return (optional, 1-%d, default: %d)", globMaxResults, globDefaultResults),
+					},
+				},
+				Required: []string{"pattern"},
+			},
+		},
+	}
+}
+
+// Execute lists the paths below the directory matching the pattern
+func (gt *GlobTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	pattern, ok := args["pattern"].(string)
+	if !ok || strings.TrimSpace(pattern) == "" {
+		return nil, fmt.Errorf("pattern parameter required and must be a non-empty string")
+	}
+	expression, err := regexp.Compile("^" + configuration.GlobExpression(strings.TrimPrefix(pattern, "/"), true) + "$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	maxResults := intArgument(args, "max_results", globDefaultResults, 1, globMaxResults)
+
+	var paths []string
+	truncated := false
+	start, err := gt.files.walkAllowed(ctx, pathArgument(args), 0, func(_, relative string, entry fs.DirEntry, _ int) error {
+		if !expression.MatchString(relative) {
+			return nil
+		}
+		if len(paths) == maxResults {
+			truncated = true
+			return fs.SkipAll
+		}
+		if entry.IsDir() {
+			relative += "/"
+		}
+		paths = append(paths, relative)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(paths) == 0 {
+		return fmt.Sprintf("No paths match %q in %s.", pattern, start), nil
+	}
+	summary := fmt.Sprintf("%d paths match %q in %s", len(paths), pattern, start)
+	if truncated {
+		summary += fmt.Sprintf("; stopped at %d results, narrow the pattern to see more", maxResults)
+	}
+	return summary + ":\n" + strings.Join(paths, "\n") + "\n", nil
+}
+
+// Name returns the tool name
+func (ftt *FileTreeTool) Name() string {
+	return "file_tree"
+}
+
+// Description returns the tool description
+func (ftt *FileTreeTool) Description() string {
+	return "Show the files and directories below a directory as a tree, skipping files ignored by .gitignore"
+}
+
+// GetAPITool returns the Ollama API tool definition
+func (ftt *FileTreeTool) GetAPITool() *api.Tool {
+	return &api.Tool{
+		Type: "function",
+		Function: api.ToolFunction{
+			Name:        "file_tree",
+			Description: "Show the files and directories below a directory as a tree, to get an overview of a project. Files ignored by .gitignore and denied files are skipped.",
+			Parameters: api.ToolFunctionParameters{
+				Type: "object",
+				Properties: map[string]api.ToolProperty{
+					"path": {
+						Type:        api.PropertyType{"string"},
+						Description: "Directory to show (optional, default: the working directory)",
+					},
+					"max_depth": {
+						Type:        api.PropertyType{"integer"},
+						Description: fmt.Sprintf("Levels of directories to descend (optional, 1-%d, default: %d)", treeMaxDepth, treeDefaultDepth),
+					},
+				},
+			},
+		},
+	}
+}
+
+// treeEntry is a file or directory shown by file_tree
+type treeEntry struct {
+	name  string
+	depth int
+	isDir bool
+}
+
+// Execute renders the tree below the directory
+func (ftt *FileTreeTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	maxDepth := intArgument(args, "max_depth", treeDefaultDepth, 1, treeMaxDepth)
+
+	var entries []treeEntry
+	truncated := false
+	start, err := ftt.files.walkAllowed(ctx, pathArgument(args), maxDepth, func(_, _ string, entry fs.DirEntry, depth int) error {
+		if len(entries) == treeMaxEntries {
+			truncated = true
+			return fs.SkipAll
+		}
+		entries = append(entries, treeEntry{name: entry.Name(), depth: depth, isDir: entry.IsDir()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	directories, files := 0, 0
+	var output strings.Builder
+	output.WriteString(start + "/\n")
+	// open[d] records whether the directory at depth d has entries after the current one
+	open := make([]bool, maxDepth+2)
+	for i, entry := range entries {
+		last := true
+		for _, next := range entries[i+1:] {
+			if next.depth <= entry.depth {
+				last = next.depth < entry.depth
+				break
+			}
+		}
+		for depth := 1; depth < entry.depth; depth++ {
+			if open[depth] {
+				output.WriteString("│   ")
+			} else {
+				output.WriteString("    ")
+			}
+		}
+		if last {
+			output.WriteString("└── ")
+		} else {
+			output.WriteString("├── ")
+		}
+		open[entry.depth] = !last
+
+		output.WriteString(entry.name)
+		if entry.isDir {
+			output.WriteString("/")
+			directories++
+		} else {
+			files++
+		}
+		output.WriteString("\n")
+	}
+
+	fmt.Fprintf(&output, "\n%d directories, %d files", directories, files)
+	if truncated {
+		fmt.Fprintf(&output, " (stopped at %d entries; use a smaller max_depth or a subdirectory)", treeMaxEntries)
+	}
+	return output.String() + "\n", nil
+}
@@ -0,0 +1,235 @@
+package tooling
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+// codeSearchTree creates a project for the code search tools and returns its root and a
+// filesystem tool allowed to read it
+func codeSearchTree(t *testing.T) (string, *FileSystemTool) {
+	t.Helper()
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":           "bin/\n*.log\n",
+		"go.mod":               "module example\n",
+		"main.go":              "package main\n\nfunc main() {\n\trun()\n}\n\nfunc run() {}\n",
+		"internal/a/a.go":      "package a\n\n// TODO: handle errors\nfunc A() {}\n",
+		"internal/a/a_test.go": "package a\n",
+		"internal/b/b.go":      "package b\n\nfunc B() {} // todo later\n",
+		"bin/tool":             "TODO in a build output",
+		"debug.log":            "TODO in a log",
+		".env":                 "TODO=secret",
+		".git/config":          "TODO in git",
+		"image.png":            "TODO\x00binary",
+	})
+	fst := &FileSystemTool{}
+	fst.SetAccess(configuration.FileAccess{AllowedRoots: []string{root}})
+	return root, fst
+}
+
+func TestGrepTool(t *testing.T) {
+	root, fst := codeSearchTree(t)
+	grep := &GrepTool{files: fst}
+
+	tests := []struct {
+		name     string
+		args     map[string]any
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "skips ignored, denied and binary files",
+			args:     map[string]any{"pattern": "TODO", "path": root},
+			contains: []string{"1 matching lines", "internal/a/a.go:3: // TODO: handle errors"},
+			excludes: []string{"bin/tool", "debug.log", ".env", ".git", "image.png", "b.go"},
+		},
+		{
+			name:     "case insensitive",
+			args:     map[string]any{"pattern": "todo", "path": root, "case_insensitive": true},
+			contains: []string{"2 matching lines", "internal/b/b.go:3: func B() {} // todo later"},
+		},
+		{
+			name:     "context lines",
+			args:     map[string]any{"pattern": `run\(\)`, "path": root, "context_lines": float64(1)},
+			contains: []string{"main.go-3- func main() {", "main.go:4: \trun()", "main.go-5- }\nmain.go-6- \nmain.go:7: func run() {}"},
+			excludes: []string{"--"},
+		},
+		{
+			name:     "separated context",
+			args:     map[string]any{"pattern": `^package main|^func run`, "path": root, "include": "main.go", "context_lines": float64(1)},
+			contains: []string{"main.go:1: package main\nmain.go-2- \n--\nmain.go-6- \nmain.go:7: func run() {}"},
+		},
+		{
+			name:     "include glob",
+			args:     map[string]any{"pattern": "package", "path": root, "include": "*_test.go"},
+			contains: []string{"1 matching lines", "internal/a/a_test.go:1: package a"},
+		},
+		{
+			name:     "include path glob",
+			args:     map[string]any{"pattern": "package", "path": root, "include": "internal/**/b.go"},
+			contains: []string{"1 matching lines", "internal/b/b.go:1: package b"},
+		},
+		{
+			name:     "subdirectory paths are relative to it",
+			args:     map[string]any{"pattern": "func", "path": filepath.Join(root, "internal", "a")},
+			contains: []string{"a.go:4: func A() {}"},
+			excludes: []string{"internal/a/a.go"},
+		},
+		{
+			name:     "result limit",
+			args:     map[string]any{"pattern": "package", "path": root, "max_results": float64(2)},
+			contains: []string{"2 matching lines", "stopped at 2 results"},
+		},
+		{
+			name:     "no matches",
+			args:     map[string]any{"pattern": "nothing here", "path": root},
+			contains: []string{`No matches for "nothing here"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := grep.Execute(t.Context(), tt.args)
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			output := result.(string)
+			for _, want := range tt.contains {
+				if !strings.Contains(output, want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(output, unwanted) {
+					t.Errorf("Expected output not to contain %q, got:\n%s", unwanted, output)
+				}
+			}
+		})
+	}
+
+	if _, err := grep.Execute(t.Context(), map[string]any{"pattern": "(", "path": root}); err == nil {
+		t.Error("Expected an invalid pattern to fail")
+	}
+	if _, err := grep.Execute(t.Context(), map[string]any{"pattern": "x", "path": filepath.Dir(root)}); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Expected searching outside the allowed roots to be denied, got %v", err)
+	}
+}
+
+func TestGlobTool(t *testing.T) {
+	root, fst := codeSearchTree(t)
+	glob := &GlobTool{files: fst}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"**/*.go", []string{"internal/a/a.go", "internal/a/a_test.go", "internal/b/b.go", "main.go"}},
+		{"*.go", []string{"main.go"}},
+		{"internal/*", []string{"internal/a/", "internal/b/"}},
+		{"**/*_test.go", []string{"internal/a/a_test.go"}},
+		{"**/*.log", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			result, err := glob.Execute(t.Context(), map[string]any{"pattern": tt.pattern, "path": root})
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(result.(string)), "\n")
+			if tt.want == nil {
+				if !strings.HasPrefix(lines[0], "No paths match") {
+					t.Errorf("Expected no matches, got %v", lines)
+				}
+				return
+			}
+			if got := lines[1:]; strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	result, err := glob.Execute(t.Context(), map[string]any{"pattern": "**", "path": root, "max_results": float64(3)})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(result.(string), "stopped at 3 results") {
+		t.Errorf("Expected the result limit to be reported, got:\n%s", result)
+	}
+}
+
+func TestFileTreeTool(t *testing.T) {
+	root, fst := codeSearchTree(t)
+	tree := &FileTreeTool{files: fst}
+
+	result, err := tree.Execute(t.Context(), map[string]any{"path": root})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	want := root + `/
+├── .gitignore
+├── go.mod
+├── image.png
+├── internal/
+│   ├── a/
+│   │   ├── a.go
+│   │   └── a_test.go
+│   └── b/
+│       └── b.go
+└── main.go
+
+3 directories, 7 files
+`
+	if result != want {
+		t.Errorf("Unexpected tree:\n%s\nwant:\n%s", result, want)
+	}
+
+	result, err = tree.Execute(t.Context(), map[string]any{"path": root, "max_depth": float64(1)})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output := result.(string); strings.Contains(output, "a.go") || !strings.Contains(output, "└── main.go") {
+		t.Errorf("Expected only the top level with max_depth 1, got:\n%s", output)
+	}
+
+	if _, err := tree.Execute(t.Context(), map[string]any{"path": filepath.Join(root, "main.go")}); err == nil {
+		t.Error("Expected a file path to fail")
+	}
+}
+
+func TestCodeSearchTools_Registration(t *testing.T) {
+	for _, name := range []string{"grep", "glob", "file_tree"} {
+		tool, exists := DefaultRegistry.GetTool(name)
+		if !exists {
+			t.Errorf("Expected %s to be registered", name)
+			continue
+		}
+		if tool.GetAPITool().Function.Name != name {
+			t.Errorf("Expected API tool name %s, got %s", name, tool.GetAPITool().Function.Name)
+		}
+	}
+}
+
+func TestWalkAllowed_SkipsSymlinksOutsideRoots(t *testing.T) {
+	root, fst := codeSearchTree(t)
+	outside := t.TempDir()
+	writeFiles(t, outside, map[string]string{"secret.go": "package secret"})
+	if err := os.Symlink(outside, filepath.Join(root, "linked")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.go"), filepath.Join(root, "secret.go")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	result, err := (&GlobTool{files: fst}).Execute(t.Context(), map[string]any{"pattern": "**", "path": root})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if output := result.(string); strings.Contains(output, "linked") || strings.Contains(output, "secret.go") {
+		t.Errorf("Expected symlinks outside the allowed roots to be skipped, got:\n%s", output)
+	}
+}
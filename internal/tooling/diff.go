@@ -0,0 +1,301 @@
+package tooling
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// diffContextLines is the number of unchanged lines shown around each change
+	diffContextLines = 3
+	// diffMaxCells bounds the line comparison table; larger changes are shown as a
+	// replacement of the whole changed region
+	diffMaxCells = 4 << 20
+)
+
+// diffLine is one line of a line diff: ' ' for unchanged, '-' for removed, '+' for added
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// hunkHeader matches the header of a unified diff hunk; the line counts are optional
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// splitLines splits text into lines without their line endings
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines compares two texts line by line
+func diffLines(a, b []string) []diffLine {
+	// Unchanged lines at the start and end are common, so only the middle is compared
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]diffLine, 0, len(a)+len(b))
+	for _, text := range a[:prefix] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	lines = append(lines, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	return lines
+}
+
+// diffMiddle compares two texts using their longest common subsequence of lines
+func diffMiddle(a, b []string) []diffLine {
+	var lines []diffLine
+	if (len(a)+1)*(len(b)+1) > diffMaxCells {
+		for _, text := range a {
+			lines = append(lines, diffLine{'-', text})
+		}
+		for _, text := range b {
+			lines = append(lines, diffLine{'+', text})
+		}
+		return lines
+	}
+
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{'+', b[j]})
+	}
+	return lines
+}
+
+// UnifiedDiff returns the changes from oldText to newText as a unified diff of path.
+// It is empty when the texts have the same lines.
+func UnifiedDiff(path, oldText, newText string) string {
+	lines := diffLines(splitLines(oldText), splitLines(newText))
+
+	var output strings.Builder
+	oldLine, newLine := 1, 1 // Line numbers at lines[index]
+	for index := 0; index < len(lines); {
+		if lines[index].kind == ' ' {
+			oldLine++
+			newLine++
+			index++
+			continue
+		}
+
+		// A hunk starts with up to diffContextLines unchanged lines before the change
+		start := index
+		for start > 0 && index-start < diffContextLines && lines[start-1].kind == ' ' {
+			start--
+		}
+		hunkOld, hunkNew := oldLine-(index-start), newLine-(index-start)
+
+		// and runs until diffContextLines unchanged lines follow the last change, so
+		// changes closer together than twice that share a hunk
+		end := index
+		unchanged := 0
+		for end < len(lines) && unchanged <= 2*diffContextLines {
+			if lines[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+			end++
+		}
+		if unchanged > diffContextLines {
+			end -= unchanged - diffContextLines
+		}
+
+		oldCount, newCount := 0, 0
+		var body strings.Builder
+		for _, line := range lines[start:end] {
+			if line.kind != '+' {
+				oldCount++
+			}
+			if line.kind != '-' {
+				newCount++
+			}
+			body.WriteByte(line.kind)
+			body.WriteString(line.text)
+			body.WriteByte('\n')
+		}
+
+		if output.Len() == 0 {
+			fmt.Fprintf(&output, "--- a/%s\n+++ b/%s\n", path, path)
+		}
+		fmt.Fprintf(&output, "@@ -%s +%s @@\n", hunkRange(hunkOld, oldCount), hunkRange(hunkNew, newCount))
+		output.WriteString(body.String())
+
+		oldLine = hunkOld + oldCount
+		newLine = hunkNew + newCount
+		index = end
+	}
+	return output.String()
+}
+
+// hunkRange formats the start and length of a hunk; empty ranges start at the line before
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// patchHunk is a hunk of a unified diff
+type patchHunk struct {
+	oldStart int // 1-based line the hunk is expected at
+	oldLines []string
+	newLines []string
+}
+
+// parseUnifiedDiff reads the hunks of a unified diff of a single file. The line counts in
+// hunk headers are not trusted, since hand-written diffs often get them wrong.
+func parseUnifiedDiff(patch string) ([]patchHunk, error) {
+	var hunks []patchHunk
+	var current *patchHunk
+	for _, line := range strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n") {
+		if match := hunkHeader.FindStringSubmatch(line); match != nil {
+			start, _ := strconv.Atoi(match[1])
+			hunks = append(hunks, patchHunk{oldStart: start})
+			current = &hunks[len(hunks)-1]
+			continue
+		}
+		if current == nil || strings.HasPrefix(line, `\`) {
+			// File headers and "\ No newline at end of file"
+			continue
+		}
+		if strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ") || strings.HasPrefix(line, "diff ") {
+			current = nil
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+"):
+			current.newLines = append(current.newLines, line[1:])
+		case strings.HasPrefix(line, "-"):
+			current.oldLines = append(current.oldLines, line[1:])
+		case strings.HasPrefix(line, " "):
+			current.oldLines = append(current.oldLines, line[1:])
+			current.newLines = append(current.newLines, line[1:])
+		case line == "":
+			// Editors often strip the space of empty context lines
+			current.oldLines = append(current.oldLines, "")
+			current.newLines = append(current.newLines, "")
+		default:
+			return nil, fmt.Errorf("unexpected line in hunk %d: %q", len(hunks), line)
+		}
+	}
+
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("patch has no hunks")
+	}
+	// The newline ending the patch is not an empty context line
+	last := &hunks[len(hunks)-1]
+	if strings.HasSuffix(patch, "\n") && len(last.oldLines) > 0 && len(last.newLines) > 0 &&
+		last.oldLines[len(last.oldLines)-1] == "" && last.newLines[len(last.newLines)-1] == "" {
+		last.oldLines = last.oldLines[:len(last.oldLines)-1]
+		last.newLines = last.newLines[:len(last.newLines)-1]
+	}
+	return hunks, nil
+}
+
+// applyUnifiedDiff applies a unified diff to text. Each hunk is applied where its lines
+// are found closest to the line its header names, after the previous hunk.
+func applyUnifiedDiff(text, patch string) (string, error) {
+	hunks, err := parseUnifiedDiff(patch)
+	if err != nil {
+		return "", err
+	}
+
+	lines := splitLines(text)
+	var result []string
+	next := 0   // First line of text not yet copied to result
+	offset := 0 // How far earlier hunks were from their headers
+	for index, hunk := range hunks {
+		position, ok := findLines(lines, hunk.oldLines, next, hunk.oldStart-1+offset)
+		if !ok {
+			return "", fmt.Errorf("hunk %d (line %d) does not match the file", index+1, hunk.oldStart)
+		}
+		offset = position - (hunk.oldStart - 1)
+
+		result = append(result, lines[next:position]...)
+		result = append(result, hunk.newLines...)
+		next = position + len(hunk.oldLines)
+	}
+	result = append(result, lines[next:]...)
+
+	if len(result) == 0 {
+		return "", nil
+	}
+	joined := strings.Join(result, "\n")
+	if text == "" || strings.HasSuffix(text, "\n") {
+		joined += "\n"
+	}
+	return joined, nil
+}
+
+// findLines returns the position at or after from where want occurs in lines, choosing
+// the one closest to hint
+func findLines(lines, want []string, from, hint int) (int, bool) {
+	matches := func(position int) bool {
+		if position < from || position+len(want) > len(lines) {
+			return false
+		}
+		for i, line := range want {
+			if lines[position+i] != line {
+				return false
+			}
+		}
+		return true
+	}
+
+	hint = max(hint, from)
+	for distance := 0; hint-distance >= from || hint+distance <= len(lines); distance++ {
+		if matches(hint - distance) {
+			return hint - distance, true
+		}
+		if matches(hint + distance) {
+			return hint + distance, true
+		}
+	}
+	return 0, false
+}
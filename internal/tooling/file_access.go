@@ -0,0 +1,288 @@
+package tooling
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+// ErrAccessDenied is returned when filesystem_read is asked for a path its file access
+// settings do not allow
+var ErrAccessDenied = errors.New("access denied")
+
+// projectDirectory is the directory AGENTS.md is detected in. The file tools resolve
+// relative paths against it and it is the default allowed root; empty uses the working
+// directory.
+var projectDirectory = struct {
+	sync.RWMutex
+	dir string
+}{}
+
+// SetProjectDirectory sets the directory the file tools treat as the project
+func SetProjectDirectory(dir string) {
+	projectDirectory.Lock()
+	defer projectDirectory.Unlock()
+	projectDirectory.dir = dir
+}
+
+// projectDir returns the project directory, falling back to the working directory
+func projectDir() (string, error) {
+	projectDirectory.RLock()
+	dir := projectDirectory.dir
+	projectDirectory.RUnlock()
+	if dir != "" {
+		return dir, nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("cannot get working directory: %w", err)
+	}
+	return wd, nil
+}
+
+// fileSystemTool is the instance registered in DefaultRegistry
+var fileSystemTool = &FileSystemTool{}
+
+// SetFileAccess sets what the registered filesystem_read tool can read
+func SetFileAccess(access configuration.FileAccess) {
+	fileSystemTool.SetAccess(access)
+}
+
+// SetAccess sets what the tool can read
+func (fst *FileSystemTool) SetAccess(access configuration.FileAccess) {
+	fst.mu.Lock()
+	defer fst.mu.Unlock()
+	fst.access = access
+}
+
+// Access returns what the tool can read
+func (fst *FileSystemTool) Access() configuration.FileAccess {
+	fst.mu.RLock()
+	defer fst.mu.RUnlock()
+	return fst.access
+}
+
+// resolvePath resolves a requested path through symlinks to an absolute path and checks
+// it against the allowed roots, deny patterns and .gitignore files. Denials wrap
+// ErrAccessDenied and say why, so the model can tell them apart from missing files.
+func (fst *FileSystemTool) resolvePath(requested string) (string, error) {
+	access := fst.Access()
+	project, roots, err := allowedRoots(access)
+	if err != nil {
+		return "", err
+	}
+
+	absolute := requested
+	if !filepath.IsAbs(absolute) {
+		absolute = filepath.Join(project, absolute)
+	}
+	resolved, err := evalSymlinks(absolute)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s %v", ErrAccessDenied, requested, err)
+	}
+
+	if root, relative, ok := rootOf(roots, resolved); ok {
+		if reason := fst.deniedReason(access, root, relative, isDirectory(resolved)); reason != "" {
+			return "", fmt.Errorf("%w: %s %s", ErrAccessDenied, requested, reason)
+		}
+		return resolved, nil
+	}
+
+	target := requested
+	if resolved != filepath.Clean(absolute) {
+		target = fmt.Sprintf("%s resolves to %s, which", requested, resolved)
+	}
+	return "", fmt.Errorf("%w: %s is outside the allowed directories (%s)",
+		ErrAccessDenied, target, strings.Join(roots, ", "))
+}
+
+// evalSymlinks resolves the symlinks in an absolute path. A path that does not exist yet
+// is resolved through its nearest existing directory, so a file about to be created is
+// checked where it would really be written. Broken symlinks cannot be resolved and are
+// reported.
+func evalSymlinks(absolute string) (string, error) {
+	var missing []string
+	for dir := filepath.Clean(absolute); ; dir = filepath.Dir(dir) {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if info, lstatErr := os.Lstat(dir); lstatErr == nil && info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("is a broken symlink")
+		}
+		if filepath.Dir(dir) == dir {
+			return filepath.Clean(absolute), nil
+		}
+		missing = append([]string{filepath.Base(dir)}, missing...)
+	}
+}
+
+// allowedRoots returns the project directory and the allowed roots, with symlinks in the
+// roots resolved so they compare with resolved paths
+func allowedRoots(access configuration.FileAccess) (string, []string, error) {
+	project, err := projectDir()
+	if err != nil {
+		return "", nil, err
+	}
+	roots, err := access.Roots(project)
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot resolve allowed directories: %w", err)
+	}
+	for i, root := range roots {
+		if resolved, err := filepath.EvalSymlinks(root); err == nil {
+			roots[i] = resolved
+		}
+	}
+	return project, roots, nil
+}
+
+// rootOf returns the allowed root holding a resolved path and the path relative to it
+func rootOf(roots []string, resolved string) (string, string, bool) {
+	for _, root := range roots {
+		if relative, ok := withinRoot(root, resolved); ok {
+			return root, relative, true
+		}
+	}
+	return "", "", false
+}
+
+// deniedReason returns why a path relative to an allowed root may not be read, or an
+// empty string when it may
+func (fst *FileSystemTool) deniedReason(access configuration.FileAccess, root, relative string, isDir bool) string {
+	if relative == "." {
+		return ""
+	}
+	if pattern := access.DeniedBy(relative); pattern != "" {
+		return fmt.Sprintf("matches the deny pattern %q", pattern)
+	}
+	if !access.ReadGitignored && gitignored(root, relative, isDir) {
+		return "is ignored by .gitignore"
+	}
+	return ""
+}
+
+// withinRoot returns path relative to root and whether it is inside root
+func withinRoot(root, path string) (string, bool) {
+	relative, err := filepath.Rel(root, path)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return relative, true
+}
+
+// isDirectory reports whether path is an existing directory
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// gitignoreRule is one pattern from a .gitignore file
+type gitignoreRule struct {
+	base       string         // Directory of the .gitignore file, relative to the root
+	expression *regexp.Regexp // Matches paths relative to base
+	basename   bool           // Whether the pattern matches names at any depth
+	negate     bool           // Whether the pattern re-includes what it matches
+	dirOnly    bool           // Whether the pattern only matches directories
+}
+
+// gitignored reports whether a path relative to root is ignored by the .gitignore files
+// in root and the directories between root and the path. Like git, a path inside an
+// ignored directory is ignored.
+func gitignored(root, relative string, isDir bool) bool {
+	parts := strings.Split(filepath.ToSlash(relative), "/")
+	var rules []gitignoreRule
+	for i := range parts {
+		dir := path.Join(parts[:i]...)
+		rules = append(rules, readGitignore(root, dir)...)
+		target := path.Join(parts[:i+1]...)
+		if matchGitignore(rules, target, i < len(parts)-1 || isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGitignore reports whether the last rule matching target ignores it
+func matchGitignore(rules []gitignoreRule, target string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		candidate := target
+		if rule.base != "" {
+			if !strings.HasPrefix(target, rule.base+"/") {
+				continue
+			}
+			candidate = strings.TrimPrefix(target, rule.base+"/")
+		}
+		if rule.basename {
+			candidate = path.Base(candidate)
+		}
+		if rule.expression.MatchString(candidate) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// readGitignore reads the rules of the .gitignore file in dir, relative to root. A missing
+// or unreadable file has no rules.
+func readGitignore(root, dir string) []gitignoreRule {
+	file, err := os.Open(filepath.Join(root, filepath.FromSlash(dir), ".gitignore"))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var rules []gitignoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseGitignoreLine(dir, scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// parseGitignoreLine parses one line of a .gitignore file in dir, reporting false for
+// blank lines and comments
+func parseGitignoreLine(dir, line string) (gitignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return gitignoreRule{}, false
+	}
+
+	rule := gitignoreRule{base: dir}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return gitignoreRule{}, false
+	}
+
+	// Patterns without a slash match names at any depth; others are relative to dir
+	rule.basename = !strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	expression, err := regexp.Compile("^" + configuration.GlobExpression(line, true) + "$")
+	if err != nil {
+		return gitignoreRule{}, false
+	}
+	rule.expression = expression
+	return rule, true
+}
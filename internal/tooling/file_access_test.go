@@ -0,0 +1,225 @@
+package tooling
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+// writeFiles creates files with contents under dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestFileSystemTool_AccessChecks(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.go":           "package main",
+		".env":              "TOKEN=secret",
+		"certs/server.key":  "key",
+		"custom/secret.txt": "secret",
+		".gitignore":        "build/\n*.log\n!keep.log\n",
+		"build/out.txt":     "built",
+		"debug.log":         "log",
+		"keep.log":          "kept",
+		".ssh/id_ed25519":   "key",
+	})
+	writeFiles(t, outside, map[string]string{"passwd": "root:x:0:0"})
+	if err := os.Symlink(filepath.Join(outside, "passwd"), filepath.Join(root, "link")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "main.go"), filepath.Join(root, "alias.go")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	fst := &FileSystemTool{}
+	fst.SetAccess(configuration.FileAccess{AllowedRoots: []string{root}, DenyPatterns: []string{"custom/**"}})
+
+	tests := []struct {
+		name   string
+		path   string
+		denied string // Expected part of the denial, empty when the read is allowed
+	}{
+		{name: "file in root", path: filepath.Join(root, "main.go")},
+		{name: "symlink within root", path: filepath.Join(root, "alias.go")},
+		{name: "re-included by negation", path: filepath.Join(root, "keep.log")},
+		{name: "outside roots", path: filepath.Join(outside, "passwd"), denied: "outside the allowed directories"},
+		{name: "traversal out of root", path: filepath.Join(root, "..", filepath.Base(outside), "passwd"), denied: "outside the allowed directories"},
+		{name: "symlink escape", path: filepath.Join(root, "link"), denied: "resolves to"},
+		{name: "default deny pattern", path: filepath.Join(root, ".env"), denied: `deny pattern ".env"`},
+		{name: "nested key", path: filepath.Join(root, "certs", "server.key"), denied: `deny pattern "*.key"`},
+		{name: "configured deny pattern", path: filepath.Join(root, "custom", "secret.txt"), denied: `deny pattern "custom/**"`},
+		{name: "denied directory", path: filepath.Join(root, ".ssh"), denied: `deny pattern ".ssh/**"`},
+		{name: "gitignored file", path: filepath.Join(root, "debug.log"), denied: "ignored by .gitignore"},
+		{name: "file in gitignored directory", path: filepath.Join(root, "build", "out.txt"), denied: "ignored by .gitignore"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := fst.Execute(t.Context(), map[string]any{"action": "read_file", "path": tt.path})
+			if tt.denied == "" {
+				if err != nil {
+					t.Fatalf("Expected read to be allowed, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrAccessDenied) {
+				t.Fatalf("Expected access denied, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.denied) {
+				t.Errorf("Expected denial to mention %q, got %q", tt.denied, err.Error())
+			}
+		})
+	}
+
+	t.Run("gitignored files readable when allowed", func(t *testing.T) {
+		fst := &FileSystemTool{}
+		fst.SetAccess(configuration.FileAccess{AllowedRoots: []string{root}, ReadGitignored: true})
+		if _, err := fst.Execute(t.Context(), map[string]any{"action": "read_file", "path": filepath.Join(root, "debug.log")}); err != nil {
+			t.Errorf("Expected read to be allowed, got %v", err)
+		}
+	})
+
+	t.Run("listing hides denied entries", func(t *testing.T) {
+		result, err := fst.Execute(t.Context(), map[string]any{"action": "list_directory", "path": root})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		fields := result.(map[string]any)
+		var names []string
+		for _, entry := range fields["entries"].([]FileInfo) {
+			names = append(names, entry.Name)
+		}
+		listed := strings.Join(names, ",")
+		// Directories matched by a dir/** pattern are hidden along with their contents
+		for _, name := range []string{".env", "build", "debug.log", "link", "custom", ".ssh"} {
+			if strings.Contains(","+listed+",", ","+name+",") {
+				t.Errorf("Expected %s to be hidden, listed %s", name, listed)
+			}
+		}
+		for _, name := range []string{"main.go", "certs", "keep.log"} {
+			if !strings.Contains(","+listed+",", ","+name+",") {
+				t.Errorf("Expected %s to be listed, listed %s", name, listed)
+			}
+		}
+		if fields["hidden"] != 6 {
+			t.Errorf("Expected 6 hidden entries, got %v", fields["hidden"])
+		}
+	})
+}
+
+func TestFileSystemTool_ProjectDirectoryRoot(t *testing.T) {
+	project := t.TempDir()
+	writeFiles(t, project, map[string]string{"main.go": "package main"})
+	SetProjectDirectory(project)
+	t.Cleanup(func() { SetProjectDirectory("") })
+
+	// Without configured roots the project directory is the root, whatever the process's
+	// working directory is, and relative paths are resolved against it
+	fst := &FileSystemTool{}
+	if _, err := fst.Execute(t.Context(), map[string]any{"action": "read_file", "path": "main.go"}); err != nil {
+		t.Errorf("Expected a relative path in the project to be readable, got %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd failed: %v", err)
+	}
+	_, err = fst.Execute(t.Context(), map[string]any{"action": "read_file", "path": filepath.Join(wd, "file_access_test.go")})
+	if !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Expected the working directory to be outside the roots, got %v", err)
+	}
+}
+
+func TestFileSystemTool_ReadLimit(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"big.txt": strings.Repeat("a", 99) + "é"})
+
+	fst := &FileSystemTool{}
+	fst.SetAccess(configuration.FileAccess{AllowedRoots: []string{root}, MaxReadBytes: 100})
+	path := filepath.Join(root, "big.txt")
+
+	tests := []struct {
+		name      string
+		maxBytes  float64
+		wantBytes int
+		truncated bool
+	}{
+		{name: "configured limit cuts before a split rune", wantBytes: 99, truncated: true},
+		{name: "model asks for less", maxBytes: 10, wantBytes: 10, truncated: true},
+		{name: "model cannot exceed the limit", maxBytes: 1000, wantBytes: 99, truncated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]any{"action": "read_file", "path": path}
+			if tt.maxBytes > 0 {
+				args["max_bytes"] = tt.maxBytes
+			}
+			result, err := fst.Execute(t.Context(), args)
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			fields := result.(map[string]any)
+			if fields["bytes_read"] != tt.wantBytes {
+				t.Errorf("Expected %d bytes read, got %v", tt.wantBytes, fields["bytes_read"])
+			}
+			if fields["truncated"] != tt.truncated {
+				t.Errorf("Expected truncated %v, got %v", tt.truncated, fields["truncated"])
+			}
+		})
+	}
+
+	fst.SetAccess(configuration.FileAccess{AllowedRoots: []string{root}, MaxReadBytes: -1})
+	result, err := fst.Execute(t.Context(), map[string]any{"action": "read_file", "path": path})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if fields := result.(map[string]any); fields["bytes_read"] != 101 || fields["truncated"] != false {
+		t.Errorf("Expected the whole file without a limit, got %v bytes, truncated %v", fields["bytes_read"], fields["truncated"])
+	}
+}
+
+func TestGitignored(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":          "# comment\n/vendor\ndocs/*.tmp\n**/cache/\n",
+		"sub/.gitignore":      "*.bak\n",
+		"sub/notes.bak":       "",
+		"notes.bak":           "",
+		"vendor/lib.go":       "",
+		"pkg/vendor/lib.go":   "",
+		"docs/a.tmp":          "",
+		"docs/deep/a.tmp":     "",
+		"deep/x/cache/c.json": "",
+	})
+
+	tests := []struct {
+		path    string
+		ignored bool
+	}{
+		{"sub/notes.bak", true},
+		{"notes.bak", false},
+		{"vendor/lib.go", true},
+		{"pkg/vendor/lib.go", false},
+		{"docs/a.tmp", true},
+		{"docs/deep/a.tmp", false},
+		{"deep/x/cache/c.json", true},
+	}
+	for _, tt := range tests {
+		if got := gitignored(root, filepath.FromSlash(tt.path), false); got != tt.ignored {
+			t.Errorf("gitignored(%s) = %v, want %v", tt.path, got, tt.ignored)
+		}
+	}
+}
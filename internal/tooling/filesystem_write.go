@@ -0,0 +1,311 @@
+package tooling
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/ollama/ollama/api"
+)
+
+// Previewer is implemented by builtin tools that can describe what a call would change
+// before it runs, so the change can be reviewed when asking for permission
+type Previewer interface {
+	Preview(args map[string]any) (string, error)
+}
+
+// FileWriteTool creates and changes files. It writes only where the file access settings
+// allow reading, and every change is recorded so that it can be undone later in the
+// session.
+type FileWriteTool struct {
+	files    *FileSystemTool // Provides the file access settings
+	mu       sync.Mutex
+	history  []fileChange
+	reviewed map[string]reviewedFile // State of the target when a call was previewed, by call
+}
+
+// fileChange records the state of a file before the tool changed it and what it wrote
+type fileChange struct {
+	path     string
+	existed  bool
+	previous []byte
+	written  []byte
+	mode     fs.FileMode
+}
+
+// reviewedFile is the state of a file when a change to it was previewed for review
+type reviewedFile struct {
+	existed  bool
+	previous string
+}
+
+// fileWrite is a planned change to a file
+type fileWrite struct {
+	action   string
+	path     string
+	existed  bool
+	previous string
+	content  string
+	mode     fs.FileMode
+}
+
+// fileWriteTool is the instance registered in DefaultRegistry
+var fileWriteTool = &FileWriteTool{files: fileSystemTool}
+
+// UndoFileChange reverts the most recent change made by the registered filesystem_write
+// tool and describes what was restored
+func UndoFileChange() (string, error) {
+	return fileWriteTool.Undo()
+}
+
+// Name returns the tool name
+func (fwt *FileWriteTool) Name() string {
+	return "filesystem_write"
+}
+
+// Description returns the tool description
+func (fwt *FileWriteTool) Description() string {
+	return "Write local files - create, overwrite or append to files and apply unified diffs"
+}
+
+// GetAPITool returns the Ollama API tool definition
+func (fwt *FileWriteTool) GetAPITool() *api.Tool {
+	return &api.Tool{
+		Type: "function",
+		Function: api.ToolFunction{
+			Name:        "filesystem_write",
+			Description: "Write local files - create a new file, overwrite or append to a file, or apply a unified diff to a file. Prefer apply_patch for small changes to existing files.",
+			Parameters: api.ToolFunctionParameters{
+				Type: "object",
+				Properties: map[string]api.ToolProperty{
+					"action": {
+						Type:        api.PropertyType{"string"},
+						Description: "Action to perform: 'create' (fails if the file exists), 'overwrite', 'append' or 'apply_patch'",
+						Enum:        []any{"create", "overwrite", "append", "apply_patch"},
+					},
+					"path": {
+						Type:        api.PropertyType{"string"},
+						Description: "Path of the file to write",
+					},
+					"content": {
+						Type:        api.PropertyType{"string"},
+						Description: "Content to write (for create, overwrite and append)",
+					},
+					"patch": {
+						Type:        api.PropertyType{"string"},
+						Description: "Unified diff of the file with @@ hunk headers (for apply_patch)",
+					},
+				},
+				Required: []string{"action", "path"},
+			},
+		},
+	}
+}
+
+// Execute performs the file change. A call that was previewed fails when the file changed
+// since, so what is written is always the change that was reviewed.
+func (fwt *FileWriteTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	fwt.mu.Lock()
+	defer fwt.mu.Unlock()
+
+	write, err := fwt.plan(args)
+	if err != nil {
+		return nil, err
+	}
+	key := reviewKey(args)
+	if reviewed, ok := fwt.reviewed[key]; ok {
+		delete(fwt.reviewed, key)
+		if reviewed.existed != write.existed || reviewed.previous != write.previous {
+			return nil, fmt.Errorf("file %s changed after the change was reviewed; nothing was written", write.path)
+		}
+	}
+
+	if !write.existed {
+		if err := os.MkdirAll(filepath.Dir(write.path), 0755); err != nil {
+			return nil, fmt.Errorf("cannot create directory for %s: %w", write.path, err)
+		}
+	}
+	if err := os.WriteFile(write.path, []byte(write.content), write.mode); err != nil {
+		return nil, fmt.Errorf("cannot write file %s: %w", write.path, err)
+	}
+	fwt.history = append(fwt.history, fileChange{
+		path:     write.path,
+		existed:  write.existed,
+		previous: []byte(write.previous),
+		written:  []byte(write.content),
+		mode:     write.mode,
+	})
+
+	added, removed := diffStats(write.previous, write.content)
+	return map[string]any{
+		"path":          write.path,
+		"action":        write.action,
+		"created":       !write.existed,
+		"size":          len(write.content),
+		"lines_added":   added,
+		"lines_removed": removed,
+	}, nil
+}
+
+// Preview returns the change a call would make as a unified diff and remembers the state
+// of the file it was computed from
+func (fwt *FileWriteTool) Preview(args map[string]any) (string, error) {
+	fwt.mu.Lock()
+	defer fwt.mu.Unlock()
+
+	write, err := fwt.plan(args)
+	if err != nil {
+		return "", err
+	}
+	if fwt.reviewed == nil {
+		fwt.reviewed = make(map[string]reviewedFile)
+	}
+	fwt.reviewed[reviewKey(args)] = reviewedFile{existed: write.existed, previous: write.previous}
+
+	diff := UnifiedDiff(write.path, write.previous, write.content)
+	if diff == "" {
+		return fmt.Sprintf("No changes to %s", write.path), nil
+	}
+	if !write.existed {
+		diff = strings.Replace(diff, "--- a/"+write.path, "--- /dev/null", 1)
+	}
+	return diff, nil
+}
+
+// Undo reverts the most recent change, removing files the tool created. It refuses when
+// the file no longer holds what the tool wrote, so later edits are not overwritten.
+func (fwt *FileWriteTool) Undo() (string, error) {
+	fwt.mu.Lock()
+	defer fwt.mu.Unlock()
+
+	if len(fwt.history) == 0 {
+		return "", fmt.Errorf("no file changes to undo")
+	}
+	change := fwt.history[len(fwt.history)-1]
+
+	current, err := os.ReadFile(change.path)
+	if err != nil {
+		return "", fmt.Errorf("cannot undo the change to %s: %w", change.path, err)
+	}
+	if !bytes.Equal(current, change.written) {
+		return "", fmt.Errorf("cannot undo the change to %s: the file changed after it was written", change.path)
+	}
+
+	if !change.existed {
+		if err := os.Remove(change.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("cannot remove %s: %w", change.path, err)
+		}
+		fwt.history = fwt.history[:len(fwt.history)-1]
+		return fmt.Sprintf("Removed %s", change.path), nil
+	}
+
+	if err := os.WriteFile(change.path, change.previous, change.mode); err != nil {
+		return "", fmt.Errorf("cannot restore %s: %w", change.path, err)
+	}
+	fwt.history = fwt.history[:len(fwt.history)-1]
+	return fmt.Sprintf("Restored %s", change.path), nil
+}
+
+// plan works out the content a call would write without changing anything
+func (fwt *FileWriteTool) plan(args map[string]any) (*fileWrite, error) {
+	action, ok := args["action"].(string)
+	if !ok {
+		return nil, fmt.Errorf("action parameter required and must be a string")
+	}
+	path, ok := args["path"].(string)
+	if !ok || strings.TrimSpace(path) == "" {
+		return nil, fmt.Errorf("path parameter required and must be a non-empty string")
+	}
+
+	resolved, err := fwt.files.resolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	write := &fileWrite{action: action, path: resolved, mode: 0644}
+	stat, err := os.Stat(write.path)
+	switch {
+	case err == nil:
+		if stat.IsDir() {
+			return nil, fmt.Errorf("path %s is a directory, not a file", write.path)
+		}
+		previous, err := os.ReadFile(write.path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read file %s: %w", write.path, err)
+		}
+		if !utf8.Valid(previous) {
+			return nil, fmt.Errorf("file %s is not a text file", write.path)
+		}
+		write.existed = true
+		write.previous = string(previous)
+		write.mode = stat.Mode().Perm()
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("cannot access file %s: %w", write.path, err)
+	}
+
+	content, hasContent := args["content"].(string)
+	switch action {
+	case "create", "overwrite", "append":
+		if !hasContent {
+			return nil, fmt.Errorf("content parameter required for action '%s' and must be a string", action)
+		}
+	}
+
+	switch action {
+	case "create":
+		if write.existed {
+			return nil, fmt.Errorf("file %s already exists; use overwrite or apply_patch to change it", write.path)
+		}
+		write.content = content
+	case "overwrite":
+		write.content = content
+	case "append":
+		write.content = write.previous + content
+	case "apply_patch":
+		patch, ok := args["patch"].(string)
+		if !ok || strings.TrimSpace(patch) == "" {
+			return nil, fmt.Errorf("patch parameter required for action 'apply_patch' and must be a non-empty string")
+		}
+		if !write.existed {
+			return nil, fmt.Errorf("cannot patch %s: file does not exist", write.path)
+		}
+		patched, err := applyUnifiedDiff(write.previous, patch)
+		if err != nil {
+			return nil, fmt.Errorf("cannot apply patch to %s: %w", write.path, err)
+		}
+		write.content = patched
+	default:
+		return nil, fmt.Errorf("unknown action: %s. Valid actions are: create, overwrite, append, apply_patch", action)
+	}
+	return write, nil
+}
+
+// reviewKey identifies a call by its arguments
+func reviewKey(args map[string]any) string {
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Sprint(args)
+	}
+	return string(encoded)
+}
+
+// diffStats counts the lines added and removed between two texts
+func diffStats(oldText, newText string) (added, removed int) {
+	for _, line := range diffLines(splitLines(oldText), splitLines(newText)) {
+		switch line.kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	return added, removed
+}
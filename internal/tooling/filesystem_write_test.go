@@ -0,0 +1,264 @@
+package tooling
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	oldText := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\ntwelve\n"
+	newText := "one\nTWO\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\ntwelve\nthirteen\n"
+
+	expected := `--- a/numbers.txt
++++ b/numbers.txt
+@@ -1,5 +1,5 @@
+ one
+-two
++TWO
+ three
+ four
+ five
+@@ -10,3 +10,4 @@
+ ten
+ eleven
+ twelve
++thirteen
+`
+	if diff := UnifiedDiff("numbers.txt", oldText, newText); diff != expected {
+		t.Errorf("Expected diff\n%s\ngot\n%s", expected, diff)
+	}
+
+	if diff := UnifiedDiff("numbers.txt", oldText, oldText); diff != "" {
+		t.Errorf("Expected no diff for identical texts, got\n%s", diff)
+	}
+	if diff := UnifiedDiff("new.txt", "", "hello\n"); diff != "--- a/new.txt\n+++ b/new.txt\n@@ -0,0 +1,1 @@\n+hello\n" {
+		t.Errorf("Unexpected diff for a new file\n%s", diff)
+	}
+}
+
+func TestApplyUnifiedDiff(t *testing.T) {
+	oldText := "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n"
+
+	tests := []struct {
+		name     string
+		patch    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name: "generated diff",
+			patch: UnifiedDiff("main.go", oldText,
+				"package main\n\nfunc main() {\n\tprintln(\"hello, world\")\n}\n"),
+			expected: "package main\n\nfunc main() {\n\tprintln(\"hello, world\")\n}\n",
+		},
+		{
+			name:     "wrong line numbers and counts",
+			patch:    "@@ -40,1 +40,1 @@\n func main() {\n-\tprintln(\"hello\")\n+\tprintln(\"bye\")\n",
+			expected: "package main\n\nfunc main() {\n\tprintln(\"bye\")\n}\n",
+		},
+		{
+			name:     "empty context line without a space",
+			patch:    "@@ -1,3 +1,3 @@\n-package main\n+package tool\n\n func main() {\n",
+			expected: "package tool\n\nfunc main() {\n\tprintln(\"hello\")\n}\n",
+		},
+		{
+			name:    "context not in file",
+			patch:   "@@ -1,1 +1,1 @@\n-package other\n+package main\n",
+			wantErr: true,
+		},
+		{
+			name:    "no hunks",
+			patch:   "just some text",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patched, err := applyUnifiedDiff(oldText, tt.patch)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got\n%s", patched)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyUnifiedDiff failed: %v", err)
+			}
+			if patched != tt.expected {
+				t.Errorf("Expected\n%q\ngot\n%q", tt.expected, patched)
+			}
+		})
+	}
+}
+
+// newFileWriteTool returns a filesystem_write tool allowed to write below root
+func newFileWriteTool(root string) *FileWriteTool {
+	fst := &FileSystemTool{}
+	fst.SetAccess(configuration.FileAccess{AllowedRoots: []string{root}})
+	return &FileWriteTool{files: fst}
+}
+
+func TestFileWriteTool_ChangesAndUndo(t *testing.T) {
+	root := t.TempDir()
+	tool := newFileWriteTool(root)
+	path := filepath.Join(root, "notes", "todo.txt")
+
+	readFile := func() string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		return string(data)
+	}
+
+	if _, err := tool.Execute(t.Context(), map[string]any{"action": "create", "path": path, "content": "buy milk\n"}); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if _, err := tool.Execute(t.Context(), map[string]any{"action": "create", "path": path, "content": "again\n"}); err == nil {
+		t.Error("Expected create to fail for an existing file")
+	}
+	if _, err := tool.Execute(t.Context(), map[string]any{"action": "append", "path": path, "content": "call mum\n"}); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+
+	args := map[string]any{"action": "apply_patch", "path": path, "patch": "@@ -1,2 +1,2 @@\n-buy milk\n+buy oat milk\n call mum\n"}
+	preview, err := tool.Preview(args)
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if !strings.Contains(preview, "-buy milk\n+buy oat milk\n") {
+		t.Errorf("Expected the preview to show the change, got\n%s", preview)
+	}
+	if got := readFile(); got != "buy milk\ncall mum\n" {
+		t.Errorf("Expected Preview not to change the file, got %q", got)
+	}
+
+	result, err := tool.Execute(t.Context(), args)
+	if err != nil {
+		t.Fatalf("apply_patch failed: %v", err)
+	}
+	if stats := result.(map[string]any); stats["lines_added"] != 1 || stats["lines_removed"] != 1 {
+		t.Errorf("Expected one line added and removed, got %v", stats)
+	}
+	if got := readFile(); got != "buy oat milk\ncall mum\n" {
+		t.Errorf("Unexpected content after patch %q", got)
+	}
+
+	// Changes are undone newest first, and undoing the creation removes the file
+	for _, expected := range []string{"buy milk\ncall mum\n", "buy milk\n"} {
+		if _, err := tool.Undo(); err != nil {
+			t.Fatalf("Undo failed: %v", err)
+		}
+		if got := readFile(); got != expected {
+			t.Errorf("Expected %q after undo, got %q", expected, got)
+		}
+	}
+	if message, err := tool.Undo(); err != nil || !strings.HasPrefix(message, "Removed") {
+		t.Fatalf("Expected the created file to be removed, got %q, %v", message, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", path, err)
+	}
+	if _, err := tool.Undo(); err == nil {
+		t.Error("Expected an error with nothing to undo")
+	}
+}
+
+func TestFileWriteTool_PreviewNewFile(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "hello.txt")
+	preview, err := newFileWriteTool(root).Preview(map[string]any{"action": "overwrite", "path": path, "content": "hello\n"})
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if !strings.HasPrefix(preview, "--- /dev/null\n+++ b/"+path) || !strings.HasSuffix(preview, "+hello\n") {
+		t.Errorf("Unexpected preview for a new file\n%s", preview)
+	}
+}
+
+func TestFileWriteTool_FileAccess(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "missing.txt"), filepath.Join(root, "dangling")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	tool := newFileWriteTool(root)
+
+	tests := []struct {
+		name   string
+		path   string
+		denied string // Expected part of the denial, empty when the write is allowed
+	}{
+		{name: "new file in root", path: filepath.Join(root, "src", "main.go")},
+		{name: "outside roots", path: filepath.Join(outside, "notes.txt"), denied: "outside the allowed directories"},
+		{name: "through a symlinked directory", path: filepath.Join(root, "escape", "notes.txt"), denied: "outside the allowed directories"},
+		{name: "broken symlink", path: filepath.Join(root, "dangling"), denied: "broken symlink"},
+		{name: "secret file", path: filepath.Join(root, ".env"), denied: "deny pattern"},
+		{name: "ssh directory", path: filepath.Join(root, ".ssh", "authorized_keys"), denied: "deny pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tool.Execute(t.Context(), map[string]any{"action": "create", "path": tt.path, "content": "x\n"})
+			if tt.denied == "" {
+				if err != nil {
+					t.Fatalf("Expected the write to be allowed, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrAccessDenied) || !strings.Contains(err.Error(), tt.denied) {
+				t.Fatalf("Expected access denied containing %q, got %v", tt.denied, err)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(outside, "notes.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be written outside the root, got %v", err)
+	}
+}
+
+func TestFileWriteTool_ChangedAfterReview(t *testing.T) {
+	root := t.TempDir()
+	tool := newFileWriteTool(root)
+	path := filepath.Join(root, "todo.txt")
+	if err := os.WriteFile(path, []byte("buy milk\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	args := map[string]any{"action": "append", "path": path, "content": "call mum\n"}
+	if _, err := tool.Preview(args); err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("buy bread\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := tool.Execute(t.Context(), args); err == nil || !strings.Contains(err.Error(), "changed after the change was reviewed") {
+		t.Fatalf("Expected the reviewed change to be refused, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "buy bread\n" {
+		t.Errorf("Expected the file to be left alone, got %q", data)
+	}
+
+	// Undo does not overwrite edits made after the tool wrote the file
+	if _, err := tool.Execute(t.Context(), args); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("edited\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := tool.Undo(); err == nil || !strings.Contains(err.Error(), "changed after it was written") {
+		t.Fatalf("Expected undo to be refused, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "edited\n" {
+		t.Errorf("Expected the edit to be kept, got %q", data)
+	}
+}
@@ -0,0 +1,339 @@
+package tooling
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// skippedElements hold no readable text, so they are dropped with their contents
+var skippedElements = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true, "template": true,
+	"svg": true, "iframe": true, "canvas": true, "object": true,
+}
+
+// blockElements start on a new line
+var blockElements = map[string]bool{
+	"div": true, "section": true, "article": true, "header": true, "footer": true, "main": true,
+	"aside": true, "nav": true, "form": true, "figure": true, "figcaption": true, "dl": true,
+	"dt": true, "dd": true, "address": true, "details": true, "summary": true, "table": true,
+	"thead": true, "tbody": true, "tfoot": true, "caption": true,
+}
+
+// paragraphElements are separated from what surrounds them by a blank line
+var paragraphElements = map[string]bool{"p": true, "blockquote": true, "ul": true, "ol": true, "hr": true}
+
+// whitespace matches runs of whitespace in text outside preformatted blocks
+var whitespace = regexp.MustCompile(`\s+`)
+
+// blankLines matches more than one blank line
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// markdownConverter renders HTML tokens as Markdown
+type markdownConverter struct {
+	out   []byte
+	pre   int      // Depth of open pre elements
+	lists []int    // Open lists: -1 for unordered, otherwise the last item number
+	links []link   // Open links
+	cells int      // Cells written in the current table row
+	tags  []string // Open inline formatting elements, to close unbalanced ones
+}
+
+// link is an open a element
+type link struct {
+	start int // Position of the link text in the output
+	href  string
+}
+
+// HTMLToMarkdown converts an HTML document to Markdown. Headings, paragraphs, links,
+// images, lists, emphasis, code, preformatted text and table rows are kept; scripts,
+// styles and other markup are dropped.
+func HTMLToMarkdown(document string) string {
+	c := &markdownConverter{}
+	for i := 0; i < len(document); {
+		if document[i] != '<' {
+			end := strings.IndexByte(document[i:], '<')
+			if end < 0 {
+				end = len(document) - i
+			}
+			c.text(html.UnescapeString(document[i : i+end]))
+			i += end
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(document[i:], "<!--"):
+			i = skipPast(document, i, "-->")
+		case strings.HasPrefix(document[i:], "<!") || strings.HasPrefix(document[i:], "<?"):
+			i = skipPast(document, i, ">")
+		default:
+			end := tagEnd(document, i)
+			if end < 0 {
+				c.text(document[i:])
+				i = len(document)
+				continue
+			}
+			name, closing, attributes := parseTag(document[i+1 : end])
+			if name == "" {
+				// Not a tag, such as the < in "a < b"
+				c.text(html.UnescapeString(document[i : i+1]))
+				i++
+				continue
+			}
+			i = end + 1
+			if !closing && skippedElements[name] {
+				i = skipElement(document, i, name)
+				continue
+			}
+			if closing {
+				c.closeTag(name)
+			} else {
+				c.openTag(name, attributes)
+			}
+		}
+	}
+
+	// Trim trailing spaces of every line and limit blank lines to one
+	lines := strings.Split(string(c.out), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// skipPast returns the position after the first marker at or after start, or the end
+func skipPast(document string, start int, marker string) int {
+	if end := strings.Index(document[start:], marker); end >= 0 {
+		return start + end + len(marker)
+	}
+	return len(document)
+}
+
+// skipElement returns the position after the closing tag of an element whose contents
+// are dropped
+func skipElement(document string, start int, name string) int {
+	closing := strings.Index(strings.ToLower(document[start:]), "</"+name)
+	if closing < 0 {
+		return len(document)
+	}
+	return skipPast(document, start+closing, ">")
+}
+
+// tagEnd returns the position of the > ending the tag at start, ignoring any inside
+// quoted attribute values, or -1
+func tagEnd(document string, start int) int {
+	var quote byte
+	for i := start + 1; i < len(document); i++ {
+		switch {
+		case quote != 0:
+			if document[i] == quote {
+				quote = 0
+			}
+		case document[i] == '"' || document[i] == '\'':
+			quote = document[i]
+		case document[i] == '>':
+			return i
+		}
+	}
+	return -1
+}
+
+// tagAttribute matches one attribute of a tag
+var tagAttribute = regexp.MustCompile(`([a-zA-Z_:][-a-zA-Z0-9_:.]*)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+)))?`)
+
+// parseTag returns the lowercase name of a tag, whether it closes an element and the
+// attributes it sets
+func parseTag(tag string) (string, bool, map[string]string) {
+	closing := strings.HasPrefix(tag, "/")
+	tag = strings.TrimSuffix(strings.TrimPrefix(tag, "/"), "/")
+	nameEnd := strings.IndexAny(tag, " \t\r\n/")
+	if nameEnd < 0 {
+		nameEnd = len(tag)
+	}
+	name := strings.ToLower(tag[:nameEnd])
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return "", false, nil
+		}
+	}
+
+	attributes := make(map[string]string)
+	for _, match := range tagAttribute.FindAllStringSubmatch(tag[nameEnd:], -1) {
+		attributes[strings.ToLower(match[1])] = html.UnescapeString(match[2] + match[3] + match[4])
+	}
+	return name, closing, attributes
+}
+
+// text writes text, collapsing whitespace outside preformatted blocks
+func (c *markdownConverter) text(text string) {
+	if c.pre > 0 {
+		c.out = append(c.out, text...)
+		return
+	}
+	text = whitespace.ReplaceAllString(text, " ")
+	if text == " " || strings.HasPrefix(text, " ") {
+		if len(c.out) == 0 || strings.ContainsRune(" \n[(", rune(c.out[len(c.out)-1])) {
+			text = strings.TrimPrefix(text, " ")
+		}
+	}
+	c.out = append(c.out, text...)
+}
+
+// newlines ends the output with at least count line breaks, unless it is empty
+func (c *markdownConverter) newlines(count int) {
+	if len(c.out) == 0 {
+		return
+	}
+	for i := len(c.out) - 1; i >= 0 && count > 0 && (c.out[i] == '\n' || c.out[i] == ' '); i-- {
+		if c.out[i] == '\n' {
+			count--
+		}
+	}
+	for ; count > 0; count-- {
+		c.out = append(c.out, '\n')
+	}
+}
+
+// inline writes Markdown for inline formatting
+func (c *markdownConverter) inline(marker string) {
+	if c.pre == 0 {
+		c.out = append(c.out, marker...)
+	}
+}
+
+// openTag writes the Markdown starting an element
+func (c *markdownConverter) openTag(name string, attributes map[string]string) {
+	switch name {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		c.newlines(2)
+		level, _ := strconv.Atoi(name[1:])
+		c.out = append(c.out, strings.Repeat("#", level)+" "...)
+	case "br":
+		c.newlines(1)
+	case "hr":
+		c.newlines(2)
+		c.out = append(c.out, "---"...)
+		c.newlines(2)
+	case "pre":
+		c.newlines(2)
+		c.out = append(c.out, "```\n"...)
+		c.pre++
+	case "ul", "ol":
+		if len(c.lists) == 0 {
+			c.newlines(2)
+		}
+		if name == "ol" {
+			c.lists = append(c.lists, 0)
+		} else {
+			c.lists = append(c.lists, -1)
+		}
+	case "li":
+		c.newlines(1)
+		marker := "- "
+		if depth := len(c.lists); depth > 0 {
+			c.out = append(c.out, strings.Repeat("  ", depth-1)...)
+			if c.lists[depth-1] >= 0 {
+				c.lists[depth-1]++
+				marker = strconv.Itoa(c.lists[depth-1]) + ". "
+			}
+		}
+		c.out = append(c.out, marker...)
+	case "blockquote":
+		c.newlines(2)
+		c.out = append(c.out, "> "...)
+	case "tr":
+		c.newlines(1)
+		c.cells = 0
+	case "td", "th":
+		if c.cells == 0 {
+			c.out = append(c.out, "|"...)
+		}
+		c.out = append(c.out, ' ')
+		c.cells++
+	case "a":
+		c.links = append(c.links, link{start: len(c.out), href: attributes["href"]})
+	case "img":
+		if alt, src := attributes["alt"], attributes["src"]; src != "" && !strings.HasPrefix(src, "data:") {
+			c.text(" ")
+			c.out = append(c.out, "!["+alt+"]("+src+")"...)
+		} else if alt != "" {
+			c.text(alt)
+		}
+	case "strong", "b":
+		c.inline("**")
+		c.tags = append(c.tags, name)
+	case "em", "i":
+		c.inline("*")
+		c.tags = append(c.tags, name)
+	case "code":
+		c.inline("`")
+		c.tags = append(c.tags, name)
+	default:
+		if paragraphElements[name] {
+			c.newlines(2)
+		} else if blockElements[name] {
+			c.newlines(1)
+		}
+	}
+}
+
+// closeTag writes the Markdown ending an element
+func (c *markdownConverter) closeTag(name string) {
+	switch name {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		c.newlines(2)
+	case "pre":
+		if c.pre > 0 {
+			c.newlines(1)
+			c.out = append(c.out, "```"...)
+			c.pre--
+			c.newlines(2)
+		}
+	case "ul", "ol":
+		if len(c.lists) > 0 {
+			c.lists = c.lists[:len(c.lists)-1]
+		}
+		if len(c.lists) == 0 {
+			c.newlines(2)
+		}
+	case "td", "th":
+		c.out = append(c.out, " |"...)
+	case "a":
+		if len(c.links) == 0 {
+			return
+		}
+		open := c.links[len(c.links)-1]
+		c.links = c.links[:len(c.links)-1]
+		content := string(c.out[open.start:])
+		text := strings.TrimSpace(content)
+		if text == "" || open.href == "" || strings.HasPrefix(open.href, "#") || strings.HasPrefix(strings.ToLower(open.href), "javascript:") {
+			return
+		}
+		leading := content[:len(content)-len(strings.TrimLeft(content, " \n"))]
+		c.out = append(c.out[:open.start], leading+"["+text+"]("+open.href+")"...)
+	case "strong", "b", "em", "i", "code":
+		// Only close formatting that is open, so stray closing tags add no markers
+		for i := len(c.tags) - 1; i >= 0; i-- {
+			if c.tags[i] != name {
+				continue
+			}
+			c.tags = append(c.tags[:i], c.tags[i+1:]...)
+			switch name {
+			case "strong", "b":
+				c.inline("**")
+			case "em", "i":
+				c.inline("*")
+			default:
+				c.inline("`")
+			}
+			break
+		}
+	default:
+		if paragraphElements[name] {
+			c.newlines(2)
+		} else if blockElements[name] {
+			c.newlines(1)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package tooling
+
+import "testing"
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "headings and paragraphs",
+			html: "<html><head><title>Docs</title><style>p{}</style></head><body><h1>Install</h1><p>Run the\n   installer.</p><p>Then <b>restart</b> &amp; <em>enjoy</em>.</p></body></html>",
+			want: "# Install\n\nRun the installer.\n\nThen **restart** & *enjoy*.",
+		},
+		{
+			name: "links and images",
+			html: `<p>See <a href="https://example.com/docs">the docs</a> or <a href="#top">top</a>. <img src="/logo.png" alt="Logo"></p>`,
+			want: "See [the docs](https://example.com/docs) or top. ![Logo](/logo.png)",
+		},
+		{
+			name: "lists",
+			html: "<ul><li>One</li><li>Two<ol><li>First</li><li>Second</li></ol></li></ul><p>After</p>",
+			want: "- One\n- Two\n  1. First\n  2. Second\n\nAfter",
+		},
+		{
+			name: "code",
+			html: "<p>Use <code>go test</code>:</p><pre><code>go test ./...\n  -run X</code></pre>",
+			want: "Use `go test`:\n\n```\ngo test ./...\n  -run X\n```",
+		},
+		{
+			name: "scripts and comments dropped",
+			html: `<div>Before<script>alert("<p>x</p>")</script><!-- hidden --></div><noscript>Enable JS</noscript><div>After</div>`,
+			want: "Before\nAfter",
+		},
+		{
+			name: "tables",
+			html: "<table><tr><th>Name</th><th>Port</th></tr><tr><td>api</td><td>8080</td></tr></table>",
+			want: "| Name | Port |\n| api | 8080 |",
+		},
+		{
+			name: "stray angle brackets and closing tags",
+			html: "<p>1 < 2 and 3 > 2</b></p><hr><p>End</p>",
+			want: "1 < 2 and 3 > 2\n\n---\n\nEnd",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTMLToMarkdown(tt.html); got != tt.want {
+				t.Errorf("HTMLToMarkdown() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
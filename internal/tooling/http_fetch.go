@@ -0,0 +1,271 @@
+package tooling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+// httpFetchMaxRedirects caps the redirects followed by a request
+const httpFetchMaxRedirects = 5
+
+// ErrHostNotAllowed is returned when http_fetch is asked for a host its policy does not
+// allow
+var ErrHostNotAllowed = errors.New("host not allowed")
+
+// HTTPFetchTool fetches URLs over HTTP, converting HTML responses to Markdown. Which hosts
+// it may contact is decided by its policy.
+type HTTPFetchTool struct {
+	mu     sync.RWMutex
+	policy configuration.HTTPFetch
+}
+
+// httpFetchTool is the instance registered in DefaultRegistry
+var httpFetchTool = &HTTPFetchTool{}
+
+// SetHTTPFetch sets the policy of the registered http_fetch tool
+func SetHTTPFetch(policy configuration.HTTPFetch) {
+	httpFetchTool.SetPolicy(policy)
+}
+
+// SetPolicy sets the hosts the tool may contact and its limits
+func (hft *HTTPFetchTool) SetPolicy(policy configuration.HTTPFetch) {
+	hft.mu.Lock()
+	defer hft.mu.Unlock()
+	hft.policy = policy
+}
+
+// Policy returns the hosts the tool may contact and its limits
+func (hft *HTTPFetchTool) Policy() configuration.HTTPFetch {
+	hft.mu.RLock()
+	defer hft.mu.RUnlock()
+	return hft.policy
+}
+
+// Name returns the tool name
+func (hft *HTTPFetchTool) Name() string {
+	return "http_fetch"
+}
+
+// Description returns the tool description
+func (hft *HTTPFetchTool) Description() string {
+	return "Fetch a URL over HTTP with GET or POST, converting HTML to Markdown"
+}
+
+// GetAPITool returns the Ollama API tool definition
+func (hft *HTTPFetchTool) GetAPITool() *api.Tool {
+	return &api.Tool{
+		Type: "function",
+		Function: api.ToolFunction{
+			Name:        "http_fetch",
+			Description: "Fetch a URL over HTTP with GET or POST. HTML responses are converted to Markdown. Only hosts allowed by the user's policy can be reached, by default only localhost.",
+			Parameters: api.ToolFunctionParameters{
+				Type: "object",
+				Properties: map[string]api.ToolProperty{
+					"url": {
+						Type:        api.PropertyType{"string"},
+						Description: "http or https URL to fetch",
+					},
+					"method": {
+						Type:        api.PropertyType{"string"},
+						Description: "HTTP method (optional, default: GET)",
+						Enum:        []any{http.MethodGet, http.MethodPost},
+					},
+					"headers": {
+						Type:        api.PropertyType{"object"},
+						Description: "Request headers as name-value pairs (optional)",
+					},
+					"body": {
+						Type:        api.PropertyType{"string"},
+						Description: "Request body for POST (optional)",
+					},
+					"raw": {
+						Type:        api.PropertyType{"boolean"},
+						Description: "Return HTML as received instead of converting it to Markdown (optional, default: false)",
+					},
+				},
+				Required: []string{"url"},
+			},
+		},
+	}
+}
+
+// Execute sends the request and returns the status, content type and body of the response
+func (hft *HTTPFetchTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	rawURL, ok := args["url"].(string)
+	if !ok || strings.TrimSpace(rawURL) == "" {
+		return nil, fmt.Errorf("url parameter required and must be a non-empty string")
+	}
+	target, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	policy := hft.Policy()
+	if err := checkFetchURL(policy, target); err != nil {
+		return nil, err
+	}
+
+	method := http.MethodGet
+	if m, ok := args["method"].(string); ok && m != "" {
+		method = strings.ToUpper(m)
+	}
+	if method != http.MethodGet && method != http.MethodPost {
+		return nil, fmt.Errorf("method must be GET or POST, got %s", method)
+	}
+	var body io.Reader
+	if text, ok := args["body"].(string); ok && text != "" {
+		if method != http.MethodPost {
+			return nil, fmt.Errorf("a body can only be sent with POST")
+		}
+		body = strings.NewReader(text)
+	}
+
+	timeout, maxBytes := policy.Limits()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	request, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create request: %w", err)
+	}
+	request.Header.Set("User-Agent", "gollama-chat")
+	if headers, ok := args["headers"].(map[string]any); ok {
+		for name, value := range headers {
+			request.Header.Set(name, fmt.Sprint(value))
+		}
+	}
+
+	response, err := newFetchClient(policy).Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer response.Body.Close()
+
+	reader := io.Reader(response.Body)
+	if maxBytes > 0 {
+		reader = io.LimitReader(response.Body, int64(maxBytes)+1)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read response: %w", err)
+	}
+	truncated := maxBytes > 0 && len(content) > maxBytes
+	if truncated {
+		content = trimPartialRune(content[:maxBytes])
+	}
+
+	contentType := response.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	raw, _ := args["raw"].(bool)
+	format := "raw"
+	var text string
+	switch {
+	case !utf8.Valid(content):
+		text = fmt.Sprintf("<binary response - %d bytes>", len(content))
+	case (mediaType == "text/html" || mediaType == "application/xhtml+xml") && !raw:
+		text = HTMLToMarkdown(string(content))
+		format = "markdown"
+	default:
+		text = string(content)
+	}
+
+	return map[string]any{
+		"url":          response.Request.URL.String(),
+		"status":       response.Status,
+		"status_code":  response.StatusCode,
+		"content_type": contentType,
+		"content":      text,
+		"format":       format,
+		"bytes":        len(content),
+		"truncated":    truncated,
+	}, nil
+}
+
+// checkFetchURL checks the scheme of a URL and whether its host could be allowed before it
+// is resolved. Names outside the local policy are denied without a DNS lookup unless
+// address ranges are allowed.
+func checkFetchURL(policy configuration.HTTPFetch, target *url.URL) error {
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("only http and https URLs can be fetched, got %q", target.Scheme)
+	}
+	host := target.Hostname()
+	if host == "" {
+		return fmt.Errorf("url %s has no host", target)
+	}
+	if policy.HostDenied(host) {
+		return fmt.Errorf("%w: %s is in httpFetch.deniedHosts", ErrHostNotAllowed, host)
+	}
+	if policy.HostAllowedByName(host) {
+		return nil
+	}
+	if net.ParseIP(host) == nil && !policy.AllowsAddressRanges() {
+		return fmt.Errorf("%w: %s is not allowed by the %s policy; add it to httpFetch.allowedHosts to allow it",
+			ErrHostNotAllowed, host, policy.PolicyName())
+	}
+	// Addresses and names in allowed ranges are judged when they are dialed
+	return nil
+}
+
+// newFetchClient returns a client that only connects to addresses the policy allows and
+// checks every redirect. Proxies are not used, since they would hide the real address.
+func newFetchClient(policy configuration.HTTPFetch) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	transport := &http.Transport{
+		Proxy: nil,
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			host, port, err := net.SplitHostPort(address)
+			if err != nil {
+				return nil, err
+			}
+			addresses, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+
+			// Connect to the checked address, so the name cannot resolve differently later
+			var lastErr error
+			for _, ip := range addresses {
+				if !policy.AddressAllowed(host, ip.IP) {
+					lastErr = fmt.Errorf("%w: %s resolves to %s, which the %s policy does not allow",
+						ErrHostNotAllowed, host, ip.IP, policy.PolicyName())
+					continue
+				}
+				conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.IP.String(), port))
+				if err == nil {
+					return conn, nil
+				}
+				lastErr = err
+			}
+			if lastErr == nil {
+				lastErr = fmt.Errorf("no addresses found for %s", host)
+			}
+			return nil, lastErr
+		},
+		TLSHandshakeTimeout: 10 * time.Second,
+		DisableKeepAlives:   true, // Each request gets its own client
+	}
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			if len(via) >= httpFetchMaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", httpFetchMaxRedirects)
+			}
+			return checkFetchURL(policy, request.URL)
+		},
+	}
+}
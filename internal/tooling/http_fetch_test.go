@@ -0,0 +1,137 @@
+package tooling
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+// fetchServer starts a test server with a few pages
+func fetchServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<html><body><h1>Title</h1><p>Hello <a href=\"/next\">next</a></p></body></html>")
+	})
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"method":%q,"token":%q,"body":%q}`, r.Method, r.Header.Get("X-Token"), body)
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, strings.Repeat("x", 2048))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPFetchTool(t *testing.T) {
+	server := fetchServer(t)
+	tool := &HTTPFetchTool{}
+
+	tests := []struct {
+		name   string
+		args   map[string]any
+		check  map[string]any
+		format string
+	}{
+		{
+			name:  "HTML is converted to Markdown",
+			args:  map[string]any{"url": server.URL + "/page"},
+			check: map[string]any{"status_code": 200, "format": "markdown", "content": "# Title\n\nHello [next](/next)"},
+		},
+		{
+			name:  "raw HTML",
+			args:  map[string]any{"url": server.URL + "/page", "raw": true},
+			check: map[string]any{"format": "raw", "content": "<html><body><h1>Title</h1><p>Hello <a href=\"/next\">next</a></p></body></html>"},
+		},
+		{
+			name:  "POST with headers and body",
+			args:  map[string]any{"url": server.URL + "/echo", "method": "post", "headers": map[string]any{"X-Token": "abc"}, "body": "ping"},
+			check: map[string]any{"content": `{"method":"POST","token":"abc","body":"ping"}`},
+		},
+		{
+			name:  "redirect within policy",
+			args:  map[string]any{"url": server.URL + "/redirect?to=/page"},
+			check: map[string]any{"url": server.URL + "/page", "format": "markdown"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tool.Execute(t.Context(), tt.args)
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			fields := result.(map[string]any)
+			for key, want := range tt.check {
+				if fields[key] != want {
+					t.Errorf("Expected %s %v, got %v", key, want, fields[key])
+				}
+			}
+		})
+	}
+
+	t.Run("response size limit", func(t *testing.T) {
+		limited := &HTTPFetchTool{}
+		limited.SetPolicy(configuration.HTTPFetch{MaxResponseBytes: 100})
+		result, err := limited.Execute(t.Context(), map[string]any{"url": server.URL + "/large"})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if fields := result.(map[string]any); fields["bytes"] != 100 || fields["truncated"] != true {
+			t.Errorf("Expected 100 truncated bytes, got %v bytes, truncated %v", fields["bytes"], fields["truncated"])
+		}
+	})
+}
+
+func TestHTTPFetchTool_Policy(t *testing.T) {
+	server := fetchServer(t)
+	port := server.URL[strings.LastIndex(server.URL, ":"):]
+
+	tests := []struct {
+		name   string
+		policy configuration.HTTPFetch
+		url    string
+		denied string // Expected part of the denial, empty when the request is allowed
+	}{
+		{name: "loopback address", url: server.URL + "/page"},
+		{name: "localhost", url: "http://localhost" + port + "/page"},
+		{name: "remote host in local policy", url: "http://example.com/", denied: "not allowed by the local policy"},
+		{name: "private address in local policy", url: "http://10.1.2.3/", denied: "10.1.2.3, which the local policy does not allow"},
+		{name: "denied loopback range", policy: configuration.HTTPFetch{DeniedHosts: []string{"127.0.0.0/8"}}, url: server.URL + "/page", denied: "deniedHosts"},
+		{name: "denied name", policy: configuration.HTTPFetch{Policy: "any", DeniedHosts: []string{"*.example.com"}}, url: "http://docs.example.com/", denied: "deniedHosts"},
+		{name: "unsupported scheme", url: "file:///etc/passwd", denied: "only http and https"},
+		{name: "redirect to a remote host", url: server.URL + "/redirect?to=http://example.com/", denied: "not allowed by the local policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &HTTPFetchTool{}
+			tool.SetPolicy(tt.policy)
+			_, err := tool.Execute(t.Context(), map[string]any{"url": tt.url})
+			if tt.denied == "" {
+				if err != nil {
+					t.Fatalf("Expected the request to be allowed, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.denied) {
+				t.Fatalf("Expected a denial mentioning %q, got %v", tt.denied, err)
+			}
+			if strings.HasPrefix(tt.url, "http") && !errors.Is(err, ErrHostNotAllowed) {
+				t.Errorf("Expected ErrHostNotAllowed, got %v", err)
+			}
+		})
+	}
+}
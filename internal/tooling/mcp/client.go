@@ -1,12 +1,10 @@
 package mcp
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"os/exec"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -40,13 +38,14 @@ func (s ServerStatus) String() string {
 	}
 }
 
+// DefaultRequestTimeout limits how long a request waits for its response when the
+// caller's context has no deadline
+const DefaultRequestTimeout = 30 * time.Second
+
 // Client represents an MCP client that manages communication with an MCP server
 type Client struct {
 	server       configuration.MCPServer
-	cmd          *exec.Cmd
-	stdin        io.WriteCloser
-	stdout       io.ReadCloser
-	stderr       io.ReadCloser
+	transport    Transport
 	status       ServerStatus
 	statusMutex  sync.RWMutex
 	requestID    int64
@@ -54,12 +53,21 @@ type Client struct {
 	reqMutex     sync.RWMutex
 	tools        []Tool
 	toolsMutex   sync.RWMutex
+	prompts      []Prompt
+	promptsMutex sync.RWMutex
+	progress     progressHandlers
+	serving      servedRequests
 	capabilities ServerCapabilities
 	serverInfo   ServerInfo
 	ctx          context.Context
 	cancel       context.CancelFunc
 	wg           sync.WaitGroup
 	lastError    error
+	notify       func(notification *JSONRPCNotification) // Receives server notifications, if set
+	serve        requestHandler                          // Answers server requests other than ping, if set
+	onStderr     func(line string)                       // Receives the stderr output of a local server, if set
+	workingDir   string                                  // Directory a local server's relative WorkingDir is resolved against
+	onFailure    func(err error)                         // Called when a running server fails, if set
 }
 
 // NewClient creates a new MCP client for the given server configuration
@@ -74,10 +82,11 @@ func NewClient(ctx context.Context, server configuration.MCPServer) *Client {
 	}
 }
 
-// Start initializes and starts the MCP server process
+// Start connects to the MCP server over its configured transport and initializes the session
 func (c *Client) Start() error {
 	logger := logging.WithComponent("mcp-client")
-	logger.Info("Starting MCP server", "server", c.server.Name, "command", c.server.Command, "args", c.server.Arguments)
+	logger.Info("Starting MCP server", "server", c.server.Name, "transport", c.server.TransportName(),
+		"command", c.server.Command, "args", c.server.Arguments, "url", c.server.URL)
 
 	c.statusMutex.Lock()
 	defer c.statusMutex.Unlock()
@@ -91,58 +100,30 @@ func (c *Client) Start() error {
 	c.status = StatusStarting
 	logger.Debug("Server status changed to starting", "server", c.server.Name)
 
-	// Create the command
-	c.cmd = exec.CommandContext(c.ctx, c.server.Command, c.server.Arguments...)
-	logger.Debug("Created command for MCP server", "server", c.server.Name, "command", c.server.Command, "args", c.server.Arguments)
-
-	// Set up pipes
-	stdin, err := c.cmd.StdinPipe()
+	transport, err := newTransport(c.server, c.workingDir, c.onStderr)
 	if err != nil {
 		c.status = StatusError
-		c.lastError = fmt.Errorf("failed to create stdin pipe: %w", err)
-		logger.Error("Failed to create stdin pipe", "server", c.server.Name, "error", err)
+		c.lastError = err
+		logger.Error("Failed to create MCP transport", "server", c.server.Name, "error", err)
 		return c.lastError
 	}
-	c.stdin = stdin
+	c.transport = transport
 
-	stdout, err := c.cmd.StdoutPipe()
-	if err != nil {
+	if err := c.transport.Start(c.ctx, c.handleMessage); err != nil {
 		c.status = StatusError
-		c.lastError = fmt.Errorf("failed to create stdout pipe: %w", err)
-		logger.Error("Failed to create stdout pipe", "server", c.server.Name, "error", err)
+		c.lastError = fmt.Errorf("failed to connect to server: %w", err)
+		logger.Error("Failed to connect to MCP server", "server", c.server.Name, "error", err)
 		return c.lastError
 	}
-	c.stdout = stdout
 
-	stderr, err := c.cmd.StderrPipe()
-	if err != nil {
-		c.status = StatusError
-		c.lastError = fmt.Errorf("failed to create stderr pipe: %w", err)
-		logger.Error("Failed to create stderr pipe", "server", c.server.Name, "error", err)
-		return c.lastError
-	}
-	c.stderr = stderr
-
-	// Start the process
-	logger.Debug("Starting MCP server process", "server", c.server.Name)
-	if err := c.cmd.Start(); err != nil {
-		c.status = StatusError
-		c.lastError = fmt.Errorf("failed to start server process: %w", err)
-		logger.Error("Failed to start MCP server process", "server", c.server.Name, "error", err)
-		return c.lastError
-	}
-	logger.Info("MCP server process started successfully", "server", c.server.Name, "pid", c.cmd.Process.Pid)
-
-	// Start goroutines for handling I/O
-	c.wg.Add(3)
-	go c.handleStdout()
-	go c.handleStderr()
-	go c.monitorProcess()
+	c.wg.Add(1)
+	go c.monitorTransport()
 
 	// Initialize the MCP connection
 	logger.Debug("Initializing MCP connection", "server", c.server.Name)
 	if err := c.initialize(); err != nil {
-		c.Stop()
+		c.cancel()
+		c.transport.Close()
 		c.status = StatusError
 		c.lastError = fmt.Errorf("failed to initialize MCP connection: %w", err)
 		logger.Error("Failed to initialize MCP connection", "server", c.server.Name, "error", err)
@@ -154,7 +135,7 @@ func (c *Client) Start() error {
 	return nil
 }
 
-// Stop terminates the MCP server process and cleans up resources
+// Stop disconnects from the MCP server and cleans up resources
 func (c *Client) Stop() error {
 	logger := logging.WithComponent("mcp-client")
 	logger.Info("Stopping MCP server", "server", c.server.Name)
@@ -173,35 +154,10 @@ func (c *Client) Stop() error {
 	// Cancel the context to signal shutdown
 	c.cancel()
 
-	// Close stdin to signal the server to shutdown gracefully
-	if c.stdin != nil {
-		c.stdin.Close()
-		logger.Debug("Closed stdin for graceful shutdown", "server", c.server.Name)
-	}
-
-	// Wait for the process to exit (with timeout)
-	done := make(chan error, 1)
-	go func() {
-		if c.cmd != nil && c.cmd.Process != nil {
-			done <- c.cmd.Wait()
-		} else {
-			done <- nil
-		}
-	}()
-
-	select {
-	case err := <-done:
-		// Process exited naturally
-		if err != nil {
-			logger.Debug("MCP server process exited with error", "server", c.server.Name, "error", err)
-		} else {
-			logger.Debug("MCP server process exited cleanly", "server", c.server.Name)
-		}
-	case <-time.After(5 * time.Second):
-		// Force kill after timeout
-		logger.Warn("MCP server did not exit gracefully, force killing", "server", c.server.Name)
-		if c.cmd != nil && c.cmd.Process != nil {
-			c.cmd.Process.Kill()
+	// Close the transport, which waits for a local server process to exit
+	if c.transport != nil {
+		if err := c.transport.Close(); err != nil {
+			logger.Debug("Error closing MCP transport", "server", c.server.Name, "error", err)
 		}
 	}
 
@@ -244,6 +200,12 @@ func (c *Client) GetTools() []Tool {
 
 // CallTool invokes a tool on the MCP server
 func (c *Client) CallTool(name string, arguments map[string]any) (*CallToolResult, error) {
+	return c.CallToolContext(context.Background(), name, arguments, nil)
+}
+
+// CallToolContext invokes a tool on the MCP server. Progress reported by the server is
+// passed to onProgress, if set, and cancelling ctx cancels the call on the server.
+func (c *Client) CallToolContext(ctx context.Context, name string, arguments map[string]any, onProgress func(Progress)) (*CallToolResult, error) {
 	logger := logging.WithComponent("mcp-client")
 	logger.Debug("Calling MCP tool", "server", c.server.Name, "tool", name, "arguments", arguments)
 
@@ -257,9 +219,14 @@ func (c *Client) CallTool(name string, arguments map[string]any) (*CallToolResul
 		Name:      name,
 		Arguments: arguments,
 	}
+	if onProgress != nil {
+		token := c.progress.register(onProgress)
+		defer c.progress.unregister(token)
+		req.Meta = &RequestMeta{ProgressToken: token}
+	}
 
 	var result CallToolResult
-	if err := c.sendRequest("tools/call", req, &result); err != nil {
+	if err := c.sendRequestContext(ctx, "tools/call", req, &result); err != nil {
 		logger.Error("Failed to call MCP tool", "server", c.server.Name, "tool", name, "error", err)
 		return nil, fmt.Errorf("failed to call tool %s: %w", name, err)
 	}
@@ -277,12 +244,16 @@ func (c *Client) initialize() error {
 		ProtocolVersion: MCPVersion,
 		Capabilities: ClientCapabilities{
 			Experimental: make(map[string]any),
+			Roots:        &RootsCapability{ListChanged: true},
 		},
 		ClientInfo: ClientInfo{
 			Name:    "gollama-chat",
 			Version: "1.0.0",
 		},
 	}
+	if c.server.SamplingPolicy() != configuration.MCPSamplingNever {
+		initReq.Capabilities.Sampling = &SamplingCapability{}
+	}
 
 	var initResult InitializeResult
 	logger.Debug("Sending initialize request", "server", c.server.Name)
@@ -314,6 +285,13 @@ func (c *Client) initialize() error {
 		return fmt.Errorf("failed to list tools: %w", err)
 	}
 
+	// Prompts are optional, so a failure to list them does not fail initialization
+	if c.SupportsPrompts() {
+		if err := c.refreshPrompts(); err != nil {
+			logger.Warn("Failed to list prompts during initialization", "server", c.server.Name, "error", err)
+		}
+	}
+
 	logger.Info("MCP initialization completed successfully", "server", c.server.Name,
 		"serverName", c.serverInfo.Name, "serverVersion", c.serverInfo.Version,
 		"toolCount", len(c.tools))
@@ -345,7 +323,19 @@ func (c *Client) refreshTools() error {
 
 // sendRequest sends a JSON-RPC request and waits for the response
 func (c *Client) sendRequest(method string, params any, result any) error {
+	return c.sendRequestContext(context.Background(), method, params, result)
+}
+
+// sendRequestContext sends a JSON-RPC request and waits for the response until ctx is
+// done, or for DefaultRequestTimeout when ctx has no deadline. When the wait ends first,
+// the server is told with notifications/cancelled.
+func (c *Client) sendRequestContext(ctx context.Context, method string, params any, result any) error {
 	logger := logging.WithComponent("mcp-client")
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultRequestTimeout)
+		defer cancel()
+	}
 	id := atomic.AddInt64(&c.requestID, 1)
 	logger.Debug("Sending JSON-RPC request", "server", c.server.Name, "method", method, "id", id)
 
@@ -374,14 +364,14 @@ func (c *Client) sendRequest(method string, params any, result any) error {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	logger.Debug("Writing JSON-RPC request to stdin", "server", c.server.Name, "method", method, "data", string(data))
-	if _, err := c.stdin.Write(append(data, '\n')); err != nil {
-		logger.Error("Failed to write JSON-RPC request to stdin", "server", c.server.Name, "method", method, "error", err)
+	logger.Debug("Sending JSON-RPC request to server", "server", c.server.Name, "method", method, "data", string(data))
+	if err := c.transport.Send(data); err != nil {
+		logger.Error("Failed to send JSON-RPC request", "server", c.server.Name, "method", method, "error", err)
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 
 	logger.Debug("Waiting for JSON-RPC response", "server", c.server.Name, "method", method, "id", id)
-	// Wait for response with timeout
+	// Wait for response until the context is done
 	select {
 	case response := <-respChan:
 		logger.Debug("Received response from channel", "server", c.server.Name, "method", method, "id", id)
@@ -407,12 +397,20 @@ func (c *Client) sendRequest(method string, params any, result any) error {
 		}
 		logger.Debug("Successfully received JSON-RPC response", "server", c.server.Name, "method", method, "id", id)
 		return nil
-	case <-time.After(30 * time.Second):
-		logger.Error("JSON-RPC request timeout", "server", c.server.Name, "method", method, "id", id, "timeout", "30s")
-		return fmt.Errorf("request timeout")
+	case <-ctx.Done():
+		c.cancelRequest(id, context.Cause(ctx))
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			logger.Error("JSON-RPC request timeout", "server", c.server.Name, "method", method, "id", id)
+			return fmt.Errorf("request timeout: %w", context.Cause(ctx))
+		}
+		logger.Info("JSON-RPC request cancelled", "server", c.server.Name, "method", method, "id", id)
+		return fmt.Errorf("request cancelled: %w", context.Cause(ctx))
 	case <-c.ctx.Done():
 		logger.Debug("JSON-RPC request cancelled due to client shutdown", "server", c.server.Name, "method", method, "id", id)
 		return fmt.Errorf("client shutting down")
+	case <-c.transport.Done():
+		logger.Error("JSON-RPC connection closed while waiting for response", "server", c.server.Name, "method", method, "id", id)
+		return fmt.Errorf("connection closed")
 	}
 }
 
@@ -423,98 +421,69 @@ func (c *Client) sendNotification(notification *JSONRPCNotification) error {
 		return fmt.Errorf("failed to marshal notification: %w", err)
 	}
 
-	if _, err := c.stdin.Write(append(data, '\n')); err != nil {
+	if err := c.transport.Send(data); err != nil {
 		return fmt.Errorf("failed to send notification: %w", err)
 	}
 
 	return nil
 }
 
-// handleStdout processes messages from the server's stdout
-func (c *Client) handleStdout() {
+// handleMessage processes a message received from the server
+func (c *Client) handleMessage(message []byte) {
 	logger := logging.WithComponent("mcp-client")
-	defer c.wg.Done()
-	scanner := bufio.NewScanner(c.stdout)
-
-	for scanner.Scan() {
-		select {
-		case <-c.ctx.Done():
-			return
-		default:
-		}
-
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-
-		logger.Debug("Received message from MCP server", "server", c.server.Name, "message", string(line))
-
-		msg, err := ParseJSONRPCMessage(line)
-		if err != nil {
-			// Log error but continue processing
-			logger.Error("Failed to parse JSON-RPC message from MCP server", "server", c.server.Name, "error", err, "message", string(line))
-			continue
-		}
 
-		switch m := msg.(type) {
-		case *JSONRPCResponse:
-			logger.Debug("Handling JSON-RPC response", "server", c.server.Name, "id", m.ID)
-			c.handleResponse(m)
-		case *JSONRPCNotification:
-			logger.Debug("Handling JSON-RPC notification", "server", c.server.Name, "method", m.Method)
-			c.handleNotification(m)
-		case *JSONRPCRequest:
-			// We don't expect requests from the server in this implementation
-			// but we could handle them here if needed
-			logger.Debug("Received unexpected JSON-RPC request from MCP server", "server", c.server.Name, "method", m.Method)
-		}
+	select {
+	case <-c.ctx.Done():
+		return
+	default:
 	}
-}
 
-// handleStderr processes error messages from the server
-func (c *Client) handleStderr() {
-	logger := logging.WithComponent("mcp-client")
-	defer c.wg.Done()
-	scanner := bufio.NewScanner(c.stderr)
+	logger.Debug("Received message from MCP server", "server", c.server.Name, "message", string(message))
 
-	for scanner.Scan() {
-		select {
-		case <-c.ctx.Done():
-			return
-		default:
-		}
+	msg, err := ParseJSONRPCMessage(message)
+	if err != nil {
+		// Log error but continue processing
+		logger.Error("Failed to parse JSON-RPC message from MCP server", "server", c.server.Name, "error", err, "message", string(message))
+		return
+	}
 
-		// Log stderr output from MCP server
-		line := scanner.Text()
-		if len(line) > 0 {
-			logger.Warn("MCP server stderr", "server", c.server.Name, "message", line)
-		}
+	switch m := msg.(type) {
+	case *JSONRPCResponse:
+		logger.Debug("Handling JSON-RPC response", "server", c.server.Name, "id", m.ID)
+		c.handleResponse(m)
+	case *JSONRPCNotification:
+		logger.Debug("Handling JSON-RPC notification", "server", c.server.Name, "method", m.Method)
+		c.handleNotification(m)
+	case *JSONRPCRequest:
+		logger.Debug("Handling JSON-RPC request", "server", c.server.Name, "method", m.Method, "id", m.ID)
+		// Answering may wait on the user or on further messages from the server
+		go c.handleRequest(m)
 	}
 }
 
-// monitorProcess monitors the server process and updates status
-func (c *Client) monitorProcess() {
+// monitorTransport marks the server as failed when its connection ends unexpectedly
+func (c *Client) monitorTransport() {
 	logger := logging.WithComponent("mcp-client")
 	defer c.wg.Done()
 
-	if c.cmd == nil {
-		logger.Error("No command to monitor for MCP server", "server", c.server.Name)
+	select {
+	case <-c.transport.Done():
+	case <-c.ctx.Done():
 		return
 	}
 
-	logger.Debug("Starting to monitor MCP server process", "server", c.server.Name, "pid", c.cmd.Process.Pid)
-	err := c.cmd.Wait()
-
-	c.statusMutex.Lock()
-	if c.status == StatusRunning {
-		c.status = StatusError
-		c.lastError = fmt.Errorf("server process exited unexpectedly: %v", err)
-		logger.Error("MCP server process exited unexpectedly", "server", c.server.Name, "error", err)
-	} else {
-		logger.Debug("MCP server process exited as expected", "server", c.server.Name, "status", c.status.String())
+	err := c.transport.Err()
+	if err == nil {
+		err = fmt.Errorf("connection to server closed")
+	}
+	if !c.markFailed(err) {
+		logger.Debug("MCP server connection ended", "server", c.server.Name, "status", c.GetStatus().String())
+		return
+	}
+	logger.Error("MCP server connection ended unexpectedly", "server", c.server.Name, "error", err)
+	if c.onFailure != nil {
+		c.onFailure(err)
 	}
-	c.statusMutex.Unlock()
 }
 
 // handleResponse routes responses to the appropriate waiting request
@@ -571,9 +540,48 @@ func normalizeID(id any) any {
 	}
 }
 
-// handleNotification processes notifications from the server
+// handleNotification handles notifications from the server, passing most to the notify callback
 func (c *Client) handleNotification(notification *JSONRPCNotification) {
-	// Handle various notification types if needed
-	// For now, we'll just ignore them
-	_ = notification
+	logger := logging.WithComponent("mcp-client")
+
+	switch notification.Method {
+	case "notifications/progress":
+		c.handleProgress(notification)
+		return
+	case "notifications/tools/list_changed":
+		// Refreshing waits on the connection delivering this notification, and the
+		// manager is only told once the new tools are known
+		go func() {
+			if err := c.refreshTools(); err != nil {
+				logger.Warn("Failed to refresh tools after list change", "server", c.server.Name, "error", err)
+				return
+			}
+			c.forwardNotification(notification)
+		}()
+		return
+	case "notifications/cancelled":
+		var params CancelledNotification
+		if err := decodeParams(notification.Params, &params); err != nil {
+			logger.Warn("Invalid cancellation notification", "server", c.server.Name, "error", err)
+			return
+		}
+		c.serving.cancel(params.RequestID)
+		return
+	case "notifications/prompts/list_changed":
+		go func() {
+			if err := c.refreshPrompts(); err != nil {
+				logger.Warn("Failed to refresh prompts after list change", "server", c.server.Name, "error", err)
+			}
+		}()
+		return
+	}
+
+	c.forwardNotification(notification)
+}
+
+// forwardNotification passes a notification to the notify callback, if set
+func (c *Client) forwardNotification(notification *JSONRPCNotification) {
+	if c.notify != nil {
+		c.notify(notification)
+	}
 }
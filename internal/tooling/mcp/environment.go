@@ -0,0 +1,53 @@
+package mcp
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+// baseEnvironment lists the variables local servers receive even when they do not inherit
+// the environment, so commands can still be found and run
+var baseEnvironment = []string{
+	"HOME", "LANG", "LOGNAME", "PATH", "SHELL", "TERM", "TMPDIR", "USER",
+	"APPDATA", "SYSTEMROOT", "TEMP", "TMP", "USERPROFILE", // Windows
+}
+
+// serverEnvironment returns the environment a local server runs with: the inherited
+// environment, or just the base variables, followed by the server's own variables with
+// secret references resolved
+func serverEnvironment(server configuration.MCPServer) ([]string, error) {
+	var env []string
+	if server.InheritsEnv() {
+		env = os.Environ()
+	} else {
+		for _, name := range baseEnvironment {
+			if value, ok := os.LookupEnv(name); ok {
+				env = append(env, name+"="+value)
+			}
+		}
+	}
+
+	// Later entries take precedence over inherited ones with the same name
+	for _, name := range slices.Sorted(maps.Keys(server.Env)) {
+		value, err := configuration.ResolveSecret(server.Env[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve environment variable %s: %w", name, err)
+		}
+		env = append(env, name+"="+value)
+	}
+	return env, nil
+}
+
+// serverDirectory returns the directory a local server runs in, resolving a relative
+// WorkingDir against workingDir. It is empty when the server runs in the current directory.
+func serverDirectory(server configuration.MCPServer, workingDir string) string {
+	if server.WorkingDir == "" || filepath.IsAbs(server.WorkingDir) {
+		return server.WorkingDir
+	}
+	return filepath.Join(workingDir, server.WorkingDir)
+}
@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+// echo calls the helper server's echo tool and returns its text
+func echo(t *testing.T, manager *Manager, text string) string {
+	t.Helper()
+	result, err := manager.CallTool("fake", "echo", map[string]any{"text": text})
+	if err != nil {
+		t.Fatalf("CallTool(%q) failed: %v", text, err)
+	}
+	return result.Content[0].Text
+}
+
+func TestManager_ServerEnvironment(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "api-key")
+	if err := os.WriteFile(keyFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MCP_TEST_TOKEN", "from-env")
+	t.Setenv("MCP_TEST_UNRELATED", "inherited")
+
+	inherit := false
+	manager := startHelperManager(t, func(server *configuration.MCPServer) {
+		server.InheritEnv = &inherit
+		server.WorkingDir = dir
+		server.Env = map[string]string{
+			"GOLLAMA_MCP_HELPER": "1",
+			"TOKEN":              "${env:MCP_TEST_TOKEN}",
+			"KEY":                "${file:" + keyFile + "}",
+			"PLAIN":              "value",
+		}
+	})
+
+	for name, want := range map[string]string{
+		"TOKEN":              "from-env",
+		"KEY":                "from-file",
+		"PLAIN":              "value",
+		"MCP_TEST_UNRELATED": "",
+		"PATH":               os.Getenv("PATH"),
+	} {
+		if got := echo(t, manager, "getenv:"+name); got != want {
+			t.Errorf("Expected %s to be %q, got %q", name, want, got)
+		}
+	}
+	if cwd := echo(t, manager, "cwd"); cwd != dir {
+		t.Errorf("Expected the server to run in %s, got %s", dir, cwd)
+	}
+}
+
+func TestManager_ServerInheritsEnvironment(t *testing.T) {
+	t.Setenv("MCP_TEST_UNRELATED", "inherited")
+	manager := startHelperManager(t, func(server *configuration.MCPServer) {
+		server.Env = map[string]string{"MCP_TEST_UNRELATED": "overridden"}
+	})
+
+	if got := echo(t, manager, "getenv:MCP_TEST_UNRELATED"); got != "overridden" {
+		t.Errorf("Expected the configured value to take precedence, got %q", got)
+	}
+	if got := echo(t, manager, "getenv:GOLLAMA_MCP_HELPER"); got != "1" {
+		t.Errorf("Expected the environment to be inherited, got %q", got)
+	}
+}
+
+func TestClient_StartFailsOnMissingSecret(t *testing.T) {
+	client := NewClient(t.Context(), configuration.MCPServer{
+		Name:    "secret",
+		Command: "true",
+		Env:     map[string]string{"TOKEN": "${env:MCP_TEST_MISSING_SECRET}"},
+		Enabled: true,
+	})
+
+	err := client.Start()
+	if err == nil {
+		client.Stop()
+		t.Fatal("Expected Start to fail when a secret cannot be resolved")
+	}
+	if !strings.Contains(err.Error(), "TOKEN") || !strings.Contains(err.Error(), "MCP_TEST_MISSING_SECRET is not set") {
+		t.Errorf("Expected the error to name the variable and the secret, got %v", err)
+	}
+}
+
+func TestServerDirectory(t *testing.T) {
+	tests := []struct {
+		workingDir string
+		want       string
+	}{
+		{"", ""},
+		{"/srv/tools", "/srv/tools"},
+		{"tools", filepath.Join("/home/user/project", "tools")},
+	}
+	for _, tt := range tests {
+		server := configuration.MCPServer{Name: "dir", WorkingDir: tt.workingDir}
+		if got := serverDirectory(server, "/home/user/project"); got != tt.want {
+			t.Errorf("serverDirectory(%q) = %q, want %q", tt.workingDir, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,464 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+	"github.com/kevensen/gollama-chat/internal/logging"
+)
+
+const (
+	// sessionHeader carries the session assigned by a Streamable HTTP server
+	sessionHeader = "Mcp-Session-Id"
+	// responseHeaderTimeout bounds how long a server may take to start answering a request
+	responseHeaderTimeout = 30 * time.Second
+	// endpointTimeout bounds how long an SSE server may take to announce its message endpoint
+	endpointTimeout = 10 * time.Second
+)
+
+// newHTTPClient returns a client suitable for long-lived event streams
+func newHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = responseHeaderTimeout
+	return &http.Client{Transport: transport}
+}
+
+// resolveHeaders returns a server's headers with secret references resolved
+func resolveHeaders(server configuration.MCPServer) (map[string]string, error) {
+	headers := make(map[string]string, len(server.Headers))
+	for name, value := range server.Headers {
+		resolved, err := configuration.ResolveSecrets(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve header %s: %w", name, err)
+		}
+		headers[name] = resolved
+	}
+	return headers, nil
+}
+
+// setHeaders applies the resolved headers to a request
+func setHeaders(req *http.Request, headers map[string]string) {
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+}
+
+// responseError describes an unsuccessful HTTP response, including the start of its body
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if message := strings.TrimSpace(string(body)); message != "" {
+		return fmt.Errorf("server returned %s: %s", resp.Status, message)
+	}
+	return fmt.Errorf("server returned %s", resp.Status)
+}
+
+// mediaType returns the media type of a response without parameters
+func mediaType(resp *http.Response) string {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType
+}
+
+// readSSE parses a text/event-stream, calling handle for each event that carries data
+func readSSE(r io.Reader, handle func(event, data string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+
+	var event string
+	var data strings.Builder
+	hasData := false
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			// A blank line dispatches the pending event
+			if hasData {
+				handle(event, data.String())
+			}
+			event = ""
+			data.Reset()
+			hasData = false
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // Comment, often used as a keep-alive
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		}
+	}
+
+	return scanner.Err()
+}
+
+// httpTransport implements the Streamable HTTP transport: every message is POSTed to
+// the server URL, which answers with JSON or with an event stream of messages
+type httpTransport struct {
+	connection
+	server       configuration.MCPServer
+	headers      map[string]string
+	client       *http.Client
+	handle       func(message []byte)
+	ctx          context.Context
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+	sessionMutex sync.RWMutex
+	sessionID    string
+	listening    atomic.Bool
+}
+
+func newHTTPTransport(server configuration.MCPServer) *httpTransport {
+	return &httpTransport{
+		connection: newConnection(),
+		server:     server,
+		client:     newHTTPClient(),
+	}
+}
+
+// Start prepares the transport; the connection is made by the first message sent
+func (t *httpTransport) Start(ctx context.Context, handle func(message []byte)) error {
+	if _, err := url.Parse(t.server.URL); err != nil {
+		return fmt.Errorf("invalid server URL %s: %w", t.server.URL, err)
+	}
+	headers, err := resolveHeaders(t.server)
+	if err != nil {
+		return err
+	}
+	t.headers = headers
+	t.ctx, t.cancel = context.WithCancel(ctx)
+	t.handle = handle
+	return nil
+}
+
+// Send POSTs a message and reads any messages returned in the response in the background
+func (t *httpTransport) Send(message []byte) error {
+	logger := logging.WithComponent("mcp-client")
+
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodPost, t.server.URL, bytes.NewReader(message))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	t.setRequestHeaders(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	if sessionID := resp.Header.Get(sessionHeader); sessionID != "" {
+		t.sessionMutex.Lock()
+		t.sessionID = sessionID
+		t.sessionMutex.Unlock()
+	}
+
+	if resp.StatusCode == http.StatusNotFound && t.session() != "" {
+		resp.Body.Close()
+		err := fmt.Errorf("session expired")
+		t.end(err)
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return responseError(resp)
+	}
+	if resp.StatusCode == http.StatusAccepted {
+		// Notifications and responses are acknowledged without a body
+		resp.Body.Close()
+		return nil
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer resp.Body.Close()
+
+		var err error
+		if mediaType(resp) == "text/event-stream" {
+			err = readSSE(resp.Body, t.handleEvent)
+		} else {
+			err = t.readJSON(resp.Body)
+		}
+		if err != nil && t.ctx.Err() == nil {
+			logger.Error("Failed to read MCP server response", "server", t.server.Name, "error", err)
+		}
+	}()
+
+	t.listen()
+	return nil
+}
+
+// Close ends the session on the server and stops reading responses
+func (t *httpTransport) Close() error {
+	logger := logging.WithComponent("mcp-client")
+
+	if t.cancel == nil {
+		t.end(nil)
+		return nil
+	}
+	t.cancel()
+
+	if sessionID := t.session(); sessionID != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.server.URL, nil)
+		if err == nil {
+			t.setRequestHeaders(req)
+			if resp, err := t.client.Do(req); err != nil {
+				logger.Debug("Failed to end MCP session", "server", t.server.Name, "error", err)
+			} else {
+				resp.Body.Close()
+			}
+		}
+	}
+
+	t.wg.Wait()
+	t.end(nil)
+	return nil
+}
+
+// session returns the session ID assigned by the server, if any
+func (t *httpTransport) session() string {
+	t.sessionMutex.RLock()
+	defer t.sessionMutex.RUnlock()
+	return t.sessionID
+}
+
+// setRequestHeaders adds the configured headers and the current session to a request
+func (t *httpTransport) setRequestHeaders(req *http.Request) {
+	setHeaders(req, t.headers)
+	if sessionID := t.session(); sessionID != "" {
+		req.Header.Set(sessionHeader, sessionID)
+	}
+}
+
+// handleEvent passes the data of message events to the client
+func (t *httpTransport) handleEvent(event, data string) {
+	if event == "" || event == "message" {
+		t.handle([]byte(data))
+	}
+}
+
+// readJSON reads a single message or a batch of messages from a JSON response body
+func (t *httpTransport) readJSON(body io.Reader) error {
+	data, err := io.ReadAll(io.LimitReader(body, maxMessageSize))
+	if err != nil {
+		return err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+
+	if data[0] != '[' {
+		t.handle(data)
+		return nil
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return fmt.Errorf("failed to parse message batch: %w", err)
+	}
+	for _, message := range batch {
+		t.handle(message)
+	}
+	return nil
+}
+
+// listen opens the optional event stream for server initiated messages. It is
+// attempted once, after the first successful request has established a session.
+func (t *httpTransport) listen() {
+	if !t.listening.CompareAndSwap(false, true) {
+		return
+	}
+
+	logger := logging.WithComponent("mcp-client")
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, t.server.URL, nil)
+		if err != nil {
+			return
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		t.setRequestHeaders(req)
+
+		resp, err := t.client.Do(req)
+		if err != nil {
+			logger.Debug("MCP server event stream unavailable", "server", t.server.Name, "error", err)
+			return
+		}
+		defer resp.Body.Close()
+
+		// Servers without server initiated messages answer 405 Method Not Allowed
+		if resp.StatusCode != http.StatusOK || mediaType(resp) != "text/event-stream" {
+			logger.Debug("MCP server does not offer an event stream", "server", t.server.Name, "status", resp.Status)
+			return
+		}
+
+		if err := readSSE(resp.Body, t.handleEvent); err != nil && t.ctx.Err() == nil {
+			logger.Debug("MCP server event stream ended", "server", t.server.Name, "error", err)
+		}
+	}()
+}
+
+// sseTransport implements the legacy HTTP+SSE transport: the server sends messages
+// over a long-lived event stream and receives them at an endpoint it announces
+type sseTransport struct {
+	connection
+	server   configuration.MCPServer
+	headers  map[string]string
+	client   *http.Client
+	cancel   context.CancelFunc
+	ctx      context.Context
+	wg       sync.WaitGroup
+	endpoint string
+	closing  atomic.Bool
+}
+
+func newSSETransport(server configuration.MCPServer) *sseTransport {
+	return &sseTransport{
+		connection: newConnection(),
+		server:     server,
+		client:     newHTTPClient(),
+	}
+}
+
+// Start opens the event stream and waits for the server to announce its endpoint
+func (t *sseTransport) Start(ctx context.Context, handle func(message []byte)) error {
+	logger := logging.WithComponent("mcp-client")
+
+	baseURL, err := url.Parse(t.server.URL)
+	if err != nil {
+		return fmt.Errorf("invalid server URL %s: %w", t.server.URL, err)
+	}
+	t.headers, err = resolveHeaders(t.server)
+	if err != nil {
+		return err
+	}
+
+	t.ctx, t.cancel = context.WithCancel(ctx)
+
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, t.server.URL, nil)
+	if err != nil {
+		t.cancel()
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	setHeaders(req, t.headers)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		t.cancel()
+		return fmt.Errorf("failed to connect to event stream: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		t.cancel()
+		return responseError(resp)
+	}
+
+	endpoints := make(chan string, 1)
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer resp.Body.Close()
+
+		err := readSSE(resp.Body, func(event, data string) {
+			switch event {
+			case "endpoint":
+				endpoint, err := baseURL.Parse(strings.TrimSpace(data))
+				if err != nil {
+					logger.Error("MCP server announced an invalid endpoint", "server", t.server.Name, "endpoint", data)
+					return
+				}
+				select {
+				case endpoints <- endpoint.String():
+				default:
+				}
+			case "", "message":
+				handle([]byte(data))
+			}
+		})
+
+		if t.closing.Load() {
+			t.end(nil)
+			return
+		}
+		if err == nil {
+			err = io.EOF
+		}
+		t.end(fmt.Errorf("event stream closed: %w", err))
+	}()
+
+	select {
+	case endpoint := <-endpoints:
+		t.endpoint = endpoint
+		logger.Debug("MCP server announced message endpoint", "server", t.server.Name, "endpoint", endpoint)
+		return nil
+	case <-t.Done():
+		return fmt.Errorf("event stream closed before the server announced its endpoint: %w", t.Err())
+	case <-time.After(endpointTimeout):
+		t.Close()
+		return fmt.Errorf("server did not announce a message endpoint within %s", endpointTimeout)
+	}
+}
+
+// Send POSTs a message to the endpoint announced by the server
+func (t *sseTransport) Send(message []byte) error {
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodPost, t.endpoint, bytes.NewReader(message))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setHeaders(req, t.headers)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return responseError(resp)
+	}
+	// Replies arrive on the event stream
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Close disconnects from the event stream
+func (t *sseTransport) Close() error {
+	t.closing.Store(true)
+	if t.cancel != nil {
+		t.cancel()
+	}
+	t.wg.Wait()
+	t.end(nil)
+	return nil
+}
@@ -3,6 +3,7 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
@@ -12,9 +13,17 @@ import (
 
 // Manager handles multiple MCP server clients
 type Manager struct {
-	clients    map[string]*Client
-	clientsMux sync.RWMutex
-	config     *configuration.Config
+	clients      map[string]*Client
+	clientsMux   sync.RWMutex
+	config       *configuration.Config
+	attachments  attachments
+	calls        toolCalls
+	logs         serverLogs
+	toolsChanged func() // Called after a server's tool list has changed
+	handlerMutex sync.RWMutex
+	sampling     sampling
+	workingDir   workingDirectory
+	supervisor   supervisor
 }
 
 // NewManager creates a new MCP manager
@@ -23,8 +32,9 @@ func NewManager(config *configuration.Config) *Manager {
 	logger.Debug("Creating new MCP manager")
 
 	return &Manager{
-		clients: make(map[string]*Client),
-		config:  config,
+		clients:    make(map[string]*Client),
+		config:     config,
+		supervisor: supervisor{policy: defaultSupervisorPolicy},
 	}
 }
 
@@ -33,17 +43,19 @@ func (m *Manager) StartEnabledServers(ctx context.Context) error {
 	logger := logging.WithComponent("mcp-manager")
 	logger.Info("Starting enabled MCP servers")
 
+	m.startSupervisor(ctx)
+
 	m.clientsMux.Lock()
 	defer m.clientsMux.Unlock()
 
-	enabledServers := m.config.GetEnabledMCPServers()
+	enabledServers := m.enabledServers(m.config)
 	logger.Debug("Found enabled servers", "count", len(enabledServers))
 
 	var errors []error
 
 	for _, server := range enabledServers {
-		logger.Debug("Starting MCP server", "name", server.Name, "command", server.Command, "args", server.Arguments, "enabled", server.Enabled)
-		client := NewClient(ctx, server)
+		logger.Debug("Starting MCP server", "name", server.Name, "transport", server.TransportName(), "command", server.Command, "args", server.Arguments, "url", server.URL, "enabled", server.Enabled)
+		client := m.newClient(ctx, server)
 		m.clients[server.Name] = client
 
 		if err := client.Start(); err != nil {
@@ -72,6 +84,7 @@ func (m *Manager) StopAllServers() error {
 	var errors []error
 
 	for name, client := range m.clients {
+		m.cancelRestart(name)
 		if err := client.Stop(); err != nil {
 			errors = append(errors, fmt.Errorf("failed to stop server %s: %w", name, err))
 		}
@@ -236,6 +249,11 @@ func (m *Manager) GetAllTools() map[string][]Tool {
 
 // CallTool calls a tool on a specific server
 func (m *Manager) CallTool(serverName, toolName string, arguments map[string]any) (*CallToolResult, error) {
+	return m.CallToolContext(context.Background(), serverName, toolName, arguments)
+}
+
+// runningClient returns the client of a server that is running
+func (m *Manager) runningClient(serverName string) (*Client, error) {
 	m.clientsMux.RLock()
 	client, exists := m.clients[serverName]
 	m.clientsMux.RUnlock()
@@ -248,7 +266,50 @@ func (m *Manager) CallTool(serverName, toolName string, arguments map[string]any
 		return nil, fmt.Errorf("server %s is not running (status: %s)", serverName, client.GetStatus())
 	}
 
-	return client.CallTool(toolName, arguments)
+	return client, nil
+}
+
+// enabledServers returns the enabled servers of a configuration that apply in the working
+// directory, leaving out the servers of other projects
+func (m *Manager) enabledServers(config *configuration.Config) []configuration.MCPServer {
+	dir, err := m.WorkingDirectory()
+	if err != nil {
+		logging.WithComponent("mcp-manager").Warn("Not starting project MCP servers", "error", err)
+	}
+
+	var servers []configuration.MCPServer
+	for _, server := range config.GetEnabledMCPServers() {
+		if server.AppliesIn(dir) {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// newClient creates a client whose notifications, requests and failures are handled by the
+// manager. A local server's relative working directory is resolved against the manager's.
+func (m *Manager) newClient(ctx context.Context, server configuration.MCPServer) *Client {
+	client := NewClient(ctx, server)
+	client.notify = func(notification *JSONRPCNotification) {
+		m.handleNotification(server.Name, notification)
+	}
+	client.serve = func(ctx context.Context, method string, params any) (any, error) {
+		return m.serveRequest(ctx, server, method, params)
+	}
+	client.onStderr = func(line string) {
+		m.addStderr(server.Name, line)
+	}
+	client.onFailure = func(err error) {
+		m.serverFailed(server, client, err)
+	}
+	if dir, err := m.WorkingDirectory(); err == nil {
+		client.workingDir = dir
+	}
+
+	m.supervisor.mutex.Lock()
+	m.serverHealth(server.Name).GaveUp = false
+	m.supervisor.mutex.Unlock()
+	return client
 }
 
 // RefreshTools refreshes tools for all running servers
@@ -290,6 +351,7 @@ func (m *Manager) RefreshTools() error {
 // This will start newly enabled servers and stop disabled ones
 func (m *Manager) UpdateConfiguration(ctx context.Context, config *configuration.Config) error {
 	m.config = config
+	m.startSupervisor(ctx)
 
 	// Minimize critical section - only access shared data under lock
 	var toStop []*Client
@@ -305,15 +367,18 @@ func (m *Manager) UpdateConfiguration(ctx context.Context, config *configuration
 
 	// Get enabled servers from new config
 	enabledServers := make(map[string]configuration.MCPServer)
-	for _, server := range config.GetEnabledMCPServers() {
+	for _, server := range m.enabledServers(config) {
 		enabledServers[server.Name] = server
 	}
 
-	// Collect servers to stop (but don't stop them while holding the lock)
+	// Collect servers to stop (but don't stop them while holding the lock).
+	// Servers whose settings changed are stopped here and started again below.
 	for name, client := range m.clients {
-		if _, stillEnabled := enabledServers[name]; !stillEnabled {
+		server, stillEnabled := enabledServers[name]
+		if !stillEnabled || !reflect.DeepEqual(server, client.server) {
 			toStop = append(toStop, client)
 			delete(m.clients, name)
+			delete(currentServers, name)
 		}
 	}
 
@@ -328,6 +393,7 @@ func (m *Manager) UpdateConfiguration(ctx context.Context, config *configuration
 
 	// Stop servers outside of the critical section
 	for _, client := range toStop {
+		m.cancelRestart(client.server.Name)
 		go func(c *Client) {
 			c.Stop() // This has its own timeout handling
 		}(client)
@@ -336,7 +402,7 @@ func (m *Manager) UpdateConfiguration(ctx context.Context, config *configuration
 	// Start newly enabled servers (also outside critical section)
 	var errors []error
 	for _, server := range toStart {
-		client := NewClient(ctx, server)
+		client := m.newClient(ctx, server)
 
 		// Add to clients map under lock
 		m.clientsMux.Lock()
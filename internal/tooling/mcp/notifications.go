@@ -0,0 +1,293 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kevensen/gollama-chat/internal/logging"
+)
+
+// maxServerLogEntries bounds the log messages kept for each server
+const maxServerLogEntries = 200
+
+// errToolCallCancelled is the cause of tool calls cancelled with CancelToolCalls
+var errToolCallCancelled = errors.New("cancelled by user")
+
+// Progress is the progress of a request reported by the server. Total is zero when unknown.
+type Progress struct {
+	Progress float64
+	Total    float64
+	Message  string
+}
+
+// progressHandlers routes progress notifications to the requests that asked for them
+type progressHandlers struct {
+	mutex    sync.Mutex
+	next     int64
+	handlers map[string]func(Progress)
+}
+
+// register returns a new progress token whose notifications are passed to handle
+func (p *progressHandlers) register(handle func(Progress)) string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.handlers == nil {
+		p.handlers = make(map[string]func(Progress))
+	}
+	p.next++
+	token := fmt.Sprintf("progress-%d", p.next)
+	p.handlers[token] = handle
+	return token
+}
+
+// unregister stops passing notifications for a token
+func (p *progressHandlers) unregister(token string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.handlers, token)
+}
+
+// handler returns the handler registered for a token
+func (p *progressHandlers) handler(token any) (func(Progress), bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	handle, ok := p.handlers[fmt.Sprint(token)]
+	return handle, ok
+}
+
+// handleProgress passes a progress notification to the request that asked for it
+func (c *Client) handleProgress(notification *JSONRPCNotification) {
+	logger := logging.WithComponent("mcp-client")
+
+	var params ProgressNotification
+	if err := decodeParams(notification.Params, &params); err != nil {
+		logger.Warn("Invalid progress notification", "server", c.server.Name, "error", err)
+		return
+	}
+
+	handle, ok := c.progress.handler(params.ProgressToken)
+	if !ok {
+		logger.Debug("Progress notification for unknown token", "server", c.server.Name, "token", params.ProgressToken)
+		return
+	}
+	handle(Progress{Progress: params.Progress, Total: params.Total, Message: params.Message})
+}
+
+// cancelRequest tells the server that the client is no longer waiting for a request
+func (c *Client) cancelRequest(id int64, cause error) {
+	logger := logging.WithComponent("mcp-client")
+
+	reason := ""
+	if cause != nil {
+		reason = cause.Error()
+	}
+	notification := JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params:  CancelledNotification{RequestID: id, Reason: reason},
+	}
+	if err := c.sendNotification(&notification); err != nil {
+		logger.Warn("Failed to send cancellation", "server", c.server.Name, "id", id, "error", err)
+	}
+}
+
+// ToolCall describes an MCP tool call that is running
+type ToolCall struct {
+	Server   string
+	Tool     string
+	Started  time.Time
+	Progress Progress
+}
+
+// toolCalls tracks the running tool calls so they can be shown and cancelled
+type toolCalls struct {
+	mutex  sync.Mutex
+	next   int
+	active map[int]*activeToolCall
+}
+
+type activeToolCall struct {
+	call   ToolCall
+	cancel context.CancelCauseFunc
+}
+
+// ServerLogEntry is a log message sent by a server with notifications/message
+type ServerLogEntry struct {
+	Time    time.Time
+	Level   string
+	Logger  string
+	Message string
+}
+
+// serverLogs holds the most recent log messages of each server
+type serverLogs struct {
+	mutex   sync.Mutex
+	entries map[string][]ServerLogEntry
+}
+
+// CallToolContext calls a tool on a running server. The call is listed by ActiveToolCalls
+// with the latest progress reported by the server until it finishes, and is cancelled
+// when ctx is or by CancelToolCalls.
+func (m *Manager) CallToolContext(ctx context.Context, serverName, toolName string, arguments map[string]any) (*CallToolResult, error) {
+	client, err := m.runningClient(serverName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	active := &activeToolCall{
+		call:   ToolCall{Server: serverName, Tool: toolName, Started: time.Now()},
+		cancel: cancel,
+	}
+
+	m.calls.mutex.Lock()
+	if m.calls.active == nil {
+		m.calls.active = make(map[int]*activeToolCall)
+	}
+	m.calls.next++
+	id := m.calls.next
+	m.calls.active[id] = active
+	m.calls.mutex.Unlock()
+
+	defer func() {
+		m.calls.mutex.Lock()
+		delete(m.calls.active, id)
+		m.calls.mutex.Unlock()
+	}()
+
+	return client.CallToolContext(ctx, toolName, arguments, func(progress Progress) {
+		m.calls.mutex.Lock()
+		active.call.Progress = progress
+		m.calls.mutex.Unlock()
+	})
+}
+
+// ActiveToolCalls returns the running tool calls, oldest first
+func (m *Manager) ActiveToolCalls() []ToolCall {
+	m.calls.mutex.Lock()
+	defer m.calls.mutex.Unlock()
+
+	calls := make([]ToolCall, 0, len(m.calls.active))
+	for _, active := range m.calls.active {
+		calls = append(calls, active.call)
+	}
+	slices.SortFunc(calls, func(a, b ToolCall) int {
+		return a.Started.Compare(b.Started)
+	})
+	return calls
+}
+
+// CancelToolCalls cancels all running tool calls and returns how many there were
+func (m *Manager) CancelToolCalls() int {
+	logger := logging.WithComponent("mcp-manager")
+
+	m.calls.mutex.Lock()
+	defer m.calls.mutex.Unlock()
+
+	for _, active := range m.calls.active {
+		logger.Info("Cancelling MCP tool call", "server", active.call.Server, "tool", active.call.Tool)
+		active.cancel(errToolCallCancelled)
+	}
+	return len(m.calls.active)
+}
+
+// ServerLogs returns the most recent log messages sent by a server, oldest first
+func (m *Manager) ServerLogs(serverName string) []ServerLogEntry {
+	m.logs.mutex.Lock()
+	defer m.logs.mutex.Unlock()
+	return slices.Clone(m.logs.entries[serverName])
+}
+
+// SetToolsChangedHandler sets a function called after a server's tool list has changed
+func (m *Manager) SetToolsChangedHandler(handler func()) {
+	m.handlerMutex.Lock()
+	defer m.handlerMutex.Unlock()
+	m.toolsChanged = handler
+}
+
+// handleNotification reacts to notifications from a server's client
+func (m *Manager) handleNotification(serverName string, notification *JSONRPCNotification) {
+	logger := logging.WithComponent("mcp-manager")
+
+	switch notification.Method {
+	case "notifications/resources/updated":
+		var params ResourceUpdatedNotification
+		if err := decodeParams(notification.Params, &params); err != nil {
+			logger.Warn("Invalid resource update notification", "server", serverName, "error", err)
+			return
+		}
+		// Reading the resource waits on the connection delivering this notification
+		go m.refreshAttachment(serverName, params.URI)
+
+	case "notifications/tools/list_changed":
+		logger.Info("MCP server tools changed", "server", serverName)
+		m.handlerMutex.RLock()
+		toolsChanged := m.toolsChanged
+		m.handlerMutex.RUnlock()
+		if toolsChanged != nil {
+			go toolsChanged()
+		}
+
+	case "notifications/message":
+		var params LoggingMessageNotification
+		if err := decodeParams(notification.Params, &params); err != nil {
+			logger.Warn("Invalid log message notification", "server", serverName, "error", err)
+			return
+		}
+		m.addServerLog(serverName, params)
+	}
+}
+
+// addServerLog records a server's log message and writes it to the application log
+func (m *Manager) addServerLog(serverName string, params LoggingMessageNotification) {
+	entry := ServerLogEntry{
+		Time:    time.Now(),
+		Level:   params.Level,
+		Logger:  params.Logger,
+		Message: logMessageText(params.Data),
+	}
+
+	logger := logging.WithComponent("mcp-server")
+	args := []any{"server", serverName, "logger", entry.Logger, "message", entry.Message}
+	switch entry.Level {
+	case "debug":
+		logger.Debug("MCP server log", args...)
+	case "info", "notice":
+		logger.Info("MCP server log", args...)
+	case "warning":
+		logger.Warn("MCP server log", args...)
+	default:
+		logger.Error("MCP server log", args...)
+	}
+
+	m.logs.mutex.Lock()
+	defer m.logs.mutex.Unlock()
+	if m.logs.entries == nil {
+		m.logs.entries = make(map[string][]ServerLogEntry)
+	}
+	entries := append(m.logs.entries[serverName], entry)
+	if len(entries) > maxServerLogEntries {
+		entries = slices.Clone(entries[len(entries)-maxServerLogEntries:])
+	}
+	m.logs.entries[serverName] = entries
+}
+
+// logMessageText renders the data of a log message, which may be any JSON value
+func logMessageText(data any) string {
+	if text, ok := data.(string); ok {
+		return text
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Sprint(data)
+	}
+	return string(encoded)
+}
@@ -0,0 +1,137 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitFor polls condition until it holds or the test times out
+func waitFor(t *testing.T, description string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", description)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClient_ToolProgressAndLogs(t *testing.T) {
+	manager := startHelperManager(t)
+	client, err := manager.runningClient("fake")
+	if err != nil {
+		t.Fatalf("runningClient failed: %v", err)
+	}
+
+	var reports []Progress
+	_, err = client.CallToolContext(t.Context(), "echo", map[string]any{"text": "progress"}, func(progress Progress) {
+		reports = append(reports, progress)
+	})
+	if err != nil {
+		t.Fatalf("CallToolContext failed: %v", err)
+	}
+
+	expected := []Progress{{Progress: 1, Total: 2, Message: "halfway"}}
+	if !slices.Equal(reports, expected) {
+		t.Errorf("Expected progress %+v, got %+v", expected, reports)
+	}
+
+	waitFor(t, "the server log message", func() bool {
+		logs := manager.ServerLogs("fake")
+		return len(logs) == 1 && logs[0].Message == "working" && logs[0].Level == "info" && logs[0].Logger == "echo"
+	})
+}
+
+func TestManager_CancelToolCalls(t *testing.T) {
+	manager := startHelperManager(t)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := manager.CallToolContext(context.Background(), "fake", "echo", map[string]any{"text": "block"})
+		done <- err
+	}()
+
+	waitFor(t, "the tool call to start", func() bool {
+		calls := manager.ActiveToolCalls()
+		return len(calls) == 1 && calls[0].Server == "fake" && calls[0].Tool == "echo"
+	})
+
+	if cancelled := manager.CancelToolCalls(); cancelled != 1 {
+		t.Errorf("Expected one call to be cancelled, got %d", cancelled)
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errToolCallCancelled) {
+			t.Errorf("Expected the call to fail as cancelled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the cancelled call to return")
+	}
+
+	if calls := manager.ActiveToolCalls(); len(calls) != 0 {
+		t.Errorf("Expected no active calls after cancellation, got %+v", calls)
+	}
+
+	// The helper logs the cancellation notifications it receives
+	waitFor(t, "the server to receive the cancellation", func() bool {
+		logs := manager.ServerLogs("fake")
+		return len(logs) == 1 && strings.HasPrefix(logs[0].Message, "cancelled request ")
+	})
+}
+
+func TestClient_RequestDeadline(t *testing.T) {
+	manager := startHelperManager(t)
+	client, err := manager.runningClient("fake")
+	if err != nil {
+		t.Fatalf("runningClient failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	_, err = client.CallToolContext(ctx, "echo", map[string]any{"text": "block"}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "request timeout") {
+		t.Fatalf("Expected the call to time out, got %v", err)
+	}
+
+	// The server is told about timed out requests too
+	waitFor(t, "the server to receive the cancellation", func() bool {
+		logs := manager.ServerLogs("fake")
+		return len(logs) == 1 && strings.HasPrefix(logs[0].Message, "cancelled request ")
+	})
+}
+
+func TestManager_ToolsListChanged(t *testing.T) {
+	manager := startHelperManager(t)
+
+	changed := make(chan struct{}, 1)
+	manager.SetToolsChangedHandler(func() { changed <- struct{}{} })
+
+	if _, err := manager.CallTool("fake", "echo", map[string]any{"text": "change-tools"}); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the tools changed handler")
+	}
+
+	if tools := manager.GetAllTools()["fake"]; len(tools) != 2 || tools[1].Name != "added" {
+		t.Errorf("Expected the refreshed tool list, got %+v", tools)
+	}
+}
+
+func TestLogMessageText(t *testing.T) {
+	if text := logMessageText("plain"); text != "plain" {
+		t.Errorf("Expected string data unchanged, got %q", text)
+	}
+	if text := logMessageText(map[string]any{"error": "boom"}); text != `{"error":"boom"}` {
+		t.Errorf("Expected structured data as JSON, got %q", text)
+	}
+}
@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/kevensen/gollama-chat/internal/logging"
+)
+
+// SupportsPrompts reports whether the server declared the prompts capability
+func (c *Client) SupportsPrompts() bool {
+	return c.capabilities.Prompts != nil
+}
+
+// GetPrompts returns the prompts offered by the server, as of the last refresh
+func (c *Client) GetPrompts() []Prompt {
+	c.promptsMutex.RLock()
+	defer c.promptsMutex.RUnlock()
+	return append([]Prompt(nil), c.prompts...) // Return a copy
+}
+
+// GetPrompt renders a prompt with the given arguments
+func (c *Client) GetPrompt(name string, arguments map[string]string) (*GetPromptResult, error) {
+	if c.GetStatus() != StatusRunning {
+		return nil, fmt.Errorf("server is not running")
+	}
+	if !c.SupportsPrompts() {
+		return nil, fmt.Errorf("server %s does not provide prompts", c.server.Name)
+	}
+
+	var result GetPromptResult
+	if err := c.sendRequest("prompts/get", GetPromptRequest{Name: name, Arguments: arguments}, &result); err != nil {
+		return nil, fmt.Errorf("failed to get prompt %s: %w", name, err)
+	}
+	return &result, nil
+}
+
+// refreshPrompts fetches the current list of prompts from the server, following pagination
+func (c *Client) refreshPrompts() error {
+	logger := logging.WithComponent("mcp-client")
+
+	var prompts []Prompt
+	cursor := ""
+	for range maxListPages {
+		var result ListPromptsResult
+		if err := c.sendRequest("prompts/list", ListPromptsRequest{Cursor: cursor}, &result); err != nil {
+			return fmt.Errorf("failed to list prompts: %w", err)
+		}
+		prompts = append(prompts, result.Prompts...)
+		if result.NextCursor == "" {
+			break
+		}
+		cursor = result.NextCursor
+	}
+
+	c.promptsMutex.Lock()
+	c.prompts = prompts
+	c.promptsMutex.Unlock()
+
+	logger.Info("Successfully refreshed prompts from MCP server", "server", c.server.Name, "promptCount", len(prompts))
+	return nil
+}
+
+// PromptContentText renders prompt message content as text. Images are summarized
+// and embedded resources are rendered with ResourceText.
+func PromptContentText(content PromptContent) string {
+	switch content.Type {
+	case "image", "audio":
+		size := base64.StdEncoding.DecodedLen(len(content.Data))
+		return fmt.Sprintf("[%s content: %s, about %d bytes]", content.Type, content.MimeType, size)
+	case "resource":
+		if content.Resource == nil {
+			return ""
+		}
+		return ResourceText(&ReadResourceResult{Contents: []ResourceContents{*content.Resource}})
+	default:
+		return content.Text
+	}
+}
+
+// GetAllPrompts returns the prompts of all running servers, keyed by server name
+func (m *Manager) GetAllPrompts() map[string][]Prompt {
+	m.clientsMux.RLock()
+	defer m.clientsMux.RUnlock()
+
+	allPrompts := make(map[string][]Prompt)
+	for serverName, client := range m.clients {
+		if client.GetStatus() != StatusRunning {
+			continue
+		}
+		if prompts := client.GetPrompts(); len(prompts) > 0 {
+			allPrompts[serverName] = prompts
+		}
+	}
+	return allPrompts
+}
+
+// GetPrompt renders a prompt of a running server with the given arguments
+func (m *Manager) GetPrompt(serverName, promptName string, arguments map[string]string) (*GetPromptResult, error) {
+	client, err := m.runningClient(serverName)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetPrompt(promptName, arguments)
+}
@@ -0,0 +1,55 @@
+package mcp
+
+import (
+	"testing"
+)
+
+func TestManager_Prompts(t *testing.T) {
+	manager := startHelperManager(t)
+
+	prompts := manager.GetAllPrompts()["fake"]
+	if len(prompts) != 1 || prompts[0].Name != "review" || len(prompts[0].Arguments) != 2 {
+		t.Fatalf("Expected the review prompt with two arguments, got %+v", prompts)
+	}
+	if !prompts[0].Arguments[0].Required || prompts[0].Arguments[1].Required {
+		t.Errorf("Expected only the first argument to be required, got %+v", prompts[0].Arguments)
+	}
+
+	result, err := manager.GetPrompt("fake", "review", map[string]string{"code": "main.go", "focus": "errors"})
+	if err != nil {
+		t.Fatalf("GetPrompt failed: %v", err)
+	}
+	if len(result.Messages) != 2 {
+		t.Fatalf("Expected two messages, got %+v", result.Messages)
+	}
+	if text := PromptContentText(result.Messages[0].Content); text != `Review main.go focusing on "errors"` {
+		t.Errorf("Unexpected text message %q", text)
+	}
+	if text := PromptContentText(result.Messages[1].Content); text != "Style guide" {
+		t.Errorf("Expected embedded resource text, got %q", text)
+	}
+
+	if _, err := manager.GetPrompt("fake", "review", nil); err == nil {
+		t.Error("Expected an error when a required argument is missing")
+	}
+}
+
+func TestPromptContentText(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  PromptContent
+		expected string
+	}{
+		{"text", PromptContent{Type: "text", Text: "hello"}, "hello"},
+		{"image", PromptContent{Type: "image", Data: "iVBORw0KGgo=", MimeType: "image/png"}, "[image content: image/png, about 9 bytes]"},
+		{"empty resource", PromptContent{Type: "resource"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if text := PromptContentText(tt.content); text != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, text)
+			}
+		})
+	}
+}
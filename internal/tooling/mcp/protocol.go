@@ -35,6 +35,10 @@ type JSONRPCError struct {
 	Data    any    `json:"data,omitempty"`
 }
 
+func (e *JSONRPCError) Error() string {
+	return e.Message
+}
+
 // MCP Protocol messages
 type InitializeRequest struct {
 	ProtocolVersion string             `json:"protocolVersion"`
@@ -45,10 +49,15 @@ type InitializeRequest struct {
 type ClientCapabilities struct {
 	Experimental map[string]any      `json:"experimental,omitempty"`
 	Sampling     *SamplingCapability `json:"sampling,omitempty"`
+	Roots        *RootsCapability    `json:"roots,omitempty"`
 }
 
 type SamplingCapability struct{}
 
+type RootsCapability struct {
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
 type ClientInfo struct {
 	Name    string `json:"name"`
 	Version string `json:"version"`
@@ -69,8 +78,13 @@ type ServerCapabilities struct {
 }
 
 type LoggingCapability struct{}
-type PromptsCapability struct{}
-type ResourcesCapability struct{}
+type PromptsCapability struct {
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+type ResourcesCapability struct {
+	Subscribe   bool `json:"subscribe,omitempty"`
+	ListChanged bool `json:"listChanged,omitempty"`
+}
 type ToolsCapability struct {
 	ListChanged bool `json:"listChanged,omitempty"`
 }
@@ -102,16 +116,202 @@ type ToolSchema struct {
 type CallToolRequest struct {
 	Name      string         `json:"name"`
 	Arguments map[string]any `json:"arguments,omitempty"`
+	Meta      *RequestMeta   `json:"_meta,omitempty"`
+}
+
+// RequestMeta asks the server to report progress of a request under ProgressToken
+type RequestMeta struct {
+	ProgressToken any `json:"progressToken,omitempty"`
 }
 
 type CallToolResult struct {
-	Content []ToolContent `json:"content"`
-	IsError bool          `json:"isError,omitempty"`
+	Content           []ToolContent `json:"content"`
+	StructuredContent any           `json:"structuredContent,omitempty"` // JSON result matching the tool's output schema
+	IsError           bool          `json:"isError,omitempty"`
 }
 
+// ToolContent is an item of a tool result: text, base64 encoded image or audio Data, an
+// embedded Resource, or a link to a resource the server offers
 type ToolContent struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
+	Type        string            `json:"type"` // text, image, audio, resource or resource_link
+	Text        string            `json:"text,omitempty"`
+	Data        string            `json:"data,omitempty"`
+	MimeType    string            `json:"mimeType,omitempty"`
+	Resource    *ResourceContents `json:"resource,omitempty"`
+	URI         string            `json:"uri,omitempty"` // resource_link
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+}
+
+// Resources
+type ListResourcesRequest struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type ListResourcesResult struct {
+	Resources  []Resource `json:"resources"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type ListResourceTemplatesRequest struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type ListResourceTemplatesResult struct {
+	ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
+	NextCursor        string             `json:"nextCursor,omitempty"`
+}
+
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type ReadResourceRequest struct {
+	URI string `json:"uri"`
+}
+
+type ReadResourceResult struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+// ResourceContents holds either Text or base64 encoded Blob data
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+type SubscribeRequest struct {
+	URI string `json:"uri"`
+}
+
+type UnsubscribeRequest struct {
+	URI string `json:"uri"`
+}
+
+type ResourceUpdatedNotification struct {
+	URI string `json:"uri"`
+}
+
+// Prompts
+type ListPromptsRequest struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type ListPromptsResult struct {
+	Prompts    []Prompt `json:"prompts"`
+	NextCursor string   `json:"nextCursor,omitempty"`
+}
+
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+type GetPromptRequest struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+type GetPromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+type PromptMessage struct {
+	Role    string        `json:"role"`
+	Content PromptContent `json:"content"`
+}
+
+// PromptContent is text, base64 encoded image Data or an embedded Resource
+type PromptContent struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Data     string            `json:"data,omitempty"`
+	MimeType string            `json:"mimeType,omitempty"`
+	Resource *ResourceContents `json:"resource,omitempty"`
+}
+
+// Sampling
+type CreateMessageRequest struct {
+	Messages         []SamplingMessage `json:"messages"`
+	ModelPreferences *ModelPreferences `json:"modelPreferences,omitempty"`
+	SystemPrompt     string            `json:"systemPrompt,omitempty"`
+	IncludeContext   string            `json:"includeContext,omitempty"`
+	Temperature      *float64          `json:"temperature,omitempty"`
+	MaxTokens        int               `json:"maxTokens"`
+	StopSequences    []string          `json:"stopSequences,omitempty"`
+	Metadata         map[string]any    `json:"metadata,omitempty"`
+}
+
+type SamplingMessage struct {
+	Role    string        `json:"role"`
+	Content PromptContent `json:"content"`
+}
+
+type ModelPreferences struct {
+	Hints                []ModelHint `json:"hints,omitempty"`
+	CostPriority         float64     `json:"costPriority,omitempty"`
+	SpeedPriority        float64     `json:"speedPriority,omitempty"`
+	IntelligencePriority float64     `json:"intelligencePriority,omitempty"`
+}
+
+type ModelHint struct {
+	Name string `json:"name,omitempty"`
+}
+
+type CreateMessageResult struct {
+	Role       string        `json:"role"`
+	Content    PromptContent `json:"content"`
+	Model      string        `json:"model"`
+	StopReason string        `json:"stopReason,omitempty"`
+}
+
+// Roots
+type ListRootsResult struct {
+	Roots []Root `json:"roots"`
+}
+
+type Root struct {
+	URI  string `json:"uri"`
+	Name string `json:"name,omitempty"`
+}
+
+// Notifications
+type ProgressNotification struct {
+	ProgressToken any     `json:"progressToken"`
+	Progress      float64 `json:"progress"`
+	Total         float64 `json:"total,omitempty"`
+	Message       string  `json:"message,omitempty"`
+}
+
+type CancelledNotification struct {
+	RequestID any    `json:"requestId"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type LoggingMessageNotification struct {
+	Level  string `json:"level"`
+	Logger string `json:"logger,omitempty"`
+	Data   any    `json:"data"`
 }
 
 // Utility functions
@@ -0,0 +1,119 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+	"github.com/kevensen/gollama-chat/internal/logging"
+)
+
+// JSON-RPC error codes used when answering server requests
+const (
+	errorCodeUserRejected   = -1
+	errorCodeMethodNotFound = -32601
+	errorCodeInvalidParams  = -32602
+	errorCodeInternal       = -32603
+)
+
+// requestHandler answers a request sent by the server. Returning a *JSONRPCError sets the
+// error code of the response; other errors are reported as internal errors.
+type requestHandler func(ctx context.Context, method string, params any) (any, error)
+
+// servedRequests tracks the server requests being answered so the server can cancel them
+type servedRequests struct {
+	mutex   sync.Mutex
+	cancels map[any]context.CancelFunc
+}
+
+// add records the cancel function of a request being answered
+func (s *servedRequests) add(id any, cancel context.CancelFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.cancels == nil {
+		s.cancels = make(map[any]context.CancelFunc)
+	}
+	s.cancels[normalizeID(id)] = cancel
+}
+
+// remove forgets a request once it has been answered
+func (s *servedRequests) remove(id any) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.cancels, normalizeID(id))
+}
+
+// cancel stops answering a request
+func (s *servedRequests) cancel(id any) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if cancel, ok := s.cancels[normalizeID(id)]; ok {
+		cancel()
+	}
+}
+
+// handleRequest answers a request from the server. Ping is answered directly and other
+// requests are passed to the serve callback. Requests the server cancels are not answered.
+func (c *Client) handleRequest(request *JSONRPCRequest) {
+	logger := logging.WithComponent("mcp-client")
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+	c.serving.add(request.ID, cancel)
+	defer c.serving.remove(request.ID)
+
+	var result any
+	var err error
+	switch {
+	case request.Method == "ping":
+		result = map[string]any{}
+	case c.serve != nil:
+		result, err = c.serve(ctx, request.Method, request.Params)
+	default:
+		err = &JSONRPCError{Code: errorCodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", request.Method)}
+	}
+
+	if ctx.Err() != nil {
+		logger.Info("Server request cancelled", "server", c.server.Name, "method", request.Method, "id", request.ID)
+		return
+	}
+
+	response := NewJSONRPCResponse(request.ID, result)
+	if err != nil {
+		var rpcErr *JSONRPCError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &JSONRPCError{Code: errorCodeInternal, Message: err.Error()}
+		}
+		logger.Warn("Failed to answer server request", "server", c.server.Name, "method", request.Method, "id", request.ID, "error", rpcErr.Message)
+		response = NewJSONRPCError(request.ID, rpcErr.Code, rpcErr.Message, rpcErr.Data)
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		logger.Error("Failed to marshal response to server request", "server", c.server.Name, "method", request.Method, "error", err)
+		return
+	}
+	if err := c.transport.Send(data); err != nil {
+		logger.Warn("Failed to send response to server request", "server", c.server.Name, "method", request.Method, "error", err)
+	}
+}
+
+// serveRequest answers the requests a server's client does not answer itself
+func (m *Manager) serveRequest(ctx context.Context, server configuration.MCPServer, method string, params any) (any, error) {
+	switch method {
+	case "sampling/createMessage":
+		var request CreateMessageRequest
+		if err := decodeParams(params, &request); err != nil {
+			return nil, &JSONRPCError{Code: errorCodeInvalidParams, Message: fmt.Sprintf("invalid sampling request: %v", err)}
+		}
+		return m.createMessage(ctx, server, &request)
+	case "roots/list":
+		return m.listRoots(server)
+	default:
+		return nil, &JSONRPCError{Code: errorCodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", method)}
+	}
+}
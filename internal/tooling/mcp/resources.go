@@ -0,0 +1,302 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/kevensen/gollama-chat/internal/logging"
+)
+
+// maxListPages bounds pagination so a misbehaving server cannot loop forever
+const maxListPages = 100
+
+// SupportsResources reports whether the server declared the resources capability
+func (c *Client) SupportsResources() bool {
+	return c.capabilities.Resources != nil
+}
+
+// SupportsResourceSubscriptions reports whether the server accepts resources/subscribe
+func (c *Client) SupportsResourceSubscriptions() bool {
+	return c.capabilities.Resources != nil && c.capabilities.Resources.Subscribe
+}
+
+// ListResources returns all resources offered by the server, following pagination
+func (c *Client) ListResources() ([]Resource, error) {
+	if err := c.requireResources(); err != nil {
+		return nil, err
+	}
+
+	var resources []Resource
+	cursor := ""
+	for range maxListPages {
+		var result ListResourcesResult
+		if err := c.sendRequest("resources/list", ListResourcesRequest{Cursor: cursor}, &result); err != nil {
+			return nil, fmt.Errorf("failed to list resources: %w", err)
+		}
+		resources = append(resources, result.Resources...)
+		if result.NextCursor == "" {
+			break
+		}
+		cursor = result.NextCursor
+	}
+	return resources, nil
+}
+
+// ListResourceTemplates returns the parameterized resources offered by the server
+func (c *Client) ListResourceTemplates() ([]ResourceTemplate, error) {
+	if err := c.requireResources(); err != nil {
+		return nil, err
+	}
+
+	var templates []ResourceTemplate
+	cursor := ""
+	for range maxListPages {
+		var result ListResourceTemplatesResult
+		if err := c.sendRequest("resources/templates/list", ListResourceTemplatesRequest{Cursor: cursor}, &result); err != nil {
+			return nil, fmt.Errorf("failed to list resource templates: %w", err)
+		}
+		templates = append(templates, result.ResourceTemplates...)
+		if result.NextCursor == "" {
+			break
+		}
+		cursor = result.NextCursor
+	}
+	return templates, nil
+}
+
+// ReadResource returns the contents of a resource
+func (c *Client) ReadResource(uri string) (*ReadResourceResult, error) {
+	if err := c.requireResources(); err != nil {
+		return nil, err
+	}
+
+	var result ReadResourceResult
+	if err := c.sendRequest("resources/read", ReadResourceRequest{URI: uri}, &result); err != nil {
+		return nil, fmt.Errorf("failed to read resource %s: %w", uri, err)
+	}
+	return &result, nil
+}
+
+// SubscribeResource asks the server to send notifications/resources/updated when a resource changes
+func (c *Client) SubscribeResource(uri string) error {
+	if !c.SupportsResourceSubscriptions() {
+		return fmt.Errorf("server does not support resource subscriptions")
+	}
+	if err := c.sendRequest("resources/subscribe", SubscribeRequest{URI: uri}, nil); err != nil {
+		return fmt.Errorf("failed to subscribe to resource %s: %w", uri, err)
+	}
+	return nil
+}
+
+// UnsubscribeResource cancels a subscription made with SubscribeResource
+func (c *Client) UnsubscribeResource(uri string) error {
+	if !c.SupportsResourceSubscriptions() {
+		return fmt.Errorf("server does not support resource subscriptions")
+	}
+	if err := c.sendRequest("resources/unsubscribe", UnsubscribeRequest{URI: uri}, nil); err != nil {
+		return fmt.Errorf("failed to unsubscribe from resource %s: %w", uri, err)
+	}
+	return nil
+}
+
+// requireResources checks that the server is running and offers resources
+func (c *Client) requireResources() error {
+	if c.GetStatus() != StatusRunning {
+		return fmt.Errorf("server is not running")
+	}
+	if !c.SupportsResources() {
+		return fmt.Errorf("server %s does not provide resources", c.server.Name)
+	}
+	return nil
+}
+
+// ResourceText renders the contents of a resource as text. Binary contents are
+// summarized since they cannot be included in a text prompt.
+func ResourceText(result *ReadResourceResult) string {
+	parts := make([]string, 0, len(result.Contents))
+	for _, contents := range result.Contents {
+		if contents.Blob != "" {
+			size := base64.StdEncoding.DecodedLen(len(contents.Blob))
+			parts = append(parts, fmt.Sprintf("[binary content: %s, about %d bytes]", contents.MimeType, size))
+			continue
+		}
+		parts = append(parts, contents.Text)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Attachment is a resource whose contents are sent as context with the next chat message
+type Attachment struct {
+	Server   string
+	URI      string
+	Name     string
+	MimeType string
+	Text     string
+}
+
+// attachments holds the resources attached to the next chat message
+type attachments struct {
+	mutex sync.Mutex
+	items []Attachment
+}
+
+// ListResources returns the resources offered by a running server
+func (m *Manager) ListResources(serverName string) ([]Resource, error) {
+	client, err := m.runningClient(serverName)
+	if err != nil {
+		return nil, err
+	}
+	return client.ListResources()
+}
+
+// ListResourceTemplates returns the resource templates offered by a running server
+func (m *Manager) ListResourceTemplates(serverName string) ([]ResourceTemplate, error) {
+	client, err := m.runningClient(serverName)
+	if err != nil {
+		return nil, err
+	}
+	return client.ListResourceTemplates()
+}
+
+// ReadResource returns the contents of a resource from a running server
+func (m *Manager) ReadResource(serverName, uri string) (*ReadResourceResult, error) {
+	client, err := m.runningClient(serverName)
+	if err != nil {
+		return nil, err
+	}
+	return client.ReadResource(uri)
+}
+
+// AttachResource reads a resource and attaches it to the next chat message. When the
+// server supports it, the resource is subscribed to so updates refresh the attachment.
+func (m *Manager) AttachResource(serverName string, resource Resource) error {
+	logger := logging.WithComponent("mcp-manager")
+
+	client, err := m.runningClient(serverName)
+	if err != nil {
+		return err
+	}
+
+	result, err := client.ReadResource(resource.URI)
+	if err != nil {
+		return err
+	}
+
+	attachment := Attachment{
+		Server:   serverName,
+		URI:      resource.URI,
+		Name:     resource.Name,
+		MimeType: resource.MimeType,
+		Text:     ResourceText(result),
+	}
+
+	m.attachments.mutex.Lock()
+	replaced := false
+	for i, existing := range m.attachments.items {
+		if existing.Server == serverName && existing.URI == resource.URI {
+			m.attachments.items[i] = attachment
+			replaced = true
+		}
+	}
+	if !replaced {
+		m.attachments.items = append(m.attachments.items, attachment)
+	}
+	m.attachments.mutex.Unlock()
+
+	if !replaced && client.SupportsResourceSubscriptions() {
+		if err := client.SubscribeResource(resource.URI); err != nil {
+			logger.Warn("Failed to subscribe to attached resource", "server", serverName, "uri", resource.URI, "error", err)
+		}
+	}
+
+	logger.Info("Attached MCP resource", "server", serverName, "uri", resource.URI, "length", len(attachment.Text))
+	return nil
+}
+
+// Attachments returns the resources attached to the next chat message
+func (m *Manager) Attachments() []Attachment {
+	m.attachments.mutex.Lock()
+	defer m.attachments.mutex.Unlock()
+	return append([]Attachment(nil), m.attachments.items...)
+}
+
+// TakeAttachments returns the attached resources and detaches them
+func (m *Manager) TakeAttachments() []Attachment {
+	m.attachments.mutex.Lock()
+	taken := m.attachments.items
+	m.attachments.items = nil
+	m.attachments.mutex.Unlock()
+
+	m.unsubscribe(taken)
+	return taken
+}
+
+// ClearAttachments detaches all attached resources
+func (m *Manager) ClearAttachments() {
+	m.TakeAttachments()
+}
+
+// unsubscribe cancels the update subscriptions of detached resources
+func (m *Manager) unsubscribe(detached []Attachment) {
+	logger := logging.WithComponent("mcp-manager")
+
+	for _, attachment := range detached {
+		client, err := m.runningClient(attachment.Server)
+		if err != nil || !client.SupportsResourceSubscriptions() {
+			continue
+		}
+		go func() {
+			if err := client.UnsubscribeResource(attachment.URI); err != nil {
+				logger.Debug("Failed to unsubscribe from resource", "server", attachment.Server, "uri", attachment.URI, "error", err)
+			}
+		}()
+	}
+}
+
+// refreshAttachment re-reads an attached resource after the server reported a change
+func (m *Manager) refreshAttachment(serverName, uri string) {
+	logger := logging.WithComponent("mcp-manager")
+
+	if !m.isAttached(serverName, uri) {
+		return
+	}
+
+	result, err := m.ReadResource(serverName, uri)
+	if err != nil {
+		logger.Warn("Failed to refresh attached resource", "server", serverName, "uri", uri, "error", err)
+		return
+	}
+
+	m.attachments.mutex.Lock()
+	defer m.attachments.mutex.Unlock()
+	for i, attachment := range m.attachments.items {
+		if attachment.Server == serverName && attachment.URI == uri {
+			m.attachments.items[i].Text = ResourceText(result)
+			logger.Info("Refreshed attached MCP resource", "server", serverName, "uri", uri)
+		}
+	}
+}
+
+// isAttached reports whether a resource is currently attached
+func (m *Manager) isAttached(serverName, uri string) bool {
+	m.attachments.mutex.Lock()
+	defer m.attachments.mutex.Unlock()
+	for _, attachment := range m.attachments.items {
+		if attachment.Server == serverName && attachment.URI == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeParams converts generically decoded JSON-RPC params into a typed struct
+func decodeParams(params any, target any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+// startHelperManager starts a manager running the stdio helper server as "fake", with
+// its configuration adjusted by options
+func startHelperManager(t *testing.T, options ...func(server *configuration.MCPServer)) *Manager {
+	t.Helper()
+	t.Setenv("GOLLAMA_MCP_HELPER", "1")
+
+	server := configuration.MCPServer{
+		Name:      "fake",
+		Command:   os.Args[0],
+		Arguments: []string{"-test.run=^TestHelperProcess$"},
+		Enabled:   true,
+	}
+	for _, option := range options {
+		option(&server)
+	}
+
+	config := configuration.DefaultConfig()
+	config.MCPServers = []configuration.MCPServer{server}
+
+	manager := NewManager(config)
+	if err := manager.StartEnabledServers(t.Context()); err != nil {
+		t.Fatalf("StartEnabledServers failed: %v", err)
+	}
+	t.Cleanup(func() { manager.StopAllServers() })
+	return manager
+}
+
+func TestManager_Resources(t *testing.T) {
+	manager := startHelperManager(t)
+
+	resources, err := manager.ListResources("fake")
+	if err != nil {
+		t.Fatalf("ListResources failed: %v", err)
+	}
+	if len(resources) != 2 || resources[0].Name != "notes" || resources[1].Name != "logo" {
+		t.Errorf("Expected resources from both pages, got %+v", resources)
+	}
+
+	templates, err := manager.ListResourceTemplates("fake")
+	if err != nil {
+		t.Fatalf("ListResourceTemplates failed: %v", err)
+	}
+	if len(templates) != 1 || templates[0].URITemplate != "file:///{path}" {
+		t.Errorf("Unexpected templates %+v", templates)
+	}
+
+	result, err := manager.ReadResource("fake", "file:///logo.png")
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	if text := ResourceText(result); !strings.HasPrefix(text, "[binary content: image/png") {
+		t.Errorf("Expected binary contents to be summarized, got %q", text)
+	}
+
+	if _, err := manager.ListResources("missing"); err == nil {
+		t.Error("Expected an error for a server that is not running")
+	}
+}
+
+func TestManager_AttachmentsRefreshOnUpdate(t *testing.T) {
+	manager := startHelperManager(t)
+
+	if err := manager.AttachResource("fake", Resource{URI: "file:///notes.md", Name: "notes"}); err != nil {
+		t.Fatalf("AttachResource failed: %v", err)
+	}
+
+	// The helper reports an update after the subscription, so the attachment is re-read
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		attachments := manager.Attachments()
+		if len(attachments) == 1 && attachments[0].Text == "notes v2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the attachment to be refreshed, got %+v", attachments)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	taken := manager.TakeAttachments()
+	if len(taken) != 1 || taken[0].Server != "fake" || taken[0].Name != "notes" {
+		t.Errorf("Unexpected attachments %+v", taken)
+	}
+	if len(manager.Attachments()) != 0 {
+		t.Error("Expected attachments to be cleared after being taken")
+	}
+}
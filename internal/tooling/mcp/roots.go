@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+	"github.com/kevensen/gollama-chat/internal/logging"
+)
+
+// workingDirectory holds the directory exposed to servers as their root
+type workingDirectory struct {
+	mutex sync.RWMutex
+	dir   string
+}
+
+// SetWorkingDirectory sets the directory exposed to servers without configured roots,
+// which is also the directory relative roots are resolved against and the project whose
+// servers are started. When it changes, running servers are told their roots have changed
+// and project servers are started or stopped.
+func (m *Manager) SetWorkingDirectory(dir string) {
+	logger := logging.WithComponent("mcp-manager")
+
+	m.workingDir.mutex.Lock()
+	previous := m.workingDir.dir
+	m.workingDir.dir = dir
+	m.workingDir.mutex.Unlock()
+
+	if previous == "" || previous == dir {
+		return
+	}
+
+	logger.Info("MCP working directory changed", "previous", previous, "directory", dir)
+
+	m.clientsMux.RLock()
+	for _, client := range m.clients {
+		if client.GetStatus() == StatusRunning {
+			client.notifyRootsChanged()
+		}
+	}
+	m.clientsMux.RUnlock()
+
+	// Start the servers of the new directory's project and stop those of the previous one
+	m.supervisor.mutex.Lock()
+	ctx := m.supervisor.ctx
+	m.supervisor.mutex.Unlock()
+	if ctx == nil || m.config == nil {
+		return
+	}
+	go func() {
+		if err := m.UpdateConfiguration(ctx, m.config); err != nil {
+			logger.Warn("Failed to update MCP servers for the working directory", "directory", dir, "error", err)
+		}
+	}()
+}
+
+// WorkingDirectory returns the directory exposed to servers without configured roots
+func (m *Manager) WorkingDirectory() (string, error) {
+	m.workingDir.mutex.RLock()
+	dir := m.workingDir.dir
+	m.workingDir.mutex.RUnlock()
+
+	if dir != "" {
+		return dir, nil
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	return dir, nil
+}
+
+// listRoots returns the roots of a server: its configured directories, or the working directory
+func (m *Manager) listRoots(server configuration.MCPServer) (*ListRootsResult, error) {
+	workingDir, err := m.WorkingDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := server.Roots
+	if len(dirs) == 0 {
+		dirs = []string{workingDir}
+	}
+
+	result := &ListRootsResult{Roots: make([]Root, 0, len(dirs))}
+	for _, dir := range dirs {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(workingDir, dir)
+		}
+		result.Roots = append(result.Roots, fileRoot(filepath.Clean(dir)))
+	}
+	return result, nil
+}
+
+// fileRoot returns the root for a directory, named after the directory
+func fileRoot(dir string) Root {
+	uri := url.URL{Scheme: "file", Path: filepath.ToSlash(dir)}
+	return Root{URI: uri.String(), Name: filepath.Base(dir)}
+}
+
+// notifyRootsChanged tells the server to list its roots again
+func (c *Client) notifyRootsChanged() {
+	logger := logging.WithComponent("mcp-client")
+
+	notification := JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  "notifications/roots/list_changed",
+	}
+	if err := c.sendNotification(&notification); err != nil {
+		logger.Warn("Failed to send roots change notification", "server", c.server.Name, "error", err)
+	}
+}
@@ -0,0 +1,124 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+func TestManager_ListRoots(t *testing.T) {
+	manager := NewManager(configuration.DefaultConfig())
+	manager.SetWorkingDirectory("/home/user/project")
+
+	tests := []struct {
+		name     string
+		roots    []string
+		expected []Root
+	}{
+		{
+			name:     "working directory by default",
+			expected: []Root{{URI: "file:///home/user/project", Name: "project"}},
+		},
+		{
+			name:  "configured roots",
+			roots: []string{"/srv/docs", "src/../lib"},
+			expected: []Root{
+				{URI: "file:///srv/docs", Name: "docs"},
+				{URI: "file:///home/user/project/lib", Name: "lib"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := manager.listRoots(configuration.MCPServer{Name: "files", Roots: tt.roots})
+			if err != nil {
+				t.Fatalf("listRoots failed: %v", err)
+			}
+			if !reflect.DeepEqual(result.Roots, tt.expected) {
+				t.Errorf("Expected roots %+v, got %+v", tt.expected, result.Roots)
+			}
+		})
+	}
+}
+
+func TestManager_RootsRequestAndChange(t *testing.T) {
+	dir := t.TempDir()
+	manager := NewManager(configuration.DefaultConfig())
+	manager.SetWorkingDirectory(dir)
+
+	client := manager.newClient(t.Context(), configuration.MCPServer{Name: "files"})
+	transport := &recordingTransport{}
+	client.transport = transport
+	client.status = StatusRunning
+	manager.clients["files"] = client
+
+	client.handleMessage([]byte(`{"jsonrpc":"2.0","id":1,"method":"roots/list"}`))
+	waitFor(t, "the roots response", func() bool {
+		return len(transport.messages()) == 1
+	})
+	if response := transport.messages()[0]; !strings.Contains(response, `"uri":"file://`+filepath.ToSlash(dir)+`"`) {
+		t.Errorf("Expected the working directory as root, got %s", response)
+	}
+
+	// Setting the same directory again is not a change
+	manager.SetWorkingDirectory(dir)
+	if len(transport.messages()) != 1 {
+		t.Fatalf("Expected no notification for an unchanged directory, got %v", transport.messages())
+	}
+
+	manager.SetWorkingDirectory(filepath.Join(dir, "sub"))
+	messages := transport.messages()
+	if len(messages) != 2 || messages[1] != `{"jsonrpc":"2.0","method":"notifications/roots/list_changed"}` {
+		t.Errorf("Expected a roots change notification, got %v", messages)
+	}
+}
+
+func TestManager_ProjectServersFollowWorkingDirectory(t *testing.T) {
+	t.Setenv("GOLLAMA_MCP_HELPER", "1")
+	project, other := t.TempDir(), t.TempDir()
+
+	helper := configuration.MCPServer{
+		Command:   os.Args[0],
+		Arguments: []string{"-test.run=^TestHelperProcess$"},
+		Enabled:   true,
+	}
+	global, scoped := helper, helper
+	global.Name = "global"
+	scoped.Name = "scoped"
+	scoped.Project = project
+
+	config := configuration.DefaultConfig()
+	config.MCPServers = []configuration.MCPServer{global, scoped}
+
+	manager := NewManager(config)
+	manager.SetWorkingDirectory(other)
+	if err := manager.StartEnabledServers(t.Context()); err != nil {
+		t.Fatalf("StartEnabledServers failed: %v", err)
+	}
+	t.Cleanup(func() { manager.StopAllServers() })
+
+	if status := manager.GetServerStatus("global"); status != StatusRunning {
+		t.Errorf("Expected the global server to run everywhere, got %s", status)
+	}
+	if status := manager.GetServerStatus("scoped"); status != StatusStopped {
+		t.Errorf("Expected the project server not to start outside its project, got %s", status)
+	}
+
+	manager.SetWorkingDirectory(project)
+	waitFor(t, "the project server to start", func() bool {
+		return manager.GetServerStatus("scoped") == StatusRunning
+	})
+
+	manager.SetWorkingDirectory(other)
+	waitFor(t, "the project server to stop", func() bool {
+		return manager.GetServerStatus("scoped") == StatusStopped
+	})
+	if status := manager.GetServerStatus("global"); status != StatusRunning {
+		t.Errorf("Expected the global server to keep running, got %s", status)
+	}
+}
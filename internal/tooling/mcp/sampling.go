@@ -0,0 +1,213 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+	"github.com/kevensen/gollama-chat/internal/logging"
+)
+
+// samplingTimeout bounds a single completion requested by a server
+const samplingTimeout = 2 * time.Minute
+
+// Sampler creates the reply to a server's sampling request
+type Sampler interface {
+	CreateMessage(ctx context.Context, request *CreateMessageRequest) (*CreateMessageResult, error)
+}
+
+// SamplingDecision is the user's answer to a sampling request
+type SamplingDecision int
+
+const (
+	SamplingDenied             SamplingDecision = iota // The request is rejected
+	SamplingApproved                                   // This request may run
+	SamplingApprovedForSession                         // This and later requests of the server may run
+)
+
+// SamplingRequest is a sampling request waiting for the user's approval
+type SamplingRequest struct {
+	Server string
+	Params *CreateMessageRequest
+}
+
+// SamplingApprover asks the user whether a sampling request may run. It should return
+// SamplingDenied when ctx is cancelled, which happens when the server gives up on it.
+type SamplingApprover func(ctx context.Context, request SamplingRequest) SamplingDecision
+
+// sampling holds how the manager answers sampling requests
+type sampling struct {
+	mutex   sync.Mutex
+	sampler Sampler // Replaces the configured Ollama chat model, if set
+	approve SamplingApprover
+	trusted map[string]bool // Servers approved for the rest of the session
+}
+
+// SetSamplingApprover sets the function asking the user about sampling requests of
+// servers with the ask policy. Without one, those requests are rejected.
+func (m *Manager) SetSamplingApprover(approve SamplingApprover) {
+	m.sampling.mutex.Lock()
+	defer m.sampling.mutex.Unlock()
+	m.sampling.approve = approve
+}
+
+// createMessage answers a sampling request according to the server's sampling policy
+func (m *Manager) createMessage(ctx context.Context, server configuration.MCPServer, request *CreateMessageRequest) (*CreateMessageResult, error) {
+	logger := logging.WithComponent("mcp-manager")
+
+	switch server.SamplingPolicy() {
+	case configuration.MCPSamplingNever:
+		logger.Info("Rejected MCP sampling request", "server", server.Name, "reason", "sampling disabled")
+		return nil, &JSONRPCError{Code: errorCodeUserRejected, Message: "sampling is disabled for this server"}
+	case configuration.MCPSamplingAsk:
+		if !m.approveSampling(ctx, server.Name, request) {
+			logger.Info("Rejected MCP sampling request", "server", server.Name, "reason", "denied by user")
+			return nil, &JSONRPCError{Code: errorCodeUserRejected, Message: "user rejected sampling request"}
+		}
+	}
+
+	result, err := m.sampler().CreateMessage(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("sampling failed: %w", err)
+	}
+
+	logger.Info("Answered MCP sampling request", "server", server.Name, "model", result.Model,
+		"messages", len(request.Messages), "stopReason", result.StopReason)
+	return result, nil
+}
+
+// approveSampling reports whether the user allows a sampling request
+func (m *Manager) approveSampling(ctx context.Context, serverName string, request *CreateMessageRequest) bool {
+	m.sampling.mutex.Lock()
+	trusted := m.sampling.trusted[serverName]
+	approve := m.sampling.approve
+	m.sampling.mutex.Unlock()
+
+	if trusted {
+		return true
+	}
+	if approve == nil {
+		return false
+	}
+
+	switch approve(ctx, SamplingRequest{Server: serverName, Params: request}) {
+	case SamplingApprovedForSession:
+		m.sampling.mutex.Lock()
+		if m.sampling.trusted == nil {
+			m.sampling.trusted = make(map[string]bool)
+		}
+		m.sampling.trusted[serverName] = true
+		m.sampling.mutex.Unlock()
+		return true
+	case SamplingApproved:
+		return true
+	default:
+		return false
+	}
+}
+
+// sampler returns the sampler answering approved requests
+func (m *Manager) sampler() Sampler {
+	m.sampling.mutex.Lock()
+	defer m.sampling.mutex.Unlock()
+
+	if m.sampling.sampler != nil {
+		return m.sampling.sampler
+	}
+	return OllamaSampler{URL: m.config.OllamaURL, Model: m.config.ChatModel}
+}
+
+// OllamaSampler answers sampling requests with an Ollama chat model
+type OllamaSampler struct {
+	URL   string
+	Model string
+}
+
+// CreateMessage runs the request's messages through the chat model. The server's model
+// preferences are ignored; the configured model is always used.
+func (s OllamaSampler) CreateMessage(ctx context.Context, request *CreateMessageRequest) (*CreateMessageResult, error) {
+	baseURL, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Ollama URL %s: %w", s.URL, err)
+	}
+	client := api.NewClient(baseURL, &http.Client{Timeout: samplingTimeout})
+
+	messages, err := samplingMessages(request)
+	if err != nil {
+		return nil, err
+	}
+
+	options := make(map[string]any)
+	if request.Temperature != nil {
+		options["temperature"] = *request.Temperature
+	}
+	if request.MaxTokens > 0 {
+		options["num_predict"] = request.MaxTokens
+	}
+	if len(request.StopSequences) > 0 {
+		options["stop"] = request.StopSequences
+	}
+
+	stream := false
+	chatRequest := &api.ChatRequest{
+		Model:    s.Model,
+		Messages: messages,
+		Stream:   &stream,
+		Options:  options,
+	}
+
+	var reply strings.Builder
+	var doneReason string
+	err = client.Chat(ctx, chatRequest, func(resp api.ChatResponse) error {
+		reply.WriteString(resp.Message.Content)
+		if resp.Done {
+			doneReason = resp.DoneReason
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("chat request failed: %w", err)
+	}
+
+	stopReason := "endTurn"
+	if doneReason == "length" {
+		stopReason = "maxTokens"
+	}
+
+	return &CreateMessageResult{
+		Role:       "assistant",
+		Content:    PromptContent{Type: "text", Text: reply.String()},
+		Model:      s.Model,
+		StopReason: stopReason,
+	}, nil
+}
+
+// samplingMessages converts a sampling request to Ollama chat messages. Images are passed
+// to the model and other content is rendered as text.
+func samplingMessages(request *CreateMessageRequest) ([]api.Message, error) {
+	var messages []api.Message
+	if request.SystemPrompt != "" {
+		messages = append(messages, api.Message{Role: "system", Content: request.SystemPrompt})
+	}
+
+	for _, message := range request.Messages {
+		if message.Content.Type == "image" {
+			image, err := base64.StdEncoding.DecodeString(message.Content.Data)
+			if err != nil {
+				return nil, fmt.Errorf("invalid image in sampling request: %w", err)
+			}
+			messages = append(messages, api.Message{Role: message.Role, Images: []api.ImageData{image}})
+			continue
+		}
+		messages = append(messages, api.Message{Role: message.Role, Content: PromptContentText(message.Content)})
+	}
+	return messages, nil
+}
@@ -0,0 +1,233 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+// fakeSampler answers sampling requests with a fixed text
+type fakeSampler struct{}
+
+func (fakeSampler) CreateMessage(ctx context.Context, request *CreateMessageRequest) (*CreateMessageResult, error) {
+	return &CreateMessageResult{
+		Role:       "assistant",
+		Content:    PromptContent{Type: "text", Text: "a summary"},
+		Model:      "fake-model",
+		StopReason: "endTurn",
+	}, nil
+}
+
+func TestManager_Sampling(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    string
+		decisions []SamplingDecision // Answers given by the approver, in order
+		expected  []string           // Tool results of consecutive calls
+		asked     int                // Expected approver calls
+	}{
+		{
+			name:     "never",
+			policy:   configuration.MCPSamplingNever,
+			expected: []string{"sampling failed: -1 sampling is disabled for this server"},
+		},
+		{
+			name:      "ask and deny",
+			policy:    configuration.MCPSamplingAsk,
+			decisions: []SamplingDecision{SamplingDenied},
+			expected:  []string{"sampling failed: -1 user rejected sampling request"},
+			asked:     1,
+		},
+		{
+			name:      "ask and approve each request",
+			policy:    configuration.MCPSamplingAsk,
+			decisions: []SamplingDecision{SamplingApproved, SamplingApproved},
+			expected:  []string{"a summary", "a summary"},
+			asked:     2,
+		},
+		{
+			name:      "ask and approve for session",
+			policy:    "",
+			decisions: []SamplingDecision{SamplingApprovedForSession},
+			expected:  []string{"a summary", "a summary"},
+			asked:     1,
+		},
+		{
+			name:     "always",
+			policy:   configuration.MCPSamplingAlways,
+			expected: []string{"a summary"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := startHelperManager(t, func(server *configuration.MCPServer) {
+				server.Sampling = tt.policy
+			})
+
+			manager.sampling.sampler = fakeSampler{}
+
+			var mutex sync.Mutex
+			var asked []SamplingRequest
+			manager.SetSamplingApprover(func(ctx context.Context, request SamplingRequest) SamplingDecision {
+				mutex.Lock()
+				defer mutex.Unlock()
+				asked = append(asked, request)
+				if len(asked) > len(tt.decisions) {
+					return SamplingDenied
+				}
+				return tt.decisions[len(asked)-1]
+			})
+
+			for _, expected := range tt.expected {
+				result, err := manager.CallTool("fake", "echo", map[string]any{"text": "sample"})
+				if err != nil {
+					t.Fatalf("CallTool failed: %v", err)
+				}
+				if len(result.Content) != 1 || result.Content[0].Text != expected {
+					t.Errorf("Expected tool result %q, got %+v", expected, result.Content)
+				}
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if len(asked) != tt.asked {
+				t.Errorf("Expected %d approval requests, got %d", tt.asked, len(asked))
+			}
+			for _, request := range asked {
+				if request.Server != "fake" || len(request.Params.Messages) != 1 ||
+					request.Params.Messages[0].Content.Text != "Summarize the notes" {
+					t.Errorf("Unexpected approval request %+v", request)
+				}
+			}
+		})
+	}
+}
+
+func TestManager_SamplingWithoutApprover(t *testing.T) {
+	manager := startHelperManager(t)
+	manager.sampling.sampler = fakeSampler{}
+
+	result, err := manager.CallTool("fake", "echo", map[string]any{"text": "sample"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "user rejected") {
+		t.Errorf("Expected the request to be rejected without an approver, got %+v", result)
+	}
+}
+
+func TestClient_AdvertisedCapabilities(t *testing.T) {
+	for _, policy := range []string{configuration.MCPSamplingNever, configuration.MCPSamplingAsk, configuration.MCPSamplingAlways} {
+		client := NewClient(t.Context(), configuration.MCPServer{Name: "test", Sampling: policy})
+		transport := &recordingTransport{}
+		client.transport = transport
+
+		go client.initialize()
+		waitFor(t, "the initialize request", func() bool {
+			return len(transport.messages()) > 0
+		})
+
+		advertised := strings.Contains(transport.messages()[0], `"sampling":{}`)
+		if advertised != (policy != configuration.MCPSamplingNever) {
+			t.Errorf("Policy %s: sampling advertised = %t in %s", policy, advertised, transport.messages()[0])
+		}
+		if !strings.Contains(transport.messages()[0], `"roots":{"listChanged":true}`) {
+			t.Errorf("Expected roots to be advertised in %s", transport.messages()[0])
+		}
+		client.cancel()
+	}
+}
+
+func TestClient_AnswersServerRequests(t *testing.T) {
+	client := NewClient(t.Context(), configuration.MCPServer{Name: "test"})
+	transport := &recordingTransport{}
+	client.transport = transport
+
+	client.handleMessage([]byte(`{"jsonrpc":"2.0","id":7,"method":"ping"}`))
+	client.handleMessage([]byte(`{"jsonrpc":"2.0","id":8,"method":"roots/unknown"}`))
+
+	waitFor(t, "both responses", func() bool {
+		return len(transport.messages()) == 2
+	})
+
+	responses := map[string]bool{}
+	for _, message := range transport.messages() {
+		responses[message] = true
+	}
+	for _, expected := range []string{
+		`{"jsonrpc":"2.0","id":7,"result":{}}`,
+		`{"jsonrpc":"2.0","id":8,"error":{"code":-32601,"message":"method not found: roots/unknown"}}`,
+	} {
+		if !responses[expected] {
+			t.Errorf("Expected response %s, got %v", expected, transport.messages())
+		}
+	}
+}
+
+func TestSamplingMessages(t *testing.T) {
+	image := base64.StdEncoding.EncodeToString([]byte("png"))
+	messages, err := samplingMessages(&CreateMessageRequest{
+		SystemPrompt: "Be brief",
+		Messages: []SamplingMessage{
+			{Role: "user", Content: PromptContent{Type: "text", Text: "Describe"}},
+			{Role: "user", Content: PromptContent{Type: "image", Data: image, MimeType: "image/png"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("samplingMessages failed: %v", err)
+	}
+
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %+v", messages)
+	}
+	if messages[0].Role != "system" || messages[0].Content != "Be brief" {
+		t.Errorf("Expected the system prompt first, got %+v", messages[0])
+	}
+	if messages[1].Content != "Describe" {
+		t.Errorf("Expected the text message, got %+v", messages[1])
+	}
+	if len(messages[2].Images) != 1 || string(messages[2].Images[0]) != "png" {
+		t.Errorf("Expected the decoded image, got %+v", messages[2])
+	}
+
+	if _, err := samplingMessages(&CreateMessageRequest{
+		Messages: []SamplingMessage{{Role: "user", Content: PromptContent{Type: "image", Data: "not base64!"}}},
+	}); err == nil {
+		t.Error("Expected an error for invalid image data")
+	}
+}
+
+// recordingTransport records the messages sent by a client and never replies
+type recordingTransport struct {
+	mutex sync.Mutex
+	sent  []string
+	done  chan struct{}
+}
+
+func (r *recordingTransport) Start(ctx context.Context, handle func(message []byte)) error {
+	return nil
+}
+
+func (r *recordingTransport) Send(message []byte) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.sent = append(r.sent, string(message))
+	return nil
+}
+
+func (r *recordingTransport) Close() error { return nil }
+
+func (r *recordingTransport) Done() <-chan struct{} { return r.done }
+
+func (r *recordingTransport) Err() error { return nil }
+
+func (r *recordingTransport) messages() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string(nil), r.sent...)
+}
@@ -0,0 +1,322 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+	"github.com/kevensen/gollama-chat/internal/logging"
+)
+
+const (
+	maxRestartHistory = 20 // Restart events kept for each server
+	maxStderrLines    = 20 // Stderr lines kept for each server
+)
+
+// supervisorPolicy controls how crashed servers are restarted and how often they are pinged
+type supervisorPolicy struct {
+	backoff        time.Duration // Delay before the first restart, doubled for each further one
+	maxBackoff     time.Duration
+	maxRestarts    int // Restarts allowed within window before giving up
+	window         time.Duration
+	healthInterval time.Duration // Time between pings of running servers
+	pingTimeout    time.Duration
+}
+
+var defaultSupervisorPolicy = supervisorPolicy{
+	backoff:        time.Second,
+	maxBackoff:     time.Minute,
+	maxRestarts:    5,
+	window:         10 * time.Minute,
+	healthInterval: 30 * time.Second,
+	pingTimeout:    10 * time.Second,
+}
+
+// RestartEvent records a server failure and the restart scheduled for it
+type RestartEvent struct {
+	Time   time.Time
+	Reason string
+	Delay  time.Duration // Zero when the server was not restarted
+}
+
+// ServerHealth describes how a server has been supervised
+type ServerHealth struct {
+	Restarts    []RestartEvent // Oldest first
+	NextRestart time.Time      // Zero unless a restart is scheduled
+	GaveUp      bool           // Whether the server failed too often to be restarted again
+	LastPing    time.Time
+	PingLatency time.Duration
+	PingError   string
+	Stderr      []string // Most recent stderr lines, kept across restarts
+}
+
+// supervisor restarts failed servers and checks the health of running ones
+type supervisor struct {
+	mutex   sync.Mutex
+	policy  supervisorPolicy
+	ctx     context.Context // Set when supervision starts; restarted clients run in it
+	health  map[string]*ServerHealth
+	pending map[string]*time.Timer // Scheduled restarts
+}
+
+// startSupervisor starts checking the health of running servers until ctx is done.
+// Servers are only restarted after it has started.
+func (m *Manager) startSupervisor(ctx context.Context) {
+	m.supervisor.mutex.Lock()
+	defer m.supervisor.mutex.Unlock()
+
+	if m.supervisor.ctx != nil {
+		return
+	}
+	m.supervisor.ctx = ctx
+	go m.runHealthChecks(ctx, m.supervisor.policy.healthInterval)
+}
+
+// ServerHealth returns the restart history, last ping and recent stderr output of a server
+func (m *Manager) ServerHealth(serverName string) ServerHealth {
+	m.supervisor.mutex.Lock()
+	defer m.supervisor.mutex.Unlock()
+
+	health, ok := m.supervisor.health[serverName]
+	if !ok {
+		return ServerHealth{}
+	}
+	copied := *health
+	copied.Restarts = slices.Clone(health.Restarts)
+	copied.Stderr = slices.Clone(health.Stderr)
+	return copied
+}
+
+// serverHealth returns the health record of a server. The supervisor mutex must be held.
+func (m *Manager) serverHealth(serverName string) *ServerHealth {
+	if m.supervisor.health == nil {
+		m.supervisor.health = make(map[string]*ServerHealth)
+	}
+	health, ok := m.supervisor.health[serverName]
+	if !ok {
+		health = &ServerHealth{}
+		m.supervisor.health[serverName] = health
+	}
+	return health
+}
+
+// addStderr records a line a server wrote to stderr
+func (m *Manager) addStderr(serverName, line string) {
+	m.supervisor.mutex.Lock()
+	defer m.supervisor.mutex.Unlock()
+
+	health := m.serverHealth(serverName)
+	health.Stderr = append(health.Stderr, line)
+	if len(health.Stderr) > maxStderrLines {
+		health.Stderr = slices.Clone(health.Stderr[len(health.Stderr)-maxStderrLines:])
+	}
+}
+
+// serverFailed schedules the restart of a failed server with exponential backoff, giving
+// up when it has been restarted too often within the policy's window
+func (m *Manager) serverFailed(server configuration.MCPServer, client *Client, err error) {
+	logger := logging.WithComponent("mcp-manager")
+
+	m.supervisor.mutex.Lock()
+	defer m.supervisor.mutex.Unlock()
+
+	policy := m.supervisor.policy
+	health := m.serverHealth(server.Name)
+	now := time.Now()
+
+	recent := 0
+	for _, event := range health.Restarts {
+		if event.Delay > 0 && now.Sub(event.Time) < policy.window {
+			recent++
+		}
+	}
+
+	event := RestartEvent{Time: now, Reason: err.Error()}
+	if m.supervisor.ctx == nil || recent >= policy.maxRestarts {
+		health.GaveUp = m.supervisor.ctx != nil
+		health.NextRestart = time.Time{}
+		m.addRestartEvent(health, event)
+		logger.Error("Not restarting failed MCP server", "server", server.Name, "error", err,
+			"recentRestarts", recent, "supervised", m.supervisor.ctx != nil)
+		return
+	}
+
+	event.Delay = min(policy.backoff<<recent, policy.maxBackoff)
+	health.GaveUp = false
+	health.NextRestart = now.Add(event.Delay)
+	m.addRestartEvent(health, event)
+	logger.Warn("Restarting failed MCP server", "server", server.Name, "error", err,
+		"delay", event.Delay, "recentRestarts", recent)
+
+	if m.supervisor.pending == nil {
+		m.supervisor.pending = make(map[string]*time.Timer)
+	}
+	if timer, ok := m.supervisor.pending[server.Name]; ok {
+		timer.Stop()
+	}
+	m.supervisor.pending[server.Name] = time.AfterFunc(event.Delay, func() {
+		m.restartServer(server, client)
+	})
+}
+
+// addRestartEvent appends to a server's restart history, dropping the oldest events
+func (m *Manager) addRestartEvent(health *ServerHealth, event RestartEvent) {
+	health.Restarts = append(health.Restarts, event)
+	if len(health.Restarts) > maxRestartHistory {
+		health.Restarts = slices.Clone(health.Restarts[len(health.Restarts)-maxRestartHistory:])
+	}
+}
+
+// restartServer replaces a failed client with a new one, unless the server has been
+// stopped or restarted since it failed
+func (m *Manager) restartServer(server configuration.MCPServer, failed *Client) {
+	logger := logging.WithComponent("mcp-manager")
+
+	m.supervisor.mutex.Lock()
+	delete(m.supervisor.pending, server.Name)
+	m.serverHealth(server.Name).NextRestart = time.Time{}
+	ctx := m.supervisor.ctx
+	m.supervisor.mutex.Unlock()
+
+	m.clientsMux.Lock()
+	if m.clients[server.Name] != failed {
+		m.clientsMux.Unlock()
+		logger.Debug("Skipping restart of replaced MCP server", "server", server.Name)
+		return
+	}
+	client := m.newClient(ctx, server)
+	m.clients[server.Name] = client
+	m.clientsMux.Unlock()
+
+	failed.Stop()
+
+	if err := client.Start(); err != nil {
+		m.serverFailed(server, client, err)
+		return
+	}
+	logger.Info("Restarted MCP server", "server", server.Name)
+
+	// The restarted server may offer different tools
+	m.handlerMutex.RLock()
+	toolsChanged := m.toolsChanged
+	m.handlerMutex.RUnlock()
+	if toolsChanged != nil {
+		toolsChanged()
+	}
+}
+
+// cancelRestart stops a scheduled restart of a server
+func (m *Manager) cancelRestart(serverName string) {
+	m.supervisor.mutex.Lock()
+	defer m.supervisor.mutex.Unlock()
+
+	if timer, ok := m.supervisor.pending[serverName]; ok {
+		timer.Stop()
+		delete(m.supervisor.pending, serverName)
+		m.serverHealth(serverName).NextRestart = time.Time{}
+	}
+}
+
+// runHealthChecks pings the running servers every interval until ctx is done
+func (m *Manager) runHealthChecks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.checkHealth(ctx)
+		}
+	}
+}
+
+// checkHealth pings the running servers, failing those that do not answer in time
+func (m *Manager) checkHealth(ctx context.Context) {
+	m.clientsMux.RLock()
+	var clients []*Client
+	for _, client := range m.clients {
+		if client.GetStatus() == StatusRunning {
+			clients = append(clients, client)
+		}
+	}
+	m.clientsMux.RUnlock()
+
+	m.supervisor.mutex.Lock()
+	timeout := m.supervisor.policy.pingTimeout
+	m.supervisor.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for _, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			pingCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			started := time.Now()
+			err := client.Ping(pingCtx)
+
+			m.supervisor.mutex.Lock()
+			health := m.serverHealth(client.server.Name)
+			health.LastPing = started
+			health.PingLatency = time.Since(started)
+			health.PingError = ""
+			if err != nil {
+				health.PingError = err.Error()
+			}
+			m.supervisor.mutex.Unlock()
+
+			if err != nil && ctx.Err() == nil {
+				client.fail(fmt.Errorf("health check failed: %w", err))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Ping checks that the server is responsive
+func (c *Client) Ping(ctx context.Context) error {
+	if c.GetStatus() != StatusRunning {
+		return fmt.Errorf("server is not running")
+	}
+	if err := c.sendRequestContext(ctx, "ping", nil, nil); err != nil {
+		return fmt.Errorf("ping failed: %w", err)
+	}
+	return nil
+}
+
+// markFailed moves a running server to the error state, reporting whether it was running
+func (c *Client) markFailed(err error) bool {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+
+	if c.status != StatusRunning {
+		return false
+	}
+	c.status = StatusError
+	c.lastError = err
+	return true
+}
+
+// fail disconnects from an unresponsive server and reports it as failed
+func (c *Client) fail(err error) {
+	logger := logging.WithComponent("mcp-client")
+
+	if !c.markFailed(err) {
+		return
+	}
+	logger.Error("MCP server failed", "server", c.server.Name, "error", err)
+
+	c.cancel()
+	if err := c.transport.Close(); err != nil {
+		logger.Debug("Error closing MCP transport", "server", c.server.Name, "error", err)
+	}
+	if c.onFailure != nil {
+		c.onFailure(err)
+	}
+}
@@ -0,0 +1,129 @@
+package mcp
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setPolicy replaces the manager's supervisor policy
+func setPolicy(manager *Manager, policy supervisorPolicy) {
+	manager.supervisor.mutex.Lock()
+	defer manager.supervisor.mutex.Unlock()
+	manager.supervisor.policy = policy
+}
+
+// crash makes the helper server exit
+func crash(t *testing.T, manager *Manager) {
+	t.Helper()
+	if _, err := manager.CallTool("fake", "echo", map[string]any{"text": "crash"}); err == nil {
+		t.Fatal("Expected the tool call to fail when the server crashes")
+	}
+}
+
+func TestManager_RestartsCrashedServer(t *testing.T) {
+	manager := startHelperManager(t)
+	setPolicy(manager, supervisorPolicy{
+		backoff:        10 * time.Millisecond,
+		maxBackoff:     20 * time.Millisecond,
+		maxRestarts:    2,
+		window:         time.Minute,
+		healthInterval: time.Hour,
+		pingTimeout:    time.Second,
+	})
+
+	for restarts := 1; restarts <= 2; restarts++ {
+		crash(t, manager)
+		waitFor(t, "the server to be restarted", func() bool {
+			return manager.GetServerStatus("fake") == StatusRunning && len(manager.ServerHealth("fake").Restarts) == restarts
+		})
+	}
+
+	result, err := manager.CallTool("fake", "echo", map[string]any{"text": "hello"})
+	if err != nil || result.Content[0].Text != "hello" {
+		t.Fatalf("Expected the restarted server to work, got %+v, %v", result, err)
+	}
+
+	health := manager.ServerHealth("fake")
+	if delays := []time.Duration{health.Restarts[0].Delay, health.Restarts[1].Delay}; !slices.Equal(delays, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}) {
+		t.Errorf("Expected exponential backoff, got %v", delays)
+	}
+	if !strings.Contains(health.Restarts[0].Reason, "exited unexpectedly") {
+		t.Errorf("Expected the exit to be the restart reason, got %q", health.Restarts[0].Reason)
+	}
+	if !slices.Contains(health.Stderr, "fatal: crashing as requested") {
+		t.Errorf("Expected the stderr output to be kept, got %v", health.Stderr)
+	}
+
+	// A third crash within the window exceeds the restart limit
+	crash(t, manager)
+	waitFor(t, "the supervisor to give up", func() bool {
+		return manager.ServerHealth("fake").GaveUp
+	})
+	if status := manager.GetServerStatus("fake"); status != StatusError {
+		t.Errorf("Expected the server to stay failed, got %s", status)
+	}
+	if last := manager.ServerHealth("fake").Restarts[2]; last.Delay != 0 {
+		t.Errorf("Expected no restart to be scheduled, got %+v", last)
+	}
+}
+
+func TestManager_HealthCheckRestartsUnresponsiveServer(t *testing.T) {
+	manager := startHelperManager(t)
+	setPolicy(manager, supervisorPolicy{
+		backoff:        10 * time.Millisecond,
+		maxBackoff:     10 * time.Millisecond,
+		maxRestarts:    1,
+		window:         time.Minute,
+		healthInterval: time.Hour,
+		pingTimeout:    100 * time.Millisecond,
+	})
+
+	manager.checkHealth(t.Context())
+	if health := manager.ServerHealth("fake"); health.LastPing.IsZero() || health.PingError != "" {
+		t.Fatalf("Expected a successful ping, got %+v", health)
+	}
+
+	if _, err := manager.CallTool("fake", "echo", map[string]any{"text": "stall"}); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	manager.checkHealth(t.Context())
+
+	health := manager.ServerHealth("fake")
+	if health.PingError == "" || len(health.Restarts) != 1 || !strings.Contains(health.Restarts[0].Reason, "health check failed") {
+		t.Fatalf("Expected the failed ping to schedule a restart, got %+v", health)
+	}
+	waitFor(t, "the server to be restarted", func() bool {
+		return manager.GetServerStatus("fake") == StatusRunning
+	})
+}
+
+func TestManager_StopCancelsRestart(t *testing.T) {
+	manager := startHelperManager(t)
+	setPolicy(manager, supervisorPolicy{
+		backoff:        time.Hour,
+		maxBackoff:     time.Hour,
+		maxRestarts:    1,
+		window:         time.Minute,
+		healthInterval: time.Hour,
+		pingTimeout:    time.Second,
+	})
+
+	crash(t, manager)
+	waitFor(t, "the restart to be scheduled", func() bool {
+		return !manager.ServerHealth("fake").NextRestart.IsZero()
+	})
+
+	if err := manager.StopAllServers(); err != nil {
+		t.Fatalf("StopAllServers failed: %v", err)
+	}
+	if next := manager.ServerHealth("fake").NextRestart; !next.IsZero() {
+		t.Errorf("Expected the scheduled restart to be cancelled, got %v", next)
+	}
+	manager.supervisor.mutex.Lock()
+	defer manager.supervisor.mutex.Unlock()
+	if len(manager.supervisor.pending) != 0 {
+		t.Errorf("Expected no pending restarts, got %v", manager.supervisor.pending)
+	}
+}
@@ -0,0 +1,249 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os/exec"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+	"github.com/kevensen/gollama-chat/internal/logging"
+)
+
+// maxMessageSize bounds a single JSON-RPC message read from a server
+const maxMessageSize = 10 * 1024 * 1024
+
+// Transport carries JSON-RPC messages between the client and an MCP server
+type Transport interface {
+	// Start connects to the server. Each message received from the server is passed
+	// to handle until the transport is closed; handle may be called concurrently.
+	Start(ctx context.Context, handle func(message []byte)) error
+	// Send delivers a single JSON-RPC message to the server
+	Send(message []byte) error
+	// Close disconnects from the server and releases its resources
+	Close() error
+	// Done is closed when the connection has ended, through Close or a failure
+	Done() <-chan struct{}
+	// Err returns why the connection ended unexpectedly, or nil
+	Err() error
+}
+
+// newTransport creates the transport configured for a server. A local server's relative
+// working directory is resolved against workingDir, and the lines it writes to stderr are
+// passed to onStderr, if set.
+func newTransport(server configuration.MCPServer, workingDir string, onStderr func(line string)) (Transport, error) {
+	switch server.TransportName() {
+	case configuration.MCPTransportStdio:
+		return newStdioTransport(server, workingDir, onStderr), nil
+	case configuration.MCPTransportHTTP:
+		return newHTTPTransport(server), nil
+	case configuration.MCPTransportSSE:
+		return newSSETransport(server), nil
+	default:
+		return nil, fmt.Errorf("unknown transport %q", server.Transport)
+	}
+}
+
+// connection tracks the end of a transport's connection and its cause
+type connection struct {
+	done      chan struct{}
+	closeOnce sync.Once
+	errMutex  sync.Mutex
+	err       error
+}
+
+func newConnection() connection {
+	return connection{done: make(chan struct{})}
+}
+
+// end marks the connection as finished, recording err unless it ended cleanly
+func (c *connection) end(err error) {
+	c.closeOnce.Do(func() {
+		c.errMutex.Lock()
+		c.err = err
+		c.errMutex.Unlock()
+		close(c.done)
+	})
+}
+
+// Done is closed when the connection has ended
+func (c *connection) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection ended unexpectedly, or nil
+func (c *connection) Err() error {
+	c.errMutex.Lock()
+	defer c.errMutex.Unlock()
+	return c.err
+}
+
+// stdioTransport runs the server as a subprocess and exchanges newline delimited
+// JSON-RPC messages over its stdin and stdout
+type stdioTransport struct {
+	connection
+	server     configuration.MCPServer
+	workingDir string // Directory a relative server.WorkingDir is resolved against
+	cmd        *exec.Cmd
+	stdin      io.WriteCloser
+	writeMutex sync.Mutex
+	closing    atomic.Bool
+	onStderr   func(line string) // Receives each line written to stderr, if set
+}
+
+func newStdioTransport(server configuration.MCPServer, workingDir string, onStderr func(line string)) *stdioTransport {
+	return &stdioTransport{
+		connection: newConnection(),
+		server:     server,
+		workingDir: workingDir,
+		onStderr:   onStderr,
+	}
+}
+
+// Start spawns the server process
+func (t *stdioTransport) Start(ctx context.Context, handle func(message []byte)) error {
+	logger := logging.WithComponent("mcp-client")
+
+	env, err := serverEnvironment(t.server)
+	if err != nil {
+		return err
+	}
+
+	t.cmd = exec.CommandContext(ctx, t.server.Command, t.server.Arguments...)
+	t.cmd.Env = env
+	t.cmd.Dir = serverDirectory(t.server, t.workingDir)
+	logger.Debug("Created command for MCP server", "server", t.server.Name, "command", t.server.Command, "args", t.server.Arguments,
+		"dir", t.cmd.Dir, "inheritEnv", t.server.InheritsEnv(), "env", slices.Sorted(maps.Keys(t.server.Env)))
+
+	stdin, err := t.cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	t.stdin = stdin
+
+	stdout, err := t.cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	stderr, err := t.cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+
+	if err := t.cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start server process: %w", err)
+	}
+	logger.Info("MCP server process started successfully", "server", t.server.Name, "pid", t.cmd.Process.Pid)
+
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		t.readStdout(stdout, handle)
+	}()
+	go func() {
+		defer readers.Done()
+		t.readStderr(stderr)
+	}()
+
+	// Wait for the process only after its output has been read, as exec.Cmd requires
+	go func() {
+		readers.Wait()
+		err := t.cmd.Wait()
+		if t.closing.Load() {
+			logger.Debug("MCP server process exited as expected", "server", t.server.Name)
+			t.end(nil)
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("process exited")
+		}
+		t.end(fmt.Errorf("server process exited unexpectedly: %w", err))
+	}()
+
+	return nil
+}
+
+// Send writes a message as a single line to the server's stdin
+func (t *stdioTransport) Send(message []byte) error {
+	t.writeMutex.Lock()
+	defer t.writeMutex.Unlock()
+
+	if _, err := t.stdin.Write(append(message, '\n')); err != nil {
+		return fmt.Errorf("failed to write to server stdin: %w", err)
+	}
+	return nil
+}
+
+// Close closes stdin so the server can exit gracefully, killing it if it does not
+func (t *stdioTransport) Close() error {
+	logger := logging.WithComponent("mcp-client")
+
+	if t.cmd == nil || t.cmd.Process == nil {
+		t.end(nil)
+		return nil
+	}
+
+	t.closing.Store(true)
+	if t.stdin != nil {
+		t.stdin.Close()
+		logger.Debug("Closed stdin for graceful shutdown", "server", t.server.Name)
+	}
+
+	select {
+	case <-t.Done():
+	case <-time.After(5 * time.Second):
+		logger.Warn("MCP server did not exit gracefully, force killing", "server", t.server.Name)
+		t.cmd.Process.Kill()
+
+		select {
+		case <-t.Done():
+		case <-time.After(2 * time.Second):
+			// A child process may still hold the output pipes open
+			logger.Warn("MCP server output did not close after kill", "server", t.server.Name)
+			t.end(nil)
+		}
+	}
+
+	return nil
+}
+
+// readStdout passes each line the server writes to handle
+func (t *stdioTransport) readStdout(stdout io.Reader, handle func(message []byte)) {
+	logger := logging.WithComponent("mcp-client")
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		handle(append([]byte(nil), line...))
+	}
+	if err := scanner.Err(); err != nil {
+		logger.Error("Failed to read from MCP server stdout", "server", t.server.Name, "error", err)
+	}
+}
+
+// readStderr logs error output from the server and passes it to onStderr
+func (t *stdioTransport) readStderr(stderr io.Reader) {
+	logger := logging.WithComponent("mcp-client")
+
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		if line := scanner.Text(); len(line) > 0 {
+			logger.Warn("MCP server stderr", "server", t.server.Name, "message", line)
+			if t.onStderr != nil {
+				t.onStderr(line)
+			}
+		}
+	}
+}
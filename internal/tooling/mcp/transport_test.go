@@ -0,0 +1,527 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+// fakeServer is a minimal MCP server offering an echo tool and a changing resource
+type fakeServer struct {
+	mutex        sync.Mutex
+	reads        int
+	toolsChanged bool
+	stalled      bool                                    // Whether pings go unanswered
+	sampling     any                                     // ID of the tool call waiting for a sampling result
+	emit         func(method string, params any)         // Sends notifications, if set
+	ask          func(id any, method string, params any) // Sends requests, if set
+}
+
+// notify sends a notification when the transport supports it
+func (f *fakeServer) notify(method string, params any) {
+	if f.emit != nil {
+		f.emit(method, params)
+	}
+}
+
+// reply returns the server's response to a request, or nil to leave it unanswered.
+// Some echo texts trigger notifications: "progress" reports progress and logs,
+// "change-tools" changes the tool list and "block" is never answered. "sample"
+// asks the client for a completion and is answered by sampled. After "stall",
+// pings are no longer answered.
+func (f *fakeServer) reply(req *JSONRPCRequest) *JSONRPCResponse {
+	params, _ := req.Params.(map[string]any)
+
+	switch req.Method {
+	case "initialize":
+		return NewJSONRPCResponse(req.ID, InitializeResult{
+			ProtocolVersion: MCPVersion,
+			Capabilities: ServerCapabilities{
+				Tools:     &ToolsCapability{},
+				Resources: &ResourcesCapability{Subscribe: true},
+				Prompts:   &PromptsCapability{},
+			},
+			ServerInfo: ServerInfo{Name: "fake", Version: "1.0.0"},
+		})
+	case "tools/list":
+		tools := []Tool{{Name: "echo", Description: "Echo text", InputSchema: ToolSchema{Type: "object"}}}
+		f.mutex.Lock()
+		if f.toolsChanged {
+			tools = append(tools, Tool{Name: "added", InputSchema: ToolSchema{Type: "object"}})
+		}
+		f.mutex.Unlock()
+		return NewJSONRPCResponse(req.ID, ListToolsResult{Tools: tools})
+	case "tools/call":
+		arguments, _ := params["arguments"].(map[string]any)
+		text, _ := arguments["text"].(string)
+		switch text {
+		case "progress":
+			meta, _ := params["_meta"].(map[string]any)
+			f.notify("notifications/progress", ProgressNotification{ProgressToken: meta["progressToken"], Progress: 1, Total: 2, Message: "halfway"})
+			f.notify("notifications/message", LoggingMessageNotification{Level: "info", Logger: "echo", Data: "working"})
+		case "change-tools":
+			f.mutex.Lock()
+			f.toolsChanged = true
+			f.mutex.Unlock()
+			f.notify("notifications/tools/list_changed", nil)
+		case "block":
+			return nil
+		case "stall":
+			f.mutex.Lock()
+			f.stalled = true
+			f.mutex.Unlock()
+		case "sample":
+			if f.ask == nil {
+				break
+			}
+			f.mutex.Lock()
+			f.sampling = req.ID
+			f.mutex.Unlock()
+			f.ask("sample-1", "sampling/createMessage", CreateMessageRequest{
+				Messages:  []SamplingMessage{{Role: "user", Content: PromptContent{Type: "text", Text: "Summarize the notes"}}},
+				MaxTokens: 100,
+			})
+			return nil
+		}
+		return NewJSONRPCResponse(req.ID, CallToolResult{Content: []ToolContent{{Type: "text", Text: text}}})
+	case "resources/list":
+		// Two pages exercise cursor handling
+		if params["cursor"] == "page-2" {
+			return NewJSONRPCResponse(req.ID, ListResourcesResult{
+				Resources: []Resource{{URI: "file:///logo.png", Name: "logo", MimeType: "image/png"}},
+			})
+		}
+		return NewJSONRPCResponse(req.ID, ListResourcesResult{
+			Resources:  []Resource{{URI: "file:///notes.md", Name: "notes", MimeType: "text/markdown"}},
+			NextCursor: "page-2",
+		})
+	case "resources/templates/list":
+		return NewJSONRPCResponse(req.ID, ListResourceTemplatesResult{
+			ResourceTemplates: []ResourceTemplate{{URITemplate: "file:///{path}", Name: "file"}},
+		})
+	case "resources/read":
+		uri, _ := params["uri"].(string)
+		if uri == "file:///logo.png" {
+			return NewJSONRPCResponse(req.ID, ReadResourceResult{
+				Contents: []ResourceContents{{URI: uri, MimeType: "image/png", Blob: "iVBORw0KGgo="}},
+			})
+		}
+		f.mutex.Lock()
+		f.reads++
+		version := f.reads
+		f.mutex.Unlock()
+		return NewJSONRPCResponse(req.ID, ReadResourceResult{
+			Contents: []ResourceContents{{URI: uri, MimeType: "text/markdown", Text: fmt.Sprintf("notes v%d", version)}},
+		})
+	case "prompts/list":
+		return NewJSONRPCResponse(req.ID, ListPromptsResult{
+			Prompts: []Prompt{{
+				Name:        "review",
+				Description: "Review code",
+				Arguments: []PromptArgument{
+					{Name: "code", Required: true},
+					{Name: "focus"},
+				},
+			}},
+		})
+	case "prompts/get":
+		arguments, _ := params["arguments"].(map[string]any)
+		if params["name"] != "review" || arguments["code"] == nil {
+			return NewJSONRPCError(req.ID, -32602, "invalid prompt arguments", nil)
+		}
+		focus, _ := arguments["focus"].(string)
+		return NewJSONRPCResponse(req.ID, GetPromptResult{
+			Messages: []PromptMessage{
+				{Role: "user", Content: PromptContent{Type: "text", Text: fmt.Sprintf("Review %s focusing on %q", arguments["code"], focus)}},
+				{Role: "user", Content: PromptContent{Type: "resource", Resource: &ResourceContents{URI: "file:///style.md", Text: "Style guide"}}},
+			},
+		})
+	case "ping":
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		if f.stalled {
+			return nil
+		}
+		return NewJSONRPCResponse(req.ID, map[string]any{})
+	case "resources/subscribe", "resources/unsubscribe":
+		return NewJSONRPCResponse(req.ID, map[string]any{})
+	default:
+		return NewJSONRPCError(req.ID, -32601, "method not found", nil)
+	}
+}
+
+// sampled turns the client's answer to a sampling request into the result of the tool
+// call that asked for it
+func (f *fakeServer) sampled(response *JSONRPCResponse) *JSONRPCResponse {
+	f.mutex.Lock()
+	id := f.sampling
+	f.mutex.Unlock()
+
+	if response.Error != nil {
+		return NewJSONRPCResponse(id, CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: fmt.Sprintf("sampling failed: %d %s", response.Error.Code, response.Error.Message)}},
+			IsError: true,
+		})
+	}
+	var result CreateMessageResult
+	if err := decodeParams(response.Result, &result); err != nil {
+		return NewJSONRPCError(id, -32603, err.Error(), nil)
+	}
+	return NewJSONRPCResponse(id, CallToolResult{Content: []ToolContent{{Type: "text", Text: result.Content.Text}}})
+}
+
+// parseFakeRequest decodes a message sent by the client, returning nil for notifications
+func parseFakeRequest(t *testing.T, data []byte) *JSONRPCRequest {
+	t.Helper()
+	msg, err := ParseJSONRPCMessage(data)
+	if err != nil {
+		t.Errorf("Client sent invalid message %q: %v", data, err)
+		return nil
+	}
+	req, _ := msg.(*JSONRPCRequest)
+	return req
+}
+
+// exerciseClient starts a client for server, checks the tool round trip and stops it
+func exerciseClient(t *testing.T, server configuration.MCPServer) {
+	t.Helper()
+
+	client := NewClient(t.Context(), server)
+	if err := client.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if client.GetStatus() != StatusRunning {
+		t.Errorf("Expected status Running, got %s", client.GetStatus())
+	}
+	if tools := client.GetTools(); len(tools) != 1 || tools[0].Name != "echo" {
+		t.Errorf("Expected the echo tool, got %+v", tools)
+	}
+
+	result, err := client.CallTool("echo", map[string]any{"text": "hello"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if len(result.Content) != 1 || result.Content[0].Text != "hello" {
+		t.Errorf("Expected echoed text, got %+v", result)
+	}
+
+	if err := client.Stop(); err != nil {
+		t.Errorf("Stop failed: %v", err)
+	}
+	if client.GetStatus() != StatusStopped {
+		t.Errorf("Expected status Stopped, got %s", client.GetStatus())
+	}
+}
+
+// TestHelperProcess acts as a stdio MCP server when run by TestStdioTransport
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GOLLAMA_MCP_HELPER") != "1" {
+		t.Skip("helper process for stdio transport tests")
+	}
+
+	emit := func(method string, params any) {
+		data, _ := json.Marshal(JSONRPCNotification{JSONRPC: "2.0", Method: method, Params: params})
+		fmt.Println(string(data))
+	}
+	ask := func(id any, method string, params any) {
+		data, _ := json.Marshal(NewJSONRPCRequest(id, method, params))
+		fmt.Println(string(data))
+	}
+	server := &fakeServer{emit: emit, ask: ask}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		msg, err := ParseJSONRPCMessage(scanner.Bytes())
+		if err != nil {
+			os.Exit(2)
+		}
+
+		switch msg := msg.(type) {
+		case *JSONRPCRequest:
+			// The "crash" echo text makes the server exit with an error, while "cwd" and
+			// "getenv:NAME" echo the process's directory and environment
+			if params, _ := msg.Params.(map[string]any); msg.Method == "tools/call" {
+				arguments, _ := params["arguments"].(map[string]any)
+				text, _ := arguments["text"].(string)
+				if text == "crash" {
+					fmt.Fprintln(os.Stderr, "fatal: crashing as requested")
+					os.Exit(3)
+				}
+				if name, ok := strings.CutPrefix(text, "getenv:"); ok {
+					arguments["text"] = os.Getenv(name)
+				}
+				if text == "cwd" {
+					arguments["text"], _ = os.Getwd()
+				}
+			}
+
+			if response := server.reply(msg); response != nil {
+				data, _ := json.Marshal(response)
+				fmt.Println(string(data))
+			}
+
+			// Report a change right after a subscription so clients refresh the resource
+			if msg.Method == "resources/subscribe" {
+				params, _ := msg.Params.(map[string]any)
+				emit("notifications/resources/updated", map[string]any{"uri": params["uri"]})
+			}
+		case *JSONRPCResponse:
+			data, _ := json.Marshal(server.sampled(msg))
+			fmt.Println(string(data))
+		case *JSONRPCNotification:
+			// Make cancellations observable through the server's log
+			if msg.Method == "notifications/cancelled" {
+				params, _ := msg.Params.(map[string]any)
+				emit("notifications/message", LoggingMessageNotification{Level: "warning", Data: fmt.Sprintf("cancelled request %v", params["requestId"])})
+			}
+		}
+	}
+	os.Exit(0)
+}
+
+func TestStdioTransport(t *testing.T) {
+	t.Setenv("GOLLAMA_MCP_HELPER", "1")
+
+	exerciseClient(t, configuration.MCPServer{
+		Name:      "stdio-server",
+		Command:   os.Args[0],
+		Arguments: []string{"-test.run=^TestHelperProcess$"},
+		Enabled:   true,
+	})
+}
+
+func TestStdioTransport_ProcessExit(t *testing.T) {
+	client := NewClient(t.Context(), configuration.MCPServer{
+		Name:    "exits",
+		Command: "true",
+		Enabled: true,
+	})
+
+	if err := client.Start(); err == nil {
+		client.Stop()
+		t.Fatal("Expected Start to fail when the server exits immediately")
+	}
+	if client.GetStatus() != StatusError {
+		t.Errorf("Expected status Error, got %s", client.GetStatus())
+	}
+}
+
+func TestHTTPTransport(t *testing.T) {
+	const sessionID = "session-123"
+
+	server := &fakeServer{}
+	var mu sync.Mutex
+	var deleted bool
+	var authHeaders []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		mu.Unlock()
+
+		switch r.Method {
+		case http.MethodGet:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		case http.MethodDelete:
+			mu.Lock()
+			deleted = r.Header.Get(sessionHeader) == sessionID
+			mu.Unlock()
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		req := parseFakeRequest(t, body)
+		if req == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		if req.Method == "initialize" {
+			w.Header().Set(sessionHeader, sessionID)
+		} else if r.Header.Get(sessionHeader) != sessionID {
+			http.Error(w, "missing session", http.StatusBadRequest)
+			return
+		}
+
+		data, _ := json.Marshal(server.reply(req))
+		if req.Method == "tools/list" {
+			// Answer on an event stream, preceded by an unrelated notification
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, ": keep-alive\n\nevent: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/message\"}\n\n")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	t.Setenv("GOLLAMA_TEST_MCP_TOKEN", "token")
+	exerciseClient(t, configuration.MCPServer{
+		Name:      "http-server",
+		Transport: configuration.MCPTransportHTTP,
+		URL:       srv.URL,
+		Headers:   map[string]string{"Authorization": "Bearer ${env:GOLLAMA_TEST_MCP_TOKEN}"},
+		Enabled:   true,
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !deleted {
+		t.Error("Expected the session to be ended with DELETE on stop")
+	}
+	for _, header := range authHeaders {
+		if header != "Bearer token" {
+			t.Errorf("Expected configured headers on every request, got %q", header)
+		}
+	}
+}
+
+func TestHTTPTransport_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	client := NewClient(t.Context(), configuration.MCPServer{
+		Name:      "http-server",
+		Transport: configuration.MCPTransportHTTP,
+		URL:       srv.URL,
+		Enabled:   true,
+	})
+
+	err := client.Start()
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected an error mentioning the 401 status, got %v", err)
+	}
+}
+
+func TestHTTPTransport_UnresolvedHeader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected no request when a header cannot be resolved")
+	}))
+	defer srv.Close()
+
+	for _, transport := range []string{configuration.MCPTransportHTTP, configuration.MCPTransportSSE} {
+		client := NewClient(t.Context(), configuration.MCPServer{
+			Name:      "remote-server",
+			Transport: transport,
+			URL:       srv.URL,
+			Headers:   map[string]string{"Authorization": "Bearer ${env:GOLLAMA_TEST_UNSET}"},
+			Enabled:   true,
+		})
+
+		err := client.Start()
+		if err == nil || !strings.Contains(err.Error(), "header Authorization") {
+			t.Errorf("Expected %s transport to fail resolving the header, got %v", transport, err)
+		}
+	}
+}
+
+func TestSSETransport(t *testing.T) {
+	server := &fakeServer{}
+	messages := make(chan []byte, 10)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sse", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: endpoint\ndata: /messages?session=1\n\n")
+		w.(http.Flusher).Flush()
+
+		for {
+			select {
+			case data := <-messages:
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("POST /messages", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("session") != "1" {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if req := parseFakeRequest(t, body); req != nil {
+			data, _ := json.Marshal(server.reply(req))
+			messages <- data
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	exerciseClient(t, configuration.MCPServer{
+		Name:      "sse-server",
+		Transport: configuration.MCPTransportSSE,
+		URL:       srv.URL + "/sse",
+		Headers:   map[string]string{"X-Api-Key": "secret"},
+		Enabled:   true,
+	})
+}
+
+func TestSSETransport_ConnectError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	client := NewClient(t.Context(), configuration.MCPServer{
+		Name:      "sse-server",
+		Transport: configuration.MCPTransportSSE,
+		URL:       srv.URL + "/sse",
+		Enabled:   true,
+	})
+
+	if err := client.Start(); err == nil {
+		client.Stop()
+		t.Fatal("Expected Start to fail when the event stream is unavailable")
+	}
+}
+
+func TestReadSSE(t *testing.T) {
+	stream := ": comment\n" +
+		"event: endpoint\n" +
+		"data: /messages\n" +
+		"\n" +
+		"data: line one\n" +
+		"data: line two\n" +
+		"\n" +
+		"event: ignored-without-data\n" +
+		"\n" +
+		"data: incomplete at end of stream"
+
+	type event struct{ name, data string }
+	var events []event
+	err := readSSE(strings.NewReader(stream), func(name, data string) {
+		events = append(events, event{name, data})
+	})
+	if err != nil {
+		t.Fatalf("readSSE failed: %v", err)
+	}
+
+	expected := []event{
+		{"endpoint", "/messages"},
+		{"", "line one\nline two"},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected events %+v, got %+v", expected, events)
+	}
+}
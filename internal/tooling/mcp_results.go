@@ -0,0 +1,141 @@
+package tooling
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/kevensen/gollama-chat/internal/tooling/mcp"
+)
+
+// ToolResult is the result of a tool that returns more than text, such as an MCP tool
+// returning images or embedded resources
+type ToolResult struct {
+	Text        string           // Text for the model, including structured content as JSON
+	Images      []api.ImageData  // Images for vision-capable models
+	Attachments []ToolAttachment // Resources embedded in or linked from the result
+}
+
+// String returns the text of the result, so results print like text tools' results
+func (r *ToolResult) String() string {
+	return r.Text
+}
+
+// ToolAttachment is a resource returned by a tool, shown with the response in the chat view
+type ToolAttachment struct {
+	URI      string `json:"uri"`
+	Name     string `json:"name,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	Size     int    `json:"size,omitempty"` // Bytes of embedded content; zero for links
+}
+
+// Label describes the attachment on one line
+func (a ToolAttachment) Label() string {
+	name := a.Name
+	if name == "" {
+		name = a.URI
+	}
+	var details []string
+	if a.MimeType != "" {
+		details = append(details, a.MimeType)
+	}
+	if a.Size > 0 {
+		details = append(details, formatSize(a.Size))
+	}
+	if name != a.URI {
+		details = append(details, a.URI)
+	}
+	if len(details) == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, strings.Join(details, ", "))
+}
+
+// formatSize renders a byte count for display
+func formatSize(size int) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// mcpToolResult converts the result of an MCP tool. Text and text resources are passed to
+// the model as text and images are decoded for vision-capable models; audio, binary
+// resources and resource links are described in the text. Structured content is added
+// as JSON unless a text item already holds it.
+func mcpToolResult(result *mcp.CallToolResult) (*ToolResult, error) {
+	converted := &ToolResult{}
+	var parts []string
+
+	for _, item := range result.Content {
+		switch item.Type {
+		case "image":
+			image, err := base64.StdEncoding.DecodeString(item.Data)
+			if err != nil {
+				return nil, fmt.Errorf("invalid image in tool result: %w", err)
+			}
+			converted.Images = append(converted.Images, image)
+			parts = append(parts, fmt.Sprintf("[image %d: %s, %s]", len(converted.Images), item.MimeType, formatSize(len(image))))
+		case "audio":
+			size := base64.StdEncoding.DecodedLen(len(item.Data))
+			parts = append(parts, fmt.Sprintf("[audio content: %s, about %s]", item.MimeType, formatSize(size)))
+		case "resource":
+			if item.Resource == nil {
+				continue
+			}
+			attachment := ToolAttachment{URI: item.Resource.URI, MimeType: item.Resource.MimeType, Size: len(item.Resource.Text)}
+			if item.Resource.Blob != "" {
+				attachment.Size = base64.StdEncoding.DecodedLen(len(item.Resource.Blob))
+			}
+			converted.Attachments = append(converted.Attachments, attachment)
+			text := mcp.ResourceText(&mcp.ReadResourceResult{Contents: []mcp.ResourceContents{*item.Resource}})
+			parts = append(parts, fmt.Sprintf("Resource %s:\n%s", item.Resource.URI, text))
+		case "resource_link":
+			attachment := ToolAttachment{URI: item.URI, Name: item.Name, MimeType: item.MimeType}
+			converted.Attachments = append(converted.Attachments, attachment)
+			link := fmt.Sprintf("[resource link: %s]", attachment.Label())
+			if item.Description != "" {
+				link += " " + item.Description
+			}
+			parts = append(parts, link)
+		default:
+			parts = append(parts, item.Text)
+		}
+	}
+
+	if result.StructuredContent != nil {
+		structured, err := json.Marshal(result.StructuredContent)
+		if err != nil {
+			return nil, fmt.Errorf("invalid structured content in tool result: %w", err)
+		}
+		if !containsJSON(result.Content, result.StructuredContent) {
+			parts = append(parts, "Structured content:\n"+string(structured))
+		}
+	}
+
+	converted.Text = strings.Join(parts, "\n")
+	return converted, nil
+}
+
+// containsJSON reports whether a text item holds value as JSON, as servers returning
+// structured content are asked to do for older clients
+func containsJSON(content []mcp.ToolContent, value any) bool {
+	for _, item := range content {
+		if item.Type != "text" {
+			continue
+		}
+		var decoded any
+		if json.Unmarshal([]byte(item.Text), &decoded) == nil && reflect.DeepEqual(decoded, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package tooling
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kevensen/gollama-chat/internal/tooling/mcp"
+)
+
+func TestMCPToolResult(t *testing.T) {
+	png := []byte("\x89PNG fake image")
+	result, err := mcpToolResult(&mcp.CallToolResult{
+		Content: []mcp.ToolContent{
+			{Type: "text", Text: "Rendered the chart"},
+			{Type: "image", Data: base64.StdEncoding.EncodeToString(png), MimeType: "image/png"},
+			{Type: "resource", Resource: &mcp.ResourceContents{URI: "file:///data.csv", MimeType: "text/csv", Text: "a,b\n1,2"}},
+			{Type: "resource_link", URI: "https://example.com/report", Name: "report", Description: "Full report"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("mcpToolResult failed: %v", err)
+	}
+
+	if len(result.Images) != 1 || string(result.Images[0]) != string(png) {
+		t.Errorf("Expected the decoded image, got %v", result.Images)
+	}
+	for _, expected := range []string{
+		"Rendered the chart",
+		"[image 1: image/png, 15 B]",
+		"Resource file:///data.csv:\na,b\n1,2",
+		"[resource link: report (https://example.com/report)] Full report",
+	} {
+		if !strings.Contains(result.Text, expected) {
+			t.Errorf("Expected text to contain %q, got:\n%s", expected, result.Text)
+		}
+	}
+
+	expectedAttachments := []ToolAttachment{
+		{URI: "file:///data.csv", MimeType: "text/csv", Size: 7},
+		{URI: "https://example.com/report", Name: "report"},
+	}
+	if !reflect.DeepEqual(result.Attachments, expectedAttachments) {
+		t.Errorf("Expected attachments %+v, got %+v", expectedAttachments, result.Attachments)
+	}
+}
+
+func TestMCPToolResult_InvalidImage(t *testing.T) {
+	_, err := mcpToolResult(&mcp.CallToolResult{
+		Content: []mcp.ToolContent{{Type: "image", Data: "not base64!", MimeType: "image/png"}},
+	})
+	if err == nil {
+		t.Error("Expected an error for an image that is not base64")
+	}
+}
+
+func TestMCPToolResult_StructuredContent(t *testing.T) {
+	structured := map[string]any{"temperature": 21.5, "unit": "C"}
+
+	result, err := mcpToolResult(&mcp.CallToolResult{StructuredContent: structured})
+	if err != nil {
+		t.Fatalf("mcpToolResult failed: %v", err)
+	}
+	if result.Text != `Structured content:
+{"temperature":21.5,"unit":"C"}` {
+		t.Errorf("Expected structured content as JSON, got:\n%s", result.Text)
+	}
+
+	// Servers also returning the structured content as text are not repeated
+	result, err = mcpToolResult(&mcp.CallToolResult{
+		Content:           []mcp.ToolContent{{Type: "text", Text: `{"unit": "C", "temperature": 21.5}`}},
+		StructuredContent: structured,
+	})
+	if err != nil {
+		t.Fatalf("mcpToolResult failed: %v", err)
+	}
+	if strings.Contains(result.Text, "Structured content") {
+		t.Errorf("Expected structured content already in the text not to be repeated, got:\n%s", result.Text)
+	}
+}
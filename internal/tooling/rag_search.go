@@ -0,0 +1,184 @@
+package tooling
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/kevensen/gollama-chat/internal/rag"
+)
+
+const (
+	// ragSearchMaxK caps the number of documents the model may request
+	ragSearchMaxK = 20
+	// ragSearchTimeout bounds a single retrieval
+	ragSearchTimeout = 30 * time.Second
+)
+
+// RAGSearcher performs document retrieval for the rag_search tool
+type RAGSearcher interface {
+	IsReady() bool
+	// Collections returns the collections @mentions in a query can scope it to
+	Collections() []string
+	QueryDocumentsScoped(ctx context.Context, query rag.Query) (*rag.RAGResult, error)
+}
+
+// RAGSearchTool lets the model retrieve documents from the selected RAG collections on demand
+type RAGSearchTool struct {
+	mu       sync.RWMutex
+	searcher RAGSearcher
+}
+
+// ragSearchTool is the instance registered in DefaultRegistry
+var ragSearchTool = &RAGSearchTool{}
+
+// SetRAGSearcher connects the registered rag_search tool to a RAG service.
+// Passing nil disconnects it.
+func SetRAGSearcher(searcher RAGSearcher) {
+	ragSearchTool.SetSearcher(searcher)
+}
+
+// SetSearcher sets the RAG service used by the tool
+func (rst *RAGSearchTool) SetSearcher(searcher RAGSearcher) {
+	rst.mu.Lock()
+	defer rst.mu.Unlock()
+	rst.searcher = searcher
+}
+
+// Name returns the tool name
+func (rst *RAGSearchTool) Name() string {
+	return "rag_search"
+}
+
+// Description returns the tool description
+func (rst *RAGSearchTool) Description() string {
+	return "Search the user's document collections for passages relevant to a query"
+}
+
+// GetAPITool returns the Ollama API tool definition
+func (rst *RAGSearchTool) GetAPITool() *api.Tool {
+	return &api.Tool{
+		Type: "function",
+		Function: api.ToolFunction{
+			Name:        "rag_search",
+			Description: "Search the user's document collections for passages relevant to a query. Use it when the answer may depend on the user's own documents.",
+			Parameters: api.ToolFunctionParameters{
+				Type: "object",
+				Properties: map[string]api.ToolProperty{
+					"query": {
+						Type:        api.PropertyType{"string"},
+						Description: "Standalone search query describing the information needed",
+					},
+					"collections": {
+						Type:        api.PropertyType{"array"},
+						Description: "Collection names to search (optional, default: the selected collections)",
+						Items:       map[string]any{"type": "string"},
+					},
+					"k": {
+						Type:        api.PropertyType{"integer"},
+						Description: fmt.Sprintf("Maximum number of documents to return (optional, 1-%d, default: configured limit)", ragSearchMaxK),
+					},
+				},
+				Required: []string{"query"},
+			},
+		},
+	}
+}
+
+// Execute runs the retrieval and formats the matching documents
+func (rst *RAGSearchTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	text, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("query parameter required and must be a non-empty string")
+	}
+
+	rst.mu.RLock()
+	searcher := rst.searcher
+	rst.mu.RUnlock()
+
+	if searcher == nil || !searcher.IsReady() {
+		return nil, fmt.Errorf("RAG is not available: enable RAG and select at least one collection in the RAG tab")
+	}
+
+	// Scoping tokens in the query text are honored, explicit arguments take precedence
+	query := rag.ParseQuery(text, searcher.Collections())
+
+	collections, err := stringList(args["collections"])
+	if err != nil {
+		return nil, fmt.Errorf("collections parameter %w", err)
+	}
+	if len(collections) > 0 {
+		query.Collections = collections
+	}
+
+	if k, ok := args["k"].(float64); ok {
+		if k < 1 {
+			return nil, fmt.Errorf("k must be at least 1")
+		}
+		query.Limit = min(int(k), ragSearchMaxK)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ragSearchTimeout)
+	defer cancel()
+
+	result, err := searcher.QueryDocumentsScoped(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+
+	return formatRAGSearchResult(result), nil
+}
+
+// formatRAGSearchResult renders retrieved documents for the model
+func formatRAGSearchResult(result *rag.RAGResult) string {
+	if len(result.Documents) == 0 {
+		return fmt.Sprintf("No relevant documents found for %q.", result.Query)
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("Found %d document(s) for %q:\n\n", len(result.Documents), result.Query))
+	for i, doc := range result.Documents {
+		output.WriteString(fmt.Sprintf("Document %d (Collection: %s, Relevance: %.3f", i+1, doc.Collection, 1.0-doc.Distance))
+		if source, ok := doc.Metadata["source"]; ok {
+			output.WriteString(", Source: " + source)
+		}
+		output.WriteString("):\n")
+		output.WriteString(doc.Content)
+		output.WriteString("\n\n")
+	}
+	return strings.TrimRight(output.String(), "\n")
+}
+
+// stringList accepts a JSON array of strings or a comma separated string
+func stringList(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		var items []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	case []string:
+		return v, nil
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("must be a list of strings")
+			}
+			items = append(items, s)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("must be a list of strings")
+	}
+}
@@ -0,0 +1,127 @@
+package tooling
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kevensen/gollama-chat/internal/rag"
+)
+
+// fakeSearcher records the last query and returns canned results
+type fakeSearcher struct {
+	ready     bool
+	lastQuery rag.Query
+	result    *rag.RAGResult
+	err       error
+}
+
+func (fs *fakeSearcher) IsReady() bool {
+	return fs.ready
+}
+
+func (fs *fakeSearcher) Collections() []string {
+	return []string{"handbook", "notes"}
+}
+
+func (fs *fakeSearcher) QueryDocumentsScoped(ctx context.Context, query rag.Query) (*rag.RAGResult, error) {
+	fs.lastQuery = query
+	if fs.err != nil {
+		return nil, fs.err
+	}
+	return fs.result, nil
+}
+
+func TestRAGSearchTool_Execute(t *testing.T) {
+	searcher := &fakeSearcher{
+		ready: true,
+		result: &rag.RAGResult{
+			Query: "rotation policy",
+			Documents: []rag.RetrievedDocument{
+				{Content: "Rotate weekly.", Collection: "handbook", Distance: 0.25, Metadata: map[string]string{"source": "oncall.md"}},
+			},
+		},
+	}
+	tool := &RAGSearchTool{}
+	tool.SetSearcher(searcher)
+
+	result, err := tool.Execute(t.Context(), map[string]any{
+		"query":       "rotation policy #team=platform",
+		"collections": []any{"handbook", "notes"},
+		"k":           float64(50),
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	output, ok := result.(string)
+	if !ok {
+		t.Fatalf("Expected string result, got %T", result)
+	}
+	for _, want := range []string{"Found 1 document(s)", "Collection: handbook", "Relevance: 0.750", "Source: oncall.md", "Rotate weekly."} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	if searcher.lastQuery.Text != "rotation policy" {
+		t.Errorf("Expected scoping tokens removed from query text, got %q", searcher.lastQuery.Text)
+	}
+	if !reflect.DeepEqual(searcher.lastQuery.Collections, []string{"handbook", "notes"}) {
+		t.Errorf("Expected collections argument to be used, got %v", searcher.lastQuery.Collections)
+	}
+	if searcher.lastQuery.Filters["team"] != "platform" {
+		t.Errorf("Expected metadata filter from query text, got %v", searcher.lastQuery.Filters)
+	}
+	if searcher.lastQuery.Limit != ragSearchMaxK {
+		t.Errorf("Expected k to be capped at %d, got %d", ragSearchMaxK, searcher.lastQuery.Limit)
+	}
+}
+
+func TestRAGSearchTool_Errors(t *testing.T) {
+	tool := &RAGSearchTool{}
+
+	if _, err := tool.Execute(t.Context(), map[string]any{}); err == nil {
+		t.Error("Expected error for missing query")
+	}
+	if _, err := tool.Execute(t.Context(), map[string]any{"query": "anything"}); err == nil {
+		t.Error("Expected error when no searcher is configured")
+	}
+
+	tool.SetSearcher(&fakeSearcher{ready: false})
+	if _, err := tool.Execute(t.Context(), map[string]any{"query": "anything"}); err == nil {
+		t.Error("Expected error when RAG is not ready")
+	}
+
+	tool.SetSearcher(&fakeSearcher{ready: true, err: errors.New("boom")})
+	if _, err := tool.Execute(t.Context(), map[string]any{"query": "anything"}); err == nil {
+		t.Error("Expected search error to be returned")
+	}
+	if _, err := tool.Execute(t.Context(), map[string]any{"query": "anything", "k": float64(0)}); err == nil {
+		t.Error("Expected error for k below 1")
+	}
+	if _, err := tool.Execute(t.Context(), map[string]any{"query": "anything", "collections": []any{1}}); err == nil {
+		t.Error("Expected error for non-string collections")
+	}
+}
+
+func TestRAGSearchTool_NoResults(t *testing.T) {
+	tool := &RAGSearchTool{}
+	tool.SetSearcher(&fakeSearcher{ready: true, result: &rag.RAGResult{Query: "nothing"}})
+
+	result, err := tool.Execute(t.Context(), map[string]any{"query": "nothing", "collections": "a, b"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(result.(string), "No relevant documents") {
+		t.Errorf("Expected no results message, got %q", result)
+	}
+}
+
+func TestDefaultRegistry_HasRAGSearch(t *testing.T) {
+	if _, exists := DefaultRegistry.GetTool("rag_search"); !exists {
+		t.Error("Expected rag_search to be registered in the default registry")
+	}
+}
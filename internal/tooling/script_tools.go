@@ -0,0 +1,302 @@
+package tooling
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+	"github.com/kevensen/gollama-chat/internal/logging"
+)
+
+// ScriptTool runs a program declared in the configuration. The call's arguments replace
+// the placeholders of its fixed argv, so they are never interpreted by a shell. Commands
+// run in the sandbox of execute_bash.
+type ScriptTool struct {
+	definition configuration.ScriptTool
+	runner     *ExecuteBashTool
+}
+
+// NewScriptTool creates a script tool running its commands in the sandbox of runner
+func NewScriptTool(definition configuration.ScriptTool, runner *ExecuteBashTool) *ScriptTool {
+	return &ScriptTool{definition: definition, runner: runner}
+}
+
+// scriptTools holds the names of the script tools registered in DefaultRegistry
+var scriptTools = struct {
+	sync.Mutex
+	names []string
+}{}
+
+// SetScriptTools registers the declared script tools in DefaultRegistry, replacing the
+// ones registered before. Tools named like another builtin tool are skipped.
+func SetScriptTools(definitions []configuration.ScriptTool) {
+	logger := logging.WithComponent("tooling")
+
+	scriptTools.Lock()
+	defer scriptTools.Unlock()
+
+	for _, name := range scriptTools.names {
+		DefaultRegistry.Unregister(name)
+	}
+	scriptTools.names = nil
+
+	for _, definition := range definitions {
+		if _, exists := DefaultRegistry.GetTool(definition.Name); exists {
+			logger.Warn("Script tool skipped, a builtin tool has its name", "name", definition.Name)
+			continue
+		}
+		DefaultRegistry.Register(NewScriptTool(definition, executeBashTool))
+		scriptTools.names = append(scriptTools.names, definition.Name)
+	}
+}
+
+// Name returns the tool name
+func (st *ScriptTool) Name() string {
+	return st.definition.Name
+}
+
+// Description returns the tool description
+func (st *ScriptTool) Description() string {
+	return st.definition.Description
+}
+
+// Command returns the argv template of the tool
+func (st *ScriptTool) Command() []string {
+	return slices.Clone(st.definition.Command)
+}
+
+// GetAPITool returns the Ollama API tool definition built from the declared schema
+func (st *ScriptTool) GetAPITool() *api.Tool {
+	properties := make(map[string]api.ToolProperty, len(st.definition.Parameters.Properties))
+	for name, property := range st.definition.Parameters.Properties {
+		apiProperty := api.ToolProperty{
+			Type:        api.PropertyType{property.Type},
+			Description: property.Description,
+			Enum:        property.Enum,
+		}
+		if property.Type == "array" {
+			apiProperty.Items = map[string]any{"type": "string"}
+		}
+		properties[name] = apiProperty
+	}
+
+	return &api.Tool{
+		Type: "function",
+		Function: api.ToolFunction{
+			Name:        st.definition.Name,
+			Description: st.definition.Description,
+			Parameters: api.ToolFunctionParameters{
+				Type:       "object",
+				Properties: properties,
+				Required:   st.definition.Parameters.Required,
+			},
+		},
+	}
+}
+
+// Execute substitutes the arguments into the command and runs it
+func (st *ScriptTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	argv, err := scriptArgv(st.definition, args)
+	if err != nil {
+		return nil, err
+	}
+	workingDir, err := scriptWorkingDir(st.definition.WorkingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := st.definition.Timeout()
+	run, err := st.runner.runCommand(ctx, st.Name(), argv, workingDir, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]any{
+		"argv":             argv,
+		"working_dir":      workingDir,
+		"stdout":           run.stdout,
+		"stderr":           run.stderr,
+		"exit_code":        run.exitCode,
+		"success":          run.exitCode == 0,
+		"duration_ms":      run.duration.Milliseconds(),
+		"timeout":          timeout,
+		"sandbox":          st.runner.Sandbox().ProfileName(),
+		"output_truncated": run.truncated,
+	}
+	if run.outputFile != "" {
+		result["output_file"] = run.outputFile
+	}
+	return result, nil
+}
+
+// scriptArgv replaces the placeholders of a script tool's command with the arguments of a
+// call. An argument that is only a placeholder for an array becomes one argument per item,
+// and arguments using an optional parameter that was not given are left out. Values that
+// would start an argument with - are refused unless their parameter allows options.
+func scriptArgv(definition configuration.ScriptTool, args map[string]any) ([]string, error) {
+	for _, name := range definition.Parameters.Required {
+		if value, ok := args[name]; !ok || value == nil {
+			return nil, fmt.Errorf("%s parameter required", name)
+		}
+	}
+
+	argv := []string{definition.Command[0]}
+	for _, template := range definition.Command[1:] {
+		matches := configuration.ScriptToolPlaceholder.FindAllStringSubmatch(template, -1)
+		if len(matches) == 0 {
+			argv = append(argv, template)
+			continue
+		}
+
+		if property := definition.Parameters.Properties[matches[0][1]]; property.Type == "array" {
+			value, ok := args[matches[0][1]]
+			if !ok || value == nil {
+				continue
+			}
+			items, ok := value.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%s parameter must be an array", matches[0][1])
+			}
+			for _, item := range items {
+				text, err := scriptValue(matches[0][1], item)
+				if err == nil {
+					err = scriptOption(matches[0][1], property, text)
+				}
+				if err != nil {
+					return nil, err
+				}
+				argv = append(argv, text)
+			}
+			continue
+		}
+
+		// Only a placeholder at the start of an argument can turn it into an option
+		leading := configuration.ScriptToolPlaceholder.FindStringIndex(template)[0] == 0
+		missing := false
+		var err error
+		argument := configuration.ScriptToolPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+			name := configuration.ScriptToolPlaceholder.FindStringSubmatch(placeholder)[1]
+			value, ok := args[name]
+			if !ok || value == nil {
+				missing = true
+				return ""
+			}
+			property := definition.Parameters.Properties[name]
+			text, valueErr := scriptArgument(name, property, value)
+			if valueErr == nil && leading {
+				valueErr = scriptOption(name, property, text)
+			}
+			leading = false
+			if valueErr != nil && err == nil {
+				err = valueErr
+			}
+			return text
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !missing {
+			argv = append(argv, argument)
+		}
+	}
+	return argv, nil
+}
+
+// scriptArgument checks an argument against the type and values its parameter declares and
+// returns it as text
+func scriptArgument(name string, property configuration.ScriptToolProperty, value any) (string, error) {
+	switch property.Type {
+	case "string":
+		if _, ok := value.(string); !ok {
+			return "", fmt.Errorf("%s parameter must be a string", name)
+		}
+	case "integer":
+		if number, ok := value.(float64); ok && number == math.Trunc(number) {
+			break
+		}
+		if _, ok := value.(int); !ok {
+			return "", fmt.Errorf("%s parameter must be an integer", name)
+		}
+	case "number":
+		switch value.(type) {
+		case float64, int:
+		default:
+			return "", fmt.Errorf("%s parameter must be a number", name)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return "", fmt.Errorf("%s parameter must be a boolean", name)
+		}
+	}
+
+	text, err := scriptValue(name, value)
+	if err != nil {
+		return "", err
+	}
+	if len(property.Enum) > 0 && !slices.ContainsFunc(property.Enum, func(allowed any) bool {
+		return fmt.Sprint(allowed) == text
+	}) {
+		return "", fmt.Errorf("%s parameter must be one of %v", name, property.Enum)
+	}
+	return text, nil
+}
+
+// scriptOption refuses a value starting a command argument with - unless its parameter
+// allows options, so the model cannot pass flags such as --output=/etc/passwd
+func scriptOption(name string, property configuration.ScriptToolProperty, text string) error {
+	if strings.HasPrefix(text, "-") && !property.AllowOptions {
+		return fmt.Errorf("%s parameter cannot start with '-': the command would read it as an option", name)
+	}
+	return nil
+}
+
+// scriptValue returns an argument value as command line text
+func scriptValue(name string, value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("%s parameter cannot be passed to the command: %w", name, err)
+		}
+		return string(encoded), nil
+	}
+}
+
+// scriptWorkingDir returns the directory a script tool runs in, resolving a relative
+// directory against the project directory
+func scriptWorkingDir(dir string) (string, error) {
+	project, err := projectDir()
+	if err != nil {
+		return "", err
+	}
+	if dir == "" {
+		return project, nil
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(project, dir)
+	}
+	if stat, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("working directory '%s' does not exist: %w", dir, err)
+	} else if !stat.IsDir() {
+		return "", fmt.Errorf("working directory '%s' is not a directory", dir)
+	}
+	return dir, nil
+}
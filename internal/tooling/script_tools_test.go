@@ -0,0 +1,157 @@
+package tooling
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+// greetTool declares a script tool printing its arguments one per line
+func greetTool() configuration.ScriptTool {
+	return configuration.ScriptTool{
+		Name:        "greet",
+		Description: "Print a greeting",
+		Parameters: configuration.ScriptToolSchema{
+			Type: "object",
+			Properties: map[string]configuration.ScriptToolProperty{
+				"name":  {Type: "string", Description: "Who to greet"},
+				"times": {Type: "integer"},
+				"loud":  {Type: "boolean"},
+				"tone":  {Type: "string", Enum: []any{"warm", "dry"}},
+				"extra": {Type: "array"},
+				"file":  {Type: "string"},
+				"flags": {Type: "array", AllowOptions: true},
+			},
+			Required: []string{"name"},
+		},
+		Command: []string{"printf", `%s\n`, "Hello, {{name}}!", "--times={{times}}", "--loud={{loud}}", "{{tone}}", "{{file}}", "{{extra}}", "{{flags}}"},
+	}
+}
+
+func TestScriptArgv(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   map[string]any
+		want   []string
+		errMsg string
+	}{
+		{
+			name: "optional arguments left out",
+			args: map[string]any{"name": "world"},
+			want: []string{"printf", `%s\n`, "Hello, world!"},
+		},
+		{
+			name: "all arguments",
+			args: map[string]any{"name": "world", "times": float64(3), "loud": true, "tone": "dry", "extra": []any{"a b", float64(1.5)}},
+			want: []string{"printf", `%s\n`, "Hello, world!", "--times=3", "--loud=true", "dry", "a b", "1.5"},
+		},
+		{
+			name: "shell syntax stays one argument",
+			args: map[string]any{"name": "$(rm -rf /); `id` | cat"},
+			want: []string{"printf", `%s\n`, "Hello, $(rm -rf /); `id` | cat!"},
+		},
+		{
+			name: "options where allowed",
+			args: map[string]any{"name": "-x", "times": float64(-1), "flags": []any{"-v", "--count=2"}},
+			want: []string{"printf", `%s\n`, "Hello, -x!", "--times=-1", "-v", "--count=2"},
+		},
+		{name: "option in a leading placeholder", args: map[string]any{"name": "x", "file": "--output=/etc/passwd"}, errMsg: "file parameter cannot start with '-'"},
+		{name: "option in an array item", args: map[string]any{"name": "x", "extra": []any{"a", "-rf"}}, errMsg: "extra parameter cannot start with '-'"},
+		{name: "missing required argument", args: map[string]any{}, errMsg: "name parameter required"},
+		{name: "wrong type", args: map[string]any{"name": float64(1)}, errMsg: "name parameter must be a string"},
+		{name: "fractional integer", args: map[string]any{"name": "x", "times": 1.5}, errMsg: "times parameter must be an integer"},
+		{name: "value outside enum", args: map[string]any{"name": "x", "tone": "loud"}, errMsg: "tone parameter must be one of"},
+		{name: "array expected", args: map[string]any{"name": "x", "extra": "a"}, errMsg: "extra parameter must be an array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			argv, err := scriptArgv(greetTool(), tt.args)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("scriptArgv failed: %v", err)
+			}
+			if strings.Join(argv, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("scriptArgv() = %q, want %q", argv, tt.want)
+			}
+		})
+	}
+}
+
+func TestScriptTool_Execute(t *testing.T) {
+	definition := greetTool()
+	definition.WorkingDir = t.TempDir()
+	tool := NewScriptTool(definition, &ExecuteBashTool{})
+
+	result, err := tool.Execute(t.Context(), map[string]any{"name": "$HOME; echo injected", "extra": []any{"*"}})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	output := result.(map[string]any)
+	if output["exit_code"] != 0 {
+		t.Errorf("Expected exit code 0, got %v (stderr %q)", output["exit_code"], output["stderr"])
+	}
+	if want := "Hello, $HOME; echo injected!\n*\n"; output["stdout"] != want {
+		t.Errorf("Expected arguments to reach the program unchanged, got %q, want %q", output["stdout"], want)
+	}
+	if output["working_dir"] != definition.WorkingDir {
+		t.Errorf("Expected working directory %s, got %v", definition.WorkingDir, output["working_dir"])
+	}
+
+	definition.WorkingDir = "does-not-exist"
+	if _, err := NewScriptTool(definition, &ExecuteBashTool{}).Execute(t.Context(), map[string]any{"name": "x"}); err == nil {
+		t.Error("Expected a missing working directory to fail")
+	}
+}
+
+func TestScriptTool_GetAPITool(t *testing.T) {
+	apiTool := NewScriptTool(greetTool(), &ExecuteBashTool{}).GetAPITool()
+	if apiTool.Function.Name != "greet" || apiTool.Function.Description != "Print a greeting" {
+		t.Errorf("Unexpected function %s: %s", apiTool.Function.Name, apiTool.Function.Description)
+	}
+	if name := apiTool.Function.Parameters.Properties["name"]; name.Type[0] != "string" || name.Description != "Who to greet" {
+		t.Errorf("Unexpected name property %+v", name)
+	}
+	if extra := apiTool.Function.Parameters.Properties["extra"]; extra.Items == nil {
+		t.Error("Expected array properties to declare their items")
+	}
+	if required := apiTool.Function.Parameters.Required; len(required) != 1 || required[0] != "name" {
+		t.Errorf("Expected name to be required, got %v", required)
+	}
+}
+
+func TestSetScriptTools(t *testing.T) {
+	t.Cleanup(func() { SetScriptTools(nil) })
+
+	clash := greetTool()
+	clash.Name = "execute_bash"
+	SetScriptTools([]configuration.ScriptTool{greetTool(), clash})
+	tool, exists := DefaultRegistry.GetTool("greet")
+	if !exists {
+		t.Fatal("Expected greet to be registered")
+	}
+	if _, ok := tool.(*ScriptTool); !ok {
+		t.Errorf("Expected greet to be a script tool, got %T", tool)
+	}
+	if tool, _ := DefaultRegistry.GetTool("execute_bash"); tool != executeBashTool {
+		t.Error("Expected a script tool not to replace a builtin tool")
+	}
+
+	renamed := greetTool()
+	renamed.Name = "welcome"
+	SetScriptTools([]configuration.ScriptTool{renamed})
+	if _, exists := DefaultRegistry.GetTool("greet"); exists {
+		t.Error("Expected greet to be unregistered when it is no longer configured")
+	}
+	if _, exists := DefaultRegistry.GetUnifiedTool("greet"); exists {
+		t.Error("Expected greet to be removed from the unified tools")
+	}
+	if _, exists := DefaultRegistry.GetTool("welcome"); !exists {
+		t.Error("Expected welcome to be registered")
+	}
+}
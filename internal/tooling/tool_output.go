@@ -0,0 +1,269 @@
+package tooling
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kevensen/gollama-chat/internal/logging"
+)
+
+// outputTailLines is how many of the latest output lines a stream keeps for display
+const outputTailLines = 200
+
+// outputRetention is how long saved tool output files are kept
+const outputRetention = 7 * 24 * time.Hour
+
+// OutputStream collects the output of a running tool execution, saving all of it to a
+// file and keeping the latest lines for display
+type OutputStream struct {
+	mu      sync.Mutex
+	id      int
+	tool    string
+	started time.Time
+	lines   []string
+	partial string
+	bytes   int
+	file    *os.File
+	path    string
+}
+
+// OutputSnapshot is the state of an output stream at one moment
+type OutputSnapshot struct {
+	Tool    string
+	Started time.Time
+	Lines   []string // Latest complete lines, followed by the incomplete last line if any
+	Bytes   int
+	Path    string // File holding the full output; empty when it could not be created
+}
+
+// outputStreams tracks the running output streams
+var outputStreams = struct {
+	mu      sync.Mutex
+	dir     string
+	nextID  int
+	running []*OutputStream
+}{}
+
+// SetToolOutputDir sets the directory tool output is saved to and removes files older
+// than a week from it
+func SetToolOutputDir(dir string) {
+	outputStreams.mu.Lock()
+	outputStreams.dir = dir
+	outputStreams.mu.Unlock()
+	go pruneToolOutput(dir, time.Now().Add(-outputRetention))
+}
+
+// toolOutputDir returns the directory tool output is saved to, creating it if needed
+func toolOutputDir() (string, error) {
+	outputStreams.mu.Lock()
+	dir := outputStreams.dir
+	outputStreams.mu.Unlock()
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "gollama-chat-tool-output")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("cannot create tool output directory: %w", err)
+	}
+	return dir, nil
+}
+
+// pruneToolOutput removes saved tool output older than cutoff
+func pruneToolOutput(dir string, cutoff time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			logging.WithComponent("tooling").Warn("Failed to remove old tool output", "file", entry.Name(), "error", err)
+		}
+	}
+}
+
+// createOutputFile creates a file for the output of a tool
+func createOutputFile(tool string) (*os.File, error) {
+	dir, err := toolOutputDir()
+	if err != nil {
+		return nil, err
+	}
+	name := strings.NewReplacer("/", "_", string(filepath.Separator), "_").Replace(tool)
+	file, err := os.CreateTemp(dir, time.Now().Format("20060102-150405-")+name+"-*.log")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create tool output file: %w", err)
+	}
+	return file, nil
+}
+
+// StartOutputStream starts collecting the output of a tool execution. The stream is
+// listed by ActiveOutputStreams until it is closed. Output is still collected for display
+// when the file cannot be created.
+func StartOutputStream(tool string) *OutputStream {
+	stream := &OutputStream{tool: tool, started: time.Now()}
+	file, err := createOutputFile(tool)
+	if err != nil {
+		logging.WithComponent("tooling").Warn("Tool output will not be saved", "tool", tool, "error", err)
+	} else {
+		stream.file = file
+		stream.path = file.Name()
+	}
+
+	outputStreams.mu.Lock()
+	defer outputStreams.mu.Unlock()
+	outputStreams.nextID++
+	stream.id = outputStreams.nextID
+	outputStreams.running = append(outputStreams.running, stream)
+	return stream
+}
+
+// ActiveOutputStreams returns the running output streams, oldest first
+func ActiveOutputStreams() []OutputSnapshot {
+	outputStreams.mu.Lock()
+	running := slices.Clone(outputStreams.running)
+	outputStreams.mu.Unlock()
+
+	snapshots := make([]OutputSnapshot, len(running))
+	for i, stream := range running {
+		snapshots[i] = stream.Snapshot()
+	}
+	return snapshots
+}
+
+// Write saves output and splits it into lines for display
+func (s *OutputStream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		if _, err := s.file.Write(p); err != nil {
+			logging.WithComponent("tooling").Warn("Failed to save tool output", "tool", s.tool, "error", err)
+			s.file.Close()
+			os.Remove(s.path)
+			s.file, s.path = nil, ""
+		}
+	}
+	s.bytes += len(p)
+
+	lines := strings.Split(s.partial+strings.ReplaceAll(string(p), "\r\n", "\n"), "\n")
+	s.partial = lines[len(lines)-1]
+	s.lines = append(s.lines, lines[:len(lines)-1]...)
+	if len(s.lines) > outputTailLines {
+		s.lines = slices.Delete(s.lines, 0, len(s.lines)-outputTailLines)
+	}
+	return len(p), nil
+}
+
+// Path returns the file holding the full output, or an empty string when there is none
+func (s *OutputStream) Path() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.path
+}
+
+// Snapshot returns the current state of the stream
+func (s *OutputStream) Snapshot() OutputSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lines := slices.Clone(s.lines)
+	if s.partial != "" {
+		lines = append(lines, s.partial)
+	}
+	return OutputSnapshot{Tool: s.tool, Started: s.started, Lines: lines, Bytes: s.bytes, Path: s.path}
+}
+
+// Close finishes the stream, removing the saved file when there was no output
+func (s *OutputStream) Close() {
+	outputStreams.mu.Lock()
+	outputStreams.running = slices.DeleteFunc(outputStreams.running, func(stream *OutputStream) bool {
+		return stream.id == s.id
+	})
+	outputStreams.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return
+	}
+	if err := s.file.Close(); err != nil {
+		logging.WithComponent("tooling").Warn("Failed to close tool output file", "tool", s.tool, "error", err)
+	}
+	s.file = nil
+	if s.bytes == 0 {
+		os.Remove(s.path)
+		s.path = ""
+	}
+}
+
+// LatestToolOutput returns the most recently saved tool output file
+func LatestToolOutput() (string, error) {
+	dir, err := toolOutputDir()
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("cannot read tool output directory: %w", err)
+	}
+
+	var latest string
+	var latestTime time.Time
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		if latest == "" || info.ModTime().After(latestTime) {
+			latest, latestTime = filepath.Join(dir, entry.Name()), info.ModTime()
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no tool output has been saved in %s", dir)
+	}
+	return latest, nil
+}
+
+// SaveToolOutput saves the output of a tool to a file and returns its path
+func SaveToolOutput(tool, output string) (string, error) {
+	file, err := createOutputFile(tool)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err := file.WriteString(output); err != nil {
+		return "", fmt.Errorf("cannot save tool output: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// TruncateOutput shortens output to about limit bytes, keeping its beginning and end,
+// which usually hold the command echo and the errors or summary. It reports whether the
+// output was shortened; a limit of 0 or less keeps everything.
+func TruncateOutput(output string, limit int) (string, bool) {
+	if limit <= 0 || len(output) <= limit {
+		return output, false
+	}
+
+	// Cut at rune boundaries so the output stays valid UTF-8
+	head := output[:limit/2]
+	for len(head) > 0 {
+		if r, size := utf8.DecodeLastRuneInString(head); r != utf8.RuneError || size > 1 {
+			break
+		}
+		head = head[:len(head)-1]
+	}
+	tail := output[len(output)-(limit-len(head)):]
+	for len(tail) > 0 && !utf8.RuneStart(tail[0]) {
+		tail = tail[1:]
+	}
+	omitted := len(output) - len(head) - len(tail)
+	return fmt.Sprintf("%s\n[... %d bytes omitted ...]\n%s", head, omitted, tail), true
+}
@@ -0,0 +1,119 @@
+package tooling
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the output of the commands run by the tests out of the shared temporary directory
+	dir, err := os.MkdirTemp("", "tool-output-test-")
+	if err != nil {
+		panic(err)
+	}
+	SetToolOutputDir(dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestOutputStream(t *testing.T) {
+	stream := StartOutputStream("execute_bash")
+	stream.Write([]byte("first\nsec"))
+	stream.Write([]byte("ond\r\nthird"))
+
+	active := ActiveOutputStreams()
+	if len(active) != 1 || active[0].Tool != "execute_bash" {
+		t.Fatalf("Expected the stream to be active, got %+v", active)
+	}
+	if got := strings.Join(active[0].Lines, "|"); got != "first|second|third" {
+		t.Errorf("Expected the lines and the incomplete last line, got %q", got)
+	}
+	if active[0].Bytes != 19 {
+		t.Errorf("Expected 19 bytes, got %d", active[0].Bytes)
+	}
+
+	stream.Close()
+	if active := ActiveOutputStreams(); len(active) != 0 {
+		t.Errorf("Expected no active streams after closing, got %+v", active)
+	}
+	content, err := os.ReadFile(stream.Path())
+	if err != nil {
+		t.Fatalf("Expected the output to be saved: %v", err)
+	}
+	if string(content) != "first\nsecond\r\nthird" {
+		t.Errorf("Expected the full output to be saved, got %q", content)
+	}
+	if latest, err := LatestToolOutput(); err != nil || latest != stream.Path() {
+		t.Errorf("Expected the latest output to be %s, got %s (%v)", stream.Path(), latest, err)
+	}
+
+	// Streams without output leave no file
+	empty := StartOutputStream("execute_bash")
+	path := empty.Path()
+	empty.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) || empty.Path() != "" {
+		t.Errorf("Expected the empty output file to be removed, got %v", err)
+	}
+}
+
+func TestOutputStream_KeepsLatestLines(t *testing.T) {
+	stream := StartOutputStream("execute_bash")
+	defer stream.Close()
+	for range outputTailLines + 10 {
+		stream.Write([]byte("line\n"))
+	}
+	stream.Write([]byte("last\n"))
+
+	lines := stream.Snapshot().Lines
+	if len(lines) != outputTailLines || lines[len(lines)-1] != "last" {
+		t.Errorf("Expected the latest %d lines, got %d ending with %q", outputTailLines, len(lines), lines[len(lines)-1])
+	}
+}
+
+func TestTruncateOutput(t *testing.T) {
+	if output, truncated := TruncateOutput("short", 10); truncated || output != "short" {
+		t.Errorf("Expected short output to be kept, got %q", output)
+	}
+	if output, truncated := TruncateOutput(strings.Repeat("x", 100), 0); truncated || len(output) != 100 {
+		t.Error("Expected a limit of 0 to keep everything")
+	}
+
+	output := "start " + strings.Repeat("é", 500) + " end"
+	truncated, ok := TruncateOutput(output, 101)
+	if !ok {
+		t.Fatal("Expected long output to be truncated")
+	}
+	if !strings.HasPrefix(truncated, "start ") || !strings.HasSuffix(truncated, " end") {
+		t.Errorf("Expected the beginning and end to be kept, got %q", truncated)
+	}
+	if !strings.Contains(truncated, "bytes omitted") {
+		t.Errorf("Expected a truncation notice, got %q", truncated)
+	}
+	if !utf8.ValidString(truncated) {
+		t.Errorf("Expected valid UTF-8, got %q", truncated)
+	}
+}
+
+func TestExecuteBash_SavesFullOutput(t *testing.T) {
+	tool := &ExecuteBashTool{}
+	result, err := tool.Execute(t.Context(), map[string]any{"command": "seq 1 3; echo oops >&2"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	path, _ := result.(map[string]any)["output_file"].(string)
+	if filepath.Ext(path) != ".log" {
+		t.Fatalf("Expected the output file in the result, got %v", result)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected the output file to exist: %v", err)
+	}
+	if !strings.Contains(string(content), "1\n2\n3\n") || !strings.Contains(string(content), "oops") {
+		t.Errorf("Expected stdout and stderr to be saved, got %q", content)
+	}
+}
@@ -57,6 +57,7 @@
 package tooling
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -68,6 +69,7 @@ import (
 	"time"
 	"unicode/utf8"
 
+	"github.com/kevensen/gollama-chat/internal/configuration"
 	"github.com/kevensen/gollama-chat/internal/logging"
 	"github.com/kevensen/gollama-chat/internal/tooling/mcp"
 	"github.com/ollama/ollama/api"
@@ -85,8 +87,14 @@ func init() {
 
 	// Register built-in tools
 	logger.Info("Registering built-in tools")
-	DefaultRegistry.Register(&FileSystemTool{})
-	DefaultRegistry.Register(&ExecuteBashTool{})
+	DefaultRegistry.Register(fileSystemTool)
+	DefaultRegistry.Register(grepTool)
+	DefaultRegistry.Register(globTool)
+	DefaultRegistry.Register(fileTreeTool)
+	DefaultRegistry.Register(httpFetchTool)
+	DefaultRegistry.Register(executeBashTool)
+	DefaultRegistry.Register(fileWriteTool)
+	DefaultRegistry.Register(ragSearchTool)
 
 	logger.Info("Default tool registry initialized", "builtinToolCount", len(DefaultRegistry.builtinTools))
 }
@@ -174,12 +182,13 @@ type toolResponse struct {
 	error           error
 }
 
-// BuiltinTool represents a built-in tool that can be registered
+// BuiltinTool represents a built-in tool that can be registered. Execute should stop
+// when its context is done, which is how calls are timed out.
 type BuiltinTool interface {
 	Name() string
 	Description() string
 	GetAPITool() *api.Tool
-	Execute(args map[string]any) (any, error)
+	Execute(ctx context.Context, args map[string]any) (any, error)
 }
 
 // NewToolRegistry creates a new tool registry with channel support.
@@ -556,6 +565,21 @@ func (tr *ToolRegistry) worker() {
 					error:       nil,
 				}
 
+			case "unregister":
+				_, found := builtinTools[req.toolName]
+				delete(builtinTools, req.toolName)
+				delete(unifiedTools, req.toolName)
+
+				logger.Info("Unregistered builtin tool via channel", "name", req.toolName, "found", found)
+
+				// Mark that worker state has changed
+				tr.markWorkerStateChanged()
+
+				req.response <- toolResponse{
+					found: found,
+					error: nil,
+				}
+
 			case "list":
 				// Create a copy of all builtin tools for the response
 				allToolsCopy := make(map[string]BuiltinTool)
@@ -710,6 +734,49 @@ func (tr *ToolRegistry) registerViaChannel(tool BuiltinTool) {
 	}
 }
 
+// Unregister removes a builtin tool from the registry
+func (tr *ToolRegistry) Unregister(name string) {
+	if tr.useChannelForRegister && tr.requests != nil {
+		tr.unregisterViaChannel(name)
+		return
+	}
+
+	logger := logging.WithComponent("tooling")
+	logger.Info("Unregistering builtin tool", "name", name)
+
+	tr.toolsMutex.Lock()
+	defer tr.toolsMutex.Unlock()
+
+	delete(tr.builtinTools, name)
+	if tool, exists := tr.tools[name]; exists && tool.Source == "builtin" {
+		delete(tr.tools, name)
+	}
+
+	// Mark that mutex state has changed
+	tr.markMutexStateChanged()
+}
+
+// unregisterViaChannel removes a builtin tool using the channel-based approach
+func (tr *ToolRegistry) unregisterViaChannel(name string) {
+	responseChan := make(chan toolResponse, 1)
+	request := toolRequest{
+		operation: "unregister",
+		toolName:  name,
+		response:  responseChan,
+	}
+
+	select {
+	case tr.requests <- request:
+		select {
+		case <-responseChan:
+		case <-tr.shutdown:
+			// Service is shutting down, ignore
+		}
+	case <-tr.shutdown:
+		// Service is shutting down, ignore
+	}
+}
+
 // syncWorkerStateIfNeeded synchronizes worker state if mixed channel/mutex usage is detected.
 //
 // This optimization uses atomic counters to avoid unnecessary synchronization operations.
@@ -1015,6 +1082,15 @@ func (tr *ToolRegistry) GetAllTools() map[string]BuiltinTool {
 
 // SetMCPManager sets the MCP manager for this registry
 func (tr *ToolRegistry) SetMCPManager(manager *mcp.Manager) {
+	// Keep the registry in step with servers whose tools change while running
+	if manager != nil {
+		manager.SetToolsChangedHandler(func() {
+			if err := tr.RefreshMCPTools(); err != nil {
+				logging.WithComponent("tooling").Warn("Failed to refresh MCP tools after list change", "error", err)
+			}
+		})
+	}
+
 	if tr.useChannelForSetMCPManager && tr.requests != nil {
 		tr.setMCPManagerViaChannel(manager)
 		return
@@ -1207,15 +1283,23 @@ func convertMCPSchemaToOllamaParams(schema mcp.ToolSchema) api.ToolFunctionParam
 	return params
 }
 
-// ExecuteTool executes a tool (builtin or MCP) by name
-func (tr *ToolRegistry) ExecuteTool(name string, args map[string]any) (any, error) {
+// ExecuteTool executes a tool (builtin or MCP) by name. The context limits how long the
+// call may take. The registry is only locked while the tool is looked up, so calls can run
+// concurrently and slow tools do not hold up registrations.
+func (tr *ToolRegistry) ExecuteTool(ctx context.Context, name string, args map[string]any) (any, error) {
 	logger := logging.WithComponent("tooling")
 	logger.Info("Executing tool", "name", name, "args", args)
 
 	tr.toolsMutex.RLock()
-	defer tr.toolsMutex.RUnlock()
+	unifiedTool, exists := tr.tools[name]
+	var tool Tool
+	if exists {
+		tool = *unifiedTool
+	}
+	builtinTool, builtinExists := tr.builtinTools[name]
+	mcpManager := tr.mcpManager
+	tr.toolsMutex.RUnlock()
 
-	tool, exists := tr.tools[name]
 	if !exists {
 		logger.Error("Tool not found", "name", name)
 		return nil, fmt.Errorf("tool %s not found", name)
@@ -1229,12 +1313,11 @@ func (tr *ToolRegistry) ExecuteTool(name string, args map[string]any) (any, erro
 	if tool.Source == "builtin" {
 		logger.Debug("Executing builtin tool", "name", name)
 		// Execute builtin tool
-		builtinTool, exists := tr.builtinTools[name]
-		if !exists {
+		if !builtinExists {
 			logger.Error("Builtin tool not found in registry", "name", name)
 			return nil, fmt.Errorf("builtin tool %s not found", name)
 		}
-		result, err := builtinTool.Execute(args)
+		result, err := builtinTool.Execute(ctx, args)
 		if err != nil {
 			logger.Error("Builtin tool execution failed", "name", name, "error", err)
 		} else {
@@ -1244,47 +1327,56 @@ func (tr *ToolRegistry) ExecuteTool(name string, args map[string]any) (any, erro
 	} else if tool.Source == "mcp" {
 		logger.Debug("Executing MCP tool", "name", name, "server", tool.ServerName, "toolName", tool.DisplayName)
 		// Execute MCP tool
-		if tr.mcpManager == nil {
+		if mcpManager == nil {
 			logger.Error("MCP manager not configured for MCP tool execution", "name", name)
 			return nil, fmt.Errorf("MCP manager not configured")
 		}
 
-		result, err := tr.mcpManager.CallTool(tool.ServerName, tool.DisplayName, args)
+		result, err := mcpManager.CallToolContext(ctx, tool.ServerName, tool.DisplayName, args)
 		if err != nil {
 			logger.Error("MCP tool execution failed", "name", name, "server", tool.ServerName, "error", err)
 			return nil, fmt.Errorf("MCP tool execution failed: %w", err)
 		}
 
 		// Convert MCP result to expected format
+		converted, err := mcpToolResult(result)
+		if err != nil {
+			logger.Error("Invalid MCP tool result", "name", name, "server", tool.ServerName, "error", err)
+			return nil, err
+		}
 		if result.IsError {
-			errorMsg := formatMCPContent(result.Content)
-			logger.Error("MCP tool returned error", "name", name, "server", tool.ServerName, "error", errorMsg)
-			return nil, fmt.Errorf("MCP tool returned error: %s", errorMsg)
+			logger.Error("MCP tool returned error", "name", name, "server", tool.ServerName, "error", converted.Text)
+			return nil, fmt.Errorf("MCP tool returned error: %s", converted.Text)
 		}
 
-		resultStr := formatMCPContent(result.Content)
-		logger.Info("MCP tool executed successfully", "name", name, "server", tool.ServerName)
-		return resultStr, nil
+		logger.Info("MCP tool executed successfully", "name", name, "server", tool.ServerName,
+			"images", len(converted.Images), "attachments", len(converted.Attachments))
+		return converted, nil
 	}
 
 	logger.Error("Unknown tool source", "name", name, "source", tool.Source)
 	return nil, fmt.Errorf("unknown tool source: %s", tool.Source)
 }
 
-// formatMCPContent formats MCP tool content for return
-func formatMCPContent(content []mcp.ToolContent) string {
-	var result strings.Builder
-	for i, item := range content {
-		if i > 0 {
-			result.WriteString("\n")
-		}
-		result.WriteString(item.Text)
+// PreviewTool describes the change a call to a builtin tool would make, or returns an
+// empty string when the tool cannot preview its calls
+func (tr *ToolRegistry) PreviewTool(name string, args map[string]any) (string, error) {
+	tool, exists := tr.GetTool(name)
+	if !exists {
+		return "", nil
+	}
+	previewer, ok := tool.(Previewer)
+	if !ok {
+		return "", nil
 	}
-	return result.String()
+	return previewer.Preview(args)
 }
 
-// FileSystemTool provides filesystem operations
-type FileSystemTool struct{}
+// FileSystemTool provides filesystem operations within the allowed directories
+type FileSystemTool struct {
+	mu     sync.RWMutex
+	access configuration.FileAccess
+}
 
 // FileInfo represents file/directory information
 type FileInfo struct {
@@ -1335,11 +1427,11 @@ func (fst *FileSystemTool) GetAPITool() *api.Tool {
 					},
 					"path": {
 						Type:        api.PropertyType{"string"},
-						Description: "File or directory path (not required for get_working_directory)",
+						Description: "File or directory path within the allowed directories, by default the project directory (not required for get_working_directory)",
 					},
 					"max_bytes": {
 						Type:        api.PropertyType{"integer"},
-						Description: "Maximum bytes to read for files (optional, default: 10MB)",
+						Description: "Maximum bytes to read for files (optional, default and upper bound: 1MB unless configured otherwise); larger files are truncated",
 					},
 				},
 				Required: []string{"action"},
@@ -1349,7 +1441,7 @@ func (fst *FileSystemTool) GetAPITool() *api.Tool {
 }
 
 // Execute performs the filesystem operation
-func (fst *FileSystemTool) Execute(args map[string]any) (any, error) {
+func (fst *FileSystemTool) Execute(ctx context.Context, args map[string]any) (any, error) {
 	action, ok := args["action"].(string)
 	if !ok {
 		return nil, fmt.Errorf("action parameter required and must be a string")
@@ -1365,25 +1457,30 @@ func (fst *FileSystemTool) Execute(args map[string]any) (any, error) {
 			return nil, fmt.Errorf("path parameter required for action '%s' and must be a string", action)
 		}
 
-		// Clean and validate the path
-		cleanPath := filepath.Clean(path)
+		// Resolve the path and check it is allowed
+		resolved, err := fst.resolvePath(filepath.Clean(path))
+		if err != nil {
+			return nil, err
+		}
 
 		switch action {
 		case "list_directory":
-			return fst.listDirectory(cleanPath)
+			return fst.listDirectory(resolved)
 		case "read_file":
-			maxBytes := 10 * 1024 * 1024 // 10MB default
-			if mb, ok := args["max_bytes"].(float64); ok {
+			// The model may ask for less than the configured limit, never more
+			maxBytes := fst.Access().ReadLimit()
+			if mb, ok := args["max_bytes"].(float64); ok && mb > 0 && (maxBytes == 0 || int(mb) < maxBytes) {
 				maxBytes = int(mb)
 			}
-			return fst.readFile(cleanPath, maxBytes)
+			return fst.readFile(resolved, maxBytes)
 		}
 	}
 
 	return nil, fmt.Errorf("unknown action: %s. Valid actions are: get_working_directory, list_directory, read_file", action)
 }
 
-// listDirectory lists files and directories in the given path
+// listDirectory lists files and directories in the given path, leaving out entries that
+// may not be read
 func (fst *FileSystemTool) listDirectory(path string) (any, error) {
 	// Check if path exists and is accessible
 	stat, err := os.Stat(path)
@@ -1402,12 +1499,17 @@ func (fst *FileSystemTool) listDirectory(path string) (any, error) {
 	}
 
 	var fileInfos []FileInfo
+	hidden := 0
 	for _, entry := range entries {
 		info, err := entry.Info()
 		if err != nil {
 			// Skip entries that can't be accessed
 			continue
 		}
+		if _, err := fst.resolvePath(filepath.Join(path, entry.Name())); err != nil {
+			hidden++
+			continue
+		}
 
 		fileInfo := FileInfo{
 			Name:     entry.Name(),
@@ -1420,15 +1522,20 @@ func (fst *FileSystemTool) listDirectory(path string) (any, error) {
 		fileInfos = append(fileInfos, fileInfo)
 	}
 
-	return map[string]any{
+	result := map[string]any{
 		"path":     path,
 		"entries":  fileInfos,
 		"count":    len(fileInfos),
 		"readable": true,
-	}, nil
+	}
+	if hidden > 0 {
+		result["hidden"] = hidden
+		result["note"] = fmt.Sprintf("%d entries are not listed because they are denied, ignored by .gitignore or link outside the allowed directories", hidden)
+	}
+	return result, nil
 }
 
-// readFile reads the contents of a file with size limits
+// readFile reads the contents of a file, up to maxBytes unless it is 0
 func (fst *FileSystemTool) readFile(path string, maxBytes int) (any, error) {
 	// Check if path exists and is accessible
 	stat, err := os.Stat(path)
@@ -1440,11 +1547,6 @@ func (fst *FileSystemTool) readFile(path string, maxBytes int) (any, error) {
 		return nil, fmt.Errorf("path %s is a directory, not a file", path)
 	}
 
-	// Check file size
-	if stat.Size() > int64(maxBytes) {
-		return nil, fmt.Errorf("file %s is too large (%d bytes, max: %d bytes)", path, stat.Size(), maxBytes)
-	}
-
 	// Open and read the file
 	file, err := os.Open(path)
 	if err != nil {
@@ -1452,14 +1554,19 @@ func (fst *FileSystemTool) readFile(path string, maxBytes int) (any, error) {
 	}
 	defer file.Close()
 
-	// Read up to maxBytes
-	content := make([]byte, maxBytes)
-	n, err := io.ReadFull(file, content)
-	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+	// Read up to maxBytes; larger files are truncated rather than refused
+	var reader io.Reader = file
+	if maxBytes > 0 {
+		reader = io.LimitReader(file, int64(maxBytes))
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
 		return nil, fmt.Errorf("error reading file %s: %v", path, err)
 	}
-
-	content = content[:n] // Trim to actual size
+	truncated := int64(len(content)) < stat.Size()
+	if truncated {
+		content = trimPartialRune(content)
+	}
 
 	// Check if content is valid UTF-8
 	isText := utf8.Valid(content)
@@ -1494,14 +1601,29 @@ func (fst *FileSystemTool) readFile(path string, maxBytes int) (any, error) {
 		"is_binary":  isBinary,
 		"is_text":    !isBinary,
 		"bytes_read": len(content),
+		"truncated":  truncated,
 	}, nil
 }
 
-// getWorkingDirectory returns the current working directory
+// trimPartialRune drops a rune cut in half at the end of content, so truncated text
+// stays valid UTF-8
+func trimPartialRune(content []byte) []byte {
+	for cut := 1; cut < utf8.UTFMax && cut <= len(content); cut++ {
+		if utf8.RuneStart(content[len(content)-cut]) {
+			if !utf8.FullRune(content[len(content)-cut:]) {
+				return content[:len(content)-cut]
+			}
+			break
+		}
+	}
+	return content
+}
+
+// getWorkingDirectory returns the project directory relative paths are resolved against
 func (fst *FileSystemTool) getWorkingDirectory() (any, error) {
-	wd, err := os.Getwd()
+	wd, err := projectDir()
 	if err != nil {
-		return nil, fmt.Errorf("cannot get working directory: %v", err)
+		return nil, err
 	}
 
 	// Get directory info
@@ -1526,8 +1648,15 @@ func (fst *FileSystemTool) getWorkingDirectory() (any, error) {
 	}, nil
 }
 
-// ExecuteBashTool provides bash command execution
-type ExecuteBashTool struct{}
+// ExecuteBashTool provides bash command execution, optionally in a sandbox
+type ExecuteBashTool struct {
+	mu         sync.RWMutex
+	sandbox    configuration.BashSandbox
+	scratchDir string // Writable directory of isolated commands
+}
+
+// executeBashTool is the instance registered in DefaultRegistry
+var executeBashTool = &ExecuteBashTool{}
 
 // ExecuteBashArgs represents arguments for executing a bash command
 type ExecuteBashArgs struct {
@@ -1572,7 +1701,7 @@ func (ebt *ExecuteBashTool) GetAPITool() *api.Tool {
 }
 
 // Execute performs the bash command execution
-func (ebt *ExecuteBashTool) Execute(args map[string]any) (any, error) {
+func (ebt *ExecuteBashTool) Execute(ctx context.Context, args map[string]any) (any, error) {
 	command, ok := args["command"].(string)
 	if !ok {
 		return nil, fmt.Errorf("command parameter required and must be a string")
@@ -1615,27 +1744,73 @@ func (ebt *ExecuteBashTool) Execute(args map[string]any) (any, error) {
 		}
 	}
 
-	return ebt.executeBashCommand(command, workingDir, timeout)
+	return ebt.executeBashCommand(ctx, command, workingDir, timeout)
 }
 
 // executeBashCommand executes a bash command with the specified parameters
-func (ebt *ExecuteBashTool) executeBashCommand(command, workingDir string, timeoutSeconds int) (any, error) {
-	// Create the command
-	cmd := exec.Command("bash", "-c", command)
+func (ebt *ExecuteBashTool) executeBashCommand(ctx context.Context, command, workingDir string, timeoutSeconds int) (any, error) {
+	run, err := ebt.runCommand(ctx, ebt.Name(), []string{"bash", "-c", command}, workingDir, timeoutSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get current working directory for context
+	currentDir := workingDir
+	if currentDir == "" {
+		if wd, err := os.Getwd(); err == nil {
+			currentDir = wd
+		}
+	}
+
+	result := map[string]any{
+		"command":          command,
+		"working_dir":      currentDir,
+		"stdout":           run.stdout,
+		"stderr":           run.stderr,
+		"exit_code":        run.exitCode,
+		"success":          run.exitCode == 0,
+		"duration_ms":      run.duration.Milliseconds(),
+		"timeout":          timeoutSeconds,
+		"sandbox":          ebt.Sandbox().ProfileName(),
+		"output_truncated": run.truncated,
+	}
+	if run.outputFile != "" {
+		result["output_file"] = run.outputFile
+	}
+	return result, nil
+}
+
+// commandRun is the outcome of a command run by runCommand
+type commandRun struct {
+	stdout     string
+	stderr     string
+	exitCode   int
+	duration   time.Duration
+	truncated  bool   // Whether stdout or stderr exceeded the output limit
+	outputFile string // File holding the streamed output, if it was kept
+}
 
-	// Set working directory if specified
-	if workingDir != "" {
-		cmd.Dir = workingDir
+// runCommand runs argv in the configured sandbox, streaming its output for display as the
+// output of the named tool, and kills it when the timeout is reached or ctx is done
+func (ebt *ExecuteBashTool) runCommand(ctx context.Context, toolName string, argv []string, workingDir string, timeoutSeconds int) (*commandRun, error) {
+	// Create the command in the configured sandbox
+	cmd, err := ebt.sandboxedCommand(argv, workingDir)
+	if err != nil {
+		return nil, err
 	}
 
-	// Capture both stdout and stderr
-	var stdout, stderr strings.Builder
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	// Capture both stdout and stderr, up to the output limit, and stream them for display
+	_, _, maxOutputBytes := ebt.Sandbox().Limits()
+	stdout := &limitedBuffer{limit: maxOutputBytes}
+	stderr := &limitedBuffer{limit: maxOutputBytes}
+	stream := StartOutputStream(toolName)
+	defer stream.Close()
+	cmd.Stdout = io.MultiWriter(stdout, stream)
+	cmd.Stderr = io.MultiWriter(stderr, stream)
 
 	// Start the command
 	startTime := time.Now()
-	err := cmd.Start()
+	err = cmd.Start()
 	if err != nil {
 		return nil, fmt.Errorf("failed to start command: %v", err)
 	}
@@ -1656,6 +1831,11 @@ func (ebt *ExecuteBashTool) executeBashCommand(command, workingDir string, timeo
 			cmd.Process.Kill()
 		}
 		return nil, fmt.Errorf("command timed out after %d seconds", timeoutSeconds)
+	case <-ctx.Done():
+		if cmd.Process != nil {
+			cmd.Process.Kill()
+		}
+		return nil, fmt.Errorf("command stopped: %w", ctx.Err())
 	}
 
 	duration := time.Since(startTime)
@@ -1670,23 +1850,13 @@ func (ebt *ExecuteBashTool) executeBashCommand(command, workingDir string, timeo
 		}
 	}
 
-	// Get current working directory for context
-	currentDir := workingDir
-	if currentDir == "" {
-		if wd, err := os.Getwd(); err == nil {
-			currentDir = wd
-		}
-	}
-
-	return map[string]any{
-		"command":     command,
-		"working_dir": currentDir,
-		"stdout":      stdout.String(),
-		"stderr":      stderr.String(),
-		"exit_code":   exitCode,
-		"success":     exitCode == 0,
-		"duration_ms": duration.Milliseconds(),
-		"timeout":     timeoutSeconds,
+	return &commandRun{
+		stdout:     stdout.String(),
+		stderr:     stderr.String(),
+		exitCode:   exitCode,
+		duration:   duration,
+		truncated:  stdout.truncated || stderr.truncated,
+		outputFile: stream.Path(),
 	}, nil
 }
 
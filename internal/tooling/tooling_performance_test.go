@@ -1,6 +1,7 @@
 package tooling
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -12,7 +13,7 @@ type testMetricsTool struct{}
 
 func (t *testMetricsTool) Name() string        { return "metrics_test_tool" }
 func (t *testMetricsTool) Description() string { return "Tool for testing metrics" }
-func (t *testMetricsTool) Execute(args map[string]any) (any, error) {
+func (t *testMetricsTool) Execute(ctx context.Context, args map[string]any) (any, error) {
 	return "test result", nil
 }
 func (t *testMetricsTool) GetAPITool() *api.Tool {
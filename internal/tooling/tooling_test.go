@@ -1,12 +1,17 @@
 package tooling
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ollama/ollama/api"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
 )
 
 func TestFileSystemTool_GetWorkingDirectory(t *testing.T) {
@@ -16,7 +21,7 @@ func TestFileSystemTool_GetWorkingDirectory(t *testing.T) {
 		"action": "get_working_directory",
 	}
 
-	result, err := fst.Execute(args)
+	result, err := fst.Execute(t.Context(), args)
 	if err != nil {
 		t.Fatalf("Execute failed: %v", err)
 	}
@@ -66,7 +71,7 @@ func TestFileSystemTool_ListDirectory(t *testing.T) {
 		"path":   wd,
 	}
 
-	result, err := fst.Execute(args)
+	result, err := fst.Execute(t.Context(), args)
 	if err != nil {
 		t.Fatalf("Execute failed: %v", err)
 	}
@@ -90,6 +95,7 @@ func TestFileSystemTool_ListDirectory(t *testing.T) {
 
 func TestFileSystemTool_ReadFile(t *testing.T) {
 	fst := &FileSystemTool{}
+	fst.SetAccess(configuration.FileAccess{AllowedRoots: []string{os.TempDir()}})
 
 	// Create a temporary test file
 	tempFile := filepath.Join(os.TempDir(), "test_file.txt")
@@ -105,7 +111,7 @@ func TestFileSystemTool_ReadFile(t *testing.T) {
 		"path":   tempFile,
 	}
 
-	result, err := fst.Execute(args)
+	result, err := fst.Execute(t.Context(), args)
 	if err != nil {
 		t.Fatalf("Execute failed: %v", err)
 	}
@@ -145,7 +151,7 @@ func TestFileSystemTool_InvalidAction(t *testing.T) {
 		"path":   "/tmp",
 	}
 
-	_, err := fst.Execute(args)
+	_, err := fst.Execute(t.Context(), args)
 	if err == nil {
 		t.Error("Expected error for invalid action")
 	}
@@ -205,7 +211,7 @@ func (m *MockBuiltinTool) GetAPITool() *api.Tool {
 	}
 }
 
-func (m *MockBuiltinTool) Execute(args map[string]any) (any, error) {
+func (m *MockBuiltinTool) Execute(ctx context.Context, args map[string]any) (any, error) {
 	return map[string]any{
 		"tool":   m.name,
 		"result": "mock execution result",
@@ -297,7 +303,7 @@ func TestToolRegistry_ExecuteTool(t *testing.T) {
 		"param2": 42,
 	}
 
-	result, err := registry.ExecuteTool("executable_tool", args)
+	result, err := registry.ExecuteTool(t.Context(), "executable_tool", args)
 	if err != nil {
 		t.Errorf("Tool execution should not fail: %v", err)
 	}
@@ -312,7 +318,7 @@ func TestToolRegistry_ExecuteTool(t *testing.T) {
 	}
 
 	// Test execution of non-existent tool
-	_, err = registry.ExecuteTool("non_existent_tool", args)
+	_, err = registry.ExecuteTool(t.Context(), "non_existent_tool", args)
 	if err == nil {
 		t.Error("Execution of non-existent tool should fail")
 	}
@@ -322,6 +328,84 @@ func TestToolRegistry_ExecuteTool(t *testing.T) {
 	}
 }
 
+// blockingTool is a builtin tool whose calls wait until they are released or their
+// context is done
+type blockingTool struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingTool) Name() string          { return "blocking_tool" }
+func (b *blockingTool) Description() string   { return "Waits to be released" }
+func (b *blockingTool) GetAPITool() *api.Tool { return &api.Tool{Type: "function"} }
+
+func (b *blockingTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	b.started <- struct{}{}
+	select {
+	case <-b.release:
+		return "released", nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestToolRegistry_ExecuteTool_DoesNotLockWhileRunning(t *testing.T) {
+	registry := NewToolRegistry()
+	tool := &blockingTool{started: make(chan struct{}, 1), release: make(chan struct{})}
+	registry.Register(tool)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := registry.ExecuteTool(t.Context(), "blocking_tool", nil)
+		done <- err
+	}()
+	<-tool.started
+
+	// Registering needs the write lock, which a running call must not hold
+	registered := make(chan struct{})
+	go func() {
+		registry.Register(&MockBuiltinTool{name: "late_tool"})
+		close(registered)
+	}()
+	select {
+	case <-registered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Register blocked while a tool was running")
+	}
+
+	close(tool.release)
+	if err := <-done; err != nil {
+		t.Errorf("Tool execution failed: %v", err)
+	}
+}
+
+func TestToolRegistry_ExecuteTool_Context(t *testing.T) {
+	registry := NewToolRegistry()
+	tool := &blockingTool{started: make(chan struct{}, 1), release: make(chan struct{})}
+	registry.Register(tool)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := registry.ExecuteTool(ctx, "blocking_tool", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the call to end with its context, got %v", err)
+	}
+}
+
+func TestExecuteBashTool_StopsWithContext(t *testing.T) {
+	ebt := &ExecuteBashTool{}
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := ebt.Execute(ctx, map[string]any{"command": "sleep 10"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the command to stop with its context, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("Expected the command to be killed promptly, took %v", elapsed)
+	}
+}
+
 func TestDefaultRegistry_FileSystemTool(t *testing.T) {
 	// Test that the default registry has the filesystem tool registered
 	allTools := DefaultRegistry.GetAllTools()
@@ -341,7 +425,7 @@ func TestDefaultRegistry_FileSystemTool(t *testing.T) {
 	}
 
 	// Test the tool can be executed (basic validation)
-	_, err := DefaultRegistry.ExecuteTool("filesystem_read", map[string]any{
+	_, err := DefaultRegistry.ExecuteTool(t.Context(), "filesystem_read", map[string]any{
 		"operation": "get_working_directory",
 	})
 
@@ -388,7 +472,7 @@ func TestExecuteBashTool_BasicExecution(t *testing.T) {
 				"command": tt.command,
 			}
 
-			result, err := ebt.Execute(args)
+			result, err := ebt.Execute(t.Context(), args)
 			if (err != nil) != tt.expectError {
 				t.Errorf("Execute() error = %v, expectError %v", err, tt.expectError)
 				return
@@ -435,7 +519,7 @@ func TestExecuteBashTool_WorkingDirectory(t *testing.T) {
 		"working_dir": tempDir,
 	}
 
-	result, err := ebt.Execute(args)
+	result, err := ebt.Execute(t.Context(), args)
 	if err != nil {
 		t.Fatalf("Execute failed: %v", err)
 	}
@@ -521,7 +605,7 @@ func TestExecuteBashTool_ErrorHandling(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := ebt.Execute(tt.args)
+			result, err := ebt.Execute(t.Context(), tt.args)
 			if (err != nil) != tt.expectError {
 				t.Errorf("Execute() error = %v, expectError %v", err, tt.expectError)
 				return
@@ -548,7 +632,7 @@ func TestExecuteBashTool_CommandFailure(t *testing.T) {
 		"command": "exit 1", // Command that exits with code 1
 	}
 
-	result, err := ebt.Execute(args)
+	result, err := ebt.Execute(t.Context(), args)
 	if err != nil {
 		t.Fatalf("Execute should not return error for command with non-zero exit code: %v", err)
 	}
@@ -579,7 +663,7 @@ func TestExecuteBashTool_Timeout(t *testing.T) {
 		"timeout": 1,                   // 1 second timeout
 	}
 
-	result, err := ebt.Execute(args)
+	result, err := ebt.Execute(t.Context(), args)
 	if err == nil {
 		t.Error("Expected timeout error for long-running command")
 		if result != nil {
@@ -636,7 +720,7 @@ func TestExecuteBashTool_TimeoutBoundaries(t *testing.T) {
 				"timeout": tt.timeout,
 			}
 
-			result, err := ebt.Execute(args)
+			result, err := ebt.Execute(t.Context(), args)
 			if err != nil {
 				t.Fatalf("Execute failed: %v", err)
 			}
@@ -22,7 +22,7 @@ func TestTabVisibilityWithSystemPrompt(t *testing.T) {
 		RAGEnabled:          true,
 		ChromaDBDistance:    0.7,
 		MaxDocuments:        10,
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 		ToolTrustLevels:     make(map[string]int),
 		MCPServers:          []configuration.MCPServer{},
 		LogLevel:            "info",
@@ -86,7 +86,7 @@ func TestConfigurationTabHeightConsistency(t *testing.T) {
 		OllamaURL:           "http://localhost:11434",
 		DefaultSystemPrompt: "Test prompt",
 		RAGEnabled:          true,
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 		ToolTrustLevels:     make(map[string]int),
 		MCPServers:          []configuration.MCPServer{},
 		LogLevel:            "info",
@@ -68,6 +68,9 @@ func NewModel(ctx context.Context, config *configuration.Config) *Model {
 	agentsDetector := agents.NewDetector(config.AgentsFileEnabled)
 	logger.Debug("Created agents detector", "enabled", config.AgentsFileEnabled)
 
+	// MCP servers and the file tools see the directory AGENTS.md detection runs in as their root
+	syncProjectDirectory(agentsDetector, sharedMCPManager)
+
 	// Detect AGENTS.md file in working directory
 	agentsFile, err := agentsDetector.DetectInWorkingDirectory()
 	if err != nil {
@@ -91,10 +94,24 @@ func NewModel(ctx context.Context, config *configuration.Config) *Model {
 		mcpModel:       mcpTab.NewModel(ctx, config, sharedMCPManager),
 	}
 
+	model.chatModel.SetMCPManager(sharedMCPManager)
+
 	logger.Info("TUI model created successfully")
 	return model
 }
 
+// syncProjectDirectory exposes the directory AGENTS.md detection runs in to MCP servers,
+// which are notified when it has changed, and to the file tools as their default root
+func syncProjectDirectory(detector *agents.Detector, manager *mcpManager.Manager) {
+	dir, err := detector.WorkingDirectory()
+	if err != nil {
+		logging.WithComponent("tui-core").Warn("Failed to update the project directory", "error", err)
+		return
+	}
+	manager.SetWorkingDirectory(dir)
+	tooling.SetProjectDirectory(dir)
+}
+
 // Init initializes the TUI model
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
@@ -184,12 +201,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		logger := logging.WithComponent("tui-core")
 		// Debug ALL keys to see what we're actually receiving
 		logger.Debug("Key received", "key", msg.String(), "type", msg.Type, "runes", msg.Runes, "alt", msg.Alt)
-		
+
 		// Debug all keys to see if Page Up/Down reach the main TUI
 		if msg.String() == "pgup" || msg.String() == "pgdown" || msg.String() == "page_up" || msg.String() == "page_down" {
 			logger.Debug("Page Up/Down key received in main TUI", "key", msg.String(), "active_tab", m.activeTab)
 		}
-		
+
 		switch msg.String() {
 		case "ctrl+c":
 			logger.Info("User requested quit")
@@ -414,6 +431,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Update the main config
 			m.config = configMsg.Config
 
+			syncProjectDirectory(m.agentsDetector, m.mcpManager)
+
 			// Update the agents detector if the setting changed
 			if m.agentsDetector.IsEnabled() != configMsg.Config.AgentsFileEnabled {
 				logger.Info("AGENTS.md detection setting changed",
@@ -454,9 +473,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				"count", len(collectionsMsg.SelectedCollections))
 
 			// Handle collection selection changes
-			selectedCollectionsMap := make(map[string]bool)
+			selectedCollectionsMap := make(map[string]configuration.CollectionSettings)
 			for _, collectionName := range collectionsMsg.SelectedCollections {
-				selectedCollectionsMap[collectionName] = true
+				selectedCollectionsMap[collectionName] = configuration.CollectionSettings{Selected: true}
 			}
 
 			// Update the chat model's RAG service
@@ -473,6 +492,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			ragModel, ragCmd := m.ragModel.Update(msg)
 			m.ragModel = ragModel.(ragTab.Model)
 			cmd = ragCmd
+		} else if _, isSamplingRequest := msg.(chat.SamplingRequestMsg); isSamplingRequest {
+			// Sampling requests are answered in the chat tab, whichever tab is active
+			chatModel, chatCmd := m.chatModel.Update(msg)
+			m.chatModel = chatModel.(chat.Model)
+			cmd = chatCmd
 		} else if _, isConnectionMsg := msg.(connection.CheckMsg); isConnectionMsg {
 			// Check if this is a ConnectionCheckMsg and route it to config tab
 			configModel, configCmd := m.configModel.Update(msg)
@@ -741,9 +765,9 @@ func (m *Model) syncRAGCollections() {
 	}
 
 	// Convert to the map format expected by UpdateSelectedCollections
-	selectedCollectionsMap := make(map[string]bool)
+	selectedCollectionsMap := make(map[string]configuration.CollectionSettings)
 	for _, collectionName := range selectedCollectionNames {
-		selectedCollectionsMap[collectionName] = true
+		selectedCollectionsMap[collectionName] = configuration.CollectionSettings{Selected: true}
 	}
 
 	// Update the chat model's RAG service with the selected collections
@@ -18,7 +18,7 @@ func createBenchmarkConfig() *configuration.Config {
 		ChromaDBURL:         "http://localhost:8000",
 		ChromaDBDistance:    1.0,
 		MaxDocuments:        5,
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 		DefaultSystemPrompt: "You are a helpful assistant.",
 	}
 }
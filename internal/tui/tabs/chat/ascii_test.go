@@ -14,7 +14,7 @@ func TestASCIIArtDisplayLogic(t *testing.T) {
 	config := &configuration.Config{
 		ChatModel:           "llama3.1",
 		DefaultSystemPrompt: "You are a helpful assistant",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 	}
 
 	ctx := t.Context()
@@ -89,7 +89,7 @@ func TestASCIIArtNotDisplayedWhenMessagesExist(t *testing.T) {
 	config := &configuration.Config{
 		ChatModel:           "llama3.1",
 		DefaultSystemPrompt: "You are a helpful assistant",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 	}
 
 	ctx := t.Context()
@@ -1,9 +1,13 @@
 package chat
 
 import (
+	"fmt"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/kevensen/gollama-chat/internal/configuration"
+	"github.com/kevensen/gollama-chat/internal/tooling"
 	"github.com/ollama/ollama/api"
 )
 
@@ -73,7 +77,7 @@ func TestExecuteToolCallsAndCreateMessages(t *testing.T) {
 				ChatModel:           "llama3.1",
 				OllamaURL:           "http://localhost:11434",
 				DefaultSystemPrompt: "Test prompt",
-				SelectedCollections: make(map[string]bool),
+				SelectedCollections: make(map[string]configuration.CollectionSettings),
 				ToolTrustLevels: map[string]int{
 					tt.toolName: tt.trustLevel,
 				},
@@ -94,7 +98,7 @@ func TestExecuteToolCallsAndCreateMessages(t *testing.T) {
 			}
 
 			// Execute the tool calls
-			messages, err := model.executeToolCallsAndCreateMessages(toolCalls, "test-ulid-123")
+			messages, _, err := model.executeToolCallsAndCreateMessages(toolCalls, "test-ulid-123")
 
 			// For tools that don't exist, we should get an error message
 			if err != nil {
@@ -180,7 +184,7 @@ func TestMCPToolAuthorizationFlow(t *testing.T) {
 				ChatModel:           "llama3.1",
 				OllamaURL:           "http://localhost:11434",
 				DefaultSystemPrompt: "Test prompt",
-				SelectedCollections: make(map[string]bool),
+				SelectedCollections: make(map[string]configuration.CollectionSettings),
 				ToolTrustLevels: map[string]int{
 					tt.toolName: tt.trustLevel,
 				},
@@ -201,7 +205,7 @@ func TestMCPToolAuthorizationFlow(t *testing.T) {
 				},
 			}
 
-			messages, err := model.executeToolCallsAndCreateMessages(toolCalls, "test-ulid-123")
+			messages, _, err := model.executeToolCallsAndCreateMessages(toolCalls, "test-ulid-123")
 
 			if err != nil {
 				t.Errorf("Unexpected error: %v", err)
@@ -233,7 +237,7 @@ func TestDefaultTrustLevel(t *testing.T) {
 		ChatModel:           "llama3.1",
 		OllamaURL:           "http://localhost:11434",
 		DefaultSystemPrompt: "Test prompt",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 		// Explicitly empty ToolTrustLevels to test defaults
 		ToolTrustLevels: make(map[string]int),
 	}
@@ -244,15 +248,15 @@ func TestDefaultTrustLevel(t *testing.T) {
 	toolCalls := []api.ToolCall{
 		{
 			Function: api.ToolCallFunction{
-				Name: "filesystem_read",
+				Name: "execute_bash",
 				Arguments: map[string]any{
-					"action": "get_working_directory",
+					"command": "pwd",
 				},
 			},
 		},
 	}
 
-	messages, err := model.executeToolCallsAndCreateMessages(toolCalls, "test-ulid-123")
+	messages, _, err := model.executeToolCallsAndCreateMessages(toolCalls, "test-ulid-123")
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -284,7 +288,7 @@ func TestSecurityBypass(t *testing.T) {
 		ChatModel:           "test-model",
 		OllamaURL:           "http://localhost:11434",
 		DefaultSystemPrompt: "Test prompt",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 		ToolTrustLevels:     make(map[string]int),
 	}
 
@@ -305,7 +309,7 @@ func TestSecurityBypass(t *testing.T) {
 		},
 	}
 
-	messages, err := model.executeToolCallsAndCreateMessages(toolCalls, "test-ulid-123")
+	messages, _, err := model.executeToolCallsAndCreateMessages(toolCalls, "test-ulid-123")
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -345,3 +349,144 @@ func containsSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+// TestOfferedTools verifies that only rag_search can be offered to the model
+func TestOfferedTools(t *testing.T) {
+	config := configuration.DefaultConfig()
+	config.ToolTrustLevels = map[string]int{
+		"filesystem_read": 2,
+		"execute_bash":    0,
+		"rag_search":      2,
+	}
+	config.RAGEnabled = true
+	config.RAGMode = configuration.RAGModeTool
+
+	model := Model{config: config}
+
+	offered := make(map[string]bool)
+	for _, tool := range model.offeredTools() {
+		offered[tool.Function.Name] = true
+	}
+
+	if offered["filesystem_read"] {
+		t.Error("SECURITY VIOLATION: tool filesystem_read was offered to the model")
+	}
+	if offered["execute_bash"] {
+		t.Error("SECURITY VIOLATION: blocked tool execute_bash was offered to the model")
+	}
+	if offered["rag_search"] {
+		t.Error("Expected rag_search to be withheld when the RAG service is not ready")
+	}
+}
+
+// TestExecuteToolCallsAndCreateMessages_TrustRules tests that trust rules on arguments
+// take precedence over the tool's trust level
+func TestExecuteToolCallsAndCreateMessages_TrustRules(t *testing.T) {
+	config := &configuration.Config{
+		ChatModel:       "llama3.1",
+		ToolTrustLevels: map[string]int{"execute_bash": 0},
+		ToolTrustRules: []configuration.ToolTrustRule{
+			{Tool: "execute_bash", Pattern: "echo", Decision: configuration.TrustRuleAllow},
+			{Tool: "execute_bash", Pattern: "git push", Decision: configuration.TrustRuleAsk},
+			{Tool: "execute_bash", Pattern: "/rm -rf/", Decision: configuration.TrustRuleDeny},
+		},
+	}
+	model := NewModel(t.Context(), config)
+
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{"echo trusted", "trusted"},
+		{"git push origin main", "Allow execution"},
+		{"echo hi && rm -rf build", "blocked by trust rule: execute_bash * /rm -rf/ → deny"},
+		{"ls", "Tool trust is set to 'None'"},
+	}
+	for _, tt := range tests {
+		toolCalls := []api.ToolCall{{Function: api.ToolCallFunction{
+			Name:      "execute_bash",
+			Arguments: map[string]any{"command": tt.command},
+		}}}
+		messages, _, err := model.executeToolCallsAndCreateMessages(toolCalls, "test-ulid-123")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(messages) != 1 || !contains(messages[0].Content, tt.expected) {
+			t.Errorf("Expected the result of %q to contain %q, got %v", tt.command, tt.expected, messages)
+		}
+	}
+
+	// Rules allowing some commands do not make the tool offered to the model
+	for _, tool := range model.offeredTools() {
+		if tool.Function.Name == "execute_bash" {
+			t.Error("SECURITY VIOLATION: execute_bash was offered to the model")
+		}
+	}
+}
+
+func TestExecuteToolCallsAndCreateMessages_RecordsAuditLog(t *testing.T) {
+	tooling.SetAuditLogPath(filepath.Join(t.TempDir(), tooling.AuditFileName))
+	t.Cleanup(func() { tooling.SetAuditLogPath("") })
+
+	config := &configuration.Config{
+		ChatModel:       "llama3.1",
+		ToolTrustLevels: map[string]int{"execute_bash": 1, "filesystem_read": 2},
+		ToolTrustRules: []configuration.ToolTrustRule{
+			{Tool: "execute_bash", Pattern: "exit", Decision: configuration.TrustRuleAllow},
+			{Tool: "execute_bash", Pattern: "rm", Decision: configuration.TrustRuleDeny},
+		},
+	}
+	model := NewModel(t.Context(), config)
+
+	toolCall := func(name string, args map[string]any) api.ToolCall {
+		return api.ToolCall{Function: api.ToolCallFunction{Name: name, Arguments: args}}
+	}
+	toolCalls := []api.ToolCall{
+		toolCall("filesystem_read", map[string]any{"action": "get_working_directory"}),
+		toolCall("execute_bash", map[string]any{"command": "exit 3"}),
+		toolCall("execute_bash", map[string]any{"command": "rm -rf build"}),
+		toolCall("execute_bash", map[string]any{"command": "ls"}),
+		toolCall("no_such_tool", map[string]any{}),
+	}
+	if _, _, err := model.executeToolCallsAndCreateMessages(toolCalls, "conversation"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	entries, err := tooling.ReadAuditLog(tooling.AuditFilter{})
+	if err != nil || len(entries) != len(toolCalls) {
+		t.Fatalf("Expected an audit entry per request, got %d (%v)", len(entries), err)
+	}
+	// Calls that run finish in any order, so match the entries to the requests
+	slices.SortFunc(entries, func(a, b tooling.AuditEntry) int {
+		position := func(entry tooling.AuditEntry) int {
+			return slices.IndexFunc(toolCalls, func(call api.ToolCall) bool {
+				return call.Function.Name == entry.Tool && fmt.Sprint(call.Function.Arguments) == fmt.Sprint(entry.Arguments)
+			})
+		}
+		return position(a) - position(b)
+	})
+
+	expected := []struct{ decision, decidedBy, status string }{
+		{tooling.AuditAllow, tooling.DecidedBySessionTrust, tooling.AuditStatusSuccess},
+		{tooling.AuditAllow, tooling.DecidedByRule, tooling.AuditStatusFailed},
+		{tooling.AuditDeny, tooling.DecidedByRule, tooling.AuditStatusBlocked},
+		{tooling.AuditAsk, tooling.DecidedByTrustLevel, tooling.AuditStatusPending},
+		{"", "", tooling.AuditStatusError},
+	}
+	for i, want := range expected {
+		entry := entries[i]
+		if entry.Decision != want.decision || entry.DecidedBy != want.decidedBy || entry.Status != want.status {
+			t.Errorf("Entry %d for %s: expected %s by %q with status %s, got %s by %q with status %s",
+				i, entry.Tool, want.decision, want.decidedBy, want.status, entry.Decision, entry.DecidedBy, entry.Status)
+		}
+		if entry.ConversationID != "conversation" {
+			t.Errorf("Entry %d: expected the conversation ID, got %q", i, entry.ConversationID)
+		}
+	}
+	if entries[1].ExitCode == nil || *entries[1].ExitCode != 3 || entries[1].Source != "builtin" {
+		t.Errorf("Expected the exit code and source of the command, got %+v", entries[1])
+	}
+	if entries[2].Rule != "execute_bash * rm → deny" {
+		t.Errorf("Expected the deciding rule, got %q", entries[2].Rule)
+	}
+}
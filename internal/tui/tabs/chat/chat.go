@@ -19,6 +19,7 @@ import (
 	"github.com/kevensen/gollama-chat/internal/logging"
 	"github.com/kevensen/gollama-chat/internal/rag"
 	"github.com/kevensen/gollama-chat/internal/tooling"
+	"github.com/kevensen/gollama-chat/internal/tooling/mcp"
 	"github.com/kevensen/gollama-chat/internal/tui/tabs/chat/input"
 )
 
@@ -31,6 +32,10 @@ type Message struct {
 	ToolName  string         `json:"tool_name,omitempty"`  // For tool messages
 	Hidden    bool           `json:"hidden,omitempty"`     // Whether to hide from TUI display
 	ToolCalls []ToolCallInfo `json:"tool_calls,omitempty"` // For assistant messages with tool calls
+	// Attachments are resources returned by the tools used for the response
+	Attachments []tooling.ToolAttachment `json:"attachments,omitempty"`
+	// Diff is a change proposed by a tool, shown colored below the content
+	Diff string `json:"diff,omitempty"`
 }
 
 // ToolCallInfo stores tool call information for persistence
@@ -44,6 +49,7 @@ type ToolPermissionRequest struct {
 	ToolCall    api.ToolCall
 	ToolName    string
 	Description string
+	Preview     string // Unified diff of the change the tool would make, if it can tell
 }
 
 // Model represents the chat tab model
@@ -54,6 +60,7 @@ type Model struct {
 	height           int
 	scrollOffset     int
 	ragService       *rag.Service
+	mcpManager       *mcp.Manager // Source of MCP resources attached to the next message
 	ctx              context.Context
 	tokenCount       int  // Estimated token count for current conversation
 	showSystemPrompt bool // Whether to show the system prompt
@@ -81,6 +88,19 @@ type Model struct {
 	waitingForPermission  bool
 	pendingToolCalls      map[string]api.ToolCall // Store complete tool calls by tool name
 
+	// MCP prompt whose arguments are being entered
+	pendingPrompt *promptInvocation
+
+	// MCP sampling requests waiting for the user's approval
+	samplingRequests      chan SamplingRequestMsg
+	pendingSampling       *SamplingRequestMsg
+	queuedSampling        []SamplingRequestMsg
+	samplingResumeLoading bool // Whether the input was loading when the request was shown
+
+	// Live output of running tools
+	toolOutputs         []tooling.OutputSnapshot
+	toolOutputCollapsed bool
+
 	// ULID for conversation traceability
 	currentConversationULID string // The ULID for the current user prompt and its entire flow
 
@@ -169,8 +189,13 @@ func contentPreview(content string, maxLength int) string {
 
 // NewModel creates a new chat model
 func NewModel(ctx context.Context, config *configuration.Config) Model {
-	// Initialize RAG service
+	// Initialize RAG service and make it available to the rag_search tool
 	ragService := rag.NewService(config)
+	tooling.SetRAGSearcher(ragService)
+	tooling.SetBashSandbox(config.BashSandbox)
+	tooling.SetFileAccess(config.FileAccess)
+	tooling.SetHTTPFetch(config.HTTPFetch)
+	tooling.SetScriptTools(config.ScriptTools)
 
 	// Initialize input component
 	inputModel := input.NewModel()
@@ -200,8 +225,13 @@ func NewModel(ctx context.Context, config *configuration.Config) Model {
 
 // NewModelWithAgents creates a new chat model with AGENTS.md integration
 func NewModelWithAgents(ctx context.Context, config *configuration.Config, agentsFile *agents.AgentsFile) Model {
-	// Initialize RAG service
+	// Initialize RAG service and make it available to the rag_search tool
 	ragService := rag.NewService(config)
+	tooling.SetRAGSearcher(ragService)
+	tooling.SetBashSandbox(config.BashSandbox)
+	tooling.SetFileAccess(config.FileAccess)
+	tooling.SetHTTPFetch(config.HTTPFetch)
+	tooling.SetScriptTools(config.ScriptTools)
 
 	// Initialize input component
 	inputModel := input.NewModel()
@@ -256,8 +286,9 @@ type sendMessageMsg struct {
 type responseMsg struct {
 	content            string
 	err                error
-	additionalMessages []Message // For tool calls and results that need to be added to history
-	conversationULID   string    // ULID for the entire conversation flow
+	additionalMessages []Message                // For tool calls and results that need to be added to history
+	attachments        []tooling.ToolAttachment // Resources returned by the tools used for the response
+	conversationULID   string                   // ULID for the entire conversation flow
 }
 
 // ragStatusMsg is sent to update RAG status in the input
@@ -293,6 +324,10 @@ func (m Model) Init() tea.Cmd {
 	})
 	cmds = append(cmds, contextCmd)
 
+	if samplingCmd := m.waitForSamplingRequest(); samplingCmd != nil {
+		cmds = append(cmds, samplingCmd)
+	}
+
 	if len(cmds) > 0 {
 		return tea.Batch(cmds...)
 	}
@@ -327,6 +362,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// Handle key messages by first checking for chat-level controls, then delegating to input
 	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		// A pending MCP sampling request is answered before anything else
+		if m.pendingSampling != nil {
+			switch keyMsg.String() {
+			case "enter":
+				response := strings.TrimSpace(strings.ToLower(m.inputModel.Value()))
+				m.inputModel.Clear()
+				return m.answerSampling(response)
+			case "esc":
+				m.inputModel.Clear()
+				return m.resolveSampling(mcp.SamplingDenied)
+			}
+		}
+
+		// Esc aborts running MCP tool calls
+		if m.inputModel.IsLoading() && keyMsg.String() == "esc" {
+			return m.cancelToolCalls(), nil
+		}
+
+		// Ctrl+O collapses or expands the live output of running tools
+		if keyMsg.String() == "ctrl+o" {
+			return m.toggleToolOutput()
+		}
+
 		// Don't process certain keys while loading
 		if m.inputModel.IsLoading() && keyMsg.String() != "ctrl+c" && keyMsg.String() != "ctrl+l" {
 			return m, nil
@@ -351,6 +409,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		// Esc abandons an MCP prompt while its arguments are being entered
+		if key == "esc" && m.pendingPrompt != nil {
+			return m.cancelPrompt(), nil
+		}
+
 		// Handle chat-level control keys
 		switch key {
 		case "enter":
@@ -391,6 +454,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					logConversationEvent(invalidULID, "system", invalidMsg.Content, m.config.ChatModel)
 					return m, nil
 				}
+			} else if m.pendingPrompt != nil {
+				// The input answers the pending MCP prompt's current argument
+				value := strings.TrimSpace(m.inputModel.Value())
+				m.inputModel.Clear()
+				return m.answerPromptArgument(value)
 			} else if strings.TrimSpace(m.inputModel.Value()) != "" {
 				userInput := strings.TrimSpace(m.inputModel.Value())
 
@@ -413,6 +481,38 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				}
 
+				// Handle /detach command
+				if userInput == "/detach" {
+					m.inputModel.Clear()
+					if m.mcpManager != nil {
+						m.mcpManager.ClearAttachments()
+					}
+					m.statusNeedsUpdate = true
+
+					logger := logging.WithComponent("chat")
+					logger.Info("Attached MCP resources cleared by user command")
+
+					return m, nil
+				}
+
+				// Handle /undo command, which reverts the last file change made by a tool
+				if userInput == "/undo" {
+					m.inputModel.Clear()
+					return m.undoFileChange()
+				}
+
+				// Handle /output command, which opens the last saved tool output
+				if userInput == "/output" {
+					m.inputModel.Clear()
+					return m.openToolOutput()
+				}
+
+				// Handle /server:prompt commands, which invoke MCP prompts
+				if server, name, ok := parsePromptCommand(userInput); ok && m.mcpManager != nil {
+					m.inputModel.Clear()
+					return m.startPrompt(server, name)
+				}
+
 				// Generate a single ULID for the entire conversation flow
 				conversationULID := generateULID()
 				m.currentConversationULID = conversationULID
@@ -451,7 +551,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.updateTokenCount()
 				m.statusNeedsUpdate = true
 
-				return m, m.sendMessage(prompt, conversationULID)
+				return m, tea.Batch(m.sendMessage(prompt, conversationULID), m.toolProgressTick())
 			}
 
 		case "ctrl+s":
@@ -595,7 +695,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			// Get system prompt height
 			systemPromptHeight := m.getSystemPromptHeight()
-			availableHeight := m.height - 6 - systemPromptHeight // Reserve space for input area, status bar, and system prompt
+			availableHeight := m.height - 6 - systemPromptHeight - m.toolOutputHeight() // Reserve space for input area, status bar, system prompt and tool output
 
 			if messagesHeight > availableHeight {
 				maxScroll := messagesHeight - availableHeight
@@ -609,8 +709,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "pgup":
 			// Page Up - scroll up by available height
 			systemPromptHeight := m.getSystemPromptHeight()
-			availableHeight := m.height - 6 - systemPromptHeight // Reserve space for input area, status bar, and system prompt
-			pageSize := availableHeight - 1                      // Leave one line for context
+			availableHeight := m.height - 6 - systemPromptHeight - m.toolOutputHeight() // Reserve space for input area, status bar, system prompt and tool output
+			pageSize := availableHeight - 1                                             // Leave one line for context
 			if pageSize < 1 {
 				pageSize = 1
 			}
@@ -625,7 +725,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "pgdown":
 			// Page Down - scroll down by available height
 			systemPromptHeight := m.getSystemPromptHeight()
-			availableHeight := m.height - 6 - systemPromptHeight // Reserve space for input area, status bar, and system prompt
+			availableHeight := m.height - 6 - systemPromptHeight - m.toolOutputHeight() // Reserve space for input area, status bar, system prompt and tool output
 			pageSize := max(
 				// Leave one line for context
 				availableHeight-1, 1)
@@ -670,6 +770,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case sendMessageMsg:
 		return m, m.sendMessage(msg.message, msg.conversationULID)
 
+	case toolProgressTickMsg:
+		return m.updateToolProgress()
+
+	case approvedToolResultMsg:
+		return m.showApprovedToolResult(msg)
+
+	case toolOutputClosedMsg:
+		if msg.err != nil {
+			m.addSystemMessage(fmt.Sprintf("Failed to open tool output: %v", msg.err))
+		}
+		return m, nil
+
+	case promptLoadedMsg:
+		return m.insertPromptMessages(msg)
+
+	case SamplingRequestMsg:
+		return m.queueSamplingRequest(msg)
+
 	case ragStatusMsg:
 		// Update the input's RAG status
 		m.inputModel.SetRAGStatus(msg.status)
@@ -677,6 +795,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case responseMsg:
 		m.inputModel.SetLoading(false)
+		m.samplingResumeLoading = false
 		if msg.err != nil {
 			// Add error message using conversation ULID for traceability
 			errorMsg := Message{
@@ -701,27 +820,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					toolName := additionalMsg.ToolName
 					if toolName != "" {
 						// Extract arguments from the TOOL_CALL_DATA section
-						var arguments map[string]any
-						if strings.Contains(additionalMsg.Content, "TOOL_CALL_DATA:") {
-							// Parse the tool call data - this is a simplified approach
-							// The format is: TOOL_CALL_DATA:toolname:arguments
-							parts := strings.Split(additionalMsg.Content, "TOOL_CALL_DATA:")
-							if len(parts) > 1 {
-								// For now, we'll reconstruct the arguments based on the tool
-								// This is not ideal but works for the filesystem tool
-								if toolName == "filesystem_read" && strings.Contains(additionalMsg.Content, "get_working_directory") {
-									arguments = map[string]any{
-										"action": "get_working_directory",
-									}
-								} else {
-									arguments = make(map[string]any)
-								}
-							} else {
-								arguments = make(map[string]any)
-							}
-						} else {
-							arguments = make(map[string]any)
-						}
+						arguments := parseToolCallData(additionalMsg.Content, toolName)
 
 						// Create the complete tool call
 						toolCall := api.ToolCall{
@@ -739,6 +838,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							ToolCall:    toolCall,
 							ToolName:    toolName,
 							Description: fmt.Sprintf("Tool '%s' requires permission to execute", toolName),
+							Preview:     previewToolCall(toolCall),
 						}
 						m.waitingForPermission = true
 						m.inputModel.SetPlaceholder("Type your response...")
@@ -752,6 +852,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							Time:     time.Now(),
 							ULID:     msg.conversationULID, // Use conversation ULID for traceability
 							ToolName: additionalMsg.ToolName,
+							Diff:     m.pendingToolPermission.Preview,
 						}
 						m.messages = append(m.messages, cleanMsg)
 
@@ -785,10 +886,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 				assistantMsg := Message{
-					Role:    "assistant",
-					Content: responseContent,
-					Time:    time.Now(),
-					ULID:    msg.conversationULID, // Use conversation ULID for traceability
+					Role:        "assistant",
+					Content:     responseContent,
+					Time:        time.Now(),
+					ULID:        msg.conversationULID, // Use conversation ULID for traceability
+					Attachments: msg.attachments,
 				}
 				m.messages = append(m.messages, assistantMsg)
 
@@ -850,6 +952,11 @@ func (m Model) View() string {
 	}
 	components = append(components, messagesView)
 
+	// Live output of running tools, between the messages and the status bar
+	if len(m.toolOutputs) > 0 {
+		components = append(components, m.renderToolOutput(time.Now()))
+	}
+
 	// Status bar - only recompute if needed
 	if m.statusNeedsUpdate || m.cachedStatusView == "" {
 		statusView = m.renderStatusBar()
@@ -879,7 +986,7 @@ func (m Model) scrollToBottom() {
 	// Get system prompt height
 	systemPromptHeight := m.getSystemPromptHeight()
 
-	availableHeight := m.height - 6 - systemPromptHeight // Adjust for input area, status bar and system prompt
+	availableHeight := m.height - 6 - systemPromptHeight - m.toolOutputHeight() // Adjust for input area, status bar, system prompt and tool output
 	if messagesHeight > availableHeight {
 		m.scrollOffset = messagesHeight - availableHeight
 	} else {
@@ -1261,6 +1368,13 @@ func (m *Model) renderStatusBar() string {
 	// Combine information with spacing
 	status := fmt.Sprintf("%s | %s | %s (%d%%)", modelInfo, contextInfo, tokenInfo, percentUsed)
 
+	// Show resources waiting to be sent with the next message
+	if m.mcpManager != nil {
+		if count := len(m.mcpManager.Attachments()); count > 0 {
+			status += fmt.Sprintf(" | 📎 %d resource(s)", count)
+		}
+	}
+
 	return statusStyle.Render(status)
 }
 
@@ -1283,6 +1397,18 @@ func (m *Model) getCachedModelContextSize() int {
 	return contextSize
 }
 
+// SetMCPManager sets the MCP manager whose attached resources are sent with the next message.
+// The manager's sampling requests are approved through the chat tab.
+func (m *Model) SetMCPManager(manager *mcp.Manager) {
+	m.mcpManager = manager
+	m.statusNeedsUpdate = true
+
+	if manager != nil {
+		m.samplingRequests = make(chan SamplingRequestMsg)
+		manager.SetSamplingApprover(samplingApprover(m.samplingRequests))
+	}
+}
+
 // GetRAGService returns the RAG service for external access
 func (m Model) GetRAGService() *rag.Service {
 	return m.ragService
@@ -1299,58 +1425,85 @@ func (m Model) executeApprovedTool(updateTrustLevel bool) (tea.Model, tea.Cmd) {
 		_ = m.config.SetToolTrustLevel(m.pendingToolPermission.ToolName, 2) // TrustSession
 	}
 
-	// Execute the tool using the unified tool system
-	result, err := tooling.DefaultRegistry.ExecuteTool(m.pendingToolPermission.ToolCall.Function.Name, m.pendingToolPermission.ToolCall.Function.Arguments)
-	if err != nil {
+	// Execute the tool in the background so its output can be shown while it runs
+	toolCall := m.pendingToolPermission.ToolCall
+	m.pendingToolPermission = nil
+	m.waitingForPermission = false
+	m.inputModel.SetPlaceholder("Type your question...")
+	m.inputModel.SetLoading(true)
+
+	audit := tooling.NewAuditEntry(m.currentConversationULID, toolCall.Function.Name, toolCall.Function.Arguments)
+	audit.Decision, audit.DecidedBy = tooling.AuditAllow, tooling.DecidedByUser
+	return m, tea.Batch(m.runApprovedTool(audit), m.toolProgressTick())
+}
+
+// showApprovedToolResult shows the result of a tool call the user approved
+func (m Model) showApprovedToolResult(msg approvedToolResultMsg) (tea.Model, tea.Cmd) {
+	m.inputModel.SetLoading(false)
+	m = m.updateToolOutputs()
+
+	if msg.err != nil {
 		// Add error message using current conversation ULID
 		errorMsg := Message{
 			Role:    "assistant",
-			Content: fmt.Sprintf("Error executing %s: %v", m.pendingToolPermission.ToolName, err),
+			Content: fmt.Sprintf("Error executing %s: %v", msg.toolName, msg.err),
 			Time:    time.Now(),
-			ULID:    m.currentConversationULID, // Use conversation ULID for traceability
+			ULID:    msg.conversationULID, // Use conversation ULID for traceability
 		}
 		m.messages = append(m.messages, errorMsg)
 
 		// Log tool execution error with conversation ULID
-		logConversationEvent(m.currentConversationULID, "assistant", errorMsg.Content, m.config.ChatModel)
+		logConversationEvent(msg.conversationULID, "assistant", errorMsg.Content, m.config.ChatModel)
 	} else {
 		// Add successful result
 		var resultStr string
-		switch v := result.(type) {
+		var attachments []tooling.ToolAttachment
+		switch v := msg.result.(type) {
 		case string:
 			resultStr = v
+		case *tooling.ToolResult:
+			resultStr = v.Text
+			attachments = v.Attachments
 		default:
-			resultStr = fmt.Sprintf("%v", result)
+			resultStr = fmt.Sprintf("%v", msg.result)
+		}
+		resultStr, fullOutput := m.limitToolOutput(msg.toolName, msg.result, resultStr)
+		if fullOutput != nil {
+			attachments = append(attachments, *fullOutput)
 		}
 
 		resultMsg := Message{
-			Role:    "assistant",
-			Content: fmt.Sprintf("✅ Tool '%s' executed successfully:\n%s", m.pendingToolPermission.ToolName, resultStr),
-			Time:    time.Now(),
-			ULID:    m.currentConversationULID, // Use conversation ULID for traceability
+			Role:        "assistant",
+			Content:     fmt.Sprintf("✅ Tool '%s' executed successfully:\n%s", msg.toolName, resultStr),
+			Time:        time.Now(),
+			ULID:        msg.conversationULID, // Use conversation ULID for traceability
+			Attachments: attachments,
 		}
 		m.messages = append(m.messages, resultMsg)
 
 		// Log tool execution result with conversation ULID
-		logConversationEvent(m.currentConversationULID, "assistant", resultMsg.Content, m.config.ChatModel)
+		logConversationEvent(msg.conversationULID, "assistant", resultMsg.Content, m.config.ChatModel)
 	}
 
-	// Clear permission state
-	m.pendingToolPermission = nil
-	m.waitingForPermission = false
-	m.inputModel.SetPlaceholder("Type your question...")
-
 	// Update UI
 	m.messagesNeedsUpdate = true
 	m.messageCache.InvalidateCache()
 
 	return m, nil
-} // denyToolExecution denies the pending tool execution
+}
+
+// denyToolExecution denies the pending tool execution
 func (m Model) denyToolExecution() (tea.Model, tea.Cmd) {
 	if m.pendingToolPermission == nil {
 		return m, nil
 	}
 
+	// Record the user's decision in the audit log
+	toolCall := m.pendingToolPermission.ToolCall
+	audit := tooling.NewAuditEntry(m.currentConversationULID, toolCall.Function.Name, toolCall.Function.Arguments)
+	audit.Decision, audit.DecidedBy, audit.Status = tooling.AuditDeny, tooling.DecidedByUser, tooling.AuditStatusBlocked
+	tooling.RecordToolCall(audit)
+
 	// Add denial message using current conversation ULID
 	denialMsg := Message{
 		Role:    "assistant",
@@ -1390,13 +1543,21 @@ func (m *Model) UpdateFromConfiguration(ctx context.Context, newConfig *configur
 		"new_embedding_model", newConfig.EmbeddingModel,
 	)
 
+	// The sandbox applies to the next command execute_bash runs
+	tooling.SetBashSandbox(newConfig.BashSandbox)
+	tooling.SetFileAccess(newConfig.FileAccess)
+	tooling.SetHTTPFetch(newConfig.HTTPFetch)
+	tooling.SetScriptTools(newConfig.ScriptTools)
+
 	// Check if RAG-related settings have changed
 	ragSettingsChanged := m.config.RAGEnabled != newConfig.RAGEnabled ||
+		m.config.VectorStore != newConfig.VectorStore ||
+		m.config.LocalStorePath != newConfig.LocalStorePath ||
 		m.config.ChromaDBURL != newConfig.ChromaDBURL ||
 		m.config.EmbeddingModel != newConfig.EmbeddingModel ||
 		m.config.ChromaDBDistance != newConfig.ChromaDBDistance ||
 		m.config.MaxDocuments != newConfig.MaxDocuments ||
-		!equalStringMaps(m.config.SelectedCollections, newConfig.SelectedCollections)
+		!equalCollectionMaps(m.config.SelectedCollections, newConfig.SelectedCollections)
 
 	logger.Info("RAG settings change check",
 		"ragSettingsChanged", ragSettingsChanged,
@@ -1457,8 +1618,8 @@ func (m *Model) UpdateFromConfiguration(ctx context.Context, newConfig *configur
 	}
 }
 
-// equalStringMaps compares two map[string]bool for equality
-func equalStringMaps(a, b map[string]bool) bool {
+// equalCollectionMaps compares two collection settings maps for equality
+func equalCollectionMaps(a, b map[string]configuration.CollectionSettings) bool {
 	if len(a) != len(b) {
 		return false
 	}
@@ -1509,6 +1670,14 @@ func (m *Model) formatConversationHistory() string {
 				sb.WriteString("  " + line + "\n")
 			}
 		}
+		for _, attachment := range msg.Attachments {
+			sb.WriteString("  Attachment: " + attachment.Label() + "\n")
+		}
+		if msg.Diff != "" {
+			for _, line := range splitDiffLines(msg.Diff) {
+				sb.WriteString("  " + line + "\n")
+			}
+		}
 
 		// Add separator between messages (except for the last one)
 		if i < len(m.messages)-1 {
@@ -3,6 +3,8 @@ package chat
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -11,6 +13,9 @@ import (
 	"github.com/ollama/ollama/api"
 
 	"github.com/kevensen/gollama-chat/internal/configuration"
+	"github.com/kevensen/gollama-chat/internal/rag"
+	"github.com/kevensen/gollama-chat/internal/tooling"
+	"github.com/kevensen/gollama-chat/internal/tooling/mcp"
 )
 
 func TestNewModel(t *testing.T) {
@@ -24,7 +29,7 @@ func TestNewModel(t *testing.T) {
 		RAGEnabled:          true,
 		ChromaDBDistance:    1.0,
 		MaxDocuments:        10,
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 	}
 
 	ctx := t.Context()
@@ -45,7 +50,7 @@ func TestNewModel(t *testing.T) {
 			config: &configuration.Config{
 				ChatModel:           "llama3.1",
 				DefaultSystemPrompt: "Test prompt",
-				SelectedCollections: make(map[string]bool),
+				SelectedCollections: make(map[string]configuration.CollectionSettings),
 			},
 		},
 	}
@@ -117,7 +122,7 @@ func TestModel_BasicGetters(t *testing.T) {
 	config := &configuration.Config{
 		ChatModel:           "llama3.1",
 		DefaultSystemPrompt: "Test prompt",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 	}
 	ctx := t.Context()
 	model := NewModel(ctx, config)
@@ -149,7 +154,7 @@ func TestModel_SetSize(t *testing.T) {
 	config := &configuration.Config{
 		ChatModel:           "llama3.1",
 		DefaultSystemPrompt: "Test prompt",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 	}
 	ctx := t.Context()
 	model := NewModel(ctx, config)
@@ -188,7 +193,7 @@ func TestModel_ScrollManagement(t *testing.T) {
 	config := &configuration.Config{
 		ChatModel:           "llama3.1",
 		DefaultSystemPrompt: "Test prompt",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 	}
 	ctx := t.Context()
 	model := NewModel(ctx, config)
@@ -221,7 +226,7 @@ func TestModel_CacheManagement(t *testing.T) {
 	config := &configuration.Config{
 		ChatModel:           "llama3.1",
 		DefaultSystemPrompt: "Test prompt",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 	}
 	ctx := t.Context()
 	model := NewModel(ctx, config)
@@ -638,6 +643,37 @@ func TestModel_FormatMessage(t *testing.T) {
 }
 
 // TestModel_EnhancedFormatting tests the new enhanced formatting features
+func TestModel_FormatMessageAttachments(t *testing.T) {
+	model := NewModel(t.Context(), &configuration.Config{ChatModel: "test-model"})
+	model.width = 80
+	model.styles = DefaultStyles()
+
+	message := Message{
+		Role:    "assistant",
+		Content: "Here is the report.",
+		Time:    time.Now(),
+		Attachments: []tooling.ToolAttachment{
+			{URI: "file:///report.csv", MimeType: "text/csv", Size: 2048},
+			{URI: "https://example.com/logo.png", Name: "logo"},
+		},
+	}
+
+	allContent := strings.Join(model.formatMessage(message), "\n")
+	for _, expected := range []string{
+		"📎 file:///report.csv (text/csv, 2.0 KB)",
+		"📎 logo (https://example.com/logo.png)",
+	} {
+		if !strings.Contains(allContent, expected) {
+			t.Errorf("Expected formatted message to contain %q, got:\n%s", expected, allContent)
+		}
+	}
+
+	model.messages = []Message{message}
+	if history := model.formatConversationHistory(); !strings.Contains(history, "Attachment: logo (https://example.com/logo.png)") {
+		t.Errorf("Expected conversation history to list attachments, got:\n%s", history)
+	}
+}
+
 func TestModel_EnhancedFormatting(t *testing.T) {
 	config := &configuration.Config{
 		ChatModel:      "test-model",
@@ -1059,7 +1095,7 @@ func TestToolPermissionRequest(t *testing.T) {
 		ChatModel:           "llama3.1",
 		OllamaURL:           "http://localhost:11434",
 		DefaultSystemPrompt: "Test prompt",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 		ToolTrustLevels: map[string]int{
 			"filesystem_read": 1, // AskForTrust
 		},
@@ -1161,7 +1197,7 @@ func TestToolPermissionResponses(t *testing.T) {
 				ChatModel:           "llama3.1",
 				OllamaURL:           "http://localhost:11434",
 				DefaultSystemPrompt: "Test prompt",
-				SelectedCollections: make(map[string]bool),
+				SelectedCollections: make(map[string]configuration.CollectionSettings),
 				ToolTrustLevels:     make(map[string]int),
 			}
 
@@ -1242,7 +1278,7 @@ func TestPlaceholderTextChange(t *testing.T) {
 		ChatModel:           "llama3.1",
 		OllamaURL:           "http://localhost:11434",
 		DefaultSystemPrompt: "Test prompt",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 		ToolTrustLevels:     make(map[string]int),
 	}
 
@@ -1293,7 +1329,7 @@ func TestPermissionRequestDetection(t *testing.T) {
 		ChatModel:           "llama3.1",
 		OllamaURL:           "http://localhost:11434",
 		DefaultSystemPrompt: "Test prompt",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 		ToolTrustLevels:     make(map[string]int),
 	}
 
@@ -1331,6 +1367,48 @@ func TestPermissionRequestDetection(t *testing.T) {
 	}
 }
 
+func TestPermissionRequestPreviewsFileChange(t *testing.T) {
+	root := t.TempDir()
+	config := &configuration.Config{
+		ChatModel:       "llama3.1",
+		ToolTrustLevels: make(map[string]int),
+		FileAccess:      configuration.FileAccess{AllowedRoots: []string{root}},
+	}
+	model := NewModel(t.Context(), config)
+	model.width = 80
+	model.styles = DefaultStyles()
+
+	path := filepath.Join(root, "hello.txt")
+	toolCall := api.ToolCall{Function: api.ToolCallFunction{
+		Name:      "filesystem_write",
+		Arguments: map[string]any{"action": "create", "path": path, "content": "hello\n"},
+	}}
+	content := "❓ Tool 'filesystem_write' wants to execute with arguments: ...\n\nAllow execution? (y)es / (n)o / (t)rust for session\n\n" + toolCallData(toolCall)
+
+	updated, _ := model.Update(responseMsg{
+		additionalMessages: []Message{{Role: "tool", Content: content, ToolName: "filesystem_write", Time: time.Now()}},
+	})
+	updatedModel := updated.(Model)
+
+	if updatedModel.pendingToolPermission == nil {
+		t.Fatal("Pending tool permission should be set")
+	}
+	if got := updatedModel.pendingToolPermission.ToolCall.Function.Arguments; got["path"] != path || got["content"] != "hello\n" {
+		t.Errorf("Expected the tool call arguments to be kept, got %v", got)
+	}
+
+	last := updatedModel.messages[len(updatedModel.messages)-1]
+	if !strings.Contains(last.Diff, "+hello") || strings.Contains(last.Content, "TOOL_CALL_DATA") {
+		t.Errorf("Expected the permission request to show the diff, got content %q and diff %q", last.Content, last.Diff)
+	}
+	if formatted := strings.Join(updatedModel.formatMessage(last), "\n"); !strings.Contains(formatted, "+hello") {
+		t.Errorf("Expected the formatted message to include the diff, got:\n%s", formatted)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the file not to be written before approval, got %v", err)
+	}
+}
+
 func TestToolTrustLevelEnforcement(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -1368,7 +1446,7 @@ func TestToolTrustLevelEnforcement(t *testing.T) {
 				ChatModel:           "llama3.1",
 				OllamaURL:           "http://localhost:11434",
 				DefaultSystemPrompt: "Test prompt",
-				SelectedCollections: make(map[string]bool),
+				SelectedCollections: make(map[string]configuration.CollectionSettings),
 				ToolTrustLevels: map[string]int{
 					"filesystem_read": tt.trustLevel,
 				},
@@ -1392,7 +1470,7 @@ func TestSequentialChatInteractionFlow(t *testing.T) {
 	config := &configuration.Config{
 		ChatModel:           "test-model",
 		DefaultSystemPrompt: "Test assistant",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 		ToolTrustLevels:     map[string]int{},
 	}
 	ctx := t.Context()
@@ -1459,7 +1537,7 @@ func TestClearCommand(t *testing.T) {
 	config := &configuration.Config{
 		ChatModel:           "llama3.1",
 		DefaultSystemPrompt: "Test prompt",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 	}
 
 	ctx := t.Context()
@@ -1517,12 +1595,67 @@ func TestClearCommand(t *testing.T) {
 	t.Log("✓ /clear command successfully cleared chat history and input")
 }
 
+// TestDetachCommand tests that /detach is handled locally, even without an MCP manager
+func TestDetachCommand(t *testing.T) {
+	config := &configuration.Config{
+		ChatModel:           "llama3.1",
+		DefaultSystemPrompt: "Test prompt",
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
+	}
+
+	model := NewModel(t.Context(), config)
+	model.inputModel.SetValue("/detach")
+
+	updatedModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter, Runes: []rune{'\r'}})
+	model = updatedModel.(Model)
+
+	if len(model.messages) != 0 {
+		t.Errorf("Expected /detach not to add messages, got %d", len(model.messages))
+	}
+	if model.inputModel.Value() != "" {
+		t.Errorf("Expected input to be cleared after /detach, got '%s'", model.inputModel.Value())
+	}
+	if cmd != nil {
+		t.Errorf("Expected no command after /detach, got %v", cmd)
+	}
+}
+
+func TestFormatAttachmentsForPrompt(t *testing.T) {
+	formatted := formatAttachmentsForPrompt([]mcp.Attachment{
+		{Server: "docs", URI: "file:///notes.md", Name: "notes", Text: "Meeting notes"},
+		{Server: "docs", URI: "file:///todo.txt", Text: "Buy milk"},
+	})
+
+	for _, expected := range []string{
+		"=== ATTACHED RESOURCES ===",
+		"Resource notes (file:///notes.md, from docs):\nMeeting notes",
+		"Resource file:///todo.txt (file:///todo.txt, from docs):\nBuy milk",
+		"=== END RESOURCES ===",
+	} {
+		if !strings.Contains(formatted, expected) {
+			t.Errorf("Expected formatted attachments to contain %q, got:\n%s", expected, formatted)
+		}
+	}
+}
+
+func TestRAGScopeNotice(t *testing.T) {
+	query := rag.Query{Collections: []string{"docs"}, Filters: map[string]string{"version": "2", "lang": "go"}}
+
+	notice := ragScopeNotice(query, "test-ulid")
+	if notice.Role != "system" || notice.ULID != "test-ulid" {
+		t.Errorf("Unexpected notice %+v", notice)
+	}
+	if !strings.Contains(notice.Content, "No documents matched @docs #lang=go #version=2") {
+		t.Errorf("Expected the notice to name the scope, got %q", notice.Content)
+	}
+}
+
 func TestFormatConversationHistory(t *testing.T) {
 	// Create a test model
 	config := &configuration.Config{
 		ChatModel:           "llama3.1",
 		DefaultSystemPrompt: "You are a helpful assistant",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 	}
 
 	ctx := t.Context()
@@ -1609,7 +1742,7 @@ func TestCopyConversationKeyBinding(t *testing.T) {
 	config := &configuration.Config{
 		ChatModel:           "llama3.1",
 		DefaultSystemPrompt: "You are a helpful assistant",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 	}
 
 	ctx := t.Context()
@@ -17,6 +17,7 @@ type Model struct {
 	prompt      string         // Prompt prefix before the input
 	loading     bool           // Whether the input is in loading state
 	ragStatus   string         // RAG status message to display during loading
+	toolStatus  string         // Progress of running tool calls to display during loading
 	placeholder string         // Custom placeholder text
 }
 
@@ -47,6 +48,7 @@ func (m *Model) SetLoading(loading bool) {
 	m.loading = loading
 	if !loading {
 		m.ragStatus = "" // Clear RAG status when done loading
+		m.toolStatus = ""
 	}
 }
 
@@ -55,6 +57,11 @@ func (m *Model) SetRAGStatus(status string) {
 	m.ragStatus = status
 }
 
+// SetToolStatus sets the progress of running tool calls
+func (m *Model) SetToolStatus(status string) {
+	m.toolStatus = status
+}
+
 // SetPlaceholder sets the placeholder text
 func (m *Model) SetPlaceholder(placeholder string) {
 	m.placeholder = placeholder
@@ -258,6 +265,9 @@ func (m *Model) View() string {
 		if m.ragStatus != "" {
 			content += " (" + m.ragStatus + ")"
 		}
+		if m.toolStatus != "" {
+			content += " [" + m.toolStatus + "]"
+		}
 	} else {
 		// Pre-calculate content length to avoid multiple string operations
 		valueLen := len(m.value)
@@ -1,6 +1,7 @@
 package input
 
 import (
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -363,6 +364,22 @@ func TestModel_LoadingBehavior(t *testing.T) {
 	}
 }
 
+func TestModel_ToolStatus(t *testing.T) {
+	model := NewModel()
+	model.SetLoading(true)
+	model.SetToolStatus("⚙ files/search 50%")
+
+	if view := model.View(); !strings.Contains(view, "[⚙ files/search 50%]") {
+		t.Errorf("Expected tool status in loading view, got %q", view)
+	}
+
+	// Finishing loading clears the status
+	model.SetLoading(false)
+	if model.toolStatus != "" {
+		t.Errorf("Expected tool status to be cleared, got %q", model.toolStatus)
+	}
+}
+
 func TestModel_SpaceKeyHandling(t *testing.T) {
 	model := NewModel()
 
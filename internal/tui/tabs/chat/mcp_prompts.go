@@ -0,0 +1,185 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kevensen/gollama-chat/internal/logging"
+	"github.com/kevensen/gollama-chat/internal/tooling/mcp"
+)
+
+// promptInvocation collects the arguments of an MCP prompt invoked from the chat input
+type promptInvocation struct {
+	server    string
+	prompt    mcp.Prompt
+	arguments map[string]string
+	next      int // Index of the argument being asked for
+}
+
+// promptLoadedMsg carries the messages rendered by an MCP prompt
+type promptLoadedMsg struct {
+	server           string
+	name             string
+	result           *mcp.GetPromptResult
+	err              error
+	conversationULID string
+}
+
+// parsePromptCommand splits a "/server:prompt" command into its server and prompt names
+func parsePromptCommand(input string) (server, prompt string, ok bool) {
+	command, found := strings.CutPrefix(input, "/")
+	if !found || strings.ContainsAny(command, " \t\n") {
+		return "", "", false
+	}
+	server, prompt, found = strings.Cut(command, ":")
+	if !found || server == "" || prompt == "" {
+		return "", "", false
+	}
+	return server, prompt, true
+}
+
+// startPrompt begins invoking an MCP prompt, asking for its arguments one at a time
+func (m Model) startPrompt(server, name string) (Model, tea.Cmd) {
+	for _, prompt := range m.mcpManager.GetAllPrompts()[server] {
+		if prompt.Name == name {
+			m.pendingPrompt = &promptInvocation{
+				server:    server,
+				prompt:    prompt,
+				arguments: make(map[string]string),
+			}
+			return m.askPromptArgument()
+		}
+	}
+
+	m.addSystemMessage(fmt.Sprintf("Unknown MCP prompt /%s:%s. Prompts of running servers are listed in the MCP Servers tab.", server, name))
+	return m, nil
+}
+
+// askPromptArgument asks for the next argument of the pending prompt, or fetches the
+// prompt once all arguments have been collected
+func (m Model) askPromptArgument() (Model, tea.Cmd) {
+	invocation := m.pendingPrompt
+	if invocation.next >= len(invocation.prompt.Arguments) {
+		m.pendingPrompt = nil
+		m.inputModel.SetLoading(true)
+		conversationULID := generateULID()
+		m.currentConversationULID = conversationULID
+		return m, m.getPrompt(invocation, conversationULID)
+	}
+
+	argument := invocation.prompt.Arguments[invocation.next]
+	question := fmt.Sprintf("/%s:%s - enter %s", invocation.server, invocation.prompt.Name, argument.Name)
+	if argument.Required {
+		question += " (required)"
+	} else {
+		question += " (optional, Enter to skip)"
+	}
+	if argument.Description != "" {
+		question += ": " + argument.Description
+	}
+	m.addSystemMessage(question + ". Esc cancels.")
+	return m, nil
+}
+
+// answerPromptArgument records the value entered for the pending prompt's current argument
+func (m Model) answerPromptArgument(value string) (Model, tea.Cmd) {
+	invocation := m.pendingPrompt
+	argument := invocation.prompt.Arguments[invocation.next]
+
+	if value == "" && argument.Required {
+		m.addSystemMessage(fmt.Sprintf("%s is required. Enter a value or press Esc to cancel.", argument.Name))
+		return m, nil
+	}
+	if value != "" {
+		invocation.arguments[argument.Name] = value
+	}
+	invocation.next++
+	return m.askPromptArgument()
+}
+
+// cancelPrompt abandons the pending prompt
+func (m Model) cancelPrompt() Model {
+	invocation := m.pendingPrompt
+	m.pendingPrompt = nil
+	m.inputModel.Clear()
+	m.addSystemMessage(fmt.Sprintf("Cancelled /%s:%s", invocation.server, invocation.prompt.Name))
+	return m
+}
+
+// insertPromptMessages adds the messages rendered by a prompt to the conversation. When
+// the last message is from the user, it is sent to the model right away.
+func (m Model) insertPromptMessages(msg promptLoadedMsg) (Model, tea.Cmd) {
+	logger := logging.WithComponent("chat")
+
+	if msg.err != nil {
+		m.inputModel.SetLoading(false)
+		m.addSystemMessage(fmt.Sprintf("Failed to get MCP prompt /%s:%s: %v", msg.server, msg.name, msg.err))
+		return m, nil
+	}
+
+	var last Message
+	for _, promptMessage := range msg.result.Messages {
+		text := mcp.PromptContentText(promptMessage.Content)
+		if text == "" {
+			continue
+		}
+		last = Message{
+			Role:    promptMessage.Role,
+			Content: text,
+			Time:    time.Now(),
+			ULID:    msg.conversationULID,
+		}
+		m.messages = append(m.messages, last)
+		logConversationEvent(msg.conversationULID, last.Role, last.Content, m.config.ChatModel)
+	}
+
+	logger.Info("Inserted MCP prompt messages",
+		"server", msg.server,
+		"prompt", msg.name,
+		"messages", len(msg.result.Messages),
+		"conversation_id", msg.conversationULID,
+	)
+
+	m.messagesNeedsUpdate = true
+	m.messageCache.InvalidateCache()
+	m.updateTokenCount()
+	m.statusNeedsUpdate = true
+
+	if last.Role != "user" {
+		m.inputModel.SetLoading(false)
+		return m, nil
+	}
+	return m, tea.Batch(m.sendMessage(last.Content, msg.conversationULID), m.toolProgressTick())
+}
+
+// addSystemMessage shows an informational message in the conversation
+func (m *Model) addSystemMessage(content string) {
+	systemULID := generateULID()
+	m.messages = append(m.messages, Message{
+		Role:    "system",
+		Content: content,
+		Time:    time.Now(),
+		ULID:    systemULID,
+	})
+	m.messagesNeedsUpdate = true
+	m.messageCache.InvalidateCache()
+
+	logConversationEvent(systemULID, "system", content, m.config.ChatModel)
+}
+
+// Commands
+func (m Model) getPrompt(invocation *promptInvocation, conversationULID string) tea.Cmd {
+	return func() tea.Msg {
+		result, err := m.mcpManager.GetPrompt(invocation.server, invocation.prompt.Name, invocation.arguments)
+		return promptLoadedMsg{
+			server:           invocation.server,
+			name:             invocation.prompt.Name,
+			result:           result,
+			err:              err,
+			conversationULID: conversationULID,
+		}
+	}
+}
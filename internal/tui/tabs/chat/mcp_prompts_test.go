@@ -0,0 +1,132 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+	"github.com/kevensen/gollama-chat/internal/tooling/mcp"
+)
+
+func newPromptTestModel(t *testing.T) Model {
+	t.Helper()
+	config := &configuration.Config{
+		ChatModel:           "llama3.1",
+		DefaultSystemPrompt: "Test prompt",
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
+	}
+	return NewModel(t.Context(), config)
+}
+
+func TestParsePromptCommand(t *testing.T) {
+	tests := []struct {
+		input  string
+		server string
+		prompt string
+		ok     bool
+	}{
+		{"/github:review_pr", "github", "review_pr", true},
+		{"/clear", "", "", false},
+		{"/github:", "", "", false},
+		{"/:review", "", "", false},
+		{"/github:review extra", "", "", false},
+		{"github:review", "", "", false},
+	}
+
+	for _, tt := range tests {
+		server, prompt, ok := parsePromptCommand(tt.input)
+		if server != tt.server || prompt != tt.prompt || ok != tt.ok {
+			t.Errorf("parsePromptCommand(%q) = %q, %q, %t; want %q, %q, %t",
+				tt.input, server, prompt, ok, tt.server, tt.prompt, tt.ok)
+		}
+	}
+}
+
+func TestPromptArgumentsEnteredInteractively(t *testing.T) {
+	model := newPromptTestModel(t)
+	model.pendingPrompt = &promptInvocation{
+		server: "docs",
+		prompt: mcp.Prompt{
+			Name: "summarize",
+			Arguments: []mcp.PromptArgument{
+				{Name: "topic", Required: true},
+				{Name: "style"},
+			},
+		},
+		arguments: make(map[string]string),
+	}
+	invocation := model.pendingPrompt
+	enter := tea.KeyMsg{Type: tea.KeyEnter, Runes: []rune{'\r'}}
+
+	// A required argument cannot be skipped
+	updated, cmd := model.Update(enter)
+	model = updated.(Model)
+	if cmd != nil || invocation.next != 0 {
+		t.Fatal("Expected an empty required argument to be asked for again")
+	}
+
+	model.inputModel.SetValue("testing")
+	updated, _ = model.Update(enter)
+	model = updated.(Model)
+	if invocation.arguments["topic"] != "testing" || invocation.next != 1 {
+		t.Fatalf("Expected topic to be recorded, got %+v", invocation.arguments)
+	}
+
+	// Skipping the optional argument fetches the prompt
+	updated, cmd = model.Update(enter)
+	model = updated.(Model)
+	if cmd == nil || model.pendingPrompt != nil || !model.inputModel.IsLoading() {
+		t.Error("Expected the prompt to be fetched once all arguments are entered")
+	}
+	if _, ok := invocation.arguments["style"]; ok {
+		t.Error("Expected the skipped argument to be omitted")
+	}
+}
+
+func TestPromptCancelledWithEsc(t *testing.T) {
+	model := newPromptTestModel(t)
+	model.pendingPrompt = &promptInvocation{
+		server:    "docs",
+		prompt:    mcp.Prompt{Name: "summarize", Arguments: []mcp.PromptArgument{{Name: "topic"}}},
+		arguments: make(map[string]string),
+	}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model = updated.(Model)
+	if model.pendingPrompt != nil {
+		t.Error("Expected Esc to cancel the pending prompt")
+	}
+	if last := model.messages[len(model.messages)-1]; !strings.Contains(last.Content, "Cancelled /docs:summarize") {
+		t.Errorf("Expected a cancellation notice, got %q", last.Content)
+	}
+}
+
+func TestInsertPromptMessages(t *testing.T) {
+	model := newPromptTestModel(t)
+	model.inputModel.SetLoading(true)
+
+	result := &mcp.GetPromptResult{
+		Messages: []mcp.PromptMessage{
+			{Role: "user", Content: mcp.PromptContent{Type: "text", Text: "What changed?"}},
+			{Role: "assistant", Content: mcp.PromptContent{Type: "text", Text: "Let me look."}},
+		},
+	}
+	updated, cmd := model.Update(promptLoadedMsg{server: "docs", name: "changes", result: result, conversationULID: "ulid"})
+	model = updated.(Model)
+
+	if len(model.messages) != 2 || model.messages[0].Content != "What changed?" || model.messages[1].Role != "assistant" {
+		t.Fatalf("Expected prompt messages in the conversation, got %+v", model.messages)
+	}
+	if cmd != nil || model.inputModel.IsLoading() {
+		t.Error("Expected no request to the model when the prompt ends with an assistant message")
+	}
+
+	result.Messages = result.Messages[:1]
+	model.inputModel.SetLoading(true)
+	_, cmd = model.Update(promptLoadedMsg{server: "docs", name: "changes", result: result, conversationULID: "ulid"})
+	if cmd == nil {
+		t.Error("Expected the prompt to be sent when it ends with a user message")
+	}
+}
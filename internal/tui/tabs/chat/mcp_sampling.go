@@ -0,0 +1,144 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kevensen/gollama-chat/internal/logging"
+	"github.com/kevensen/gollama-chat/internal/tooling/mcp"
+)
+
+// SamplingRequestMsg asks the user to approve an MCP server's sampling request. It is
+// routed to the chat tab whichever tab is active.
+type SamplingRequestMsg struct {
+	Request mcp.SamplingRequest
+	reply   chan mcp.SamplingDecision
+}
+
+// samplingApprover returns an approver that asks the user through the chat tab
+func samplingApprover(requests chan<- SamplingRequestMsg) mcp.SamplingApprover {
+	return func(ctx context.Context, request mcp.SamplingRequest) mcp.SamplingDecision {
+		msg := SamplingRequestMsg{Request: request, reply: make(chan mcp.SamplingDecision, 1)}
+		select {
+		case requests <- msg:
+		case <-ctx.Done():
+			return mcp.SamplingDenied
+		}
+
+		select {
+		case decision := <-msg.reply:
+			return decision
+		case <-ctx.Done():
+			return mcp.SamplingDenied
+		}
+	}
+}
+
+// queueSamplingRequest shows a sampling request, or queues it behind the one being answered
+func (m Model) queueSamplingRequest(msg SamplingRequestMsg) (Model, tea.Cmd) {
+	if m.pendingSampling != nil {
+		m.queuedSampling = append(m.queuedSampling, msg)
+		return m, m.waitForSamplingRequest()
+	}
+	return m.showSamplingRequest(msg), m.waitForSamplingRequest()
+}
+
+// showSamplingRequest asks the user about a sampling request
+func (m Model) showSamplingRequest(msg SamplingRequestMsg) Model {
+	m.pendingSampling = &msg
+
+	// The answer is typed in the input, which ignores keys while a response is loading
+	m.samplingResumeLoading = m.inputModel.IsLoading()
+	m.inputModel.SetLoading(false)
+	m.inputModel.SetPlaceholder("Type your response...")
+
+	m.addSystemMessage(formatSamplingRequest(msg.Request))
+	return m
+}
+
+// answerSampling handles the user's response to the pending sampling request
+func (m Model) answerSampling(response string) (Model, tea.Cmd) {
+	switch response {
+	case "y", "yes":
+		return m.resolveSampling(mcp.SamplingApproved)
+	case "n", "no":
+		return m.resolveSampling(mcp.SamplingDenied)
+	case "t", "trust":
+		return m.resolveSampling(mcp.SamplingApprovedForSession)
+	default:
+		m.addSystemMessage("Please respond with 'y' (yes), 'n' (no), or 't' (trust for session)")
+		return m, nil
+	}
+}
+
+// resolveSampling passes the user's decision to the server's pending sampling request
+// and moves on to the next queued request
+func (m Model) resolveSampling(decision mcp.SamplingDecision) (Model, tea.Cmd) {
+	logger := logging.WithComponent("chat")
+
+	request := m.pendingSampling
+	request.reply <- decision
+	m.pendingSampling = nil
+
+	server := request.Request.Server
+	if decision == mcp.SamplingDenied {
+		m.addSystemMessage(fmt.Sprintf("❌ Sampling request from MCP server '%s' denied", server))
+	} else {
+		m.addSystemMessage(fmt.Sprintf("✅ Sampling request from MCP server '%s' approved", server))
+	}
+	logger.Info("Answered MCP sampling request",
+		"server", server,
+		"approved", decision != mcp.SamplingDenied,
+		"trusted_for_session", decision == mcp.SamplingApprovedForSession,
+		"conversation_id", m.currentConversationULID,
+	)
+
+	var cmd tea.Cmd
+	if m.samplingResumeLoading {
+		m.inputModel.SetLoading(true)
+		cmd = m.toolProgressTick()
+	}
+	m.samplingResumeLoading = false
+	m.inputModel.SetPlaceholder("Type your question...")
+
+	if len(m.queuedSampling) > 0 {
+		next := m.queuedSampling[0]
+		m.queuedSampling = m.queuedSampling[1:]
+		m = m.showSamplingRequest(next)
+	}
+	return m, cmd
+}
+
+// formatSamplingRequest describes the messages a server wants to send to the chat model
+func formatSamplingRequest(request mcp.SamplingRequest) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "❓ MCP server '%s' wants the chat model to answer:\n", request.Server)
+
+	params := request.Params
+	if params.SystemPrompt != "" {
+		fmt.Fprintf(&s, "\n[system] %s", params.SystemPrompt)
+	}
+	for _, message := range params.Messages {
+		fmt.Fprintf(&s, "\n[%s] %s", message.Role, mcp.PromptContentText(message.Content))
+	}
+	if params.MaxTokens > 0 {
+		fmt.Fprintf(&s, "\n\n(up to %d tokens)", params.MaxTokens)
+	}
+
+	s.WriteString("\n\nAllow? (y)es / (n)o / (t)rust for session")
+	return s.String()
+}
+
+// Commands
+func (m Model) waitForSamplingRequest() tea.Cmd {
+	if m.samplingRequests == nil {
+		return nil
+	}
+	requests := m.samplingRequests
+	return func() tea.Msg {
+		return <-requests
+	}
+}
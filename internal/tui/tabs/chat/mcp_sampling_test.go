@@ -0,0 +1,103 @@
+package chat
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kevensen/gollama-chat/internal/tooling/mcp"
+)
+
+// newSamplingRequest returns a sampling request from server and the channel receiving its answer
+func newSamplingRequest(server string) (SamplingRequestMsg, chan mcp.SamplingDecision) {
+	reply := make(chan mcp.SamplingDecision, 1)
+	return SamplingRequestMsg{
+		Request: mcp.SamplingRequest{
+			Server: server,
+			Params: &mcp.CreateMessageRequest{
+				Messages: []mcp.SamplingMessage{{Role: "user", Content: mcp.PromptContent{Type: "text", Text: "Summarize the notes"}}},
+			},
+		},
+		reply: reply,
+	}, reply
+}
+
+// answer types a response to the pending sampling request and presses Enter
+func answer(t *testing.T, model Model, response string) Model {
+	t.Helper()
+	for _, r := range response {
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		model = updated.(Model)
+	}
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	return updated.(Model)
+}
+
+func TestSamplingApproval(t *testing.T) {
+	model := newPromptTestModel(t)
+	model.inputModel.SetLoading(true) // A tool call is waiting on the request
+
+	first, firstReply := newSamplingRequest("files")
+	second, secondReply := newSamplingRequest("web")
+
+	updated, _ := model.Update(first)
+	model = updated.(Model)
+	updated, _ = model.Update(second)
+	model = updated.(Model)
+
+	if model.pendingSampling == nil || model.pendingSampling.Request.Server != "files" || len(model.queuedSampling) != 1 {
+		t.Fatalf("Expected the first request shown and the second queued, got %+v / %d queued", model.pendingSampling, len(model.queuedSampling))
+	}
+	if model.inputModel.IsLoading() {
+		t.Error("Expected the input to accept the answer while the request is pending")
+	}
+	last := model.messages[len(model.messages)-1].Content
+	if !strings.Contains(last, "MCP server 'files'") || !strings.Contains(last, "[user] Summarize the notes") {
+		t.Errorf("Expected the request's messages to be shown, got %q", last)
+	}
+
+	model = answer(t, model, "maybe")
+	if model.pendingSampling == nil || !strings.Contains(model.messages[len(model.messages)-1].Content, "Please respond") {
+		t.Fatal("Expected an invalid response to keep the request pending")
+	}
+
+	model = answer(t, model, "t")
+	if decision := <-firstReply; decision != mcp.SamplingApprovedForSession {
+		t.Errorf("Expected the first request trusted for the session, got %v", decision)
+	}
+	if model.pendingSampling == nil || model.pendingSampling.Request.Server != "web" {
+		t.Fatal("Expected the queued request to be shown next")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model = updated.(Model)
+	if decision := <-secondReply; decision != mcp.SamplingDenied {
+		t.Errorf("Expected Esc to deny the request, got %v", decision)
+	}
+	if model.pendingSampling != nil || !model.inputModel.IsLoading() {
+		t.Error("Expected no pending request and the input loading again")
+	}
+}
+
+func TestSamplingApprover(t *testing.T) {
+	requests := make(chan SamplingRequestMsg)
+	approve := samplingApprover(requests)
+
+	go func() {
+		msg := <-requests
+		msg.reply <- mcp.SamplingApproved
+	}()
+	if decision := approve(t.Context(), mcp.SamplingRequest{Server: "files"}); decision != mcp.SamplingApproved {
+		t.Errorf("Expected the user's decision, got %v", decision)
+	}
+
+	// A request the server gives up on is denied without waiting for the user
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if decision := approve(ctx, mcp.SamplingRequest{Server: "files"}); decision != mcp.SamplingDenied {
+		t.Errorf("Expected a cancelled request to be denied, got %v", decision)
+	}
+}
@@ -85,7 +85,7 @@ func (c *MessageCache) GetTotalHeight(model *Model) int {
 func (c *MessageCache) RenderAllMessages(model *Model) string {
 	// Calculate available height for messages, accounting for system prompt
 	systemPromptHeight := model.getSystemPromptHeight()
-	availableHeight := model.height - 6 - systemPromptHeight // Reserve space for input, status, and system prompt
+	availableHeight := model.height - 6 - systemPromptHeight - model.toolOutputHeight() // Reserve space for input, status, system prompt and tool output
 
 	// Apply styling with border regardless of message count
 	messageStyle := model.styles.messages.
@@ -1,23 +1,34 @@
 package chat
 
 import (
+	"context"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/url"
+	"os"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/ollama/ollama/api"
 
+	"github.com/kevensen/gollama-chat/internal/configuration"
 	"github.com/kevensen/gollama-chat/internal/logging"
+	"github.com/kevensen/gollama-chat/internal/rag"
 	"github.com/kevensen/gollama-chat/internal/tooling"
+	"github.com/kevensen/gollama-chat/internal/tooling/mcp"
 )
 
 // sendMessage sends a message to Ollama using the Ollama API client
 func (m Model) sendMessage(prompt string, conversationULID string) tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
 		var fullPrompt string
+		var notices []Message
+		collections := m.ragCollections()
+		text := m.chatPrompt(prompt, collections)
 
 		// If RAG is enabled, use it to retrieve relevant documents
 		ragLogger := logging.WithComponent("rag")
@@ -27,8 +38,8 @@ func (m Model) sendMessage(prompt string, conversationULID string) tea.Cmd {
 			"conversation_id", conversationULID,
 		)
 
-		if m.config.RAGEnabled && m.ragService != nil && m.ragService.IsReady() {
-			ragResult, err := m.ragService.QueryDocuments(m.ctx, prompt)
+		if m.config.RAGInjectsContext() && m.ragService != nil && m.ragService.IsReady() {
+			ragResult, err := m.ragService.QueryDocumentsWithHistory(m.ctx, prompt, m.ragHistory())
 			if err == nil && ragResult != nil && len(ragResult.Documents) > 0 {
 				// Log successful RAG document retrieval with detailed information
 
@@ -65,7 +76,7 @@ func (m Model) sendMessage(prompt string, conversationULID string) tea.Cmd {
 				}
 
 				// Add formatted RAG documents to the prompt
-				fullPrompt = ragResult.FormatDocumentsForPrompt() + prompt
+				fullPrompt = ragResult.FormatDocumentsForPrompt() + text
 			} else if err != nil {
 				// Log RAG query failure
 				ragLogger := logging.WithComponent("rag")
@@ -75,7 +86,10 @@ func (m Model) sendMessage(prompt string, conversationULID string) tea.Cmd {
 					"error", err.Error(),
 					"timestamp", time.Now().Format(time.RFC3339),
 				)
-				fullPrompt = prompt
+				fullPrompt = text
+				if query := rag.ParseQuery(prompt, collections); query.IsScoped() {
+					notices = append(notices, ragScopeNotice(query, conversationULID))
+				}
 			} else {
 				// Log when no relevant documents found
 				ragLogger := logging.WithComponent("rag")
@@ -84,17 +98,32 @@ func (m Model) sendMessage(prompt string, conversationULID string) tea.Cmd {
 					"query_preview", contentPreview(prompt, 100),
 					"timestamp", time.Now().Format(time.RFC3339),
 				)
-				fullPrompt = prompt
+				fullPrompt = text
+				if query := rag.ParseQuery(prompt, collections); query.IsScoped() {
+					notices = append(notices, ragScopeNotice(query, conversationULID))
+				}
 			}
 		} else {
 			// Log why RAG was not triggered
 			ragLogger.Info("RAG not triggered",
 				"conversation_id", conversationULID,
 				"rag_enabled", m.config.RAGEnabled,
+				"rag_mode", m.config.RAGMode,
 				"rag_service_nil", m.ragService == nil,
 				"rag_service_ready", m.ragService != nil && m.ragService.IsReady(),
 			)
-			fullPrompt = prompt
+			fullPrompt = text
+		}
+
+		// Include MCP resources the user attached to this message
+		if m.mcpManager != nil {
+			if attachments := m.mcpManager.TakeAttachments(); len(attachments) > 0 {
+				logging.WithComponent("chat").Info("Sending attached MCP resources",
+					"conversation_id", conversationULID,
+					"resources", len(attachments),
+				)
+				fullPrompt = formatAttachmentsForPrompt(attachments) + fullPrompt
+			}
 		}
 
 		// Create Ollama client with the configured URL
@@ -153,6 +182,9 @@ func (m Model) sendMessage(prompt string, conversationULID string) tea.Cmd {
 					Role:    msg.Role,
 					Content: msg.Content,
 				}
+				if msg.Role == "user" {
+					apiMsg.Content = m.chatPrompt(msg.Content, collections)
+				}
 
 				// For tool messages, set the ToolName field
 				if msg.Role == "tool" && msg.ToolName != "" {
@@ -193,8 +225,9 @@ func (m Model) sendMessage(prompt string, conversationULID string) tea.Cmd {
 		// that bypasses local authorization. All tool calls must go through local
 		// authorization as per AGENTS.md requirements.
 		//
-		// Previous code sent tools directly to Ollama, allowing models to execute
-		// tools server-side without user consent, violating the authorization requirements.
+		// The only exception is rag_search in the tool and both RAG modes. Its calls
+		// are still executed locally through executeToolCallsAndCreateMessages.
+		tools := m.offeredTools()
 
 		// Create chat request with stream enabled (true is default, but we're explicit)
 		stream := true
@@ -203,7 +236,7 @@ func (m Model) sendMessage(prompt string, conversationULID string) tea.Cmd {
 			Messages: messages,
 			Stream:   &stream,
 			Options:  options,
-			// Tools:    nil, // Explicitly do not send tools to prevent unauthorized execution
+			Tools:    tools,
 		}
 
 		// Use ChatStream for real-time response with enhanced error handling
@@ -229,6 +262,25 @@ func (m Model) sendMessage(prompt string, conversationULID string) tea.Cmd {
 			return nil
 		})
 
+		// Models without tool support reject requests that include tools; retry without them
+		if err != nil && len(tools) > 0 && responseErr == nil && isToolsUnsupportedError(err) {
+			logger := logging.WithComponent("chat")
+			logger.Info("Model does not support tools, retrying without tools", "model", m.config.ChatModel)
+
+			tools = nil
+			chatRequest.Tools = nil
+			fullResponse.Reset()
+			toolCalls = nil
+			err = client.Chat(m.ctx, chatRequest, func(response api.ChatResponse) error {
+				if m.ctx.Err() != nil {
+					responseErr = m.ctx.Err()
+					return responseErr
+				}
+				fullResponse.WriteString(response.Message.Content)
+				return nil
+			})
+		}
+
 		if err != nil {
 			if responseErr != nil {
 				return responseMsg{err: fmt.Errorf("chat response error: %w", responseErr)}
@@ -239,10 +291,11 @@ func (m Model) sendMessage(prompt string, conversationULID string) tea.Cmd {
 		// Execute tool calls if present and get follow-up response
 		responseContent := fullResponse.String()
 		var additionalMessages []Message
+		var attachments []tooling.ToolAttachment
 
 		if len(toolCalls) > 0 {
 			// Execute the tool calls
-			toolResultMessages, toolErr := m.executeToolCallsAndCreateMessages(toolCalls, conversationULID)
+			toolResultMessages, toolAttachments, toolErr := m.executeToolCallsAndCreateMessages(toolCalls, conversationULID)
 			if toolErr != nil {
 				responseContent += fmt.Sprintf("\n\n[Tool execution error: %v]", toolErr)
 			} else {
@@ -296,7 +349,7 @@ func (m Model) sendMessage(prompt string, conversationULID string) tea.Cmd {
 					Messages: messages,
 					Stream:   &stream,
 					Options:  options,
-					// Tools:    nil, // Explicitly do not send tools to prevent unauthorized execution
+					Tools:    tools,
 				}
 
 				var followUpResponse strings.Builder
@@ -313,111 +366,305 @@ func (m Model) sendMessage(prompt string, conversationULID string) tea.Cmd {
 				} else {
 					responseContent = followUpResponse.String()
 				}
+				attachments = toolAttachments
 			}
 		}
 
 		return responseMsg{
 			content:            responseContent,
-			additionalMessages: additionalMessages,
+			additionalMessages: append(notices, additionalMessages...),
+			attachments:        attachments,
 			conversationULID:   conversationULID,
 		}
 	})
 }
 
-// executeToolCallsAndCreateMessages executes the tool calls and returns the tool result messages
-func (m Model) executeToolCallsAndCreateMessages(toolCalls []api.ToolCall, conversationULID string) ([]api.Message, error) {
-	var messages []api.Message
+// chatPrompt returns the text of a user message as the chat model is sent it. When RAG
+// adds context, @collection and #key=value tokens only steer retrieval and are left out.
+func (m Model) chatPrompt(content string, collections []string) string {
+	if !m.config.RAGInjectsContext() {
+		return content
+	}
+	if text := rag.PromptText(content, collections); text != "" {
+		return text
+	}
+	return content
+}
+
+// ragCollections returns the collections @mentions can scope retrieval to
+func (m Model) ragCollections() []string {
+	if m.ragService == nil {
+		return nil
+	}
+	return m.ragService.Collections()
+}
+
+// ragScopeNotice returns the message telling the user that the @collection and
+// #key=value tokens of their prompt matched no documents
+func ragScopeNotice(query rag.Query, conversationULID string) Message {
+	var tokens []string
+	for _, collection := range query.Collections {
+		tokens = append(tokens, "@"+collection)
+	}
+	for _, key := range slices.Sorted(maps.Keys(query.Filters)) {
+		tokens = append(tokens, "#"+key+"="+query.Filters[key])
+	}
+	return Message{
+		Role:    "system",
+		Content: fmt.Sprintf("No documents matched %s; the message was sent without retrieved context.", strings.Join(tokens, " ")),
+		Time:    time.Now(),
+		ULID:    conversationULID,
+	}
+}
+
+// ragHistory returns the visible conversation preceding the current prompt, used to
+// rewrite follow-up questions into standalone RAG queries
+func (m Model) ragHistory() []rag.HistoryTurn {
+	history := make([]rag.HistoryTurn, 0, len(m.messages))
+	for i, msg := range m.messages {
+		// The current prompt is the last message and is passed separately
+		if i == len(m.messages)-1 && msg.Role == "user" {
+			break
+		}
+		if msg.Hidden || (msg.Role != "user" && msg.Role != "assistant") {
+			continue
+		}
+		history = append(history, rag.HistoryTurn{Role: msg.Role, Content: msg.Content})
+	}
+	return history
+}
+
+// offeredTools returns the tool definitions sent to the model. Only rag_search is
+// offered, and only when the RAG mode uses it and the tool is not blocked.
+func (m Model) offeredTools() api.Tools {
+	if !m.config.RAGToolEnabled() || m.ragService == nil || !m.ragService.IsReady() {
+		return nil
+	}
+	if m.config.GetToolTrustLevel("rag_search") == 0 && !hasPermissiveRule(m.config, "rag_search") {
+		return nil
+	}
+
+	tool, exists := tooling.DefaultRegistry.GetUnifiedTool("rag_search")
+	if !exists || !tool.Available || tool.APITool == nil {
+		return nil
+	}
+	return api.Tools{*tool.APITool}
+}
+
+// hasPermissiveRule reports whether a trust rule lets some calls to a tool run, so the
+// tool is offered even when its trust level blocks it
+func hasPermissiveRule(config *configuration.Config, name string) bool {
+	return slices.ContainsFunc(config.ToolTrustRulesFor(name), func(rule configuration.ToolTrustRule) bool {
+		return rule.Decision != configuration.TrustRuleDeny
+	})
+}
+
+// isToolsUnsupportedError reports whether Ollama rejected a request because the model cannot use tools
+func isToolsUnsupportedError(err error) bool {
+	return strings.Contains(err.Error(), "does not support tools")
+}
+
+// executeToolCallsAndCreateMessages executes the tool calls and returns the tool result
+// messages and the resources the tools returned. Images returned by tools are only passed
+// to vision-capable models. Calls allowed to run are executed concurrently, up to the
+// configured limit, and their results keep the order of the calls.
+func (m Model) executeToolCallsAndCreateMessages(toolCalls []api.ToolCall, conversationULID string) ([]api.Message, []tooling.ToolAttachment, error) {
+	messages := make([]api.Message, len(toolCalls))
+	callAttachments := make([][]tooling.ToolAttachment, len(toolCalls))
+	var approved []approvedToolCall
+
+	for i, toolCall := range toolCalls {
+		// Every request is recorded in the audit log, whatever comes of it
+		audit := tooling.NewAuditEntry(conversationULID, toolCall.Function.Name, toolCall.Function.Arguments)
 
-	for _, toolCall := range toolCalls {
 		// Get the tool from the registry (unified tool that supports both builtin and MCP)
 		tool, exists := tooling.DefaultRegistry.GetUnifiedTool(toolCall.Function.Name)
 		if !exists {
+			audit.Status, audit.Error = tooling.AuditStatusError, "tool not found"
+			tooling.RecordToolCall(audit)
 			// Create error message for unknown tool
-			messages = append(messages, api.Message{
+			messages[i] = api.Message{
 				Role:     "tool",
 				Content:  fmt.Sprintf("Error: Tool '%s' not found", toolCall.Function.Name),
 				ToolName: toolCall.Function.Name,
-			})
+			}
 			continue
 		}
 
 		// Check if tool is available (especially important for MCP tools)
 		if !tool.Available {
-			messages = append(messages, api.Message{
+			audit.Status, audit.Error = tooling.AuditStatusError, "tool not available"
+			tooling.RecordToolCall(audit)
+			messages[i] = api.Message{
 				Role:     "tool",
 				Content:  fmt.Sprintf("Error: Tool '%s' is not available (server may be down)", toolCall.Function.Name),
 				ToolName: toolCall.Function.Name,
-			})
+			}
 			continue
 		}
 
-		// Check tool trust level from configuration
+		// Check tool trust level from configuration; trust rules on the arguments take precedence
 		trustLevel := m.config.GetToolTrustLevel(toolCall.Function.Name)
+		workingDir, _ := os.Getwd()
+		decision, rule := m.config.ToolTrustDecision(toolCall.Function.Name, toolCall.Function.Arguments, workingDir)
+		audit.DecidedBy = tooling.DecidedByTrustLevel
+		if rule != nil {
+			audit.DecidedBy, audit.Rule = tooling.DecidedByRule, rule.String()
+		}
+		switch decision {
+		case configuration.TrustRuleDeny:
+			audit.Decision, audit.Status = tooling.AuditDeny, tooling.AuditStatusBlocked
+			tooling.RecordToolCall(audit)
+			messages[i] = api.Message{
+				Role:     "tool",
+				Content:  fmt.Sprintf("🚫 Tool '%s' execution blocked by trust rule: %s. Rules can be changed in the Tools tab (press 'u').", toolCall.Function.Name, rule),
+				ToolName: toolCall.Function.Name,
+			}
+			continue
+		case configuration.TrustRuleAsk:
+			trustLevel = 1
+		case configuration.TrustRuleAllow:
+			trustLevel = 2
+		}
 
 		// Handle trust levels
 		switch trustLevel {
 		case 0: // TrustNone - block execution
-			messages = append(messages, api.Message{
+			audit.Decision, audit.Status = tooling.AuditDeny, tooling.AuditStatusBlocked
+			tooling.RecordToolCall(audit)
+			messages[i] = api.Message{
 				Role:     "tool",
 				Content:  fmt.Sprintf("🚫 Tool '%s' execution blocked: Tool trust is set to 'None'. Go to Tools tab (press 't') to change trust level to 'Session' to allow execution.", toolCall.Function.Name),
 				ToolName: toolCall.Function.Name,
-			})
+			}
 			continue
 		case 1: // AskForTrust - require user permission
+			audit.Decision, audit.Status = tooling.AuditAsk, tooling.AuditStatusPending
+			tooling.RecordToolCall(audit)
+
 			// Create a permission request message that will be handled by the UI
-			permissionMsg := fmt.Sprintf("❓ Tool '%s' wants to execute with arguments: %v\n\nAllow execution? (y)es / (n)o / (t)rust for session\n\nTOOL_CALL_DATA:%s:%v",
-				toolCall.Function.Name, toolCall.Function.Arguments, toolCall.Function.Name, toolCall.Function.Arguments)
+			permissionMsg := fmt.Sprintf("❓ Tool '%s' wants to execute with arguments: %v\n\nAllow execution? (y)es / (n)o / (t)rust for session\n\n%s",
+				toolCall.Function.Name, toolCall.Function.Arguments, toolCallData(toolCall))
 
-			messages = append(messages, api.Message{
+			messages[i] = api.Message{
 				Role:     "tool",
 				Content:  permissionMsg,
 				ToolName: toolCall.Function.Name,
-			})
+			}
 			// Note: The actual tool execution will be deferred until user responds
 			continue
 		case 2: // TrustSession - allow execution
-			// Continue with execution
+			audit.Decision = tooling.AuditAllow
+			if rule == nil {
+				audit.DecidedBy = tooling.DecidedBySessionTrust
+			}
 		default:
 			// Unknown trust level, block for safety
-			messages = append(messages, api.Message{
+			audit.Decision, audit.Status = tooling.AuditDeny, tooling.AuditStatusBlocked
+			tooling.RecordToolCall(audit)
+			messages[i] = api.Message{
 				Role:     "tool",
 				Content:  fmt.Sprintf("⚠️  Tool '%s' execution blocked: Unknown trust level (%d). Please check Tools tab.", toolCall.Function.Name, trustLevel),
 				ToolName: toolCall.Function.Name,
-			})
+			}
 			continue
 		}
 
-		// Execute the tool with the provided arguments using the unified tool system
-		result, err := tooling.DefaultRegistry.ExecuteTool(toolCall.Function.Name, toolCall.Function.Arguments)
-		if err != nil {
-			// Create error message for tool execution failure
-			messages = append(messages, api.Message{
-				Role:     "tool",
-				Content:  fmt.Sprintf("Error executing %s: %v", toolCall.Function.Name, err),
-				ToolName: toolCall.Function.Name,
-			})
-			continue
-		}
+		approved = append(approved, approvedToolCall{index: i, toolCall: toolCall, audit: audit})
+	}
 
-		// Format the result as JSON string for the tool response
-		var resultStr string
-		switch v := result.(type) {
-		case string:
-			resultStr = v
-		default:
-			// Convert result to JSON string for proper tool response format
-			resultStr = fmt.Sprintf("%+v", result)
-		}
+	// Run the approved calls, at most the configured number at once
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, m.config.ToolConcurrencyLimit())
+	for _, call := range approved {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			messages[call.index], callAttachments[call.index] = m.runToolCall(call)
+		}()
+	}
+	wg.Wait()
+
+	var attachments []tooling.ToolAttachment
+	for _, resources := range callAttachments {
+		attachments = append(attachments, resources...)
+	}
+	return messages, attachments, nil
+}
+
+// approvedToolCall is a tool call allowed to run and its position among the calls of a
+// response
+type approvedToolCall struct {
+	index    int
+	toolCall api.ToolCall
+	audit    tooling.AuditEntry
+}
+
+// toolCallContext returns the context a tool call runs in, ending after the configured
+// tool timeout
+func (m Model) toolCallContext() (context.Context, context.CancelFunc) {
+	ctx := m.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout := m.config.ToolTimeout(); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
 
-		// Create tool response message
-		messages = append(messages, api.Message{
+// runToolCall executes an approved tool call, records it in the audit log and returns the
+// tool result message and the resources the tool returned
+func (m Model) runToolCall(call approvedToolCall) (api.Message, []tooling.ToolAttachment) {
+	toolCall, audit := call.toolCall, call.audit
+	ctx, cancel := m.toolCallContext()
+	defer cancel()
+
+	// Execute the tool with the provided arguments using the unified tool system
+	started := time.Now()
+	result, err := tooling.DefaultRegistry.ExecuteTool(ctx, toolCall.Function.Name, toolCall.Function.Arguments)
+	audit.SetResult(result, err, started)
+	tooling.RecordToolCall(audit)
+	if err != nil {
+		// Create error message for tool execution failure
+		return api.Message{
 			Role:     "tool",
-			Content:  resultStr,
+			Content:  fmt.Sprintf("Error executing %s: %v", toolCall.Function.Name, err),
 			ToolName: toolCall.Function.Name,
-		})
+		}, nil
 	}
 
-	return messages, nil
+	// Format the result as JSON string for the tool response
+	var resultStr string
+	var images []api.ImageData
+	var attachments []tooling.ToolAttachment
+	switch v := result.(type) {
+	case string:
+		resultStr = v
+	case *tooling.ToolResult:
+		resultStr = v.Text
+		attachments = append(attachments, v.Attachments...)
+		if len(v.Images) > 0 && m.modelSupportsVision() {
+			images = v.Images
+		}
+	default:
+		// Convert result to JSON string for proper tool response format
+		resultStr = fmt.Sprintf("%+v", result)
+	}
+	resultStr, fullOutput := m.limitToolOutput(toolCall.Function.Name, result, resultStr)
+	if fullOutput != nil {
+		attachments = append(attachments, *fullOutput)
+	}
+
+	// Create tool response message
+	return api.Message{
+		Role:     "tool",
+		Content:  resultStr,
+		Images:   images,
+		ToolName: toolCall.Function.Name,
+	}, attachments
 }
 
 // calculateMessagesHeight calculates the total height of all messages
@@ -462,6 +709,16 @@ func (m Model) formatMessage(msg Message) []string {
 	wrappedContent := m.wrapText(msg.Content, contentWidth)
 	lines = append(lines, wrappedContent...)
 
+	// Resources returned by tools
+	for _, attachment := range msg.Attachments {
+		lines = append(lines, m.wrapText(m.styles.attachment.Render("📎 "+attachment.Label()), contentWidth)...)
+	}
+
+	// Change proposed by a tool
+	if msg.Diff != "" {
+		lines = append(lines, m.formatDiff(msg.Diff, contentWidth)...)
+	}
+
 	// Add spacing
 	lines = append(lines, "")
 
@@ -504,3 +761,19 @@ func getMetadataKeys(metadata map[string]string) []string {
 	}
 	return keys
 }
+
+// formatAttachmentsForPrompt formats attached MCP resources for inclusion in the chat prompt
+func formatAttachmentsForPrompt(attachments []mcp.Attachment) string {
+	var prompt strings.Builder
+	prompt.WriteString("=== ATTACHED RESOURCES ===\n")
+	for _, attachment := range attachments {
+		name := attachment.Name
+		if name == "" {
+			name = attachment.URI
+		}
+		fmt.Fprintf(&prompt, "Resource %s (%s, from %s):\n", name, attachment.URI, attachment.Server)
+		prompt.WriteString(attachment.Text + "\n\n")
+	}
+	prompt.WriteString("=== END RESOURCES ===\n\n")
+	return prompt.String()
+}
@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/kevensen/gollama-chat/internal/logging"
 )
 
 // Cache for model context sizes to avoid repeated API calls
@@ -42,7 +45,52 @@ type OllamaModelInfo struct {
 
 // OllamaShowResponse represents the response from /api/show
 type OllamaShowResponse struct {
-	ModelInfo OllamaModelInfo `json:"model_info"`
+	ModelInfo    OllamaModelInfo `json:"model_info"`
+	Capabilities []string        `json:"capabilities"`
+}
+
+// Cache for whether models accept images, so tool results only query each model once
+var visionCache = make(map[string]bool)
+var visionCacheMutex sync.RWMutex
+
+// modelSupportsVisionFromAPI reports whether the Ollama API lists vision among the
+// model's capabilities
+func modelSupportsVisionFromAPI(modelName string, ollamaURL string) (bool, error) {
+	cacheKey := modelName + "@" + ollamaURL
+	visionCacheMutex.RLock()
+	if supported, found := visionCache[cacheKey]; found {
+		visionCacheMutex.RUnlock()
+		return supported, nil
+	}
+	visionCacheMutex.RUnlock()
+
+	payloadBytes, err := json.Marshal(map[string]any{"model": modelName})
+	if err != nil {
+		return false, err
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Post(ollamaURL+"/api/show", "application/json", bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+
+	var response OllamaShowResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return false, err
+	}
+	supported := slices.Contains(response.Capabilities, "vision")
+
+	visionCacheMutex.Lock()
+	visionCache[cacheKey] = supported
+	visionCacheMutex.Unlock()
+
+	return supported, nil
 }
 
 // modelContextSizeFromAPI fetches the context window size from the Ollama API
@@ -161,3 +209,18 @@ func (m Model) getModelContextSize(modelName string) int {
 	// Fall back to hardcoded values
 	return fallbackContextSize(modelName)
 }
+
+// modelSupportsVision reports whether the chat model accepts images. Models are assumed
+// not to when the Ollama API cannot be asked.
+func (m Model) modelSupportsVision() bool {
+	if m.config == nil || m.config.OllamaURL == "" || m.config.ChatModel == "" {
+		return false
+	}
+	supported, err := modelSupportsVisionFromAPI(m.config.ChatModel, m.config.OllamaURL)
+	if err != nil {
+		logging.WithComponent("chat").Warn("Failed to check whether the model supports images",
+			"model", m.config.ChatModel, "error", err)
+		return false
+	}
+	return supported
+}
@@ -15,7 +15,7 @@ func TestUpdateFromConfiguration_RAGSettings(t *testing.T) {
 		OllamaURL:           "http://localhost:11434",
 		ChromaDBURL:         "http://localhost:8000",
 		RAGEnabled:          false,
-		SelectedCollections: map[string]bool{"collection1": true},
+		SelectedCollections: map[string]configuration.CollectionSettings{"collection1": {Selected: true}},
 		MaxDocuments:        5,
 		ChromaDBDistance:    1.0,
 	}
@@ -30,7 +30,7 @@ func TestUpdateFromConfiguration_RAGSettings(t *testing.T) {
 		OllamaURL:           "http://localhost:11434",
 		ChromaDBURL:         "http://localhost:8000",
 		RAGEnabled:          true, // Changed from false to true
-		SelectedCollections: map[string]bool{"collection1": true},
+		SelectedCollections: map[string]configuration.CollectionSettings{"collection1": {Selected: true}},
 		MaxDocuments:        5,
 		ChromaDBDistance:    1.0,
 	}
@@ -49,7 +49,7 @@ func TestUpdateFromConfiguration_RAGSettings(t *testing.T) {
 		OllamaURL:           "http://localhost:11434",
 		ChromaDBURL:         "http://localhost:9000", // Changed URL
 		RAGEnabled:          true,
-		SelectedCollections: map[string]bool{"collection1": true},
+		SelectedCollections: map[string]configuration.CollectionSettings{"collection1": {Selected: true}},
 		MaxDocuments:        5,
 		ChromaDBDistance:    1.0,
 	}
@@ -68,7 +68,7 @@ func TestUpdateFromConfiguration_RAGSettings(t *testing.T) {
 		OllamaURL:           "http://localhost:11434",
 		ChromaDBURL:         "http://localhost:9000",
 		RAGEnabled:          true,
-		SelectedCollections: map[string]bool{"collection2": true, "collection3": true}, // Changed collections
+		SelectedCollections: map[string]configuration.CollectionSettings{"collection2": {Selected: true}, "collection3": {Selected: true}}, // Changed collections
 		MaxDocuments:        5,
 		ChromaDBDistance:    1.0,
 	}
@@ -76,59 +76,65 @@ func TestUpdateFromConfiguration_RAGSettings(t *testing.T) {
 	model.UpdateFromConfiguration(ctx, newConfig3)
 
 	// Verify configuration was updated
-	if !model.config.SelectedCollections["collection2"] || !model.config.SelectedCollections["collection3"] {
+	if !model.config.SelectedCollections["collection2"].Selected || !model.config.SelectedCollections["collection3"].Selected {
 		t.Error("Expected selected collections to be updated")
 	}
-	if model.config.SelectedCollections["collection1"] {
+	if model.config.SelectedCollections["collection1"].Selected {
 		t.Error("Expected collection1 to be unselected")
 	}
 }
 
-// Test helper function equalStringMaps
-func TestEqualStringMaps(t *testing.T) {
+// Test helper function equalCollectionMaps
+func TestEqualCollectionMaps(t *testing.T) {
 	tests := []struct {
 		name     string
-		map1     map[string]bool
-		map2     map[string]bool
+		map1     map[string]configuration.CollectionSettings
+		map2     map[string]configuration.CollectionSettings
 		expected bool
 	}{
 		{
 			name:     "equal maps",
-			map1:     map[string]bool{"a": true, "b": false},
-			map2:     map[string]bool{"a": true, "b": false},
+			map1:     map[string]configuration.CollectionSettings{"a": {Selected: true}, "b": {}},
+			map2:     map[string]configuration.CollectionSettings{"a": {Selected: true}, "b": {}},
 			expected: true,
 		},
 		{
 			name:     "different values",
-			map1:     map[string]bool{"a": true, "b": false},
-			map2:     map[string]bool{"a": false, "b": false},
+			map1:     map[string]configuration.CollectionSettings{"a": {Selected: true}, "b": {}},
+			map2:     map[string]configuration.CollectionSettings{"a": {}, "b": {}},
+			expected: false,
+		},
+		{
+			name:     "different overrides",
+			map1:     map[string]configuration.CollectionSettings{"a": {Selected: true, MaxDocuments: 3}},
+			map2:     map[string]configuration.CollectionSettings{"a": {Selected: true, MaxDocuments: 8}},
 			expected: false,
 		},
 		{
 			name:     "different keys",
-			map1:     map[string]bool{"a": true, "b": false},
-			map2:     map[string]bool{"a": true, "c": false},
+			map1:     map[string]configuration.CollectionSettings{"a": {Selected: true}, "b": {}},
+			map2:     map[string]configuration.CollectionSettings{"a": {Selected: true}, "c": {}},
 			expected: false,
 		},
 		{
 			name:     "different lengths",
-			map1:     map[string]bool{"a": true},
-			map2:     map[string]bool{"a": true, "b": false},
+			map1:     map[string]configuration.CollectionSettings{"a": {Selected: true}},
+			map2:     map[string]configuration.CollectionSettings{"a": {Selected: true}, "b": {}},
 			expected: false,
 		},
 		{
 			name:     "both empty",
-			map1:     map[string]bool{},
-			map2:     map[string]bool{},
+			map1:     map[string]configuration.CollectionSettings{},
+			map2:     map[string]configuration.CollectionSettings{},
 			expected: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := equalStringMaps(tt.map1, tt.map2)
+			result := equalCollectionMaps(tt.map1, tt.map2)
 			if result != tt.expected {
-				t.Errorf("equalStringMaps() = %v, expected %v", result, tt.expected)
+				t.Errorf("equalCollectionMaps() = %v, expected %v", result, tt.expected)
 			}
 		})
 	}
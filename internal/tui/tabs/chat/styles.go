@@ -29,6 +29,18 @@ type Styles struct {
 
 	// Style for italic text within message content
 	italicText lipgloss.Style
+
+	// Style for resources returned by tools
+	attachment lipgloss.Style
+
+	// Styles for diffs of changes proposed by tools
+	diffAdded   lipgloss.Style
+	diffRemoved lipgloss.Style
+	diffHunk    lipgloss.Style
+
+	// Styles for the live output of running tools
+	toolOutputHeader lipgloss.Style
+	toolOutput       lipgloss.Style
 }
 
 // DefaultStyles creates default styles for the chat UI
@@ -67,5 +79,24 @@ func DefaultStyles() Styles {
 
 		italicText: lipgloss.NewStyle().
 			Italic(true),
+
+		attachment: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("243")),
+
+		diffAdded: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("10")), // Green
+
+		diffRemoved: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("9")), // Red
+
+		diffHunk: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("14")), // Cyan
+
+		toolOutputHeader: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("14")).
+			Bold(true),
+
+		toolOutput: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("245")),
 	}
 }
@@ -12,7 +12,7 @@ func TestSystemPromptPriorityLogic(t *testing.T) {
 		ChatModel:           "llama3.2",
 		OllamaURL:           "http://localhost:11434",
 		DefaultSystemPrompt: "Original default prompt",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 		ToolTrustLevels:     make(map[string]int),
 	}
 
@@ -32,7 +32,7 @@ func TestSystemPromptPriorityLogic(t *testing.T) {
 		ChatModel:           "llama3.2",
 		OllamaURL:           "http://localhost:11434",
 		DefaultSystemPrompt: "Updated default prompt",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 		ToolTrustLevels:     make(map[string]int),
 	}
 
@@ -54,7 +54,7 @@ func TestSystemPromptPriorityLogic(t *testing.T) {
 		ChatModel:           "llama3.2",
 		OllamaURL:           "http://localhost:11434",
 		DefaultSystemPrompt: "Another updated default prompt",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 		ToolTrustLevels:     make(map[string]int),
 	}
 
@@ -76,7 +76,7 @@ func TestSystemPromptPriorityLogic(t *testing.T) {
 		ChatModel:           "llama3.2",
 		OllamaURL:           "http://localhost:11434",
 		DefaultSystemPrompt: "Final default prompt",
-		SelectedCollections: make(map[string]bool),
+		SelectedCollections: make(map[string]configuration.CollectionSettings),
 		ToolTrustLevels:     make(map[string]int),
 	}
 
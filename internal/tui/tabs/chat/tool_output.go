@@ -0,0 +1,182 @@
+package chat
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/kevensen/gollama-chat/internal/logging"
+	"github.com/kevensen/gollama-chat/internal/tooling"
+)
+
+// toolOutputPaneLines is how many of the latest output lines the live output pane shows
+const toolOutputPaneLines = 8
+
+// approvedToolResultMsg carries the result of a tool call the user approved
+type approvedToolResultMsg struct {
+	toolName         string
+	result           any
+	err              error
+	conversationULID string
+}
+
+// toolOutputClosedMsg reports that the pager showing saved tool output exited
+type toolOutputClosedMsg struct {
+	err error
+}
+
+// limitToolOutput shortens tool output to the configured limit before it is sent to the
+// model. The full output is kept in a file, which the returned attachment points to.
+func (m Model) limitToolOutput(toolName string, result any, output string) (string, *tooling.ToolAttachment) {
+	limited, truncated := tooling.TruncateOutput(output, m.config.ToolOutputLimit())
+	if !truncated {
+		return output, nil
+	}
+
+	// Streamed output is already saved in full
+	var path string
+	if fields, ok := result.(map[string]any); ok {
+		path, _ = fields["output_file"].(string)
+	}
+	if path == "" {
+		saved, err := tooling.SaveToolOutput(toolName, output)
+		if err != nil {
+			logging.WithComponent("chat").Warn("Failed to save truncated tool output", "tool", toolName, "error", err)
+			return limited + fmt.Sprintf("\n[Output truncated from %d bytes]", len(output)), nil
+		}
+		path = saved
+	}
+
+	limited += fmt.Sprintf("\n[Output truncated from %d bytes; the full output is saved to %s]", len(output), path)
+	return limited, &tooling.ToolAttachment{
+		URI:      "file://" + path,
+		Name:     fmt.Sprintf("Full output of %s (/output to open)", toolName),
+		MimeType: "text/plain",
+		Size:     len(output),
+	}
+}
+
+// updateToolOutputs refreshes the live output of running tools, redrawing the messages
+// when the output pane changes size
+func (m Model) updateToolOutputs() Model {
+	height := m.toolOutputHeight()
+	if m.inputModel.IsLoading() {
+		m.toolOutputs = tooling.ActiveOutputStreams()
+	} else {
+		m.toolOutputs = nil
+	}
+	if m.toolOutputHeight() != height {
+		m.messagesNeedsUpdate = true
+		m.messageCache.InvalidateCache()
+	}
+	return m
+}
+
+// toolOutputHeight returns the lines taken by the live output pane
+func (m Model) toolOutputHeight() int {
+	if len(m.toolOutputs) == 0 {
+		return 0
+	}
+	if m.toolOutputCollapsed {
+		return len(m.toolOutputs)
+	}
+	return len(m.toolOutputs) * (1 + toolOutputPaneLines)
+}
+
+// toggleToolOutput collapses or expands the live output pane
+func (m Model) toggleToolOutput() (tea.Model, tea.Cmd) {
+	m.toolOutputCollapsed = !m.toolOutputCollapsed
+	if len(m.toolOutputs) > 0 {
+		m.messagesNeedsUpdate = true
+		m.messageCache.InvalidateCache()
+	}
+	return m, nil
+}
+
+// renderToolOutput renders the live output of running tools: a header per tool with the
+// elapsed time and output size, followed by its latest lines unless the pane is collapsed
+func (m Model) renderToolOutput(now time.Time) string {
+	width := max(m.width-2, 10)
+	var lines []string
+	for _, output := range m.toolOutputs {
+		marker, hint := "▼", "ctrl+o to collapse"
+		if m.toolOutputCollapsed {
+			marker, hint = "▶", "ctrl+o to expand"
+		}
+		header := fmt.Sprintf("%s ⚙ %s · %ds · %d lines, %s · %s", marker, output.Tool,
+			int(now.Sub(output.Started).Seconds()), len(output.Lines), formatBytes(output.Bytes), hint)
+		lines = append(lines, m.styles.toolOutputHeader.Render(truncateRunes(header, width)))
+		if m.toolOutputCollapsed {
+			continue
+		}
+
+		tail := output.Lines[max(0, len(output.Lines)-toolOutputPaneLines):]
+		for i := range toolOutputPaneLines {
+			var line string
+			if i < len(tail) {
+				line = strings.ReplaceAll(tail[i], "\t", "    ")
+			}
+			lines = append(lines, m.styles.toolOutput.Render(truncateRunes("│ "+line, width)))
+		}
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// truncateRunes cuts text to width runes, marking the cut with an ellipsis
+func truncateRunes(text string, width int) string {
+	if runes := []rune(text); len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return text
+}
+
+// formatBytes describes a size in bytes, KB or MB
+func formatBytes(size int) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// openToolOutput opens the most recently saved tool output in the user's pager
+func (m Model) openToolOutput() (tea.Model, tea.Cmd) {
+	path, err := tooling.LatestToolOutput()
+	if err != nil {
+		m.addSystemMessage(fmt.Sprintf("No tool output to open: %v", err))
+		return m, nil
+	}
+
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less"
+	}
+	args := strings.Fields(pager)
+	logging.WithComponent("chat").Info("Opening saved tool output", "file", path, "pager", args[0])
+	return m, tea.ExecProcess(exec.Command(args[0], append(args[1:], path)...), func(err error) tea.Msg {
+		return toolOutputClosedMsg{err: err}
+	})
+}
+
+// runApprovedTool executes a tool call the user approved without blocking the UI, so
+// its output can be shown while it runs, and records the outcome in the audit log
+func (m Model) runApprovedTool(audit tooling.AuditEntry) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := m.toolCallContext()
+		defer cancel()
+
+		started := time.Now()
+		result, err := tooling.DefaultRegistry.ExecuteTool(ctx, audit.Tool, audit.Arguments)
+		audit.SetResult(result, err, started)
+		tooling.RecordToolCall(audit)
+		return approvedToolResultMsg{toolName: audit.Tool, result: result, err: err, conversationULID: audit.ConversationID}
+	}
+}
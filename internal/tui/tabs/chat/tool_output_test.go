@@ -0,0 +1,183 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ollama/ollama/api"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+	"github.com/kevensen/gollama-chat/internal/tooling"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the output of the tools run by the tests out of the shared temporary directory
+	dir, err := os.MkdirTemp("", "chat-tool-output-test-")
+	if err != nil {
+		panic(err)
+	}
+	tooling.SetToolOutputDir(dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestExecuteToolCalls_TruncatesLongOutput(t *testing.T) {
+	config := &configuration.Config{
+		ChatModel:          "llama3.1",
+		ToolTrustLevels:    map[string]int{"execute_bash": 2},
+		ToolOutputMaxBytes: 200,
+	}
+	model := NewModel(t.Context(), config)
+
+	toolCalls := []api.ToolCall{{Function: api.ToolCallFunction{
+		Name:      "execute_bash",
+		Arguments: map[string]any{"command": "seq 1 1000"},
+	}}}
+	messages, attachments, err := model.executeToolCallsAndCreateMessages(toolCalls, "ulid")
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("Expected one tool message, got %v (%v)", messages, err)
+	}
+	if content := messages[0].Content; len(content) > 400 || !strings.Contains(content, "the full output is saved to") {
+		t.Errorf("Expected the output to be truncated, got %d bytes: %q", len(content), content)
+	}
+
+	if len(attachments) != 1 || !strings.HasPrefix(attachments[0].URI, "file://") {
+		t.Fatalf("Expected the full output as an attachment, got %+v", attachments)
+	}
+	content, err := os.ReadFile(strings.TrimPrefix(attachments[0].URI, "file://"))
+	if err != nil {
+		t.Fatalf("Expected the full output file to exist: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "1\n2\n") || !strings.HasSuffix(string(content), "999\n1000\n") {
+		t.Errorf("Expected the full command output in the file, got %d bytes", len(content))
+	}
+}
+
+// concurrencyProbe is a builtin tool that sleeps for the requested time and records how
+// many of its calls ran at once
+type concurrencyProbe struct {
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (p *concurrencyProbe) Name() string          { return "concurrency_probe" }
+func (p *concurrencyProbe) Description() string   { return "Sleeps and counts concurrent calls" }
+func (p *concurrencyProbe) GetAPITool() *api.Tool { return &api.Tool{Type: "function"} }
+
+func (p *concurrencyProbe) Execute(ctx context.Context, args map[string]any) (any, error) {
+	p.mu.Lock()
+	p.running++
+	p.peak = max(p.peak, p.running)
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.running--
+		p.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(time.Duration(args["delay_ms"].(float64)) * time.Millisecond):
+		return fmt.Sprintf("call %v done", args["id"]), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestExecuteToolCalls_RunsConcurrentlyInOrder(t *testing.T) {
+	probe := &concurrencyProbe{}
+	tooling.DefaultRegistry.Register(probe)
+	t.Cleanup(func() { tooling.DefaultRegistry.Unregister(probe.Name()) })
+
+	config := &configuration.Config{
+		ChatModel:       "llama3.1",
+		ToolTrustLevels: map[string]int{probe.Name(): 2, "execute_bash": 0},
+		ToolConcurrency: 2,
+	}
+	model := NewModel(t.Context(), config)
+
+	call := func(id int, delay float64) api.ToolCall {
+		return api.ToolCall{Function: api.ToolCallFunction{
+			Name:      probe.Name(),
+			Arguments: map[string]any{"id": id, "delay_ms": delay},
+		}}
+	}
+	// Later calls finish first
+	toolCalls := []api.ToolCall{
+		call(1, 200),
+		call(2, 150),
+		{Function: api.ToolCallFunction{Name: "execute_bash", Arguments: map[string]any{"command": "ls"}}},
+		call(3, 100),
+		call(4, 50),
+	}
+	messages, _, err := model.executeToolCallsAndCreateMessages(toolCalls, "ulid")
+	if err != nil || len(messages) != len(toolCalls) {
+		t.Fatalf("Expected a message per call, got %v (%v)", messages, err)
+	}
+
+	want := []string{"call 1 done", "call 2 done", "blocked", "call 3 done", "call 4 done"}
+	for i, content := range want {
+		if !strings.Contains(messages[i].Content, content) {
+			t.Errorf("Message %d: expected %q, got %q", i, content, messages[i].Content)
+		}
+	}
+	if probe.peak != 2 {
+		t.Errorf("Expected 2 calls to run at once, got %d", probe.peak)
+	}
+}
+
+func TestLimitToolOutput_SavesOutputOfOtherTools(t *testing.T) {
+	model := NewModel(t.Context(), &configuration.Config{ChatModel: "llama3.1", ToolOutputMaxBytes: 50})
+
+	output := strings.Repeat("result ", 100)
+	limited, attachment := model.limitToolOutput("files.search", output, output)
+	if attachment == nil || !strings.Contains(limited, "bytes omitted") {
+		t.Fatalf("Expected the output to be truncated and saved, got %q", limited)
+	}
+	if saved, err := os.ReadFile(strings.TrimPrefix(attachment.URI, "file://")); err != nil || string(saved) != output {
+		t.Errorf("Expected the full output to be saved, got %d bytes (%v)", len(saved), err)
+	}
+
+	model.config.ToolOutputMaxBytes = -1
+	if limited, attachment := model.limitToolOutput("files.search", output, output); attachment != nil || limited != output {
+		t.Error("Expected a negative limit to keep the full output")
+	}
+}
+
+func TestRenderToolOutput(t *testing.T) {
+	model := NewModel(t.Context(), &configuration.Config{ChatModel: "llama3.1"})
+	model.width = 80
+	now := time.Now()
+
+	var lines []string
+	for i := range 12 {
+		lines = append(lines, strings.Repeat("x", i))
+	}
+	model.toolOutputs = []tooling.OutputSnapshot{{Tool: "execute_bash", Started: now.Add(-5 * time.Second), Lines: lines, Bytes: 2048}}
+
+	pane := model.renderToolOutput(now)
+	if !strings.Contains(pane, "execute_bash · 5s · 12 lines, 2.0 KB") {
+		t.Errorf("Expected a header with the elapsed time and size, got %q", pane)
+	}
+	if strings.Contains(pane, "│ xxx\n") || !strings.Contains(pane, strings.Repeat("x", 11)) {
+		t.Errorf("Expected only the latest lines, got %q", pane)
+	}
+	if height := model.toolOutputHeight(); height != 1+toolOutputPaneLines || strings.Count(pane, "\n")+1 != height {
+		t.Errorf("Expected the pane to take %d lines, got %d", height, strings.Count(pane, "\n")+1)
+	}
+
+	updated, _ := model.toggleToolOutput()
+	model = updated.(Model)
+	if pane := model.renderToolOutput(now); strings.Contains(pane, "│") || !strings.Contains(pane, "ctrl+o to expand") {
+		t.Errorf("Expected only the header when collapsed, got %q", pane)
+	}
+	if model.toolOutputHeight() != 1 {
+		t.Errorf("Expected a collapsed pane to take one line, got %d", model.toolOutputHeight())
+	}
+}
@@ -0,0 +1,111 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ollama/ollama/api"
+
+	"github.com/kevensen/gollama-chat/internal/logging"
+	"github.com/kevensen/gollama-chat/internal/tooling"
+)
+
+// toolCallDataMarker separates a permission request from the tool call it is for
+const toolCallDataMarker = "TOOL_CALL_DATA:"
+
+// toolCallData encodes a tool call for a permission request message as
+// TOOL_CALL_DATA:<tool>:<JSON arguments>
+func toolCallData(toolCall api.ToolCall) string {
+	arguments, err := json.Marshal(toolCall.Function.Arguments)
+	if err != nil {
+		arguments = []byte("{}")
+	}
+	return toolCallDataMarker + toolCall.Function.Name + ":" + string(arguments)
+}
+
+// parseToolCallData returns the arguments of the tool call in a permission request
+// message, or no arguments when the message does not hold them
+func parseToolCallData(content, toolName string) map[string]any {
+	arguments := make(map[string]any)
+	_, data, found := strings.Cut(content, toolCallDataMarker)
+	if !found {
+		return arguments
+	}
+	data, found = strings.CutPrefix(data, toolName+":")
+	if !found {
+		return arguments
+	}
+	if err := json.Unmarshal([]byte(data), &arguments); err != nil {
+		logging.WithComponent("chat").Warn("Failed to parse tool call arguments", "tool", toolName, "error", err)
+		return make(map[string]any)
+	}
+	return arguments
+}
+
+// previewToolCall describes the change a tool call would make, for tools that can tell
+func previewToolCall(toolCall api.ToolCall) string {
+	preview, err := tooling.DefaultRegistry.PreviewTool(toolCall.Function.Name, toolCall.Function.Arguments)
+	if err != nil {
+		return fmt.Sprintf("Preview unavailable: %v", err)
+	}
+	return preview
+}
+
+// splitDiffLines splits a diff into its lines
+func splitDiffLines(diff string) []string {
+	return strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+}
+
+// formatDiff renders a diff with added, removed and hunk header lines colored. Long lines
+// are cut rather than wrapped so the diff keeps its shape.
+func (m Model) formatDiff(diff string, width int) []string {
+	var lines []string
+	for _, line := range splitDiffLines(diff) {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		if width > 0 {
+			line = truncateRunes(line, width)
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "):
+			lines = append(lines, m.styles.boldText.Render(line))
+		case strings.HasPrefix(line, "@@"):
+			lines = append(lines, m.styles.diffHunk.Render(line))
+		case strings.HasPrefix(line, "+"):
+			lines = append(lines, m.styles.diffAdded.Render(line))
+		case strings.HasPrefix(line, "-"):
+			lines = append(lines, m.styles.diffRemoved.Render(line))
+		default:
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// undoFileChange reverts the last file change made by the filesystem_write tool
+func (m Model) undoFileChange() (tea.Model, tea.Cmd) {
+	content, err := tooling.UndoFileChange()
+	if err != nil {
+		content = fmt.Sprintf("Nothing undone: %v", err)
+	} else {
+		content = "↩️  " + content
+		logging.WithComponent("chat").Info("File change undone by user command", "result", content)
+	}
+
+	undoULID := generateULID()
+	undoMsg := Message{
+		Role:    "system",
+		Content: content,
+		Time:    time.Now(),
+		ULID:    undoULID,
+	}
+	m.messages = append(m.messages, undoMsg)
+	logConversationEvent(undoULID, "system", undoMsg.Content, m.config.ChatModel)
+
+	m.messagesNeedsUpdate = true
+	m.messageCache.InvalidateCache()
+	return m, nil
+}
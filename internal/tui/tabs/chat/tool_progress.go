@@ -0,0 +1,76 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kevensen/gollama-chat/internal/logging"
+	"github.com/kevensen/gollama-chat/internal/tooling/mcp"
+)
+
+// toolProgressInterval is how often the progress and output of running tool calls is refreshed
+const toolProgressInterval = 250 * time.Millisecond
+
+// toolProgressTickMsg refreshes the progress and output of running tool calls
+type toolProgressTickMsg struct{}
+
+// toolProgressTick schedules the next progress refresh
+func (m Model) toolProgressTick() tea.Cmd {
+	return tea.Tick(toolProgressInterval, func(time.Time) tea.Msg {
+		return toolProgressTickMsg{}
+	})
+}
+
+// updateToolProgress shows the progress of running MCP tool calls and the output of
+// running tools until the response arrives
+func (m Model) updateToolProgress() (Model, tea.Cmd) {
+	m = m.updateToolOutputs()
+	if !m.inputModel.IsLoading() {
+		return m, nil
+	}
+	if m.mcpManager != nil {
+		m.inputModel.SetToolStatus(formatToolProgress(m.mcpManager.ActiveToolCalls(), time.Now()))
+	}
+	return m, m.toolProgressTick()
+}
+
+// cancelToolCalls aborts the running MCP tool calls
+func (m Model) cancelToolCalls() Model {
+	if m.mcpManager == nil {
+		return m
+	}
+	if cancelled := m.mcpManager.CancelToolCalls(); cancelled > 0 {
+		logger := logging.WithComponent("chat")
+		logger.Info("MCP tool calls cancelled by user", "count", cancelled, "conversation_id", m.currentConversationULID)
+		m.inputModel.SetToolStatus("Cancelling tool calls...")
+	}
+	return m
+}
+
+// formatToolProgress describes running tool calls, e.g. "⚙ files/search 50% indexing"
+func formatToolProgress(calls []mcp.ToolCall, now time.Time) string {
+	if len(calls) == 0 {
+		return ""
+	}
+
+	parts := make([]string, len(calls))
+	for i, call := range calls {
+		part := fmt.Sprintf("⚙ %s/%s", call.Server, call.Tool)
+		switch progress := call.Progress; {
+		case progress.Total > 0:
+			part += fmt.Sprintf(" %d%%", int(progress.Progress*100/progress.Total))
+		case progress.Progress > 0:
+			part += fmt.Sprintf(" %g", progress.Progress)
+		default:
+			part += fmt.Sprintf(" %ds", int(now.Sub(call.Started).Seconds()))
+		}
+		if call.Progress.Message != "" {
+			part += " " + call.Progress.Message
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, ", ") + " - Esc to cancel"
+}
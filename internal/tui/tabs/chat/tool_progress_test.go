@@ -0,0 +1,26 @@
+package chat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevensen/gollama-chat/internal/tooling/mcp"
+)
+
+func TestFormatToolProgress(t *testing.T) {
+	now := time.Now()
+
+	if status := formatToolProgress(nil, now); status != "" {
+		t.Errorf("Expected no status without running calls, got %q", status)
+	}
+
+	calls := []mcp.ToolCall{
+		{Server: "files", Tool: "index", Started: now, Progress: mcp.Progress{Progress: 1, Total: 4, Message: "scanning"}},
+		{Server: "files", Tool: "count", Started: now, Progress: mcp.Progress{Progress: 12}},
+		{Server: "web", Tool: "fetch", Started: now.Add(-3 * time.Second)},
+	}
+	expected := "⚙ files/index 25% scanning, ⚙ files/count 12, ⚙ web/fetch 3s - Esc to cancel"
+	if status := formatToolProgress(calls, now); status != expected {
+		t.Errorf("Expected %q, got %q", expected, status)
+	}
+}
@@ -208,7 +208,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				ChromaDBURL:         msg.Config.ChromaDBURL,
 				ChromaDBDistance:    msg.Config.ChromaDBDistance,
 				MaxDocuments:        msg.Config.MaxDocuments,
-				SelectedCollections: make(map[string]bool),
+				SelectedCollections: make(map[string]configuration.CollectionSettings),
 				DefaultSystemPrompt: systemPrompt, // Use system prompt from file
 				ToolTrustLevels:     make(map[string]int),
 				MCPServers:          make([]configuration.MCPServer, len(msg.Config.MCPServers)),
@@ -0,0 +1,124 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	mcpManager "github.com/kevensen/gollama-chat/internal/tooling/mcp"
+)
+
+// healthRefreshInterval is how often the health pane picks up restarts and pings
+const healthRefreshInterval = time.Second
+
+// healthTickMsg refreshes the health pane while it is open
+type healthTickMsg struct{}
+
+// openHealthPane shows the restart history and stderr output of the selected server
+func (m Model) openHealthPane() (Model, tea.Cmd) {
+	if m.selectedIndex >= len(m.servers) {
+		return m, nil
+	}
+	m.healthServer = m.servers[m.selectedIndex].Name
+	return m, healthTick()
+}
+
+// handleHealthKeys handles keyboard input while the health pane is open
+func (m Model) handleHealthKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "escape", "esc", "h":
+		m.healthServer = ""
+	}
+	return m, nil
+}
+
+// renderHealthPane renders the supervision state of a server
+func (m Model) renderHealthPane(now time.Time) string {
+	var s strings.Builder
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	headingStyle := lipgloss.NewStyle().Bold(true)
+
+	s.WriteString(lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("205")).
+		Render(fmt.Sprintf("Health - %s", m.healthServer)))
+	s.WriteString("\n")
+	s.WriteString(dimStyle.Render("Restart history, health checks and recent stderr output. Esc: back"))
+	s.WriteString("\n\n")
+
+	health := m.manager.ServerHealth(m.healthServer)
+	s.WriteString(fmt.Sprintf("Status: %s", m.manager.GetServerStatus(m.healthServer)))
+	s.WriteString("\n")
+	s.WriteString(renderPing(health))
+	s.WriteString("\n")
+	switch {
+	case !health.NextRestart.IsZero():
+		s.WriteString(lipgloss.NewStyle().
+			Foreground(lipgloss.Color("226")).
+			Render(fmt.Sprintf("Restarting in %s", max(health.NextRestart.Sub(now), 0).Round(time.Second))))
+		s.WriteString("\n")
+	case health.GaveUp:
+		s.WriteString(lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196")).
+			Render("Not restarted: the server failed too often. Toggle it to start it again."))
+		s.WriteString("\n")
+	}
+
+	s.WriteString("\n")
+	s.WriteString(headingStyle.Render("Restarts"))
+	s.WriteString("\n")
+	if len(health.Restarts) == 0 {
+		s.WriteString(dimStyle.Render("No failures."))
+		s.WriteString("\n")
+	}
+	for _, event := range health.Restarts {
+		s.WriteString(renderRestartEvent(event))
+		s.WriteString("\n")
+	}
+
+	s.WriteString("\n")
+	s.WriteString(headingStyle.Render("Stderr"))
+	s.WriteString("\n")
+	if len(health.Stderr) == 0 {
+		s.WriteString(dimStyle.Render("No stderr output."))
+		s.WriteString("\n")
+	}
+	for _, line := range health.Stderr {
+		s.WriteString("  " + line)
+		s.WriteString("\n")
+	}
+
+	return s.String()
+}
+
+// renderPing describes the last health check of a server
+func renderPing(health mcpManager.ServerHealth) string {
+	if health.LastPing.IsZero() {
+		return "Last ping: none yet"
+	}
+	if health.PingError != "" {
+		return lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196")).
+			Render(fmt.Sprintf("Last ping: %s failed: %s", health.LastPing.Format("15:04:05"), health.PingError))
+	}
+	return fmt.Sprintf("Last ping: %s (%s)", health.LastPing.Format("15:04:05"), health.PingLatency.Round(time.Millisecond))
+}
+
+// renderRestartEvent renders a server failure and the restart it caused on one line
+func renderRestartEvent(event mcpManager.RestartEvent) string {
+	action := "not restarted"
+	if event.Delay > 0 {
+		action = fmt.Sprintf("restarted after %s", event.Delay)
+	}
+	return fmt.Sprintf("  %s  %-24s %s", event.Time.Format("15:04:05"), action, strings.ReplaceAll(event.Reason, "\n", " "))
+}
+
+// Commands
+func healthTick() tea.Cmd {
+	return tea.Tick(healthRefreshInterval, func(time.Time) tea.Msg {
+		return healthTickMsg{}
+	})
+}
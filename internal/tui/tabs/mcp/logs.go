@@ -0,0 +1,96 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	mcpManager "github.com/kevensen/gollama-chat/internal/tooling/mcp"
+)
+
+// logRefreshInterval is how often the log pane picks up new server log messages
+const logRefreshInterval = time.Second
+
+// logTickMsg refreshes the log pane while it is open
+type logTickMsg struct{}
+
+// openLogPane shows the log messages of the selected server
+func (m Model) openLogPane() (Model, tea.Cmd) {
+	if m.selectedIndex >= len(m.servers) {
+		return m, nil
+	}
+	m.logServer = m.servers[m.selectedIndex].Name
+	return m, logTick()
+}
+
+// handleLogKeys handles keyboard input while the log pane is open
+func (m Model) handleLogKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "escape", "esc", "l":
+		m.logServer = ""
+	}
+	return m, nil
+}
+
+// renderLogPane renders the most recent log messages of a server that fit in height lines
+func (m Model) renderLogPane(height int) string {
+	var s strings.Builder
+
+	s.WriteString(lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("205")).
+		Render(fmt.Sprintf("Log - %s", m.logServer)))
+	s.WriteString("\n")
+	s.WriteString(lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241")).
+		Render("Messages sent by the server with notifications/message. Esc: back"))
+	s.WriteString("\n\n")
+
+	entries := m.manager.ServerLogs(m.logServer)
+	if len(entries) == 0 {
+		s.WriteString(lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241")).
+			Render("No log messages yet."))
+		return s.String()
+	}
+
+	// Leave room for the header and the container's padding
+	if visible := max(height-7, 1); len(entries) > visible {
+		entries = entries[len(entries)-visible:]
+	}
+	for _, entry := range entries {
+		s.WriteString(renderLogEntry(entry))
+		s.WriteString("\n")
+	}
+	return s.String()
+}
+
+// renderLogEntry renders a log message on one line, colored by level
+func renderLogEntry(entry mcpManager.ServerLogEntry) string {
+	levelColor := "241" // Gray
+	switch entry.Level {
+	case "info", "notice":
+		levelColor = "46" // Green
+	case "warning":
+		levelColor = "226" // Yellow
+	case "error", "critical", "alert", "emergency":
+		levelColor = "196" // Red
+	}
+
+	line := entry.Time.Format("15:04:05") + " " +
+		lipgloss.NewStyle().Foreground(lipgloss.Color(levelColor)).Render(fmt.Sprintf("%-7s", entry.Level))
+	if entry.Logger != "" {
+		line += " [" + entry.Logger + "]"
+	}
+	return line + " " + strings.ReplaceAll(entry.Message, "\n", " ")
+}
+
+// Commands
+func logTick() tea.Cmd {
+	return tea.Tick(logRefreshInterval, func(time.Time) tea.Msg {
+		return logTickMsg{}
+	})
+}
@@ -3,6 +3,9 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,13 +20,65 @@ import (
 // ServerStatus represents the UI status of an MCP server
 type ServerUIStatus struct {
 	Name      string
+	Transport string
 	Command   string
 	Arguments []string
+	URL       string
+	Prompts   []string // Names of the prompts offered by the running server
+	Restarts  int      // Times the server has failed and been restarted or given up on
+	GaveUp    bool     // Whether the server failed too often to be restarted again
+	Project   string   // Directory of the project the server belongs to, if any
+	Elsewhere bool     // Whether the server belongs to a project other than the working directory
 	Enabled   bool
 	Status    mcpManager.ServerStatus
 	LastError error
 }
 
+// formField identifies an input of the add and edit server forms
+type formField int
+
+const (
+	fieldName formField = iota
+	fieldTransport
+	fieldCommand
+	fieldArguments
+	fieldEnv
+	fieldWorkingDir
+	fieldInheritEnv
+	fieldURL
+	fieldHeaders
+	fieldRoots
+	fieldSampling
+	fieldEnabled
+)
+
+// transports lists the transports in the order the form cycles through them
+var transports = []string{
+	configuration.MCPTransportStdio,
+	configuration.MCPTransportHTTP,
+	configuration.MCPTransportSSE,
+}
+
+// samplingPolicies lists the sampling policies in the order the form cycles through them
+var samplingPolicies = []string{
+	configuration.MCPSamplingAsk,
+	configuration.MCPSamplingAlways,
+	configuration.MCPSamplingNever,
+}
+
+// formFields returns the form inputs for a server, which depend on its transport
+func formFields(server configuration.MCPServer) []formField {
+	if server.IsRemote() {
+		return []formField{fieldName, fieldTransport, fieldURL, fieldHeaders, fieldRoots, fieldSampling, fieldEnabled}
+	}
+	return []formField{fieldName, fieldTransport, fieldCommand, fieldArguments, fieldEnv, fieldWorkingDir, fieldInheritEnv, fieldRoots, fieldSampling, fieldEnabled}
+}
+
+// isTextField reports whether a field is edited by typing rather than toggled
+func isTextField(field formField) bool {
+	return field != fieldTransport && field != fieldInheritEnv && field != fieldSampling && field != fieldEnabled
+}
+
 // Model represents the MCP tab model
 type Model struct {
 	config        *configuration.Config
@@ -31,10 +86,14 @@ type Model struct {
 	servers       []ServerUIStatus
 	selectedIndex int
 	editingIndex  int // -1 when not editing, >= 0 when editing a server
-	editingField  int // 0=name, 1=command, 2=arguments, 3=enabled
+	editingField  int // Index into formFields of the server being added or edited
 	inputValue    string
 	showAddForm   bool
 	newServer     configuration.MCPServer
+	editServer    configuration.MCPServer // Changes to the server being edited, saved on the last field
+	browser       *resourceBrowser        // Set while browsing the resources of a server
+	logServer     string                  // Server whose log pane is open, if any
+	healthServer  string                  // Server whose health pane is open, if any
 	width         int
 	height        int
 	ctx           context.Context
@@ -77,15 +136,44 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 	case tea.KeyMsg:
 		if m.showAddForm {
-			return m.handleAddFormKeys(msg)
+			return m.handleFormKeys(msg)
 		}
 
 		if m.editingIndex >= 0 {
-			return m.handleEditingKeys(msg)
+			return m.handleFormKeys(msg)
+		}
+
+		if m.browser != nil {
+			return m.handleBrowserKeys(msg)
+		}
+
+		if m.logServer != "" {
+			return m.handleLogKeys(msg)
+		}
+
+		if m.healthServer != "" {
+			return m.handleHealthKeys(msg)
 		}
 
 		return m.handleNormalKeys(msg)
 
+	case logTickMsg:
+		// Re-render with new log messages until the pane is closed
+		if m.logServer == "" {
+			return m, nil
+		}
+		return m, logTick()
+
+	case healthTickMsg:
+		// Re-render with new restarts and pings until the pane is closed
+		if m.healthServer == "" {
+			return m, nil
+		}
+		return m, healthTick()
+
+	case resourcesLoadedMsg, resourcePreviewMsg, resourceAttachedMsg:
+		return m.updateBrowser(msg), nil
+
 	case refreshServersMsg:
 		logger := logging.WithComponent("mcp-tab")
 		logger.Info("Received refresh servers message", "serverCount", len(msg.servers))
@@ -149,8 +237,9 @@ func (m Model) handleNormalKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
 		// Edit server
 		if m.selectedIndex < len(m.servers) {
 			m.editingIndex = m.selectedIndex
+			m.editServer = m.configuredServer(m.selectedIndex)
 			m.editingField = 0
-			m.inputValue = m.servers[m.selectedIndex].Name
+			m.inputValue = m.editServer.Name
 		}
 		return m, nil
 
@@ -174,6 +263,22 @@ func (m Model) handleNormalKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
 		m.editingField = 0
 		return m, nil
 
+	case "v":
+		// View resources
+		return m.openResourceBrowser()
+
+	case "l":
+		// View server log
+		return m.openLogPane()
+
+	case "h":
+		// View restart history and stderr output
+		return m.openHealthPane()
+
+	case "i":
+		// Import the servers of the working directory's project
+		return m, m.importProjectServers()
+
 	case "r":
 		// Refresh server statuses
 		return m, m.refreshServerList()
@@ -187,126 +292,70 @@ func (m Model) handleNormalKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
 	return m, nil
 }
 
-// handleAddFormKeys handles keyboard input while adding a new server
-func (m Model) handleAddFormKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
+// handleFormKeys handles keyboard input while adding or editing a server
+func (m Model) handleFormKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
 	switch msg.String() {
 	case "escape", "esc":
-		m.showAddForm = false
+		if m.showAddForm {
+			m.showAddForm = false
+			m.newServer = configuration.MCPServer{
+				Name:      "",
+				Command:   "",
+				Arguments: []string{},
+				Enabled:   true,
+			}
+		}
+		m.editingIndex = -1
 		m.editingField = 0
 		m.inputValue = ""
-		m.newServer = configuration.MCPServer{
-			Name:      "",
-			Command:   "",
-			Arguments: []string{},
-			Enabled:   true,
-		}
 		return m, nil
 
 	case "enter":
-		switch m.editingField {
-		case 0: // Name
-			m.newServer.Name = strings.TrimSpace(m.inputValue)
-			m.editingField = 1
-			m.inputValue = m.newServer.Command
-		case 1: // Command
-			m.newServer.Command = strings.TrimSpace(m.inputValue)
-			m.editingField = 2
-			m.inputValue = strings.Join(m.newServer.Arguments, " ")
-		case 2: // Arguments
-			args := strings.Fields(strings.TrimSpace(m.inputValue))
-			m.newServer.Arguments = args
-			m.editingField = 3
-			m.inputValue = ""
-		case 3: // Enabled (save)
-			if m.newServer.Name != "" && m.newServer.Command != "" {
-				m.showAddForm = false
-				return m, m.addServer(m.newServer)
-			}
+		m.commitField()
+		if m.editingField < len(formFields(*m.formServer()))-1 {
+			m.moveField(1)
+			return m, nil
 		}
-		return m, nil
 
-	case "tab":
-		// Save current field before moving to next
-		switch m.editingField {
-		case 0: // Name
-			m.newServer.Name = strings.TrimSpace(m.inputValue)
-		case 1: // Command
-			m.newServer.Command = strings.TrimSpace(m.inputValue)
-		case 2: // Arguments
-			args := strings.Fields(strings.TrimSpace(m.inputValue))
-			m.newServer.Arguments = args
-		}
-
-		// Move to next field
-		m.editingField = (m.editingField + 1) % 4
-		switch m.editingField {
-		case 0:
-			m.inputValue = m.newServer.Name
-		case 1:
-			m.inputValue = m.newServer.Command
-		case 2:
-			m.inputValue = strings.Join(m.newServer.Arguments, " ")
-		case 3:
-			m.inputValue = ""
+		// Enter on the last field saves the server
+		server := *m.formServer()
+		if !isComplete(server) {
+			return m, nil
 		}
-		return m, nil
-
-	case "shift+tab":
-		// Save current field before moving to previous
-		switch m.editingField {
-		case 0: // Name
-			m.newServer.Name = strings.TrimSpace(m.inputValue)
-		case 1: // Command
-			m.newServer.Command = strings.TrimSpace(m.inputValue)
-		case 2: // Arguments
-			args := strings.Fields(strings.TrimSpace(m.inputValue))
-			m.newServer.Arguments = args
-		}
-
-		// Move to previous field
-		m.editingField = (m.editingField - 1 + 4) % 4
-		switch m.editingField {
-		case 0:
-			m.inputValue = m.newServer.Name
-		case 1:
-			m.inputValue = m.newServer.Command
-		case 2:
-			m.inputValue = strings.Join(m.newServer.Arguments, " ")
-		case 3:
-			m.inputValue = ""
+		if m.showAddForm {
+			m.showAddForm = false
+			return m, m.addServer(server)
 		}
+		oldName := m.servers[m.editingIndex].Name
+		m.editingIndex = -1
+		return m, m.updateServer(oldName, server)
+
+	case "tab":
+		m.commitField()
+		m.moveField(1)
 		return m, nil
 
-	case "shift+enter":
-		// Save current field before moving to previous
-		switch m.editingField {
-		case 0: // Name
-			m.newServer.Name = strings.TrimSpace(m.inputValue)
-		case 1: // Command
-			m.newServer.Command = strings.TrimSpace(m.inputValue)
-		case 2: // Arguments
-			args := strings.Fields(strings.TrimSpace(m.inputValue))
-			m.newServer.Arguments = args
-		}
-
-		// Move to previous field
-		m.editingField = (m.editingField - 1 + 4) % 4
-		switch m.editingField {
-		case 0:
-			m.inputValue = m.newServer.Name
-		case 1:
-			m.inputValue = m.newServer.Command
-		case 2:
-			m.inputValue = strings.Join(m.newServer.Arguments, " ")
-		case 3:
-			m.inputValue = ""
-		}
+	case "shift+tab", "shift+enter":
+		m.commitField()
+		m.moveField(-1)
 		return m, nil
 
 	case "space", " ":
-		if m.editingField == 3 { // Toggle enabled
-			m.newServer.Enabled = !m.newServer.Enabled
-		} else {
+		server := m.formServer()
+		switch formFields(*server)[m.editingField] {
+		case fieldTransport:
+			server.Transport = nextTransport(server.TransportName())
+		case fieldInheritEnv:
+			server.InheritEnv = nil
+			if server.InheritsEnv() {
+				inherit := false
+				server.InheritEnv = &inherit
+			}
+		case fieldSampling:
+			server.Sampling = nextSamplingPolicy(server.SamplingPolicy())
+		case fieldEnabled:
+			server.Enabled = !server.Enabled
+		default:
 			m.inputValue += " "
 		}
 		return m, nil
@@ -318,125 +367,226 @@ func (m Model) handleAddFormKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
 		return m, nil
 
 	default:
-		if len(msg.String()) == 1 && m.editingField < 3 {
+		if len(msg.String()) == 1 && isTextField(formFields(*m.formServer())[m.editingField]) {
 			m.inputValue += msg.String()
 		}
 		return m, nil
 	}
 }
 
-// handleEditingKeys handles keyboard input while editing a server
-func (m Model) handleEditingKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
-	switch msg.String() {
-	case "escape", "esc":
-		m.editingIndex = -1
-		return m, nil
+// formServer returns the server being added or edited
+func (m *Model) formServer() *configuration.MCPServer {
+	if m.showAddForm {
+		return &m.newServer
+	}
+	return &m.editServer
+}
 
-	case "enter":
-		// Save the current field and move to next
-		server := m.servers[m.editingIndex]
-		updatedServer := configuration.MCPServer{
-			Name:      server.Name,
-			Command:   server.Command,
-			Arguments: server.Arguments,
-			Enabled:   server.Enabled,
-		}
-
-		switch m.editingField {
-		case 0: // Name
-			updatedServer.Name = strings.TrimSpace(m.inputValue)
-			m.editingField = 1
-			m.inputValue = updatedServer.Command
-		case 1: // Command
-			updatedServer.Command = strings.TrimSpace(m.inputValue)
-			m.editingField = 2
-			m.inputValue = strings.Join(updatedServer.Arguments, " ")
-		case 2: // Arguments
-			args := strings.Fields(strings.TrimSpace(m.inputValue))
-			updatedServer.Arguments = args
-			m.editingField = 3
-			m.inputValue = ""
-		case 3: // Save
-			m.editingIndex = -1
-			return m, m.updateServer(server.Name, updatedServer)
-		}
-		return m, nil
+// commitField stores the input value in the current text field of the form
+func (m *Model) commitField() {
+	server := m.formServer()
+	value := strings.TrimSpace(m.inputValue)
+
+	switch formFields(*server)[m.editingField] {
+	case fieldName:
+		server.Name = value
+	case fieldCommand:
+		server.Command = value
+	case fieldArguments:
+		server.Arguments = strings.Fields(value)
+	case fieldEnv:
+		server.Env = parseEnv(value)
+	case fieldWorkingDir:
+		server.WorkingDir = value
+	case fieldURL:
+		server.URL = value
+	case fieldHeaders:
+		server.Headers = parseHeaders(value)
+	case fieldRoots:
+		server.Roots = strings.Fields(value)
+	}
+}
 
-	case "tab":
-		// Move to next field
-		m.editingField = (m.editingField + 1) % 4
-		server := m.servers[m.editingIndex]
-		switch m.editingField {
-		case 0:
-			m.inputValue = server.Name
-		case 1:
-			m.inputValue = server.Command
-		case 2:
-			m.inputValue = strings.Join(server.Arguments, " ")
-		case 3:
-			m.inputValue = ""
-		}
-		return m, nil
+// moveField moves to the next (delta 1) or previous (delta -1) form field,
+// loading its current value for editing
+func (m *Model) moveField(delta int) {
+	fields := formFields(*m.formServer())
+	m.editingField = (m.editingField + delta + len(fields)) % len(fields)
+	m.inputValue = fieldText(*m.formServer(), fields[m.editingField])
+}
 
-	case "shift+tab":
-		// Move to previous field
-		m.editingField = (m.editingField - 1 + 4) % 4
-		server := m.servers[m.editingIndex]
-		switch m.editingField {
-		case 0:
-			m.inputValue = server.Name
-		case 1:
-			m.inputValue = server.Command
-		case 2:
-			m.inputValue = strings.Join(server.Arguments, " ")
-		case 3:
-			m.inputValue = ""
+// configuredServer returns a copy of the configuration of a listed server
+func (m Model) configuredServer(index int) configuration.MCPServer {
+	server := m.servers[index]
+	if configured := m.config.GetMCPServer(server.Name); configured != nil {
+		copied := *configured
+		copied.Arguments = slices.Clone(configured.Arguments)
+		copied.Env = maps.Clone(configured.Env)
+		if configured.InheritEnv != nil {
+			inherit := *configured.InheritEnv
+			copied.InheritEnv = &inherit
 		}
-		return m, nil
+		copied.Headers = maps.Clone(configured.Headers)
+		copied.Roots = slices.Clone(configured.Roots)
+		return copied
+	}
+	return configuration.MCPServer{
+		Name:      server.Name,
+		Transport: server.Transport,
+		Command:   server.Command,
+		Arguments: server.Arguments,
+		URL:       server.URL,
+		Enabled:   server.Enabled,
+	}
+}
 
-	case "shift+enter":
-		// Move to previous field
-		m.editingField = (m.editingField - 1 + 4) % 4
-		server := m.servers[m.editingIndex]
-		switch m.editingField {
-		case 0:
-			m.inputValue = server.Name
-		case 1:
-			m.inputValue = server.Command
-		case 2:
-			m.inputValue = strings.Join(server.Arguments, " ")
-		case 3:
-			m.inputValue = ""
-		}
-		return m, nil
+// isComplete reports whether a server has the settings its transport requires
+func isComplete(server configuration.MCPServer) bool {
+	if server.Name == "" {
+		return false
+	}
+	if server.IsRemote() {
+		return server.URL != ""
+	}
+	return server.Command != ""
+}
 
-	case "space":
-		if m.editingField == 3 { // Toggle enabled
-			server := &m.servers[m.editingIndex]
-			server.Enabled = !server.Enabled
-		} else {
-			m.inputValue += " "
+// nextTransport returns the transport following current in the form's cycle
+func nextTransport(current string) string {
+	index := slices.Index(transports, current)
+	return transports[(index+1)%len(transports)]
+}
+
+// nextSamplingPolicy returns the sampling policy following current in the form's cycle
+func nextSamplingPolicy(current string) string {
+	index := slices.Index(samplingPolicies, current)
+	return samplingPolicies[(index+1)%len(samplingPolicies)]
+}
+
+// fieldText returns the editable text of a form field
+func fieldText(server configuration.MCPServer, field formField) string {
+	switch field {
+	case fieldName:
+		return server.Name
+	case fieldCommand:
+		return server.Command
+	case fieldArguments:
+		return strings.Join(server.Arguments, " ")
+	case fieldEnv:
+		return formatEnv(server.Env, false)
+	case fieldWorkingDir:
+		return server.WorkingDir
+	case fieldURL:
+		return server.URL
+	case fieldHeaders:
+		return formatHeaders(server.Headers)
+	case fieldRoots:
+		return strings.Join(server.Roots, " ")
+	default:
+		return ""
+	}
+}
+
+// formatHeaders renders headers as "Name: value; Name: value" in name order
+func formatHeaders(headers map[string]string) string {
+	names := slices.Sorted(maps.Keys(headers))
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+": "+headers[name])
+	}
+	return strings.Join(pairs, "; ")
+}
+
+// parseHeaders parses headers entered as "Name: value; Name: value", skipping
+// entries without a name
+func parseHeaders(text string) map[string]string {
+	var headers map[string]string
+	for _, pair := range strings.Split(text, ";") {
+		name, value, _ := strings.Cut(pair, ":")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
 		}
-		return m, nil
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[name] = strings.TrimSpace(value)
+	}
+	return headers
+}
 
-	case "backspace":
-		if len(m.inputValue) > 0 {
-			m.inputValue = m.inputValue[:len(m.inputValue)-1]
+// formatEnv renders environment variables as "NAME=value; NAME=value" in name order.
+// When masked, values other than secret references are hidden.
+func formatEnv(env map[string]string, masked bool) string {
+	names := slices.Sorted(maps.Keys(env))
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		value := env[name]
+		if masked && !configuration.IsSecretReference(value) {
+			value = "****"
 		}
-		return m, nil
+		pairs = append(pairs, name+"="+value)
+	}
+	return strings.Join(pairs, "; ")
+}
 
-	default:
-		if len(msg.String()) == 1 && m.editingField < 3 {
-			m.inputValue += msg.String()
+// parseEnv parses environment variables entered as "NAME=value; NAME=value", skipping
+// entries without a name
+func parseEnv(text string) map[string]string {
+	var env map[string]string
+	for _, pair := range strings.Split(text, ";") {
+		name, value, _ := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
 		}
-		return m, nil
+		if env == nil {
+			env = make(map[string]string)
+		}
+		env[name] = strings.TrimSpace(value)
 	}
+	return env
 }
 
 // View renders the MCP tab
 func (m Model) View() string {
 	var s strings.Builder
 
+	// Calculate heights for layout
+	tabBarHeight := 1
+	footerHeight := 1
+	totalContentHeight := m.height - tabBarHeight - footerHeight + 2 // Add small adjustment to fill remaining space
+
+	if m.logServer != "" {
+		return lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#8A7FD8")).
+			Padding(1, 2).
+			Width(m.width - 2).
+			Height(totalContentHeight).
+			Render(m.renderLogPane(totalContentHeight))
+	}
+
+	if m.healthServer != "" {
+		return lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#8A7FD8")).
+			Padding(1, 2).
+			Width(m.width - 2).
+			Height(totalContentHeight).
+			Render(m.renderHealthPane(time.Now()))
+	}
+
+	if m.browser != nil {
+		return lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#8A7FD8")).
+			Padding(1, 2).
+			Width(m.width - 2).
+			Height(totalContentHeight).
+			Render(m.renderResourceBrowser())
+	}
+
 	// Header
 	s.WriteString(lipgloss.NewStyle().
 		Bold(true).
@@ -448,11 +598,11 @@ func (m Model) View() string {
 	if m.editingIndex >= 0 {
 		s.WriteString(lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241")).
-			Render("Editing server - Enter: save field/next, Tab: next field, Shift+Tab/Shift+Enter: previous field, Esc: cancel"))
+			Render("Editing server - Enter: save field/next, Tab: next field, Shift+Tab/Shift+Enter: previous field, Space: toggle, Esc: cancel"))
 	} else {
 		s.WriteString(lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241")).
-			Render("Enter: toggle, e: edit, d: delete, a: add, r: refresh, c: clear error"))
+			Render("Enter: toggle, e: edit, d: delete, a: add, v: resources, l: log, h: health, i: import .mcp.json, r: refresh, c: clear error"))
 	}
 	s.WriteString("\n")
 
@@ -477,11 +627,6 @@ func (m Model) View() string {
 		}
 	}
 
-	// Calculate heights for layout
-	tabBarHeight := 1
-	footerHeight := 1
-	totalContentHeight := m.height - tabBarHeight - footerHeight + 2 // Add small adjustment to fill remaining space
-
 	var serverListContent string
 	var addFormContent string
 
@@ -587,92 +732,113 @@ func (m Model) renderServer(index int, server ServerUIStatus) string {
 		name = "<unnamed>"
 	}
 
-	args := strings.Join(server.Arguments, " ")
-	if len(args) > 50 {
-		args = args[:47] + "..."
+	target := server.Command + " " + strings.Join(server.Arguments, " ")
+	if server.Transport == configuration.MCPTransportHTTP || server.Transport == configuration.MCPTransportSSE {
+		target = fmt.Sprintf("[%s] %s", server.Transport, server.URL)
+	}
+	if len(target) > 60 {
+		target = target[:57] + "..."
 	}
 
-	content := fmt.Sprintf("%s %s %s | %s %s",
+	content := fmt.Sprintf("%s %s %s | %s",
 		statusIndicator,
 		enabledIndicator,
 		name,
-		server.Command,
-		args)
+		target)
 
 	// Add error info if there's an error
 	if server.LastError != nil {
 		content += fmt.Sprintf(" | Error: %s", server.LastError.Error())
 	}
 
-	return style.Render(content)
-}
+	// List prompts as the chat commands that invoke them
+	if len(server.Prompts) > 0 {
+		commands := make([]string, len(server.Prompts))
+		for i, prompt := range server.Prompts {
+			commands[i] = fmt.Sprintf("/%s:%s", server.Name, prompt)
+		}
+		content += "\n    Prompts: " + strings.Join(commands, ", ")
+	}
 
-// renderEditingServer renders a server in editing mode
-func (m Model) renderEditingServer(server ServerUIStatus, style lipgloss.Style) string {
-	fields := []string{
-		fmt.Sprintf("Name: %s", m.getFieldValue(0, server.Name)),
-		fmt.Sprintf("Command: %s", m.getFieldValue(1, server.Command)),
-		fmt.Sprintf("Arguments: %s", m.getFieldValue(2, strings.Join(server.Arguments, " "))),
-		fmt.Sprintf("Enabled: %v", server.Enabled),
+	if server.Elsewhere {
+		content += fmt.Sprintf("\n    Project: %s (not started outside it)", server.Project)
+	} else if server.Project != "" {
+		content += fmt.Sprintf("\n    Project: %s", server.Project)
 	}
 
-	// Highlight the current field
-	if m.editingField < len(fields) {
-		fields[m.editingField] = lipgloss.NewStyle().
-			Background(lipgloss.Color("220")).
-			Foreground(lipgloss.Color("0")).
-			Render(fields[m.editingField])
+	if server.GaveUp {
+		content += fmt.Sprintf("\n    Not restarted after %d failures (h: details)", server.Restarts)
+	} else if server.Restarts > 0 {
+		content += fmt.Sprintf("\n    Restarts: %d (h: details)", server.Restarts)
 	}
 
-	return style.Render(strings.Join(fields, " | "))
+	return style.Render(content)
 }
 
-// getFieldValue returns the appropriate value for a field (input or original)
-func (m Model) getFieldValue(fieldIndex int, originalValue string) string {
-	if m.editingField == fieldIndex {
-		return m.inputValue + "█" // Cursor
+// renderEditingServer renders a server in editing mode
+func (m Model) renderEditingServer(server ServerUIStatus, style lipgloss.Style) string {
+	fields := formFields(m.editServer)
+	rendered := make([]string, len(fields))
+	for i, field := range fields {
+		rendered[i] = m.renderFormField(m.editServer, i, field)
 	}
-	return originalValue
+	return style.Render(strings.Join(rendered, " | "))
 }
 
-// renderAddForm renders the add server form
-func (m Model) renderAddForm() string {
-	var s strings.Builder
-
-	s.WriteString(lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("205")).
-		Render("Add New MCP Server"))
-	s.WriteString("\n\n")
-
-	s.WriteString(lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
-		Render("Tab/Enter: next field, Shift+Tab/Shift+Enter: previous field, Space: toggle enabled, Esc: cancel"))
-	s.WriteString("\n\n")
-
-	// Form fields
-	fields := []struct {
-		label string
-		value string
-	}{
-		{"Name", m.getAddFormValue(0, m.newServer.Name)},
-		{"Command", m.getAddFormValue(1, m.newServer.Command)},
-		{"Arguments", m.getAddFormValue(2, strings.Join(m.newServer.Arguments, " "))},
-		{"Enabled", m.getEnabledFieldDisplay()},
+// renderFormField renders a labelled form field, highlighting the field being edited
+func (m Model) renderFormField(server configuration.MCPServer, index int, field formField) string {
+	var label, value string
+	switch field {
+	case fieldName:
+		label = "Name"
+	case fieldTransport:
+		label, value = "Transport", server.TransportName()+" (space to change)"
+	case fieldCommand:
+		label = "Command"
+	case fieldArguments:
+		label = "Arguments"
+	case fieldEnv:
+		label = "Environment (NAME=value; NAME=${env:VAR}; NAME=${file:path})"
+	case fieldWorkingDir:
+		label = "Working directory (default: current directory)"
+	case fieldInheritEnv:
+		label, value = "Inherit environment", "✓ Yes (space to toggle)"
+		if !server.InheritsEnv() {
+			value = "✗ No, only PATH, HOME and similar (space to toggle)"
+		}
+	case fieldURL:
+		label = "URL"
+	case fieldHeaders:
+		label = "Headers (Name: value; ...)"
+	case fieldRoots:
+		label = "Roots (default: working directory)"
+	case fieldSampling:
+		label, value = "Sampling", server.SamplingPolicy()+" (space to change)"
+	case fieldEnabled:
+		label, value = "Enabled", "✓ Enabled (space to toggle)"
+		if !server.Enabled {
+			value = "✗ Disabled (space to toggle)"
+		}
 	}
 
-	for i, field := range fields {
-		style := lipgloss.NewStyle()
-		if i == m.editingField {
-			style = style.Background(lipgloss.Color("220")).Foreground(lipgloss.Color("0"))
+	if isTextField(field) {
+		value = fieldText(server, field)
+		if field == fieldEnv {
+			value = formatEnv(server.Env, true) // Keep plain secret values off the screen
+		}
+		if index == m.editingField {
+			value = m.inputValue + "█" // Cursor
 		}
-
-		s.WriteString(fmt.Sprintf("%s: %s\n", field.label, style.Render(field.value)))
 	}
 
-	s.WriteString("\nPress Enter on Enabled field to save server.")
-
-	return s.String()
+	text := fmt.Sprintf("%s: %s", label, value)
+	if index == m.editingField {
+		text = lipgloss.NewStyle().
+			Background(lipgloss.Color("220")).
+			Foreground(lipgloss.Color("0")).
+			Render(text)
+	}
+	return text
 }
 
 // renderAddFormContent renders just the add form content without header/styling
@@ -687,27 +853,12 @@ func (m Model) renderAddFormContent() string {
 
 	s.WriteString(lipgloss.NewStyle().
 		Foreground(lipgloss.Color("241")).
-		Render("Tab/Enter: next field, Shift+Tab/Shift+Enter: previous field, Space: toggle enabled, Esc: cancel"))
+		Render("Tab/Enter: next field, Shift+Tab/Shift+Enter: previous field, Space: change transport/toggle enabled, Esc: cancel"))
 	s.WriteString("\n\n")
 
-	// Form fields
-	fields := []struct {
-		label string
-		value string
-	}{
-		{"Name", m.getAddFormValue(0, m.newServer.Name)},
-		{"Command", m.getAddFormValue(1, m.newServer.Command)},
-		{"Arguments", m.getAddFormValue(2, strings.Join(m.newServer.Arguments, " "))},
-		{"Enabled", m.getEnabledFieldDisplay()},
-	}
-
-	for i, field := range fields {
-		style := lipgloss.NewStyle()
-		if i == m.editingField {
-			style = style.Background(lipgloss.Color("220")).Foreground(lipgloss.Color("0"))
-		}
-
-		s.WriteString(fmt.Sprintf("%s: %s\n", field.label, style.Render(field.value)))
+	for i, field := range formFields(m.newServer) {
+		s.WriteString(m.renderFormField(m.newServer, i, field))
+		s.WriteString("\n")
 	}
 
 	s.WriteString("\nPress Enter on Enabled field to save server.")
@@ -715,23 +866,6 @@ func (m Model) renderAddFormContent() string {
 	return s.String()
 }
 
-// getAddFormValue returns the appropriate value for add form field
-func (m Model) getAddFormValue(fieldIndex int, originalValue string) string {
-	if m.editingField == fieldIndex && fieldIndex < 3 {
-		return m.inputValue + "█" // Cursor
-	}
-	return originalValue
-}
-
-// getEnabledFieldDisplay returns the formatted enabled field display
-func (m Model) getEnabledFieldDisplay() string {
-	status := "✓ Enabled"
-	if !m.newServer.Enabled {
-		status = "✗ Disabled"
-	}
-	return fmt.Sprintf("%s (space to toggle)", status)
-}
-
 // Message types
 type refreshServersMsg struct {
 	servers []ServerUIStatus
@@ -749,8 +883,10 @@ func (m Model) refreshServerList() tea.Cmd {
 
 		var servers []ServerUIStatus
 
-		// Get server statuses from manager
+		// Get server statuses and prompts from manager
 		statuses := m.manager.GetAllServerStatuses()
+		prompts := m.manager.GetAllPrompts()
+		workingDir, _ := m.manager.WorkingDirectory()
 		logger.Info("Got statuses from manager", "statusCount", len(statuses))
 
 		// Build UI server list from configuration
@@ -766,10 +902,24 @@ func (m Model) refreshServerList() tea.Cmd {
 				lastError = m.manager.GetServerLastError(configServer.Name)
 			}
 
+			var promptNames []string
+			for _, prompt := range prompts[configServer.Name] {
+				promptNames = append(promptNames, prompt.Name)
+			}
+
+			health := m.manager.ServerHealth(configServer.Name)
+
 			servers = append(servers, ServerUIStatus{
 				Name:      configServer.Name,
+				Transport: configServer.TransportName(),
 				Command:   configServer.Command,
 				Arguments: configServer.Arguments,
+				URL:       configServer.URL,
+				Prompts:   promptNames,
+				Restarts:  len(health.Restarts),
+				GaveUp:    health.GaveUp,
+				Project:   configServer.Project,
+				Elsewhere: !configServer.AppliesIn(workingDir),
 				Enabled:   configServer.Enabled,
 				Status:    status,
 				LastError: lastError,
@@ -788,12 +938,8 @@ func (m Model) toggleServer(index int) tea.Cmd {
 		}
 
 		server := m.servers[index]
-		updatedServer := configuration.MCPServer{
-			Name:      server.Name,
-			Command:   server.Command,
-			Arguments: server.Arguments,
-			Enabled:   !server.Enabled, // Toggle
-		}
+		updatedServer := m.configuredServer(index)
+		updatedServer.Enabled = !server.Enabled // Toggle
 
 		err := m.config.UpdateMCPServer(server.Name, updatedServer)
 		if err != nil {
@@ -862,6 +1008,36 @@ func (m Model) addServer(server configuration.MCPServer) tea.Cmd {
 	})
 }
 
+// importProjectServers merges the servers of the working directory's .mcp.json into the
+// configuration, scoped to that directory
+func (m Model) importProjectServers() tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		logger := logging.WithComponent("mcp-tab")
+
+		dir, err := m.manager.WorkingDirectory()
+		if err != nil {
+			return serverActionCompleteMsg{err: err}
+		}
+		servers, err := configuration.ReadMCPJSON(filepath.Join(dir, configuration.ProjectMCPFileName))
+		if err != nil {
+			return serverActionCompleteMsg{err: err}
+		}
+		added, updated, err := m.config.ImportMCPServers(servers)
+		if err != nil {
+			return serverActionCompleteMsg{err: fmt.Errorf("failed to import MCP servers: %w", err)}
+		}
+		if err := m.config.Save(); err != nil {
+			return serverActionCompleteMsg{err: err}
+		}
+		logger.Info("Imported project MCP servers", "directory", dir, "added", added, "updated", updated)
+
+		if updateErr := m.manager.UpdateConfiguration(m.ctx, m.config); updateErr != nil {
+			return serverActionCompleteMsg{err: updateErr}
+		}
+		return serverActionCompleteMsg{err: nil}
+	})
+}
+
 func (m Model) updateServer(oldName string, server configuration.MCPServer) tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
 		err := m.config.UpdateMCPServer(oldName, server)
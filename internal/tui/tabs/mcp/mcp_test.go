@@ -0,0 +1,284 @@
+package mcp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+	mcpManager "github.com/kevensen/gollama-chat/internal/tooling/mcp"
+)
+
+// typeText sends each rune of text to the model as a key press
+func typeText(m Model, text string) Model {
+	for _, r := range text {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return m
+}
+
+func TestAddFormRemoteServer(t *testing.T) {
+	config := configuration.DefaultConfig()
+	m := NewModel(t.Context(), config, nil)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	if !m.showAddForm {
+		t.Fatal("Expected add form to be shown")
+	}
+
+	m = typeText(m, "remote")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// Switch the transport to Streamable HTTP; the form then asks for URL and headers
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	if m.newServer.Transport != configuration.MCPTransportHTTP {
+		t.Fatalf("Expected transport %q, got %q", configuration.MCPTransportHTTP, m.newServer.Transport)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if field := formFields(m.newServer)[m.editingField]; field != fieldURL {
+		t.Fatalf("Expected URL field after transport, got %v", field)
+	}
+
+	m = typeText(m, "http://localhost:9000/mcp")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = typeText(m, "Authorization: Bearer abc; X-Team: platform")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = typeText(m, "src")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m = typeText(m, "/srv/docs")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// Let the server use the chat model without asking
+	if field := formFields(m.newServer)[m.editingField]; field != fieldSampling {
+		t.Fatalf("Expected sampling field after roots, got %v", field)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	expected := configuration.MCPServer{
+		Name:      "remote",
+		Transport: configuration.MCPTransportHTTP,
+		Arguments: []string{},
+		URL:       "http://localhost:9000/mcp",
+		Headers:   map[string]string{"Authorization": "Bearer abc", "X-Team": "platform"},
+		Roots:     []string{"src", "/srv/docs"},
+		Sampling:  configuration.MCPSamplingAlways,
+		Enabled:   true,
+	}
+	if !reflect.DeepEqual(m.newServer, expected) {
+		t.Errorf("Expected server %+v, got %+v", expected, m.newServer)
+	}
+
+	// Enter on the last field saves the server
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.showAddForm || cmd == nil {
+		t.Error("Expected the form to close and a save command to be returned")
+	}
+}
+
+func TestAddFormLocalServerEnvironment(t *testing.T) {
+	config := configuration.DefaultConfig()
+	m := NewModel(t.Context(), config, nil)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	m = typeText(m, "github")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter}) // Keep the stdio transport
+	m = typeText(m, "github-mcp")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter}) // No arguments
+
+	if field := formFields(m.newServer)[m.editingField]; field != fieldEnv {
+		t.Fatalf("Expected environment field after arguments, got %v", field)
+	}
+	m = typeText(m, "GITHUB_TOKEN=${env:GH_TOKEN}; LOG_LEVEL=debug")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = typeText(m, "tools/github")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// Run the server with only the base environment
+	if field := formFields(m.newServer)[m.editingField]; field != fieldInheritEnv {
+		t.Fatalf("Expected inherit environment field after working directory, got %v", field)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	if m.newServer.InheritsEnv() {
+		t.Fatal("Expected space to stop inheriting the environment")
+	}
+
+	expected := map[string]string{"GITHUB_TOKEN": "${env:GH_TOKEN}", "LOG_LEVEL": "debug"}
+	if !reflect.DeepEqual(m.newServer.Env, expected) {
+		t.Errorf("Expected environment %v, got %v", expected, m.newServer.Env)
+	}
+	if m.newServer.WorkingDir != "tools/github" {
+		t.Errorf("Expected working directory %q, got %q", "tools/github", m.newServer.WorkingDir)
+	}
+
+	// Plain values are hidden unless the field is being edited
+	if view := m.View(); !strings.Contains(view, "GITHUB_TOKEN=${env:GH_TOKEN}; LOG_LEVEL=****") {
+		t.Errorf("Expected the environment to be masked in the form:\n%s", view)
+	}
+}
+
+func TestEnvRoundTrip(t *testing.T) {
+	env := map[string]string{"TOKEN": "${file:~/.token}", "URL": "http://host?a=b"}
+
+	text := formatEnv(env, false)
+	if text != "TOKEN=${file:~/.token}; URL=http://host?a=b" {
+		t.Errorf("Unexpected formatted environment %q", text)
+	}
+	if parsed := parseEnv(text); !reflect.DeepEqual(parsed, env) {
+		t.Errorf("Expected %v, got %v", env, parsed)
+	}
+	if parsed := parseEnv("  "); parsed != nil {
+		t.Errorf("Expected no environment for empty text, got %v", parsed)
+	}
+}
+
+func TestHeadersRoundTrip(t *testing.T) {
+	headers := map[string]string{"X-B": "2", "Authorization": "Bearer a:b"}
+
+	text := formatHeaders(headers)
+	if text != "Authorization: Bearer a:b; X-B: 2" {
+		t.Errorf("Unexpected formatted headers %q", text)
+	}
+	if parsed := parseHeaders(text); !reflect.DeepEqual(parsed, headers) {
+		t.Errorf("Expected %v, got %v", headers, parsed)
+	}
+	if parsed := parseHeaders("  "); parsed != nil {
+		t.Errorf("Expected no headers for empty text, got %v", parsed)
+	}
+}
+
+func TestResourceBrowser(t *testing.T) {
+	m := NewModel(t.Context(), configuration.DefaultConfig(), nil)
+	m.servers = []ServerUIStatus{
+		{Name: "stopped", Status: mcpManager.StatusStopped},
+		{Name: "docs", Status: mcpManager.StatusRunning},
+	}
+
+	// Stopped servers cannot be browsed
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	if m.browser != nil || m.lastError == nil {
+		t.Fatal("Expected an error when browsing a stopped server")
+	}
+
+	m.selectedIndex = 1
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	if m.browser == nil || m.browser.server != "docs" || cmd == nil {
+		t.Fatal("Expected the resource browser to open and load resources")
+	}
+
+	m, _ = m.Update(resourcesLoadedMsg{
+		server: "docs",
+		resources: []mcpManager.Resource{
+			{URI: "file:///a.md", Name: "a"},
+			{URI: "file:///b.md", Name: "b", MimeType: "text/markdown"},
+		},
+		templates: []mcpManager.ResourceTemplate{{URITemplate: "file:///{path}", Name: "file"}},
+	})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if resource, ok := m.browser.selected(); !ok || resource.Name != "b" {
+		t.Errorf("Expected resource b to be selected, got %+v", resource)
+	}
+
+	m, _ = m.Update(resourceAttachedMsg{name: "b"})
+	view := m.View()
+	for _, expected := range []string{"Resources - docs", "b (text/markdown)", "file:///{path}", "Attached b"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("Expected browser view to contain %q", expected)
+		}
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.browser != nil {
+		t.Error("Expected Esc to close the resource browser")
+	}
+}
+
+func TestLogPane(t *testing.T) {
+	config := configuration.DefaultConfig()
+	m := NewModel(t.Context(), config, mcpManager.NewManager(config))
+	m.servers = []ServerUIStatus{{Name: "docs", Status: mcpManager.StatusRunning}}
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'l'}})
+	if m.logServer != "docs" || cmd == nil {
+		t.Fatal("Expected the log pane to open and schedule a refresh")
+	}
+	if view := m.View(); !strings.Contains(view, "Log - docs") || !strings.Contains(view, "No log messages yet.") {
+		t.Errorf("Expected an empty log pane, got:\n%s", view)
+	}
+
+	if _, cmd := m.Update(logTickMsg{}); cmd == nil {
+		t.Error("Expected refreshes to continue while the pane is open")
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.logServer != "" {
+		t.Error("Expected Esc to close the log pane")
+	}
+	if _, cmd := m.Update(logTickMsg{}); cmd != nil {
+		t.Error("Expected refreshes to stop once the pane is closed")
+	}
+}
+
+func TestRenderLogEntry(t *testing.T) {
+	entry := mcpManager.ServerLogEntry{
+		Time:    time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC),
+		Level:   "warning",
+		Logger:  "indexer",
+		Message: "disk\nalmost full",
+	}
+
+	line := renderLogEntry(entry)
+	for _, expected := range []string{"15:04:05", "warning", "[indexer]", "disk almost full"} {
+		if !strings.Contains(line, expected) {
+			t.Errorf("Expected %q in log line %q", expected, line)
+		}
+	}
+}
+
+func TestHealthPane(t *testing.T) {
+	config := configuration.DefaultConfig()
+	m := NewModel(t.Context(), config, mcpManager.NewManager(config))
+	m.servers = []ServerUIStatus{{Name: "docs", Status: mcpManager.StatusError, Restarts: 5, GaveUp: true}}
+
+	if view := m.View(); !strings.Contains(view, "Not restarted after 5 failures") {
+		t.Errorf("Expected the server list to mention the failures, got:\n%s", view)
+	}
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'h'}})
+	if m.healthServer != "docs" || cmd == nil {
+		t.Fatal("Expected the health pane to open and schedule a refresh")
+	}
+	view := m.View()
+	for _, expected := range []string{"Health - docs", "Last ping: none yet", "No failures.", "No stderr output."} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("Expected %q in the health pane, got:\n%s", expected, view)
+		}
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.healthServer != "" {
+		t.Error("Expected Esc to close the health pane")
+	}
+	if _, cmd := m.Update(healthTickMsg{}); cmd != nil {
+		t.Error("Expected refreshes to stop once the pane is closed")
+	}
+}
+
+func TestRenderRestartEvent(t *testing.T) {
+	at := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	line := renderRestartEvent(mcpManager.RestartEvent{Time: at, Reason: "process exited", Delay: 2 * time.Second})
+	for _, expected := range []string{"15:04:05", "restarted after 2s", "process exited"} {
+		if !strings.Contains(line, expected) {
+			t.Errorf("Expected %q in restart line %q", expected, line)
+		}
+	}
+	if line := renderRestartEvent(mcpManager.RestartEvent{Time: at, Reason: "ping failed"}); !strings.Contains(line, "not restarted") {
+		t.Errorf("Expected a failure without restart to say so, got %q", line)
+	}
+}
@@ -0,0 +1,276 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/kevensen/gollama-chat/internal/logging"
+	mcpManager "github.com/kevensen/gollama-chat/internal/tooling/mcp"
+)
+
+// maxPreviewLines bounds the resource preview shown below the resource list
+const maxPreviewLines = 15
+
+// resourceBrowser holds the state of the resource browser for one server
+type resourceBrowser struct {
+	server    string
+	resources []mcpManager.Resource
+	templates []mcpManager.ResourceTemplate
+	index     int
+	loading   bool
+	preview   string
+	notice    string
+	err       error
+}
+
+// Messages
+type resourcesLoadedMsg struct {
+	server    string
+	resources []mcpManager.Resource
+	templates []mcpManager.ResourceTemplate
+	err       error
+}
+
+type resourcePreviewMsg struct {
+	uri  string
+	text string
+	err  error
+}
+
+type resourceAttachedMsg struct {
+	name string
+	err  error
+}
+
+// openResourceBrowser starts browsing the resources of the selected server
+func (m Model) openResourceBrowser() (Model, tea.Cmd) {
+	if m.selectedIndex >= len(m.servers) {
+		return m, nil
+	}
+
+	server := m.servers[m.selectedIndex]
+	if server.Status != mcpManager.StatusRunning {
+		m.lastError = fmt.Errorf("server %s is not running", server.Name)
+		return m, nil
+	}
+
+	m.browser = &resourceBrowser{server: server.Name, loading: true}
+	return m, m.loadResources(server.Name)
+}
+
+// handleBrowserKeys handles keyboard input while browsing resources
+func (m Model) handleBrowserKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
+	browser := m.browser
+
+	switch msg.String() {
+	case "escape", "esc":
+		m.browser = nil
+		return m, nil
+
+	case "up", "k":
+		if browser.index > 0 {
+			browser.index--
+			browser.preview = ""
+		}
+		return m, nil
+
+	case "down", "j":
+		if browser.index < len(browser.resources)-1 {
+			browser.index++
+			browser.preview = ""
+		}
+		return m, nil
+
+	case "enter":
+		if resource, ok := browser.selected(); ok {
+			browser.notice = ""
+			return m, m.attachResource(browser.server, resource)
+		}
+		return m, nil
+
+	case "p":
+		if resource, ok := browser.selected(); ok {
+			return m, m.previewResource(browser.server, resource.URI)
+		}
+		return m, nil
+
+	case "r":
+		browser.loading = true
+		return m, m.loadResources(browser.server)
+	}
+
+	return m, nil
+}
+
+// updateBrowser applies the result of a resource browser command
+func (m Model) updateBrowser(msg tea.Msg) Model {
+	browser := m.browser
+	if browser == nil {
+		return m
+	}
+
+	switch msg := msg.(type) {
+	case resourcesLoadedMsg:
+		if msg.server != browser.server {
+			return m
+		}
+		browser.loading = false
+		browser.err = msg.err
+		browser.resources = msg.resources
+		browser.templates = msg.templates
+		browser.index = min(browser.index, max(len(msg.resources)-1, 0))
+
+	case resourcePreviewMsg:
+		browser.err = msg.err
+		browser.preview = msg.text
+
+	case resourceAttachedMsg:
+		browser.err = msg.err
+		if msg.err == nil {
+			browser.notice = fmt.Sprintf("Attached %s to the next chat message", msg.name)
+		}
+	}
+
+	return m
+}
+
+// selected returns the highlighted resource
+func (b *resourceBrowser) selected() (mcpManager.Resource, bool) {
+	if b.index < 0 || b.index >= len(b.resources) {
+		return mcpManager.Resource{}, false
+	}
+	return b.resources[b.index], true
+}
+
+// renderResourceBrowser renders the resources of the browsed server
+func (m Model) renderResourceBrowser() string {
+	browser := m.browser
+	var s strings.Builder
+
+	s.WriteString(lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("205")).
+		Render(fmt.Sprintf("Resources - %s", browser.server)))
+	s.WriteString("\n")
+	s.WriteString(lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241")).
+		Render("Enter: attach to next message, p: preview, r: refresh, Esc: back"))
+	s.WriteString("\n")
+
+	if browser.err != nil {
+		s.WriteString(lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196")).
+			Bold(true).
+			Render(fmt.Sprintf("Error: %s", browser.err.Error())))
+		s.WriteString("\n")
+	}
+	if browser.notice != "" {
+		s.WriteString(lipgloss.NewStyle().
+			Foreground(lipgloss.Color("46")).
+			Render(browser.notice))
+		s.WriteString("\n")
+	}
+	s.WriteString("\n")
+
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	switch {
+	case browser.loading:
+		s.WriteString(dimStyle.Render("Loading resources..."))
+		s.WriteString("\n")
+	case len(browser.resources) == 0:
+		s.WriteString(dimStyle.Render("This server has no resources."))
+		s.WriteString("\n")
+	default:
+		for i, resource := range browser.resources {
+			line := fmt.Sprintf("  %s  %s", resourceLabel(resource), dimStyle.Render(resource.URI))
+			if i == browser.index {
+				line = lipgloss.NewStyle().
+					Bold(true).
+					Foreground(lipgloss.Color("#8A7FD8")).
+					Render("▶ " + resourceLabel(resource) + "  " + resource.URI)
+			}
+			s.WriteString(line)
+			s.WriteString("\n")
+		}
+	}
+
+	if len(browser.templates) > 0 {
+		s.WriteString("\n")
+		s.WriteString(lipgloss.NewStyle().Bold(true).Render("Templates"))
+		s.WriteString("\n")
+		for _, template := range browser.templates {
+			s.WriteString(dimStyle.Render(fmt.Sprintf("  %s  %s", template.Name, template.URITemplate)))
+			s.WriteString("\n")
+		}
+	}
+
+	if browser.preview != "" {
+		s.WriteString("\n")
+		s.WriteString(lipgloss.NewStyle().Bold(true).Render("Preview"))
+		s.WriteString("\n")
+		s.WriteString(truncateLines(browser.preview, maxPreviewLines))
+		s.WriteString("\n")
+	}
+
+	return s.String()
+}
+
+// resourceLabel returns the display name of a resource
+func resourceLabel(resource mcpManager.Resource) string {
+	label := resource.Name
+	if label == "" {
+		label = resource.URI
+	}
+	if resource.MimeType != "" {
+		label += " (" + resource.MimeType + ")"
+	}
+	return label
+}
+
+// truncateLines keeps the first limit lines of text
+func truncateLines(text string, limit int) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) <= limit {
+		return text
+	}
+	return strings.Join(lines[:limit], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-limit)
+}
+
+// Commands
+func (m Model) loadResources(serverName string) tea.Cmd {
+	return func() tea.Msg {
+		resources, err := m.manager.ListResources(serverName)
+		if err != nil {
+			return resourcesLoadedMsg{server: serverName, err: err}
+		}
+
+		// Templates are optional; a server may list resources without any
+		templates, err := m.manager.ListResourceTemplates(serverName)
+		if err != nil {
+			logging.WithComponent("mcp-tab").Debug("Failed to list resource templates", "server", serverName, "error", err)
+		}
+
+		return resourcesLoadedMsg{server: serverName, resources: resources, templates: templates}
+	}
+}
+
+func (m Model) previewResource(serverName, uri string) tea.Cmd {
+	return func() tea.Msg {
+		result, err := m.manager.ReadResource(serverName, uri)
+		if err != nil {
+			return resourcePreviewMsg{uri: uri, err: err}
+		}
+		return resourcePreviewMsg{uri: uri, text: mcpManager.ResourceText(result)}
+	}
+}
+
+func (m Model) attachResource(serverName string, resource mcpManager.Resource) tea.Cmd {
+	return func() tea.Msg {
+		err := m.manager.AttachResource(serverName, resource)
+		return resourceAttachedMsg{name: resourceLabel(resource), err: err}
+	}
+}
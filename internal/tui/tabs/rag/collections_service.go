@@ -6,11 +6,11 @@ import (
 	"net/http"
 	"time"
 
-	v2 "github.com/amikos-tech/chroma-go/pkg/api/v2"
 	"github.com/kevensen/gollama-chat/internal/configuration"
+	ragstore "github.com/kevensen/gollama-chat/internal/rag"
 )
 
-// Collection represents a ChromaDB collection with selection state
+// Collection represents a vector store collection with selection state
 type Collection struct {
 	Name     string            `json:"name"`
 	ID       string            `json:"id"`
@@ -18,10 +18,10 @@ type Collection struct {
 	Selected bool              `json:"selected"`
 }
 
-// CollectionsService handles ChromaDB collections operations
+// CollectionsService handles vector store collections operations
 type CollectionsService struct {
 	Config      *configuration.Config
-	client      v2.Client
+	store       ragstore.VectorStore
 	collections []Collection
 	connected   bool
 }
@@ -35,72 +35,66 @@ func NewCollectionsService(config *configuration.Config) *CollectionsService {
 	}
 }
 
-// TestConnection tests the ChromaDB connection
+// TestConnection tests the connection to the configured vector store
 func (cs *CollectionsService) TestConnection() error {
-	if cs.Config.ChromaDBURL == "" {
-		cs.connected = false
-		return fmt.Errorf("ChromaDB URL not configured")
-	}
+	if cs.Config.VectorStore != configuration.VectorStoreLocal {
+		if cs.Config.ChromaDBURL == "" {
+			cs.connected = false
+			return fmt.Errorf("ChromaDB URL not configured")
+		}
 
-	// Use the same approach as the settings tab - simple HTTP GET to /api/v2
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Get(cs.Config.ChromaDBURL + "/api/v2")
-	if err != nil {
-		cs.connected = false
-		return fmt.Errorf("failed to connect to ChromaDB: %w", err)
-	}
-	defer resp.Body.Close()
+		// Use the same approach as the settings tab - simple HTTP GET to /api/v2
+		client := &http.Client{Timeout: 5 * time.Second}
+		resp, err := client.Get(cs.Config.ChromaDBURL + "/api/v2")
+		if err != nil {
+			cs.connected = false
+			return fmt.Errorf("failed to connect to ChromaDB: %w", err)
+		}
+		defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		cs.connected = false
-		return fmt.Errorf("ChromaDB returned HTTP %d", resp.StatusCode)
+		if resp.StatusCode != http.StatusOK {
+			cs.connected = false
+			return fmt.Errorf("ChromaDB returned HTTP %d", resp.StatusCode)
+		}
 	}
 
-	// If basic connection works, try to create the full client for later use
-	chromaClient, err := v2.NewHTTPClient(v2.WithBaseURL(cs.Config.ChromaDBURL))
+	// If basic connection works, create the store for later use.
+	// Listing collections does not need an embedding function.
+	store, err := ragstore.NewStore(cs.Config, nil)
 	if err != nil {
 		cs.connected = false
-		return fmt.Errorf("failed to create ChromaDB client: %w", err)
+		return fmt.Errorf("failed to create vector store: %w", err)
 	}
 
-	cs.client = chromaClient
+	if cs.store != nil {
+		cs.store.Close()
+	}
+	cs.store = store
 	cs.connected = true
 	return nil
 }
 
-// LoadCollections loads collections from ChromaDB
+// LoadCollections loads collections from the vector store
 func (cs *CollectionsService) LoadCollections(ctx context.Context) error {
-	if !cs.connected || cs.client == nil {
-		return fmt.Errorf("not connected to ChromaDB")
+	if !cs.connected || cs.store == nil {
+		return fmt.Errorf("not connected to vector store")
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	chromaCollections, err := cs.client.ListCollections(ctx)
+	storeCollections, err := cs.store.ListCollections(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to list collections: %w", err)
 	}
 
 	// Convert to our Collection format and select all by default
-	cs.collections = make([]Collection, len(chromaCollections))
-	for i, collection := range chromaCollections {
-		// Convert metadata from chroma-go format to our expected format
-		metadataMap := make(map[string]string)
-		if metadata := collection.Metadata(); metadata != nil {
-			for _, key := range metadata.Keys() {
-				if strValue, ok := metadata.GetString(key); ok {
-					metadataMap[key] = strValue
-				} else if rawValue, ok := metadata.GetRaw(key); ok {
-					metadataMap[key] = fmt.Sprintf("%v", rawValue)
-				}
-			}
-		}
-
+	cs.collections = make([]Collection, len(storeCollections))
+	for i, collection := range storeCollections {
 		cs.collections[i] = Collection{
-			Name:     collection.Name(),
-			ID:       collection.ID(),
-			Metadata: metadataMap,
+			Name:     collection.Name,
+			ID:       collection.ID,
+			Metadata: collection.Metadata,
 			Selected: true, // Select all by default as requested
 		}
 	}
@@ -161,10 +155,10 @@ func (cs *CollectionsService) GetSelectedCount() int {
 	return count
 }
 
-// Close closes the ChromaDB client connection
+// Close closes the vector store connection
 func (cs *CollectionsService) Close() error {
-	if cs.client != nil {
-		return cs.client.Close()
+	if cs.store != nil {
+		return cs.store.Close()
 	}
 	return nil
 }
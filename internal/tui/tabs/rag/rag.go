@@ -40,10 +40,14 @@ type ConfigurationField int
 
 const (
 	RAGEnabledField ConfigurationField = iota
+	RAGModeField
+	VectorStoreField
 	EmbeddingModelField
 	ChromaDBURLField
 	ChromaDBDistanceField
 	MaxDocumentsField
+	QueryRewriteField
+	QueryExpansionsField
 )
 
 // ActivePane represents which pane is currently active
@@ -86,13 +90,18 @@ func NewModel(ctx context.Context, config *configuration.Config) Model {
 
 	// Create a copy for editing configuration
 	editConfig := &configuration.Config{
-		ChatModel:        config.ChatModel,
-		EmbeddingModel:   config.EmbeddingModel,
-		RAGEnabled:       config.RAGEnabled,
-		OllamaURL:        config.OllamaURL,
-		ChromaDBURL:      config.ChromaDBURL,
-		ChromaDBDistance: config.ChromaDBDistance,
-		MaxDocuments:     config.MaxDocuments,
+		ChatModel:          config.ChatModel,
+		EmbeddingModel:     config.EmbeddingModel,
+		RAGEnabled:         config.RAGEnabled,
+		RAGMode:            config.RAGMode,
+		VectorStore:        config.VectorStore,
+		LocalStorePath:     config.LocalStorePath,
+		OllamaURL:          config.OllamaURL,
+		ChromaDBURL:        config.ChromaDBURL,
+		ChromaDBDistance:   config.ChromaDBDistance,
+		MaxDocuments:       config.MaxDocuments,
+		RAGQueryRewrite:    config.RAGQueryRewrite,
+		RAGQueryExpansions: config.RAGQueryExpansions,
 	}
 
 	return Model{
@@ -384,10 +393,14 @@ func (m Model) renderSettingsPane() string {
 		help  string
 	}{
 		{RAGEnabledField, "RAG Enabled", fmt.Sprintf("%t", m.editConfig.RAGEnabled), "Enable Retrieval Augmented Generation"},
+		{RAGModeField, "RAG Mode", m.editConfig.RAGMode, "always (inject), tool (rag_search) or both"},
+		{VectorStoreField, "Vector Store", m.editConfig.VectorStore, "chromadb (server) or local (embedded)"},
 		{EmbeddingModelField, "Embedding Model", m.editConfig.EmbeddingModel, "Model for embeddings"},
 		{ChromaDBURLField, "ChromaDB URL", m.editConfig.ChromaDBURL, "URL of the ChromaDB server"},
 		{ChromaDBDistanceField, "ChromaDB Distance", fmt.Sprintf("%.2f", m.editConfig.ChromaDBDistance), "Distance threshold for similarity"},
 		{MaxDocumentsField, "Max Documents", fmt.Sprintf("%d", m.editConfig.MaxDocuments), "Maximum documents for RAG"},
+		{QueryRewriteField, "Query Rewrite", fmt.Sprintf("%t", m.editConfig.RAGQueryRewrite), "Rewrite follow-ups using chat history"},
+		{QueryExpansionsField, "Query Expansions", fmt.Sprintf("%d", m.editConfig.RAGQueryExpansions), "Extra paraphrased queries (needs rewrite)"},
 	}
 
 	for i, field := range fields {
@@ -436,10 +449,10 @@ func (m Model) renderCollectionsPane() string {
 	connectionStyle := lipgloss.NewStyle()
 	if m.connected {
 		connectionStyle = connectionStyle.Foreground(lipgloss.Color("2")) // Green
-		content.WriteString(connectionStyle.Render("✓ Connected to ChromaDB"))
+		content.WriteString(connectionStyle.Render("✓ Connected to " + m.storeName()))
 	} else {
 		connectionStyle = connectionStyle.Foreground(lipgloss.Color("1")) // Red
-		content.WriteString(connectionStyle.Render("✗ Not connected to ChromaDB"))
+		content.WriteString(connectionStyle.Render("✗ Not connected to " + m.storeName()))
 		if m.error != "" {
 			content.WriteString(fmt.Sprintf("\n%s", m.error))
 		}
@@ -452,9 +465,9 @@ func (m Model) renderCollectionsPane() string {
 			Foreground(lipgloss.Color("6"))
 		content.WriteString(loadingStyle.Render("Loading..."))
 	} else if !m.connected {
-		content.WriteString("ChromaDB connection required.\nEnsure ChromaDB is running and\nconfiguration is correct.")
+		content.WriteString(fmt.Sprintf("%s connection required.\nEnsure %s is available and\nconfiguration is correct.", m.storeName(), m.storeName()))
 	} else if len(m.collections) == 0 {
-		content.WriteString("No collections found in ChromaDB.")
+		content.WriteString(fmt.Sprintf("No collections found in %s.", m.storeName()))
 	} else {
 		// Limit viewport height for the pane
 		content.WriteString(m.viewport.View())
@@ -488,6 +501,14 @@ func (m Model) renderCollectionsPane() string {
 	return content.String()
 }
 
+// storeName returns a display name for the configured vector store
+func (m Model) storeName() string {
+	if m.config.VectorStore == configuration.VectorStoreLocal {
+		return "local vector store"
+	}
+	return "ChromaDB"
+}
+
 // renderConfigField renders a single configuration field
 func (m Model) renderConfigField(field ConfigurationField, label, value, help string) string {
 	var line strings.Builder
@@ -680,7 +701,7 @@ func (m Model) handleConfigurationKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 	case "down", "j":
-		if m.activeConfigField < MaxDocumentsField {
+		if m.activeConfigField < QueryExpansionsField {
 			m.activeConfigField++
 		}
 		return m, nil
@@ -700,6 +721,28 @@ func (m Model) handleConfigurationKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				}
 			}
 
+			return m, m.saveConfiguration()
+		case RAGModeField:
+			// Cycle through always -> tool -> both
+			switch m.editConfig.RAGMode {
+			case configuration.RAGModeTool:
+				m.editConfig.RAGMode = configuration.RAGModeBoth
+			case configuration.RAGModeBoth:
+				m.editConfig.RAGMode = configuration.RAGModeAlways
+			default:
+				m.editConfig.RAGMode = configuration.RAGModeTool
+			}
+			return m, m.saveConfiguration()
+		case VectorStoreField:
+			// Toggle between the ChromaDB server and the embedded local store
+			if m.editConfig.VectorStore == configuration.VectorStoreLocal {
+				m.editConfig.VectorStore = configuration.VectorStoreChromaDB
+			} else {
+				m.editConfig.VectorStore = configuration.VectorStoreLocal
+			}
+			return m, m.saveConfiguration()
+		case QueryRewriteField:
+			m.editConfig.RAGQueryRewrite = !m.editConfig.RAGQueryRewrite
 			return m, m.saveConfiguration()
 		default:
 			// Start editing other fields
@@ -847,6 +890,8 @@ func (m Model) getCurrentConfigFieldValue() string {
 		return fmt.Sprintf("%.2f", m.editConfig.ChromaDBDistance)
 	case MaxDocumentsField:
 		return fmt.Sprintf("%d", m.editConfig.MaxDocuments)
+	case QueryExpansionsField:
+		return fmt.Sprintf("%d", m.editConfig.RAGQueryExpansions)
 	default:
 		return ""
 	}
@@ -883,6 +928,15 @@ func (m *Model) setConfigFieldValue(value string) error {
 			return fmt.Errorf("max documents must be at least 1")
 		}
 		m.editConfig.MaxDocuments = maxDocs
+	case QueryExpansionsField:
+		expansions, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("query expansions must be a number")
+		}
+		if expansions < 0 || expansions > configuration.MaxRAGQueryExpansions {
+			return fmt.Errorf("query expansions must be between 0 and %d", configuration.MaxRAGQueryExpansions)
+		}
+		m.editConfig.RAGQueryExpansions = expansions
 	}
 	return nil
 }
@@ -892,10 +946,14 @@ func (m Model) saveConfiguration() tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
 		// Update the main config
 		m.config.RAGEnabled = m.editConfig.RAGEnabled
+		m.config.RAGMode = m.editConfig.RAGMode
+		m.config.VectorStore = m.editConfig.VectorStore
 		m.config.EmbeddingModel = m.editConfig.EmbeddingModel
 		m.config.ChromaDBURL = m.editConfig.ChromaDBURL
 		m.config.ChromaDBDistance = m.editConfig.ChromaDBDistance
 		m.config.MaxDocuments = m.editConfig.MaxDocuments
+		m.config.RAGQueryRewrite = m.editConfig.RAGQueryRewrite
+		m.config.RAGQueryExpansions = m.editConfig.RAGQueryExpansions
 
 		// Save to file
 		if err := m.config.Save(); err != nil {
@@ -0,0 +1,236 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/kevensen/gollama-chat/internal/tooling"
+)
+
+// Audit log filters that can be edited as text
+const (
+	auditFilterTool = "tool"
+	auditFilterDate = "date"
+)
+
+// auditDecisions lists the decision filters in the order f cycles through them
+var auditDecisions = []string{"", tooling.AuditAllow, tooling.AuditAsk, tooling.AuditDeny}
+
+// showAudit shows the tool audit log
+func (m Model) showAudit() (tea.Model, tea.Cmd) {
+	m.viewMode = ViewModeAudit
+	m.auditIndex = 0
+	return m.loadAudit(), nil
+}
+
+// loadAudit reads the audit entries selected by the filter
+func (m Model) loadAudit() Model {
+	entries, err := tooling.ReadAuditLog(m.auditFilter)
+	if err != nil {
+		m.message = "Cannot read the tool audit log: " + err.Error()
+		entries = nil
+	}
+	m.auditEntries = entries
+	m.auditIndex = max(0, min(m.auditIndex, len(entries)-1))
+	return m
+}
+
+// handleAuditKeys handles keyboard input in the audit log viewer
+func (m Model) handleAuditKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.auditEditing != "" {
+		return m.handleAuditFilterKeys(msg)
+	}
+
+	switch msg.String() {
+	case "esc", "escape", "q":
+		m.viewMode = ViewModeList
+	case "up", "k":
+		if m.auditIndex > 0 {
+			m.auditIndex--
+		}
+	case "down", "j":
+		if m.auditIndex < len(m.auditEntries)-1 {
+			m.auditIndex++
+		}
+	case "home":
+		m.auditIndex = 0
+	case "end":
+		m.auditIndex = max(0, len(m.auditEntries)-1)
+	case "t":
+		m.auditEditing, m.auditInput = auditFilterTool, m.auditFilter.Tool
+	case "d":
+		m.auditEditing, m.auditInput = auditFilterDate, m.auditFilter.Date
+	case "f":
+		m.auditFilter.Decision = nextAuditDecision(m.auditFilter.Decision)
+		return m.loadAudit(), nil
+	case "c":
+		m.auditFilter = tooling.AuditFilter{}
+		return m.loadAudit(), nil
+	case "r":
+		return m.loadAudit(), nil
+	}
+	return m, nil
+}
+
+// handleAuditFilterKeys handles keyboard input while a text filter is edited
+func (m Model) handleAuditFilterKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "escape":
+		m.auditEditing = ""
+	case "enter":
+		value := strings.TrimSpace(m.auditInput)
+		if m.auditEditing == auditFilterTool {
+			m.auditFilter.Tool = value
+		} else {
+			m.auditFilter.Date = value
+		}
+		m.auditEditing = ""
+		m.auditIndex = 0
+		return m.loadAudit(), nil
+	case "backspace":
+		if len(m.auditInput) > 0 {
+			m.auditInput = m.auditInput[:len(m.auditInput)-1]
+		}
+	case "space", " ":
+		m.auditInput += " "
+	default:
+		if msg.Type == tea.KeyRunes {
+			m.auditInput += string(msg.Runes)
+		}
+	}
+	return m, nil
+}
+
+// nextAuditDecision returns the decision filter after decision in auditDecisions
+func nextAuditDecision(decision string) string {
+	for i, candidate := range auditDecisions {
+		if candidate == decision {
+			return auditDecisions[(i+1)%len(auditDecisions)]
+		}
+	}
+	return auditDecisions[0]
+}
+
+// renderAudit renders the audit log viewer: the filters, a page of entries and the
+// details of the selected entry
+func (m Model) renderAudit() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("12")).
+		Padding(1, 2)
+
+	contentStyle := lipgloss.NewStyle().
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#8A7FD8"))
+
+	instructionsStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Padding(1, 2)
+
+	var content strings.Builder
+	content.WriteString(m.renderAuditFilters() + "\n\n")
+
+	if len(m.auditEntries) == 0 {
+		content.WriteString("No tool requests recorded")
+		if m.auditFilter != (tooling.AuditFilter{}) {
+			content.WriteString(" match the filters")
+		}
+		content.WriteString(".")
+	}
+
+	// Show the page of entries holding the selected one
+	pageSize := max(5, m.height-22)
+	start := max(0, min(m.auditIndex-pageSize/2, len(m.auditEntries)-pageSize))
+	end := min(len(m.auditEntries), start+pageSize)
+	for i := start; i < end; i++ {
+		entry := m.auditEntries[i]
+		line := fmt.Sprintf("%s  %-28s %-13s %-8s %6dms  ",
+			entry.Time.Local().Format("2006-01-02 15:04:05"), entry.Tool, entry.DecidedBy, entry.Status, entry.DurationMS)
+		if i == m.auditIndex {
+			line = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("15")).
+				Background(lipgloss.Color("8")).
+				Render("▶ " + line)
+		} else {
+			line = "  " + line
+		}
+		content.WriteString(line + decisionStyle(entry.Decision).Render(entry.Decision) + "\n")
+	}
+	if len(m.auditEntries) > 0 {
+		content.WriteString(fmt.Sprintf("\n%d of %d requests\n", m.auditIndex+1, len(m.auditEntries)))
+		content.WriteString("\n" + renderAuditDetails(m.auditEntries[m.auditIndex]))
+	}
+
+	var messageSection string
+	if m.message != "" {
+		messageSection = "\n" + m.messageStyle.Render(m.message)
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		titleStyle.Render("🔧 Tool Audit Log"),
+		instructionsStyle.Render("↑/↓: navigate • t: filter tool • f: filter decision • d: filter date • c: clear filters • r: reload • Esc: back"),
+		contentStyle.Render(content.String()),
+		messageSection,
+	)
+}
+
+// renderAuditFilters renders the filters, with a cursor in the one being edited
+func (m Model) renderAuditFilters() string {
+	filter := func(name, value string) string {
+		if m.auditEditing == name {
+			return lipgloss.NewStyle().
+				Background(lipgloss.Color("220")).
+				Foreground(lipgloss.Color("0")).
+				Render(m.auditInput + "█")
+		}
+		if value == "" {
+			return "any"
+		}
+		return value
+	}
+	decision := m.auditFilter.Decision
+	if decision == "" {
+		decision = "any"
+	}
+	return fmt.Sprintf("Tool: %s   Decision: %s   Date: %s",
+		filter(auditFilterTool, m.auditFilter.Tool), decision, filter(auditFilterDate, m.auditFilter.Date))
+}
+
+// renderAuditDetails describes an audit entry in full
+func renderAuditDetails(entry tooling.AuditEntry) string {
+	labelStyle := lipgloss.NewStyle().Bold(true)
+	var details strings.Builder
+	field := func(label, value string) {
+		if value != "" {
+			details.WriteString(labelStyle.Render(label+": ") + value + "\n")
+		}
+	}
+
+	source := entry.Source
+	if entry.Server != "" {
+		source += " (" + entry.Server + ")"
+	}
+	field("Source", source)
+	field("Conversation", entry.ConversationID)
+	if len(entry.Arguments) > 0 {
+		arguments, err := json.Marshal(entry.Arguments)
+		if err != nil {
+			arguments = []byte(fmt.Sprint(entry.Arguments))
+		}
+		field("Arguments", string(arguments))
+	}
+	field("Rule", entry.Rule)
+	if entry.ExitCode != nil {
+		field("Exit code", fmt.Sprint(*entry.ExitCode))
+	}
+	field("Error", entry.Error)
+	field("Result SHA-256", entry.ResultHash)
+	return details.String()
+}
@@ -0,0 +1,304 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/kevensen/gollama-chat/internal/configuration"
+)
+
+// Fields of the trust rule form
+const (
+	ruleFieldTool = iota
+	ruleFieldArgument
+	ruleFieldPattern
+	ruleFieldDecision
+	ruleFieldCount
+)
+
+// RuleForm is a trust rule being added or edited
+type RuleForm struct {
+	Rule  configuration.ToolTrustRule
+	Index int // Index of the edited rule, or -1 for a new rule
+	Field int
+	Input string // Text of the current field while it is edited
+}
+
+// ruleDecisions lists the decisions in the order space cycles through them
+var ruleDecisions = []string{configuration.TrustRuleAllow, configuration.TrustRuleAsk, configuration.TrustRuleDeny}
+
+// showRules shows the trust rules
+func (m Model) showRules() (tea.Model, tea.Cmd) {
+	m.viewMode = ViewModeRules
+	m.ruleIndex = min(m.ruleIndex, max(0, len(m.config.ToolTrustRules)-1))
+	return m, nil
+}
+
+// handleRulesKeys handles keyboard input in the trust rules list
+func (m Model) handleRulesKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	rules := m.config.ToolTrustRules
+	switch msg.String() {
+	case "esc", "escape", "q":
+		m.viewMode = ViewModeList
+	case "up", "k":
+		if m.ruleIndex > 0 {
+			m.ruleIndex--
+		}
+	case "down", "j":
+		if m.ruleIndex < len(rules)-1 {
+			m.ruleIndex++
+		}
+	case "a":
+		rule := configuration.ToolTrustRule{Decision: configuration.TrustRuleAsk}
+		if tool := m.GetTool(m.selectedIndex); tool != nil {
+			rule.Tool = tool.Name
+		}
+		m.ruleForm = &RuleForm{Rule: rule, Index: -1, Input: rule.Tool}
+		m.viewMode = ViewModeRuleForm
+	case "enter", "e":
+		if m.ruleIndex < len(rules) {
+			rule := rules[m.ruleIndex]
+			m.ruleForm = &RuleForm{Rule: rule, Index: m.ruleIndex, Input: rule.Tool}
+			m.viewMode = ViewModeRuleForm
+		}
+	case "d", "delete":
+		if m.ruleIndex < len(rules) {
+			removed := rules[m.ruleIndex]
+			if err := m.config.RemoveToolTrustRule(m.ruleIndex); err != nil {
+				m.message = "Failed to remove trust rule: " + err.Error()
+			} else {
+				m.message = "Removed trust rule " + removed.String()
+			}
+			m.ruleIndex = max(0, min(m.ruleIndex, len(m.config.ToolTrustRules)-1))
+		}
+	}
+	return m, nil
+}
+
+// handleRuleFormKeys handles keyboard input while adding or editing a trust rule
+func (m Model) handleRuleFormKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	form := m.ruleForm
+	if form == nil {
+		m.viewMode = ViewModeRules
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc", "escape":
+		m.ruleForm = nil
+		m.viewMode = ViewModeRules
+	case "enter":
+		form.commitField()
+		if form.Field < ruleFieldCount-1 {
+			form.moveField(1)
+			return m, nil
+		}
+
+		// Enter on the last field saves the rule
+		if err := m.config.SetToolTrustRule(form.Index, form.Rule); err != nil {
+			m.message = "Invalid trust rule: " + err.Error()
+			return m, nil
+		}
+		m.message = "Saved trust rule " + form.Rule.String()
+		if form.Index < 0 {
+			m.ruleIndex = len(m.config.ToolTrustRules) - 1
+		}
+		m.ruleForm = nil
+		m.viewMode = ViewModeRules
+	case "tab", "down":
+		form.commitField()
+		form.moveField(1)
+	case "shift+tab", "up":
+		form.commitField()
+		form.moveField(-1)
+	case "space", " ":
+		if form.Field == ruleFieldDecision {
+			form.Rule.Decision = nextDecision(form.Rule.Decision)
+		} else {
+			form.Input += " "
+		}
+	case "backspace":
+		if len(form.Input) > 0 {
+			form.Input = form.Input[:len(form.Input)-1]
+		}
+	default:
+		if msg.Type == tea.KeyRunes && form.Field != ruleFieldDecision {
+			form.Input += string(msg.Runes)
+		}
+	}
+	return m, nil
+}
+
+// commitField stores the input in the current text field
+func (f *RuleForm) commitField() {
+	value := strings.TrimSpace(f.Input)
+	switch f.Field {
+	case ruleFieldTool:
+		f.Rule.Tool = value
+	case ruleFieldArgument:
+		f.Rule.Argument = value
+	case ruleFieldPattern:
+		f.Rule.Pattern = value
+	}
+}
+
+// moveField moves to the next (delta 1) or previous (delta -1) field, loading its value
+func (f *RuleForm) moveField(delta int) {
+	f.Field = (f.Field + delta + ruleFieldCount) % ruleFieldCount
+	switch f.Field {
+	case ruleFieldTool:
+		f.Input = f.Rule.Tool
+	case ruleFieldArgument:
+		f.Input = f.Rule.Argument
+	case ruleFieldPattern:
+		f.Input = f.Rule.Pattern
+	default:
+		f.Input = ""
+	}
+}
+
+// nextDecision returns the decision after decision in ruleDecisions
+func nextDecision(decision string) string {
+	for i, candidate := range ruleDecisions {
+		if candidate == decision {
+			return ruleDecisions[(i+1)%len(ruleDecisions)]
+		}
+	}
+	return ruleDecisions[0]
+}
+
+// decisionStyle colors a decision
+func decisionStyle(decision string) lipgloss.Style {
+	switch decision {
+	case configuration.TrustRuleAllow:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("46"))
+	case configuration.TrustRuleDeny:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	default:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("226"))
+	}
+}
+
+// renderRules renders the trust rules list
+func (m Model) renderRules() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("12")).
+		Padding(1, 2)
+
+	contentStyle := lipgloss.NewStyle().
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#8A7FD8"))
+
+	instructionsStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Padding(1, 2)
+
+	var content strings.Builder
+	content.WriteString("Rules match tool arguments and take precedence over trust levels.\n")
+	content.WriteString("The most restrictive matching rule decides: deny, then ask, then allow.\n\n")
+
+	if len(m.config.ToolTrustRules) == 0 {
+		content.WriteString("No trust rules. Press 'a' to add one, e.g. allow execute_bash \"git status\".")
+	}
+	for i, rule := range m.config.ToolTrustRules {
+		argument := rule.Argument
+		if argument == "" {
+			argument = "(default)"
+		}
+		line := fmt.Sprintf("%-24s %-12s %-32s ", rule.Tool, argument, rule.Pattern)
+		if i == m.ruleIndex {
+			line = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("15")).
+				Background(lipgloss.Color("8")).
+				Render("▶ " + line)
+		} else {
+			line = "  " + line
+		}
+		content.WriteString(line + decisionStyle(rule.Decision).Render(rule.Decision) + "\n")
+	}
+
+	var messageSection string
+	if m.message != "" {
+		messageSection = "\n" + m.messageStyle.Render(m.message)
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		titleStyle.Render("🔧 Tool Trust Rules"),
+		instructionsStyle.Render("↑/↓: navigate • a: add • Enter: edit • d: delete • Esc: back"),
+		contentStyle.Render(content.String()),
+		messageSection,
+	)
+}
+
+// renderRuleForm renders the form adding or editing a trust rule
+func (m Model) renderRuleForm() string {
+	form := m.ruleForm
+	if form == nil {
+		return "Error: No trust rule form"
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("12")).
+		Padding(1, 2)
+
+	contentStyle := lipgloss.NewStyle().
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#8A7FD8"))
+
+	instructionsStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Padding(1, 2)
+
+	fields := []struct {
+		label string
+		value string
+	}{
+		{"Tool (name or glob, e.g. github.*)", form.Rule.Tool},
+		{"Argument (default: command for execute_bash, path for filesystem tools, any for others)", form.Rule.Argument},
+		{"Pattern (glob, or /regular expression/)", form.Rule.Pattern},
+		{"Decision", form.Rule.Decision + " (space to change)"},
+	}
+
+	var content strings.Builder
+	for i, field := range fields {
+		value := field.value
+		if i == form.Field && i != ruleFieldDecision {
+			value = form.Input + "█" // Cursor
+		}
+		text := fmt.Sprintf("%s: %s", field.label, value)
+		if i == form.Field {
+			text = lipgloss.NewStyle().
+				Background(lipgloss.Color("220")).
+				Foreground(lipgloss.Color("0")).
+				Render(text)
+		}
+		content.WriteString(text + "\n")
+	}
+
+	title := "🔧 Add Trust Rule"
+	if form.Index >= 0 {
+		title = "🔧 Edit Trust Rule"
+	}
+
+	var messageSection string
+	if m.message != "" {
+		messageSection = "\n" + m.messageStyle.Render(m.message)
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		titleStyle.Render(title),
+		contentStyle.Render(content.String()),
+		instructionsStyle.Render("Tab/↑/↓: move between fields • Enter: next field, save on Decision • Esc: cancel"),
+		messageSection,
+	)
+}
@@ -68,6 +68,15 @@ type Model struct {
 	// UI state
 	viewMode    ViewMode
 	trustPrompt *TrustPrompt
+	ruleIndex   int       // Selected trust rule
+	ruleForm    *RuleForm // Trust rule being added or edited
+
+	// Audit log viewer state
+	auditEntries []tooling.AuditEntry
+	auditFilter  tooling.AuditFilter
+	auditIndex   int
+	auditEditing string // Text filter being edited, if any
+	auditInput   string
 }
 
 // ViewMode represents the current view mode of the tools tab
@@ -76,6 +85,9 @@ type ViewMode int
 const (
 	ViewModeList ViewMode = iota
 	ViewModeTrustPrompt
+	ViewModeRules
+	ViewModeRuleForm
+	ViewModeAudit
 )
 
 // TrustPrompt represents a trust prompt for a tool
@@ -135,8 +147,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case tea.KeyMsg:
-		if m.viewMode == ViewModeTrustPrompt {
+		switch m.viewMode {
+		case ViewModeTrustPrompt:
 			return m.handleTrustPromptKeys(msg)
+		case ViewModeRules:
+			return m.handleRulesKeys(msg)
+		case ViewModeRuleForm:
+			return m.handleRuleFormKeys(msg)
+		case ViewModeAudit:
+			return m.handleAuditKeys(msg)
 		}
 
 		switch msg.String() {
@@ -171,6 +190,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if len(m.tools) > 0 {
 				return m.showToolDetails()
 			}
+		case "u":
+			// Show trust rules on tool arguments
+			return m.showRules()
+		case "l":
+			// Show the log of tool requests
+			return m.showAudit()
 		}
 	}
 
@@ -242,6 +267,13 @@ func (m Model) showToolDetails() (tea.Model, tea.Cmd) {
 	details.WriteString("Tool: " + tool.Name + "\n")
 	details.WriteString("Description: " + tool.Description + "\n")
 	details.WriteString("Source: " + tool.Source + "\n")
+	if tool.Source == "script" {
+		if builtinTool, exists := tooling.DefaultRegistry.GetTool(tool.Name); exists {
+			if scriptTool, ok := builtinTool.(*tooling.ScriptTool); ok {
+				details.WriteString("Command: " + strings.Join(scriptTool.Command(), " ") + "\n")
+			}
+		}
+	}
 	details.WriteString("Trust Level: " + tool.Trust.String() + "\n")
 	details.WriteString("Usage Count: " + strconv.Itoa(tool.UsageCount) + "\n")
 	if tool.LastUsed != nil {
@@ -268,38 +300,20 @@ func (m Model) refreshTools() tea.Cmd {
 		for _, builtinTool := range builtinTools {
 			logger.Debug("Processing built-in tool", "name", builtinTool.Name(), "description", builtinTool.Description())
 
-			// Load trust level from configuration, with fallback to defaults
-			var trustLevel TrustLevel
-			if m.config.ToolTrustLevels != nil {
-				if configuredTrustLevel, exists := m.config.ToolTrustLevels[builtinTool.Name()]; exists {
-					// Tool is explicitly configured
-					trustLevel = TrustLevel(configuredTrustLevel)
-				} else {
-					// Not configured yet, use defaults
-					defaultTrust := TrustNone
-					if builtinTool.Name() == "filesystem_read" {
-						defaultTrust = TrustSession // Filesystem read tool is trusted by default
-					}
-					trustLevel = defaultTrust
-					// Save the default to configuration (ignore error during initial setup)
-					_ = m.config.SetToolTrustLevel(builtinTool.Name(), int(defaultTrust))
-				}
-			} else {
-				// No configuration map, use defaults
-				defaultTrust := TrustNone
-				if builtinTool.Name() == "filesystem_read" {
-					defaultTrust = TrustSession // Filesystem read tool is trusted by default
-				}
-				trustLevel = defaultTrust
-				// Save the default to configuration (ignore error during initial setup)
-				_ = m.config.SetToolTrustLevel(builtinTool.Name(), int(defaultTrust))
+			// Script tools are declared in the configuration rather than built in
+			source := "builtin"
+			if _, isScript := builtinTool.(*tooling.ScriptTool); isScript {
+				source = "script"
 			}
 
+			// The configuration supplies the default of tools the user has not set a level for
+			trustLevel := TrustLevel(m.config.GetToolTrustLevel(builtinTool.Name()))
+
 			tool := Tool{
 				Name:        builtinTool.Name(),
 				Description: builtinTool.Description(),
 				Trust:       trustLevel,
-				Source:      "builtin",
+				Source:      source,
 				ServerName:  "",
 				Available:   true,
 				UsageCount:  0,
@@ -416,8 +430,15 @@ type ToolsRefreshedMsg struct {
 
 // View renders the tools tab
 func (m Model) View() string {
-	if m.viewMode == ViewModeTrustPrompt {
+	switch m.viewMode {
+	case ViewModeTrustPrompt:
 		return m.renderTrustPrompt()
+	case ViewModeRules:
+		return m.renderRules()
+	case ViewModeRuleForm:
+		return m.renderRuleForm()
+	case ViewModeAudit:
+		return m.renderAudit()
 	}
 
 	return m.renderToolsList()
@@ -451,11 +472,14 @@ func (m Model) renderToolsList() string {
 	} else {
 		// Group tools by source
 		builtinTools := []Tool{}
+		scriptTools := []Tool{}
 		mcpToolsByServer := make(map[string][]Tool)
 
 		for _, tool := range m.tools {
 			if tool.Source == "builtin" {
 				builtinTools = append(builtinTools, tool)
+			} else if tool.Source == "script" {
+				scriptTools = append(scriptTools, tool)
 			} else if tool.Source == "mcp" {
 				if mcpToolsByServer[tool.ServerName] == nil {
 					mcpToolsByServer[tool.ServerName] = []Tool{}
@@ -479,6 +503,21 @@ func (m Model) renderToolsList() string {
 			content.WriteString("\n")
 		}
 
+		// Render script tools declared in the configuration
+		if len(scriptTools) > 0 {
+			content.WriteString(lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("3")).
+				Render("Script Tools"))
+			content.WriteString("\n")
+
+			for _, tool := range scriptTools {
+				content.WriteString(m.renderTool(tool, m.getToolIndex(tool)))
+				content.WriteString("\n")
+			}
+			content.WriteString("\n")
+		}
+
 		// Render MCP tools grouped by server
 		for serverName, tools := range mcpToolsByServer {
 			// Server header with status
@@ -525,7 +564,7 @@ func (m Model) renderToolsList() string {
 
 	// Render title, instructions, content, and message
 	title := titleStyle.Render("Tools")
-	instructions := instructionsStyle.Render("↑/↓: navigate • Enter: toggle trust level • r: refresh • Ctrl+C: quit")
+	instructions := instructionsStyle.Render("↑/↓: navigate • Enter: toggle trust level • u: trust rules • l: audit log • r: refresh • Ctrl+C: quit")
 
 	var messageSection string
 	if m.message != "" {
@@ -582,6 +621,18 @@ func (m Model) renderTool(tool Tool, index int) string {
 	line := prefix + availabilityIndicator + displayName +
 		" [" + tool.Trust.String() + "]"
 
+	// Show how many trust rules refine the trust level
+	if m.config != nil {
+		if rules := len(m.config.ToolTrustRulesFor(tool.Name)); rules > 0 {
+			line += fmt.Sprintf(" (%d rules)", rules)
+		}
+	}
+
+	// Show where execute_bash runs its commands
+	if tool.Source == "builtin" && tool.Name == "execute_bash" && m.config != nil {
+		line += " {sandbox: " + m.config.BashSandbox.Summary() + "}"
+	}
+
 	if tool.Description != "" {
 		maxDescLen := 50
 		desc := tool.Description
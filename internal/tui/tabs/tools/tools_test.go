@@ -2,11 +2,14 @@ package tools
 
 import (
 	"context"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kevensen/gollama-chat/internal/configuration"
+	"github.com/kevensen/gollama-chat/internal/tooling"
 	"github.com/kevensen/gollama-chat/internal/tooling/mcp"
 	"github.com/ollama/ollama/api"
 )
@@ -106,8 +109,8 @@ func TestToolRefresh(t *testing.T) {
 		for _, tool := range toolsMsg.Tools {
 			if tool.Name == "filesystem_read" {
 				filesystemToolFound = true
-				if tool.Trust != TrustSession {
-					t.Errorf("filesystem_read should default to TrustSession, got %v", tool.Trust)
+				if tool.Trust != AskForTrust {
+					t.Errorf("filesystem_read should default to AskForTrust, got %v", tool.Trust)
 				}
 				if tool.Source != "builtin" {
 					t.Errorf("filesystem_read should be builtin, got %s", tool.Source)
@@ -122,6 +125,39 @@ func TestToolRefresh(t *testing.T) {
 	}
 }
 
+func TestToolRefresh_ScriptTools(t *testing.T) {
+	ctx := t.Context()
+	model, config := setupTestModel(ctx)
+	tooling.SetScriptTools([]configuration.ScriptTool{{
+		Name:        "make_target",
+		Description: "Run a make target",
+		Command:     []string{"make", "build"},
+	}})
+	t.Cleanup(func() { tooling.SetScriptTools(nil) })
+
+	msg := model.refreshTools()().(ToolsRefreshedMsg)
+	var script *Tool
+	for i, tool := range msg.Tools {
+		if tool.Name == "make_target" {
+			script = &msg.Tools[i]
+		}
+	}
+	if script == nil {
+		t.Fatal("Expected the script tool in the tools list")
+	}
+	if script.Source != "script" || script.Trust != AskForTrust {
+		t.Errorf("Expected a script tool asking for trust, got source %s and trust %v", script.Source, script.Trust)
+	}
+	if _, saved := config.ToolTrustLevels["make_target"]; saved {
+		t.Errorf("Expected refreshing not to save the default trust, got %d", config.ToolTrustLevels["make_target"])
+	}
+
+	model.tools = msg.Tools
+	if view := model.View(); !strings.Contains(view, "Script Tools") {
+		t.Errorf("Expected a Script Tools section, got:\n%s", view)
+	}
+}
+
 func TestModel_LoadToolTrustFromConfig(t *testing.T) {
 	ctx := t.Context()
 	model, config := setupTestModel(ctx)
@@ -554,3 +590,162 @@ func TestMCPToolAvailabilityIntegration(t *testing.T) {
 	// The main achievement is that trust levels work for MCP tools
 	t.Log("MCP tool availability integration test completed - trust levels work correctly")
 }
+
+func TestRenderTool_ShowsBashSandbox(t *testing.T) {
+	model, config := setupTestModel(t.Context())
+	config.BashSandbox = configuration.BashSandbox{Profile: configuration.SandboxProfileIsolated, Network: true}
+
+	line := model.renderTool(Tool{Name: "execute_bash", Source: "builtin", Trust: AskForTrust}, -1)
+	if !strings.Contains(line, "sandbox: isolated (network") {
+		t.Errorf("Expected execute_bash to show its sandbox profile, got %q", line)
+	}
+	if line := model.renderTool(Tool{Name: "filesystem_read", Source: "builtin"}, -1); strings.Contains(line, "sandbox") {
+		t.Errorf("Expected only execute_bash to show a sandbox, got %q", line)
+	}
+}
+
+func TestTrustRuleEditor(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	model, config := setupTestModel(t.Context())
+
+	keys := func(m tea.Model, inputs ...string) tea.Model {
+		for _, input := range inputs {
+			var msg tea.KeyMsg
+			switch input {
+			case "enter":
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			case "esc":
+				msg = tea.KeyMsg{Type: tea.KeyEsc}
+			case " ":
+				msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+			default:
+				msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(input)}
+			}
+			m, _ = m.Update(msg)
+		}
+		return m
+	}
+
+	// Add a rule allowing git status, with the argument defaulted and the decision cycled
+	// from ask to deny to allow
+	updated := keys(model, "u", "a", "execute_bash", "enter", "enter", "git", " ", "status", "enter", " ", " ", "enter")
+	m := updated.(Model)
+	if m.viewMode != ViewModeRules {
+		t.Fatalf("Expected to return to the rules list after saving, got view mode %d (message %q)", m.viewMode, m.message)
+	}
+	want := configuration.ToolTrustRule{Tool: "execute_bash", Pattern: "git status", Decision: configuration.TrustRuleAllow}
+	if len(config.ToolTrustRules) != 1 {
+		t.Fatalf("Expected one trust rule, got %v", config.ToolTrustRules)
+	}
+	if config.ToolTrustRules[0] != want {
+		t.Errorf("Expected rule %v, got %v", want, config.ToolTrustRules[0])
+	}
+	if !strings.Contains(m.View(), "git status") {
+		t.Error("Expected the rules list to show the new rule")
+	}
+
+	// An invalid rule is not saved and keeps the form open
+	m = keys(m, "a", "enter", "enter", "enter", "enter").(Model)
+	if m.viewMode != ViewModeRuleForm || !strings.Contains(m.message, "Invalid trust rule") {
+		t.Errorf("Expected an invalid rule to be rejected, got view mode %d and message %q", m.viewMode, m.message)
+	}
+	if len(config.ToolTrustRules) != 1 {
+		t.Errorf("Expected the invalid rule not to be saved, got %v", config.ToolTrustRules)
+	}
+
+	// Delete the rule
+	m = keys(m, "esc", "d").(Model)
+	if len(config.ToolTrustRules) != 0 {
+		t.Errorf("Expected the rule to be deleted, got %v", config.ToolTrustRules)
+	}
+	if m = keys(m, "esc").(Model); m.viewMode != ViewModeList {
+		t.Errorf("Expected Esc to return to the tool list, got view mode %d", m.viewMode)
+	}
+}
+
+func TestAuditLogViewer(t *testing.T) {
+	tooling.SetAuditLogPath(filepath.Join(t.TempDir(), tooling.AuditFileName))
+	t.Cleanup(func() { tooling.SetAuditLogPath("") })
+	tooling.RecordToolCall(tooling.AuditEntry{Time: time.Now(), Tool: "execute_bash", Arguments: map[string]any{"command": "rm -rf /"},
+		Decision: tooling.AuditDeny, DecidedBy: tooling.DecidedByRule, Rule: "execute_bash * rm → deny", Status: tooling.AuditStatusBlocked})
+	tooling.RecordToolCall(tooling.AuditEntry{Time: time.Now(), Tool: "filesystem_read",
+		Decision: tooling.AuditAllow, DecidedBy: tooling.DecidedBySessionTrust, Status: tooling.AuditStatusSuccess})
+
+	model, _ := setupTestModel(t.Context())
+	key := func(m Model, input string) Model {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(input)}
+		switch input {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		}
+		updated, _ := m.Update(msg)
+		return updated.(Model)
+	}
+
+	model = key(model, "l")
+	if model.viewMode != ViewModeAudit || len(model.auditEntries) != 2 {
+		t.Fatalf("Expected the audit log with two entries, got view mode %d and %d entries", model.viewMode, len(model.auditEntries))
+	}
+	if view := model.View(); !strings.Contains(view, "filesystem_read") || !strings.Contains(view, "session_trust") {
+		t.Errorf("Expected the newest entry to be shown and selected, got %q", view)
+	}
+
+	// Filter by decision: any, then allow, then ask, then deny
+	for _, input := range []string{"f", "f", "f"} {
+		model = key(model, input)
+	}
+	if model.auditFilter.Decision != tooling.AuditDeny || len(model.auditEntries) != 1 {
+		t.Fatalf("Expected only denied requests, got %+v", model.auditEntries)
+	}
+	if view := model.View(); !strings.Contains(view, "execute_bash * rm → deny") || !strings.Contains(view, `{"command":"rm -rf /"}`) {
+		t.Errorf("Expected the details of the denied request, got %q", view)
+	}
+
+	// Filter by tool and date
+	model = key(model, "c")
+	for _, input := range []string{"t", "file", "enter"} {
+		model = key(model, input)
+	}
+	if model.auditFilter.Tool != "file" || len(model.auditEntries) != 1 || model.auditEntries[0].Tool != "filesystem_read" {
+		t.Errorf("Expected only filesystem_read requests, got %+v", model.auditEntries)
+	}
+	for _, input := range []string{"d", "1999-01", "enter"} {
+		model = key(model, input)
+	}
+	if len(model.auditEntries) != 0 || !strings.Contains(model.View(), "match the filters") {
+		t.Errorf("Expected no requests on another date, got %+v", model.auditEntries)
+	}
+
+	if model = key(model, "esc"); model.viewMode != ViewModeList {
+		t.Errorf("Expected Esc to return to the tool list, got view mode %d", model.viewMode)
+	}
+}
+
+// TestToolRefresh_DefaultTrust verifies that builtin tools the user has not set a level
+// for show the configuration's defaults
+func TestToolRefresh_DefaultTrust(t *testing.T) {
+	model, config := setupTestModel(t.Context())
+	config.ToolTrustLevels = map[string]int{}
+
+	msg := model.refreshTools()().(ToolsRefreshedMsg)
+	trust := make(map[string]TrustLevel)
+	for _, tool := range msg.Tools {
+		trust[tool.Name] = tool.Trust
+	}
+	tests := map[string]TrustLevel{
+		"filesystem_read":  AskForTrust,
+		"grep":             TrustSession,
+		"filesystem_write": AskForTrust,
+		"execute_bash":     AskForTrust,
+	}
+	for name, want := range tests {
+		if got := trust[name]; got != want {
+			t.Errorf("Trust of %s = %s, want %s", name, got, want)
+		}
+	}
+	if len(config.ToolTrustLevels) != 0 {
+		t.Errorf("Expected refreshing not to change the configuration, got %v", config.ToolTrustLevels)
+	}
+}